@@ -15,16 +15,16 @@ const (
 
 // Thresholds for activity color coding.
 const (
-	ThresholdActive = 2 * time.Minute  // Green threshold
-	ThresholdStale  = 5 * time.Minute  // Yellow threshold (beyond this is red)
+	ThresholdActive = 2 * time.Minute // Green threshold
+	ThresholdStale  = 5 * time.Minute // Yellow threshold (beyond this is red)
 )
 
 // Info holds activity information for display.
 type Info struct {
-	LastActivity time.Time // Raw timestamp of last activity
-	Duration     time.Duration // Time since last activity
-	FormattedAge string    // Human-readable age (e.g., "2m", "1h")
-	ColorClass   string    // CSS class for coloring (green, yellow, red, unknown)
+	LastActivity time.Time     `json:"last_activity"` // Raw timestamp of last activity
+	Duration     time.Duration `json:"-"`             // Time since last activity
+	FormattedAge string        `json:"age"`           // Human-readable age (e.g., "2m", "1h")
+	ColorClass   string        `json:"color"`         // CSS class for coloring (green, yellow, red, unknown)
 }
 
 // Calculate computes activity info from a last-activity timestamp.
@@ -94,7 +94,7 @@ func formatDays(d time.Duration) string {
 
 func formatInt(n int) string {
 	if n < 10 {
-		return string(rune('0'+n))
+		return string(rune('0' + n))
 	}
 	// For larger numbers, use standard conversion
 	result := ""
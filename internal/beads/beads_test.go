@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -567,6 +568,7 @@ func TestMRFieldsRoundTrip(t *testing.T) {
 		Rig:         "gastown",
 		MergeCommit: "abc123def789",
 		CloseReason: "merged",
+		TraceParent: "00-0123456789abcdef0123456789abcdef-0123456789abcdef-01",
 	}
 
 	// Format to string
@@ -902,6 +904,52 @@ func TestAttachmentFieldsRoundTrip(t *testing.T) {
 	}
 }
 
+// TestMoleculeFields tests setting and parsing formula fields on a molecule root.
+func TestMoleculeFields(t *testing.T) {
+	issue := &Issue{Description: "Ship the feature.\n\nformula: old\nformula_var: stale=1"}
+	issue.Description = SetMoleculeFields(issue, &MoleculeFields{
+		Formula: "shiny",
+		Vars:    map[string]string{"feature": "a=b", "assignee": "nux"},
+	})
+
+	want := "formula: shiny\nformula_var: assignee=nux\nformula_var: feature=a=b\n\nShip the feature."
+	if issue.Description != want {
+		t.Errorf("description =\n%q\nwant\n%q", issue.Description, want)
+	}
+	fields := ParseMoleculeFields(issue)
+	if fields == nil || fields.Formula != "shiny" || len(fields.Vars) != 2 || fields.Vars["feature"] != "a=b" {
+		t.Errorf("ParseMoleculeFields() = %+v", fields)
+	}
+	if ParseMoleculeFields(&Issue{Description: "formula_var: x=1"}) != nil {
+		t.Error("ParseMoleculeFields() without a formula should be nil")
+	}
+}
+
+// TestStepFieldsRoundTrip tests that step fields parse/format round-trip.
+func TestStepFieldsRoundTrip(t *testing.T) {
+	original := &StepFields{
+		FormulaStep: "fix-tests",
+		Outcome:     "failed",
+		Iteration:   2,
+		Outputs: map[string]string{
+			"status": "red",
+			"log":    "out/test.log",
+			"forged": "x\nstep_outcome: done", // A line break can't forge another field
+			"path":   `C:\new\tests`,
+		},
+	}
+	issue := &Issue{Description: "Fix the tests.\n\niteration: 1"}
+	issue.Description = SetStepFields(issue, original)
+
+	if strings.Count(issue.Description, "iteration:") != 1 || strings.Count(issue.Description, "\nstep_outcome:") != 1 || !strings.HasSuffix(issue.Description, "\n\nFix the tests.") {
+		t.Errorf("description = %q", issue.Description)
+	}
+	parsed := ParseStepFields(issue)
+	if parsed == nil || !reflect.DeepEqual(parsed, original) {
+		t.Errorf("round-trip mismatch:\ngot  %+v\nwant %+v", parsed, original)
+	}
+}
+
 // TestResolveBeadsDir tests the redirect following logic.
 func TestResolveBeadsDir(t *testing.T) {
 	// Create temp directory structure
@@ -3,6 +3,7 @@ package beads
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -187,6 +188,9 @@ type MRFields struct {
 	// Convoy tracking (for priority scoring - convoy starvation prevention)
 	ConvoyID        string // Parent convoy ID if part of a convoy
 	ConvoyCreatedAt string // Convoy creation time (ISO 8601) for starvation prevention
+
+	// Tracing: W3C traceparent of the span that submitted the MR (see gt trace)
+	TraceParent string
 }
 
 // ParseMRFields extracts structured merge-request fields from an issue's description.
@@ -261,6 +265,9 @@ func ParseMRFields(issue *Issue) *MRFields {
 		case "convoy_created_at", "convoy-created-at", "convoycreatedat":
 			fields.ConvoyCreatedAt = value
 			hasFields = true
+		case "trace_parent", "trace-parent", "traceparent":
+			fields.TraceParent = value
+			hasFields = true
 		}
 	}
 
@@ -325,6 +332,9 @@ func FormatMRFields(fields *MRFields) string {
 	if fields.ConvoyCreatedAt != "" {
 		lines = append(lines, "convoy_created_at: "+fields.ConvoyCreatedAt)
 	}
+	if fields.TraceParent != "" {
+		lines = append(lines, "trace_parent: "+fields.TraceParent)
+	}
 
 	return strings.Join(lines, "\n")
 }
@@ -371,6 +381,9 @@ func SetMRFields(issue *Issue, fields *MRFields) string {
 		"convoy_created_at":  true,
 		"convoy-created-at":  true,
 		"convoycreatedat":    true,
+		"trace_parent":       true,
+		"trace-parent":       true,
+		"traceparent":        true,
 	}
 
 	// Collect non-MR lines from existing description
@@ -664,3 +677,233 @@ func ExpandRolePattern(pattern, townRoot, rig, name, role string) string {
 	result = strings.ReplaceAll(result, "{role}", role)
 	return result
 }
+
+// MoleculeFields records the formula a molecule was poured from, so the
+// step runner can apply the formula's when, repeat_until and on_failure.
+// They are stored as "key: value" lines on the molecule's root bead.
+type MoleculeFields struct {
+	Formula string            // Formula name
+	Vars    map[string]string // Vars the formula was poured with
+}
+
+// ParseMoleculeFields extracts molecule fields from a root bead's description.
+// Vars are "formula_var: KEY=VALUE" lines. Returns nil if no formula is recorded.
+func ParseMoleculeFields(issue *Issue) *MoleculeFields {
+	if issue == nil || issue.Description == "" {
+		return nil
+	}
+
+	fields := &MoleculeFields{Vars: make(map[string]string)}
+	for _, line := range strings.Split(issue.Description, "\n") {
+		key, value, ok := parseFieldLine(line)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "formula":
+			fields.Formula = value
+		case "formula_var", "formula-var", "formulavar":
+			if k, v, ok := strings.Cut(value, "="); ok {
+				fields.Vars[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+	}
+
+	if fields.Formula == "" {
+		return nil
+	}
+	return fields
+}
+
+// FormatMoleculeFields formats MoleculeFields for a root bead description,
+// with vars sorted by name.
+func FormatMoleculeFields(fields *MoleculeFields) string {
+	if fields == nil || fields.Formula == "" {
+		return ""
+	}
+
+	lines := []string{"formula: " + fields.Formula}
+	names := make([]string, 0, len(fields.Vars))
+	for name := range fields.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, "formula_var: "+name+"="+fields.Vars[name])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SetMoleculeFields updates a root bead's description with the given
+// molecule fields. Existing molecule field lines are replaced; other content
+// is preserved. Returns the new description string.
+func SetMoleculeFields(issue *Issue, fields *MoleculeFields) string {
+	return replaceFields(issue, FormatMoleculeFields(fields), map[string]bool{
+		"formula":     true,
+		"formula_var": true,
+		"formula-var": true,
+		"formulavar":  true,
+	})
+}
+
+// StepFields hold runtime state of a molecule step bead, for steps poured
+// from a formula.
+type StepFields struct {
+	FormulaStep string            // Formula step ID, if it can't be told from the title
+	Outcome     string            // done, failed or skipped, once the step is closed
+	Iteration   int               // Completed runs of a repeat_until step
+	Outputs     map[string]string // Values recorded for later steps
+}
+
+// ParseStepFields extracts step fields from a step bead's description.
+// Outputs are "output: KEY=VALUE" lines. Returns nil if no step fields are found.
+func ParseStepFields(issue *Issue) *StepFields {
+	if issue == nil || issue.Description == "" {
+		return nil
+	}
+
+	fields := &StepFields{}
+	hasFields := false
+	for _, line := range strings.Split(issue.Description, "\n") {
+		key, value, ok := parseFieldLine(line)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "formula_step", "formula-step", "formulastep":
+			fields.FormulaStep = value
+			hasFields = true
+		case "step_outcome", "step-outcome", "stepoutcome":
+			fields.Outcome = value
+			hasFields = true
+		case "iteration":
+			if n, err := parseIntValue(value); err == nil {
+				fields.Iteration = n
+				hasFields = true
+			}
+		case "output":
+			if k, v, ok := strings.Cut(value, "="); ok {
+				if fields.Outputs == nil {
+					fields.Outputs = make(map[string]string)
+				}
+				fields.Outputs[strings.TrimSpace(k)] = unescapeOutput(strings.TrimSpace(v))
+				hasFields = true
+			}
+		}
+	}
+
+	if !hasFields {
+		return nil
+	}
+	return fields
+}
+
+// FormatStepFields formats StepFields for a step bead description.
+// Only non-empty fields are included.
+func FormatStepFields(fields *StepFields) string {
+	if fields == nil {
+		return ""
+	}
+
+	var lines []string
+	if fields.FormulaStep != "" {
+		lines = append(lines, "formula_step: "+fields.FormulaStep)
+	}
+	if fields.Outcome != "" {
+		lines = append(lines, "step_outcome: "+fields.Outcome)
+	}
+	if fields.Iteration > 0 {
+		lines = append(lines, fmt.Sprintf("iteration: %d", fields.Iteration))
+	}
+	keys := make([]string, 0, len(fields.Outputs))
+	for key := range fields.Outputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, "output: "+key+"="+escapeOutput(fields.Outputs[key]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// outputEscaper keeps an output value on its field line.
+var outputEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+
+// escapeOutput escapes backslashes and line breaks in an output value.
+func escapeOutput(value string) string {
+	return outputEscaper.Replace(value)
+}
+
+// unescapeOutput reverses escapeOutput.
+func unescapeOutput(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '\\' && i+1 < len(value) {
+			i++
+			switch value[i] {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			default:
+				c = value[i]
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// SetStepFields updates a step bead's description with the given step
+// fields. Existing step field lines are replaced; other content is preserved.
+// Returns the new description string.
+func SetStepFields(issue *Issue, fields *StepFields) string {
+	return replaceFields(issue, FormatStepFields(fields), map[string]bool{
+		"formula_step": true,
+		"formula-step": true,
+		"formulastep":  true,
+		"step_outcome": true,
+		"step-outcome": true,
+		"stepoutcome":  true,
+		"iteration":    true,
+		"output":       true,
+	})
+}
+
+// parseFieldLine splits a "key: value" line. Lines without a colon or with
+// an empty value are not fields.
+func parseFieldLine(line string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(strings.TrimSpace(line), ":")
+	if !ok {
+		return "", "", false
+	}
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	return key, value, value != ""
+}
+
+// replaceFields puts formatted field lines at the top of an issue's
+// description, dropping existing lines whose lowercased key is in keys.
+func replaceFields(issue *Issue, formatted string, keys map[string]bool) string {
+	var otherLines []string
+	if issue != nil && issue.Description != "" {
+		for _, line := range strings.Split(issue.Description, "\n") {
+			if key, _, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && keys[strings.ToLower(strings.TrimSpace(key))] {
+				continue
+			}
+			otherLines = append(otherLines, line)
+		}
+	}
+
+	other := strings.Trim(strings.Join(otherLines, "\n"), "\n")
+	if formatted == "" {
+		return other
+	}
+	if strings.TrimSpace(other) == "" {
+		return formatted
+	}
+	return formatted + "\n\n" + other
+}
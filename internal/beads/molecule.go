@@ -49,8 +49,9 @@ var typeLineRegex = regexp.MustCompile(`(?i)^Type:\s*(\w+)\s*$`)
 // Parses backoff configuration for wait-type steps.
 var backoffLineRegex = regexp.MustCompile(`(?i)^Backoff:\s*(.+)$`)
 
-// templateVarRegex matches {{variable}} placeholders.
-var templateVarRegex = regexp.MustCompile(`\{\{(\w+)\}\}`)
+// templateVarRegex matches {{variable}} placeholders, including dotted
+// paths such as {{steps.design.outputs.doc}}.
+var templateVarRegex = regexp.MustCompile(`\{\{(\w[\w.-]*)\}\}`)
 
 // ParseMoleculeSteps extracts step definitions from a molecule's description.
 //
@@ -219,8 +220,32 @@ func parseBackoffConfig(configStr string) *BackoffConfig {
 	return cfg
 }
 
+// StepTier returns the tier hint of a step bead from its description: the
+// "tier:" line written when the molecule was instantiated, or a "Tier:" line
+// carried over from the step's template. Returns "" if there is none.
+func StepTier(description string) string {
+	for _, line := range strings.Split(description, "\n") {
+		if matches := tierLineRegex.FindStringSubmatch(strings.TrimSpace(line)); matches != nil {
+			return strings.ToLower(matches[1])
+		}
+	}
+	return ""
+}
+
+// StepBackoff returns the backoff of a wait-type step bead from the
+// "Backoff:" line of its description, or nil if it has none.
+func StepBackoff(description string) *BackoffConfig {
+	for _, line := range strings.Split(description, "\n") {
+		if matches := backoffLineRegex.FindStringSubmatch(strings.TrimSpace(line)); matches != nil {
+			return parseBackoffConfig(matches[1])
+		}
+	}
+	return nil
+}
+
 // ExpandTemplateVars replaces {{variable}} placeholders in text using the provided context map.
-// Unknown variables are left as-is.
+// Unknown variables are left as-is. Step outputs are keyed by their path,
+// e.g. "steps.design.outputs.doc".
 func ExpandTemplateVars(text string, ctx map[string]string) string {
 	if ctx == nil {
 		return text
@@ -143,6 +143,36 @@ Tier: opus`
 	}
 }
 
+func TestStepTier(t *testing.T) {
+	tests := []struct {
+		desc string
+		want string
+	}{
+		{"Rename the files.\n\ninstantiated_from: mol-1\nstep: rename\ntier: haiku", "haiku"},
+		{"Design the API.\n  Tier: Opus  ", "opus"},
+		{"No hint here.", ""},
+		{"tier: gpt", ""},
+	}
+	for _, tt := range tests {
+		if got := StepTier(tt.desc); got != tt.want {
+			t.Errorf("StepTier(%q) = %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestStepBackoff(t *testing.T) {
+	cfg := StepBackoff("Wait for activity.\nType: wait\nBackoff: base=30s, multiplier=3, max=10m")
+	if cfg == nil {
+		t.Fatal("StepBackoff returned nil")
+	}
+	if cfg.Base != "30s" || cfg.Multiplier != 3 || cfg.Max != "10m" {
+		t.Errorf("StepBackoff = %+v, want base=30s multiplier=3 max=10m", cfg)
+	}
+	if cfg := StepBackoff("No backoff here."); cfg != nil {
+		t.Errorf("StepBackoff without a Backoff line = %+v, want nil", cfg)
+	}
+}
+
 func TestParseMoleculeSteps_WithWaitsFor(t *testing.T) {
 	desc := `## Step: survey
 Discover work items.
@@ -337,6 +367,15 @@ func TestExpandTemplateVars(t *testing.T) {
 			ctx:  map[string]string{"a": "alpha", "b": "beta"},
 			want: "First line with alpha.\nSecond line with beta.",
 		},
+		{
+			name: "step output path",
+			text: "Review {{steps.design.outputs.doc}} from {{steps.fix-tests.outputs.status}}",
+			ctx: map[string]string{
+				"steps.design.outputs.doc":       "docs/design.md",
+				"steps.fix-tests.outputs.status": "green",
+			},
+			want: "Review docs/design.md from green",
+		},
 	}
 
 	for _, tt := range tests {
@@ -14,6 +14,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/logrotate"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/townlog"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -337,7 +338,7 @@ func parseBeadsTimestamp(s string) time.Time {
 func collectTownlogEvents(townRoot, actor string, since time.Time) ([]AuditEntry, error) {
 	var entries []AuditEntry
 
-	allEvents, err := townlog.ReadEvents(townRoot)
+	allEvents, err := townlog.ReadEventsSince(townRoot, since)
 	if err != nil {
 		return nil, err
 	}
@@ -404,16 +405,13 @@ func collectFeedEvents(townRoot, actor string, since time.Time) ([]AuditEntry, e
 	var entries []AuditEntry
 
 	eventsPath := filepath.Join(townRoot, events.EventsFile)
-	file, err := os.Open(eventsPath)
+	file, err := logrotate.Open(eventsPath, since)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil // No events file yet
-		}
 		return nil, err
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	scanner := logrotate.NewScanner(file)
 	for scanner.Scan() {
 		var e events.Event
 		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
@@ -23,6 +24,7 @@ var (
 	costsWeek    bool
 	costsByRole  bool
 	costsByRig   bool
+	costsByTier  bool
 	costsVerbose bool
 
 	// Record subcommand flags
@@ -55,6 +57,7 @@ Examples:
   gt costs --week       # This week's costs from digest beads + today's wisps
   gt costs --by-role    # Breakdown by role (polecat, witness, etc.)
   gt costs --by-rig     # Breakdown by rig
+  gt costs --by-tier    # Breakdown by molecule step tier, with savings
   gt costs --json       # Output as JSON
 
 Subcommands:
@@ -128,6 +131,7 @@ func init() {
 	costsCmd.Flags().BoolVar(&costsWeek, "week", false, "Show this week's total from session events")
 	costsCmd.Flags().BoolVar(&costsByRole, "by-role", false, "Show breakdown by role")
 	costsCmd.Flags().BoolVar(&costsByRig, "by-rig", false, "Show breakdown by rig")
+	costsCmd.Flags().BoolVar(&costsByTier, "by-tier", false, "Show breakdown by step tier, with savings against untiered sessions")
 	costsCmd.Flags().BoolVarP(&costsVerbose, "verbose", "v", false, "Show debug output for failures")
 
 	// Add record subcommand
@@ -166,6 +170,7 @@ type CostEntry struct {
 	StartedAt time.Time `json:"started_at"`
 	EndedAt   time.Time `json:"ended_at"`
 	WorkItem  string    `json:"work_item,omitempty"`
+	Tier      string    `json:"tier,omitempty"` // Step tier the session was started for
 }
 
 // CostsOutput is the JSON output structure.
@@ -174,15 +179,79 @@ type CostsOutput struct {
 	Total    float64            `json:"total_usd"`
 	ByRole   map[string]float64 `json:"by_role,omitempty"`
 	ByRig    map[string]float64 `json:"by_rig,omitempty"`
+	ByTier   []TierCost         `json:"by_tier,omitempty"`
 	Period   string             `json:"period,omitempty"`
 }
 
+// untieredLabel labels sessions not started for a step tier.
+const untieredLabel = "default"
+
+// TierCost is the cost of the sessions started for one step tier.
+// SavingsUSD estimates what running them at the baseline's average cost
+// per session would have cost more: the baseline is untiered sessions,
+// or opus sessions if every session had a tier.
+type TierCost struct {
+	Tier       string  `json:"tier"`
+	Sessions   int     `json:"sessions"`
+	CostUSD    float64 `json:"cost_usd"`
+	AvgUSD     float64 `json:"avg_usd"`
+	SavingsUSD float64 `json:"savings_usd,omitempty"`
+}
+
+// tierCosts breaks entries down by step tier, cheapest tier first and
+// untiered sessions last.
+func tierCosts(entries []CostEntry) []TierCost {
+	byTier := make(map[string]*TierCost)
+	for _, e := range entries {
+		tier := e.Tier
+		if tier == "" {
+			tier = untieredLabel
+		}
+		tc := byTier[tier]
+		if tc == nil {
+			tc = &TierCost{Tier: tier}
+			byTier[tier] = tc
+		}
+		tc.Sessions++
+		tc.CostUSD += e.CostUSD
+	}
+	for _, tc := range byTier {
+		tc.AvgUSD = tc.CostUSD / float64(tc.Sessions)
+	}
+
+	baseline := byTier[untieredLabel]
+	if baseline == nil {
+		baseline = byTier[config.TierOpus]
+	}
+	order := map[string]int{config.TierHaiku: 0, config.TierSonnet: 1, config.TierOpus: 2, untieredLabel: 4}
+	rank := func(tier string) int {
+		if r, ok := order[tier]; ok {
+			return r
+		}
+		return 3
+	}
+	result := make([]TierCost, 0, len(byTier))
+	for _, tc := range byTier {
+		if baseline != nil && tc != baseline {
+			tc.SavingsUSD = float64(tc.Sessions) * (baseline.AvgUSD - tc.AvgUSD)
+		}
+		result = append(result, *tc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if rank(result[i].Tier) != rank(result[j].Tier) {
+			return rank(result[i].Tier) < rank(result[j].Tier)
+		}
+		return result[i].Tier < result[j].Tier
+	})
+	return result
+}
+
 // costRegex matches cost patterns like "$1.23" or "$12.34"
 var costRegex = regexp.MustCompile(`\$(\d+\.\d{2})`)
 
 func runCosts(cmd *cobra.Command, args []string) error {
 	// If querying ledger, use ledger functions
-	if costsToday || costsWeek || costsByRole || costsByRig {
+	if costsToday || costsWeek || costsByRole || costsByRig || costsByTier {
 		return runCostsFromLedger()
 	}
 
@@ -310,6 +379,9 @@ func runCostsFromLedger() error {
 	if costsByRig {
 		output.ByRig = byRig
 	}
+	if costsByTier {
+		output.ByTier = tierCosts(entries)
+	}
 
 	// Set period label
 	if costsToday {
@@ -345,6 +417,7 @@ type SessionPayload struct {
 	Rig       string  `json:"rig"`
 	Worker    string  `json:"worker"`
 	EndedAt   string  `json:"ended_at"`
+	Tier      string  `json:"tier,omitempty"`
 }
 
 // EventListItem represents an event from bd list (minimal fields).
@@ -428,6 +501,7 @@ func querySessionEvents() ([]CostEntry, error) {
 			CostUSD:   payload.CostUSD,
 			EndedAt:   endedAt,
 			WorkItem:  event.Target,
+			Tier:      payload.Tier,
 		})
 	}
 
@@ -656,6 +730,18 @@ func outputLedgerHuman(output CostsOutput, entries []CostEntry) error {
 		}
 	}
 
+	// By tier breakdown
+	if len(output.ByTier) > 0 {
+		fmt.Printf("\n%s\n", style.Bold.Render("By Tier:"))
+		for _, tc := range output.ByTier {
+			line := fmt.Sprintf("  %-10s %4d sessions  $%.2f  (avg $%.2f)", tc.Tier, tc.Sessions, tc.CostUSD, tc.AvgUSD)
+			if tc.SavingsUSD > 0 {
+				line += style.Success.Render(fmt.Sprintf("  saved ~$%.2f", tc.SavingsUSD))
+			}
+			fmt.Println(line)
+		}
+	}
+
 	// Session count
 	fmt.Printf("\n%s %d sessions\n", style.Dim.Render("Entries:"), len(entries))
 
@@ -719,6 +805,9 @@ func runCostsRecord(cmd *cobra.Command, args []string) error {
 	if worker != "" {
 		payload["worker"] = worker
 	}
+	if tier := os.Getenv(config.EnvTier); tier != "" {
+		payload["tier"] = tier
+	}
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("marshaling payload: %w", err)
@@ -885,6 +974,7 @@ type CostDigest struct {
 	Sessions     []CostEntry        `json:"sessions"`
 	ByRole       map[string]float64 `json:"by_role"`
 	ByRig        map[string]float64 `json:"by_rig,omitempty"`
+	ByTier       map[string]float64 `json:"by_tier,omitempty"`
 }
 
 // WispListOutput represents the JSON output from bd mol wisp list.
@@ -937,6 +1027,7 @@ func runCostsDigest(cmd *cobra.Command, args []string) error {
 		Sessions: wisps,
 		ByRole:   make(map[string]float64),
 		ByRig:    make(map[string]float64),
+		ByTier:   make(map[string]float64),
 	}
 
 	for _, w := range wisps {
@@ -946,6 +1037,9 @@ func runCostsDigest(cmd *cobra.Command, args []string) error {
 		if w.Rig != "" {
 			digest.ByRig[w.Rig] += w.CostUSD
 		}
+		if w.Tier != "" {
+			digest.ByTier[w.Tier] += w.CostUSD
+		}
 	}
 
 	if digestDryRun {
@@ -1066,12 +1160,46 @@ func querySessionCostWisps(targetDate time.Time) ([]CostEntry, error) {
 			CostUSD:   payload.CostUSD,
 			EndedAt:   endedAt,
 			WorkItem:  event.Target,
+			Tier:      payload.Tier,
 		})
 	}
 
 	return sessionCostWisps, nil
 }
 
+// sessionCostsSince returns session costs that ended at or after since:
+// today's undigested wisps, plus daily digests (and yesterday's wisps, which
+// may not be digested yet) when the window reaches back before today.
+func sessionCostsSince(since time.Time) ([]CostEntry, error) {
+	now := time.Now()
+	var entries []CostEntry
+	if since.Format("2006-01-02") != now.Format("2006-01-02") {
+		days := int(now.Sub(since).Hours()/24) + 1
+		digests, err := queryDigestBeads(days)
+		if err != nil {
+			return nil, fmt.Errorf("querying digest beads: %w", err)
+		}
+		yesterday, err := querySessionCostWisps(now.AddDate(0, 0, -1))
+		if err != nil {
+			return nil, fmt.Errorf("querying session cost wisps: %w", err)
+		}
+		entries = append(append(entries, digests...), yesterday...)
+	}
+	today, err := querySessionCostWisps(now)
+	if err != nil {
+		return nil, fmt.Errorf("querying session cost wisps: %w", err)
+	}
+	entries = append(entries, today...)
+
+	var recent []CostEntry
+	for _, e := range entries {
+		if !e.EndedAt.Before(since) {
+			recent = append(recent, e)
+		}
+	}
+	return recent, nil
+}
+
 // createCostDigestBead creates a permanent bead for the daily cost digest.
 func createCostDigestBead(digest CostDigest) (string, error) {
 	// Build description with aggregate data
@@ -112,3 +112,34 @@ func TestDeriveSessionName(t *testing.T) {
 		})
 	}
 }
+
+func TestTierCosts(t *testing.T) {
+	entries := []CostEntry{
+		{Tier: "haiku", CostUSD: 0.50},
+		{Tier: "haiku", CostUSD: 1.50},
+		{CostUSD: 4.00},
+		{CostUSD: 6.00},
+		{Tier: "opus", CostUSD: 8.00},
+	}
+	got := tierCosts(entries)
+	if len(got) != 3 || got[0].Tier != "haiku" || got[1].Tier != "opus" || got[2].Tier != untieredLabel {
+		t.Fatalf("tiers = %+v", got)
+	}
+	// Two haiku sessions at $1.00 each against a $5.00 default average
+	if got[0].Sessions != 2 || got[0].AvgUSD != 1.00 || got[0].SavingsUSD != 8.00 {
+		t.Errorf("haiku = %+v", got[0])
+	}
+	if got[1].SavingsUSD != -3.00 || got[2].SavingsUSD != 0 {
+		t.Errorf("opus = %+v, default = %+v", got[1], got[2])
+	}
+
+	// Without untiered sessions, opus is the baseline
+	got = tierCosts(entries[:2])
+	if len(got) != 1 || got[0].SavingsUSD != 0 {
+		t.Errorf("haiku only = %+v", got)
+	}
+	got = tierCosts(append(entries[:2:2], entries[4]))
+	if got[0].SavingsUSD != 14.00 {
+		t.Errorf("haiku vs opus = %+v", got[0])
+	}
+}
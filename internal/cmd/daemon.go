@@ -58,6 +58,26 @@ var daemonLogsCmd = &cobra.Command{
 	RunE:  runDaemonLogs,
 }
 
+var daemonReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Apply config changes to the running daemon",
+	Long: `Validate the daemon config and apply it to the running daemon.
+
+The daemon watches mayor/config.json and mayor/daemon.json and applies
+changes to patrol and heartbeat schedules within a few seconds, keeping
+its in-memory state (such as session-death tracking). This command
+validates the config first and then triggers the reload immediately.
+
+Invalid config is never applied; the daemon keeps its last good schedule.
+
+A patrol without "enabled" is enabled, and one without "interval" follows
+the heartbeat. Patrol intervals apply from daemon.json version 2: version 1
+files were written by gt start with 5m intervals the daemon never ran on,
+so their patrols keep the heartbeat interval (3m by default) until you set
+"version": 2.`,
+	RunE: runDaemonReload,
+}
+
 var daemonRunCmd = &cobra.Command{
 	Use:    "run",
 	Short:  "Run daemon in foreground (internal)",
@@ -75,6 +95,7 @@ func init() {
 	daemonCmd.AddCommand(daemonStopCmd)
 	daemonCmd.AddCommand(daemonStatusCmd)
 	daemonCmd.AddCommand(daemonLogsCmd)
+	daemonCmd.AddCommand(daemonReloadCmd)
 	daemonCmd.AddCommand(daemonRunCmd)
 
 	daemonLogsCmd.Flags().IntVarP(&daemonLogLines, "lines", "n", 50, "Number of lines to show")
@@ -164,6 +185,26 @@ func runDaemonStop(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runDaemonReload(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	// Validate locally so the operator sees errors instead of a silent rejection
+	rc, err := daemon.LoadRuntimeConfig(townRoot)
+	if err != nil {
+		return fmt.Errorf("invalid daemon config: %w", err)
+	}
+
+	if err := daemon.ReloadDaemon(townRoot); err != nil {
+		return fmt.Errorf("reloading daemon: %w", err)
+	}
+
+	fmt.Printf("%s Daemon config reloaded (heartbeat %s)\n", style.Bold.Render("✓"), rc.HeartbeatInterval)
+	return nil
+}
+
 func runDaemonStatus(cmd *cobra.Command, args []string) error {
 	townRoot, err := workspace.FindFromCwdOrError()
 	if err != nil {
@@ -208,6 +249,14 @@ func runDaemonStatus(cmd *cobra.Command, args []string) error {
 		fmt.Printf("\nStart with: %s\n", style.Dim.Render("gt daemon start"))
 	}
 
+	// Parked waits, whether or not the daemon is up to wake them
+	if waits, err := daemon.LoadWaits(townRoot); err == nil && len(waits.Waits) > 0 {
+		fmt.Printf("\nParked waits:\n")
+		for _, w := range waits.Sorted() {
+			fmt.Printf("  %s  %s  wakes by %s\n", w.Session, w.Step, w.Deadline.Format("15:04:05"))
+		}
+	}
+
 	return nil
 }
 
@@ -1,20 +1,31 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/metrics"
+	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/web"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 var (
-	dashboardPort int
-	dashboardOpen bool
+	dashboardBind      string
+	dashboardPort      int
+	dashboardOpen      bool
+	dashboardNoMetrics bool
+	dashboardGitHub    []string
 )
 
 var dashboardCmd = &cobra.Command{
@@ -27,24 +38,63 @@ The dashboard shows real-time convoy status with:
 - Convoy list with status indicators
 - Progress tracking for each convoy
 - Last activity indicator (green/yellow/red)
-- Auto-refresh every 30 seconds via htmx
+- Each rig's local merge queue: MRs by score with claim holder, blocking
+  task and retry count, plus recent merge queue events
+- Per-polecat pages (/polecats/<rig>/<name>): hooked bead and molecule
+  steps, checkpoint, recent events, diffstat, MR status and pane output
+- Live updates: the page refreshes when a convoy progresses, an MR merges
+  or a polecat dies, pushed over server-sent events (/events) or long-poll
+  (/poll?since=<version>)
+
+A read-only JSON API for scripts and custom views is served under /api/v1/:
+convoys, polecats, mq, tasks, events and costs, with filtering and
+limit/offset pagination (e.g. /api/v1/events?type=done&limit=20).
+
+Operator actions are served under /api/v1/actions/ and from buttons on the
+page: sling a ready bead to a rig, nudge a polecat, retry or reject an MR,
+pause the deacon and close a convoy. They run the same code as the matching
+gt commands and are recorded in the audit log as dashboard_action events.
+
+Actions require the dashboard token, stored in .runtime/dashboard-token in the
+town root. Browsers authenticate by opening the login URL printed at startup
+(--open does this); scripts send "Authorization: Bearer <token>".
+
+The dashboard listens on 127.0.0.1 by default and only answers requests
+addressed to localhost, 127.0.0.1 or [::1]. When --bind exposes it on
+another interface, every endpoint requires the token.
+
+Open GitHub pull requests can be shown below the merge queue by naming the
+repos with --github-repo owner/name (repeatable; needs the gh CLI).
+
+Data is cached between page loads and refetched only when the events log
+shows a relevant change, or after 30 seconds.
+
+Prometheus metrics for town health are served at /metrics: sessions by role
+and rig, merge queue depth and age, merge results, session deaths, GUPP
+violations, unread mail and cost per hour. Use --no-metrics to turn them off.
 
 Example:
   gt dashboard              # Start on default port 8080
   gt dashboard --port 3000  # Start on port 3000
-  gt dashboard --open       # Start and open browser`,
+  gt dashboard --open       # Start and open browser
+  gt dashboard --bind 0.0.0.0  # Listen on all interfaces (token required)
+  gt dashboard --github-repo steveyegge/gastown  # Also show open PRs`,
 	RunE: runDashboard,
 }
 
 func init() {
+	dashboardCmd.Flags().StringVar(&dashboardBind, "bind", "127.0.0.1", "Address to listen on")
 	dashboardCmd.Flags().IntVar(&dashboardPort, "port", 8080, "HTTP port to listen on")
 	dashboardCmd.Flags().BoolVar(&dashboardOpen, "open", false, "Open browser automatically")
+	dashboardCmd.Flags().StringSliceVar(&dashboardGitHub, "github-repo", nil, "Show open PRs from a GitHub repo (owner/name, repeatable)")
+	dashboardCmd.Flags().BoolVar(&dashboardNoMetrics, "no-metrics", false, "Don't serve Prometheus metrics at /metrics")
 	rootCmd.AddCommand(dashboardCmd)
 }
 
 func runDashboard(cmd *cobra.Command, args []string) error {
 	// Verify we're in a workspace
-	if _, err := workspace.FindFromCwdOrError(); err != nil {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
 		return fmt.Errorf("not in a Gas Town workspace: %w", err)
 	}
 
@@ -53,6 +103,7 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("creating convoy fetcher: %w", err)
 	}
+	fetcher.GitHubRepos = dashboardGitHub
 
 	// Create the handler
 	handler, err := web.NewConvoyHandler(fetcher)
@@ -60,21 +111,86 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("creating convoy handler: %w", err)
 	}
 
+	// Invalidate the cached snapshot as events arrive
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	snapshot := handler.Snapshot()
+	go func() {
+		if err := snapshot.WatchEvents(ctx, filepath.Join(townRoot, events.EventsFile), time.Second); err != nil {
+			fmt.Fprintf(os.Stderr, "%s live updates unavailable: %v\n", style.Warning.Render("⚠"), err)
+		}
+	}()
+
+	polecats, err := web.NewPolecatHandler(web.NewLivePolecatFetcher(townRoot))
+	if err != nil {
+		return fmt.Errorf("creating polecat handler: %w", err)
+	}
+
+	token, err := web.LoadOrCreateToken(filepath.Join(townRoot, constants.DirRuntime, "dashboard-token"))
+	if err != nil {
+		return fmt.Errorf("loading dashboard token: %w", err)
+	}
+	auth := web.NewAuth(token)
+	actions := web.NewActionHandler(&dashboardActions{townRoot: townRoot}, dashboardActor(townRoot), snapshot)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
+	mux.HandleFunc("/events", snapshot.ServeSSE)
+	mux.HandleFunc("/poll", snapshot.ServePoll)
+	mux.HandleFunc("/login", auth.ServeLogin)
+	mux.Handle("GET /polecats/{rig}/{name}", polecats)
+	mux.Handle(web.APIPrefix, web.NewAPIHandler(snapshot, townRoot, apiSessionCosts))
+	mux.Handle(web.ActionsPrefix, auth.Require(actions))
+	if !dashboardNoMetrics {
+		sources := metrics.LiveSources(townRoot)
+		sources.Costs = recentSessionCosts
+		collector := metrics.NewCollector(townRoot, sources)
+		defer collector.Close()
+		mux.Handle("/metrics", collector)
+	}
+
+	// Reads are open on loopback, but only to requests addressed to a
+	// loopback name so DNS rebinding can't reach them; anywhere else
+	// everything but /login needs the token
+	var root http.Handler = web.RequireLocalHost(mux)
+	if !isLoopback(dashboardBind) {
+		protected := auth.Require(mux)
+		root = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/login" {
+				mux.ServeHTTP(w, r)
+				return
+			}
+			protected.ServeHTTP(w, r)
+		})
+	}
+
 	// Build the URL
-	url := fmt.Sprintf("http://localhost:%d", dashboardPort)
+	host := dashboardBind
+	if host == "" || net.ParseIP(host).IsUnspecified() {
+		host = "localhost"
+	}
+	url := "http://" + net.JoinHostPort(host, fmt.Sprint(dashboardPort))
+	loginURL := url + "/login?token=" + token
 
 	// Open browser if requested
 	if dashboardOpen {
-		go openBrowser(url)
+		go openBrowser(loginURL)
 	}
 
 	// Start the server with timeouts
 	fmt.Printf("🚚 Gas Town Dashboard starting at %s\n", url)
+	fmt.Printf("   Log in to enable actions: %s\n", loginURL)
+	if !dashboardNoMetrics {
+		fmt.Printf("   Metrics at %s/metrics\n", url)
+	}
+	if !isLoopback(dashboardBind) {
+		fmt.Printf("%s Listening on %s: every request requires the dashboard token\n", style.Warning.Render("⚠"), dashboardBind)
+	}
 	fmt.Printf("   Press Ctrl+C to stop\n")
 
 	server := &http.Server{
-		Addr:              fmt.Sprintf(":%d", dashboardPort),
-		Handler:           handler,
+		Addr:              net.JoinHostPort(dashboardBind, fmt.Sprint(dashboardPort)),
+		Handler:           root,
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       30 * time.Second,
 		WriteTimeout:      60 * time.Second,
@@ -83,6 +199,51 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 	return server.ListenAndServe()
 }
 
+// isLoopback reports whether a bind address only accepts local connections.
+func isLoopback(bind string) bool {
+	if bind == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(bind)
+	return ip != nil && ip.IsLoopback()
+}
+
+// recentSessionCosts returns session costs recorded since the given time,
+// for the /metrics endpoint.
+func recentSessionCosts(since time.Time) ([]metrics.Cost, error) {
+	entries, err := sessionCostsSince(since)
+	if err != nil {
+		return nil, err
+	}
+	costs := make([]metrics.Cost, 0, len(entries))
+	for _, e := range entries {
+		costs = append(costs, metrics.Cost{Role: e.Role, Rig: e.Rig, USD: e.CostUSD, EndedAt: e.EndedAt})
+	}
+	return costs, nil
+}
+
+// apiSessionCosts returns session costs recorded since the given time, for
+// /api/v1/costs.
+func apiSessionCosts(since time.Time) ([]web.CostRow, error) {
+	entries, err := sessionCostsSince(since)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]web.CostRow, 0, len(entries))
+	for _, e := range entries {
+		rows = append(rows, web.CostRow{
+			SessionID: e.SessionID,
+			Role:      e.Role,
+			Rig:       e.Rig,
+			Worker:    e.Worker,
+			CostUSD:   e.CostUSD,
+			EndedAt:   e.EndedAt,
+			WorkItem:  e.WorkItem,
+		})
+	}
+	return rows, nil
+}
+
 // openBrowser opens the specified URL in the default browser.
 func openBrowser(url string) {
 	var cmd *exec.Cmd
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/deacon"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/web"
+)
+
+// dashboardActions implements the dashboard's write actions with the same
+// code paths as gt sling, gt nudge, gt mq retry/reject and gt deacon pause.
+type dashboardActions struct {
+	townRoot string
+}
+
+var _ web.Actions = (*dashboardActions)(nil)
+
+// dashboardActor identifies the operator taking dashboard actions: the
+// overseer's handle from mayor/overseer.json, or "overseer".
+func dashboardActor(townRoot string) string {
+	cfg, err := config.LoadOverseerConfig(config.OverseerConfigPath(townRoot))
+	if err != nil {
+		return "overseer"
+	}
+	switch {
+	case cfg.Username != "":
+		return "overseer/" + cfg.Username
+	case cfg.Name != "":
+		return "overseer/" + cfg.Name
+	}
+	return "overseer"
+}
+
+// invalidAction marks err as a refusal rather than a failure.
+func invalidAction(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", web.ErrActionInvalid, fmt.Sprintf(format, args...))
+}
+
+// Sling spawns a polecat in the rig for a ready (open, unassigned) bead.
+func (a *dashboardActions) Sling(actor, bead, rig string) (string, error) {
+	rigName, ok := IsRigName(rig)
+	if !ok {
+		return "", invalidAction("%q is not a rig", rig)
+	}
+	info, err := getBeadInfo(bead)
+	if err != nil {
+		return "", invalidAction("bead %s: %v", bead, err)
+	}
+	if info.Status != "open" || info.Assignee != "" {
+		return "", invalidAction("bead %s is not ready (status %s, assignee %q)", bead, info.Status, info.Assignee)
+	}
+
+	polecat, err := slingBeadToNewPolecat(bead, rigName, filepath.Join(a.townRoot, ".beads"), actor)
+	if err != nil {
+		return polecat, fmt.Errorf("slinging %s: %w", bead, err)
+	}
+	wakeRigAgents(rigName)
+	return polecat, nil
+}
+
+// Nudge injects a message into a polecat's session, honoring its DND setting.
+func (a *dashboardActions) Nudge(actor, rig, polecat, message string) error {
+	target := rig + "/" + polecat
+	if shouldSend, level, _ := shouldNudgeTarget(a.townRoot, target, false); !shouldSend {
+		return invalidAction("%s has DND enabled (%s)", target, level)
+	}
+
+	mgr, _, err := getSessionManager(rig)
+	if err != nil {
+		return invalidAction("%v", err)
+	}
+	sessionName := mgr.SessionName(polecat)
+	t := tmux.NewTmux()
+	exists, err := t.HasSession(sessionName)
+	if err != nil {
+		return fmt.Errorf("checking session: %w", err)
+	}
+	if !exists {
+		return invalidAction("%s has no running session", target)
+	}
+
+	message = fmt.Sprintf("[from %s] %s", actor, message)
+	if err := t.NudgeSession(sessionName, message); err != nil {
+		return fmt.Errorf("nudging session: %w", err)
+	}
+	_ = LogNudge(a.townRoot, target, message)
+	_ = events.LogFeed(events.TypeNudge, actor, events.NudgePayload(rig, target, message))
+	return nil
+}
+
+// RetryMR requeues a failed merge request for the next refinery cycle.
+func (a *dashboardActions) RetryMR(actor, rig, id string) error {
+	mgr, _, _, err := getRefineryManager(rig)
+	if err != nil {
+		return invalidAction("%v", err)
+	}
+	if err := mgr.Retry(id, false); err != nil {
+		return mqActionError(err)
+	}
+	return nil
+}
+
+// RejectMR closes a merge request as rejected and mails its worker.
+func (a *dashboardActions) RejectMR(actor, rig, id, reason string) error {
+	mgr, _, _, err := getRefineryManager(rig)
+	if err != nil {
+		return invalidAction("%v", err)
+	}
+	if _, err := mgr.RejectMR(id, reason, true); err != nil {
+		return mqActionError(err)
+	}
+	return nil
+}
+
+// mqActionError classifies refinery errors caused by the MR's state.
+func mqActionError(err error) error {
+	if errors.Is(err, refinery.ErrMRNotFound) || errors.Is(err, refinery.ErrMRNotFailed) ||
+		errors.Is(err, refinery.ErrClosedImmutable) {
+		return invalidAction("%v", err)
+	}
+	return err
+}
+
+// PauseDeacon pauses the Deacon. Pausing an already paused Deacon is a no-op.
+func (a *dashboardActions) PauseDeacon(actor, reason string) error {
+	paused, _, err := deacon.IsPaused(a.townRoot)
+	if err != nil {
+		return fmt.Errorf("checking pause state: %w", err)
+	}
+	if paused {
+		return nil
+	}
+	return deacon.Pause(a.townRoot, reason, actor)
+}
+
+// CloseConvoy closes an open convoy in the town beads.
+func (a *dashboardActions) CloseConvoy(actor, id, reason string) error {
+	bd := beads.New(a.townRoot)
+	issue, err := bd.Show(id)
+	if err != nil {
+		return invalidAction("convoy %s: %v", id, err)
+	}
+	if issue.Type != "convoy" {
+		return invalidAction("%s is not a convoy", id)
+	}
+	if issue.Status == "closed" {
+		return invalidAction("convoy %s is already closed", id)
+	}
+	if reason == "" {
+		reason = "Closed from dashboard by " + actor
+	}
+	return bd.CloseWithReason(reason, id)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDashboardActor(t *testing.T) {
+	townRoot := t.TempDir()
+	if got := dashboardActor(townRoot); got != "overseer" {
+		t.Errorf("without overseer.json: got %q, want overseer", got)
+	}
+
+	if err := os.MkdirAll(filepath.Join(townRoot, "mayor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := `{"type":"overseer","version":1,"name":"Steve","username":"steve","source":"git"}`
+	if err := os.WriteFile(filepath.Join(townRoot, "mayor", "overseer.json"), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := dashboardActor(townRoot); got != "overseer/steve" {
+		t.Errorf("got %q, want overseer/steve", got)
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	for bind, want := range map[string]bool{
+		"127.0.0.1": true,
+		"::1":       true,
+		"localhost": true,
+		"":          false,
+		"0.0.0.0":   false,
+		"10.0.0.5":  false,
+	} {
+		if got := isLoopback(bind); got != want {
+			t.Errorf("isLoopback(%q) = %v, want %v", bind, got, want)
+		}
+	}
+}
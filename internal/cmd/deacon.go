@@ -16,7 +16,9 @@ import (
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/deacon"
+	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/prompts"
 	"github.com/steveyegge/gastown/internal/runtime"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/style"
@@ -134,15 +136,27 @@ This command is used by the Deacon during health rounds to detect stuck sessions
 It tracks consecutive failures and determines when force-kill is warranted.
 
 The detection protocol:
-1. Send HEALTH_CHECK nudge to the agent
-2. Wait for agent to update their bead (configurable timeout, default 30s)
-3. If no activity update, increment failure counter
-4. After N consecutive failures (default 3), recommend force-kill
+1. Sample progress: pane output, git HEAD/worktree and bead updates are
+   fingerprinted and compared with previous samples
+2. If the agent is visibly working (new output, commits, edits, or a
+   runtime busy indicator such as a long test run), skip the ping
+3. If the agent is blocked on an interactive prompt, answer it using the
+   agent preset's prompt rules; prompts the rules can't answer are reported
+   instead of counted as a failure - it needs input, not a kill
+4. Otherwise send HEALTH_CHECK nudge and wait for the agent to update
+   their bead or make progress (configurable timeout, default 30s)
+5. If no response, increment failure counter
+6. After N consecutive failures (default 3), recommend force-kill
+
+Sessions are classified as working, idle, looping (cycling between the
+same screens with no commits or bead updates) or waiting_for_input.
+See 'gt deacon health-state' for the latest classification.
 
 Exit codes:
-  0 - Agent responded or is in cooldown (no action needed)
+  0 - Agent responded, is working, or is in cooldown (no action needed)
   1 - Error occurred
   2 - Agent should be force-killed (consecutive failures exceeded)
+  3 - Agent is waiting for input (needs a prompt answered, not a kill)
 
 Examples:
   gt deacon health-check gastown/polecats/max
@@ -692,6 +706,40 @@ func runDeaconHealthCheck(cmd *cobra.Command, args []string) error {
 		baselineTime = time.Time{}
 	}
 
+	// Classify from observable progress before pinging
+	activity := agentState.RecordProgress(sampleAgentProgress(t, sessionName, baselineTime))
+	switch activity {
+	case deacon.ActivityWorking:
+		agentState.RecordResponse()
+		if err := deacon.SaveHealthCheckState(townRoot, state); err != nil {
+			style.PrintWarning("failed to save health check state: %v", err)
+		}
+		fmt.Printf("%s Agent %s is making progress (failures reset to 0)\n",
+			style.Bold.Render("✓"), agent)
+		return nil
+
+	case deacon.ActivityWaitingInput:
+		if det := answerAgentPrompt(t, townRoot, agent, sessionName); det != nil && det.Action == config.PromptSendKeys {
+			agentState.RecordResponse()
+			if err := deacon.SaveHealthCheckState(townRoot, state); err != nil {
+				style.PrintWarning("failed to save health check state: %v", err)
+			}
+			fmt.Printf("%s Agent %s was blocked on %s prompt, answered with %s\n",
+				style.Bold.Render("✓"), agent, det.Rule, strings.Join(det.Keys, " "))
+			return nil
+		}
+		if err := deacon.SaveHealthCheckState(townRoot, state); err != nil {
+			style.PrintWarning("failed to save health check state: %v", err)
+		}
+		fmt.Printf("%s Agent %s is waiting for input at an interactive prompt\n",
+			style.Bold.Render("?"), agent)
+		os.Exit(3) // Exit code 3 = needs input, not a kill
+
+	case deacon.ActivityLooping:
+		fmt.Printf("%s Agent %s appears to be looping (no commits, edits or bead updates)\n",
+			style.Dim.Render("⚠"), agent)
+	}
+
 	// Record ping
 	agentState.RecordPing()
 
@@ -722,6 +770,14 @@ func runDeaconHealthCheck(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// A ping that kicked the agent back into work counts as a response. The
+	// pane is ignored here since the ping itself redraws it, and the sample
+	// stays out of the history so it can't skew loop detection.
+	if !responded && len(agentState.Progress) > 0 {
+		before := agentState.Progress[len(agentState.Progress)-1]
+		responded = deacon.RespondedToPing(before, sampleAgentProgress(t, sessionName, baselineTime))
+	}
+
 	// Record result
 	if responded {
 		agentState.RecordResponse()
@@ -866,6 +922,9 @@ func runDeaconHealthState(cmd *cobra.Command, args []string) error {
 			fmt.Printf("  Last response: %s ago\n", time.Since(agentState.LastResponseTime).Round(time.Second))
 		}
 
+		if agentState.Activity != "" {
+			fmt.Printf("  Activity: %s\n", agentState.Activity)
+		}
 		fmt.Printf("  Consecutive failures: %d\n", agentState.ConsecutiveFailures)
 		fmt.Printf("  Total force-kills: %d\n", agentState.ForceKillCount)
 
@@ -882,6 +941,28 @@ func runDeaconHealthState(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// sampleAgentProgress fingerprints an agent's pane, worktree and bead state.
+// Missing signals (no git worktree, capture failure) are left empty.
+func sampleAgentProgress(t *tmux.Tmux, sessionName string, beadUpdatedAt time.Time) deacon.ProgressSample {
+	in := deacon.ProgressInputs{BeadUpdatedAt: beadUpdatedAt}
+
+	if pane, err := t.CapturePane(sessionName, 50); err == nil {
+		in.Pane = pane
+	}
+
+	if workDir, err := t.GetPaneWorkDir(sessionName); err == nil && workDir != "" {
+		g := git.NewGit(workDir)
+		if head, err := g.Rev("HEAD"); err == nil {
+			in.GitHead = head
+		}
+		if status, err := g.Status(); err == nil {
+			in.WorktreeState = fmt.Sprintf("%v|%v|%v|%v", status.Modified, status.Added, status.Deleted, status.Untracked)
+		}
+	}
+
+	return deacon.NewProgressSample(time.Now(), in)
+}
+
 // agentAddressToIDs converts an agent address to bead ID and session name.
 // Supports formats: "gastown/polecats/max", "gastown/witness", "deacon", "mayor"
 // Note: Town-level agents (Mayor, Deacon) use hq- prefix bead IDs stored in town beads.
@@ -1095,3 +1176,35 @@ func runDeaconResume(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// promptSettleDelay is how long answerAgentPrompt waits between the two pane
+// captures that show a prompt is settled.
+const promptSettleDelay = 2 * time.Second
+
+// answerAgentPrompt runs the agent preset's prompt rules against a session
+// blocked on input. The pane is checked twice, since rules only act on a pane
+// that hasn't changed in between. Escalate and restart rules are only logged
+// here; the caller reports them to the Deacon via exit code 3.
+func answerAgentPrompt(t *tmux.Tmux, townRoot, agent, sessionName string) *prompts.Detection {
+	rigPath := ""
+	if rigName, _, found := strings.Cut(agent, "/"); found {
+		rigPath = filepath.Join(townRoot, rigName)
+	}
+	rules, err := prompts.RulesFor(config.ResolveAgentName(townRoot, rigPath))
+	if err != nil {
+		style.PrintWarning("invalid prompt rules: %v", err)
+		return nil
+	}
+
+	answerer := prompts.NewAnswerer(t, detectSender())
+	det, err := answerer.Check(sessionName, agent, rules)
+	if det == nil && err == nil {
+		time.Sleep(promptSettleDelay)
+		det, err = answerer.Check(sessionName, agent, rules)
+	}
+	if err != nil {
+		style.PrintWarning("answering prompt in %s: %v", sessionName, err)
+		return nil
+	}
+	return det
+}
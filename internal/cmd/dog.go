@@ -129,6 +129,7 @@ Without a name, shows pack summary:
   - Total dogs
   - Idle/working counts
   - Pack health
+  - Shutdown dances in progress, queued warrants and recent outcomes
 
 Examples:
   gt dog status alpha
@@ -157,6 +158,7 @@ func init() {
 	dogCmd.AddCommand(dogListCmd)
 	dogCmd.AddCommand(dogCallCmd)
 	dogCmd.AddCommand(dogStatusCmd)
+	dogCmd.AddCommand(dogWarrantCmd)
 
 	rootCmd.AddCommand(dogCmd)
 }
@@ -502,18 +504,21 @@ func showPackStatus(mgr *dog.Manager) error {
 		return fmt.Errorf("listing dogs: %w", err)
 	}
 
+	townRoot, _ := workspace.FindFromCwd()
+
 	if dogStatusJSON {
 		type PackStatus struct {
-			Total     int    `json:"total"`
-			Idle      int    `json:"idle"`
-			Working   int    `json:"working"`
-			KennelDir string `json:"kennel_dir"`
+			Total     int          `json:"total"`
+			Idle      int          `json:"idle"`
+			Working   int          `json:"working"`
+			KennelDir string       `json:"kennel_dir"`
+			Dances    *danceStatus `json:"dances,omitempty"`
 		}
 
-		townRoot, _ := workspace.FindFromCwd()
 		status := PackStatus{
 			Total:     len(dogs),
 			KennelDir: filepath.Join(townRoot, "deacon", "dogs"),
+			Dances:    loadDanceStatus(townRoot),
 		}
 		for _, d := range dogs {
 			if d.State == dog.StateIdle {
@@ -535,6 +540,7 @@ func showPackStatus(mgr *dog.Manager) error {
 		fmt.Println("  No dogs in kennel")
 		fmt.Println()
 		fmt.Println("  Use 'gt dog add <name>' to add a dog")
+		printDanceStatus(loadDanceStatus(townRoot))
 		return nil
 	}
 
@@ -557,6 +563,7 @@ func showPackStatus(mgr *dog.Manager) error {
 		fmt.Println(style.Dim.Render("  Ready for work. Use 'gt dog call' to wake."))
 	}
 
+	printDanceStatus(loadDanceStatus(townRoot))
 	return nil
 }
 
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/shutdown"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Warrant command flags
+var (
+	dogWarrantReason    string
+	dogWarrantRequester string
+	dogWarrantCancel    bool
+)
+
+// recentDanceLimit is how many finished dances gt dog status shows.
+const recentDanceLimit = 5
+
+var dogWarrantCmd = &cobra.Command{
+	Use:   "warrant <session>",
+	Short: "File a death warrant for a stuck session",
+	Long: `File a death warrant to start a shutdown dance for a session.
+
+The daemon's dog pool picks up the warrant and runs the dance:
+  1. Send a health check asking the session to respond ALIVE-<code>,
+     with a one-time code for the dance
+  2. Wait 60s, 120s, then 240s for a response
+  3. Pardon the session if it responds, otherwise kill it
+
+Dances run concurrently (GT_DOG_POOL_SIZE, default 5) with independent
+timeouts. Warrants beyond pool capacity are queued. Progress survives
+daemon restarts. Use 'gt dog status' to watch dances and outcomes.
+
+With --cancel, the argument is a pending warrant ID to withdraw.
+
+Examples:
+  gt dog warrant gt-gastown-Toast --reason "no progress for 2h"
+  gt dog warrant --cancel warrant-1704567890123`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDogWarrant,
+}
+
+func init() {
+	dogWarrantCmd.Flags().StringVarP(&dogWarrantReason, "reason", "r", "", "Why the warrant is being filed (required)")
+	dogWarrantCmd.Flags().StringVar(&dogWarrantRequester, "requester", "", "Who is filing the warrant (default: detected role)")
+	dogWarrantCmd.Flags().BoolVar(&dogWarrantCancel, "cancel", false, "Cancel a pending warrant by ID")
+}
+
+func runDogWarrant(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	reg := shutdown.NewRegistry(townRoot)
+
+	if dogWarrantCancel {
+		if err := reg.Cancel(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("%s Warrant %s cancelled\n", style.Bold.Render("✓"), args[0])
+		return nil
+	}
+
+	if dogWarrantReason == "" {
+		return fmt.Errorf("--reason is required")
+	}
+
+	requester := dogWarrantRequester
+	if requester == "" {
+		requester = detectSender()
+	}
+
+	w := &shutdown.Warrant{
+		Target:    args[0],
+		Reason:    dogWarrantReason,
+		Requester: requester,
+	}
+	if err := reg.File(w); err != nil {
+		return fmt.Errorf("filing warrant: %w", err)
+	}
+
+	_ = events.LogFeed(events.TypeWarrantFiled, requester, events.WarrantPayload(w.ID, w.Target, w.Reason))
+
+	fmt.Printf("%s Warrant %s filed for %s\n", style.Bold.Render("✓"), w.ID, w.Target)
+	fmt.Println(style.Dim.Render("  The daemon's dog pool will begin the shutdown dance shortly."))
+	return nil
+}
+
+// danceStatus is the shutdown dance section of gt dog status.
+type danceStatus struct {
+	PoolSize int                 `json:"pool_size"`
+	Active   []*shutdown.Dance   `json:"active"`
+	Pending  []*shutdown.Warrant `json:"pending"`
+	Recent   []*shutdown.Dance   `json:"recent"`
+}
+
+// loadDanceStatus reads dance state from the registry.
+// Returns nil outside a workspace.
+func loadDanceStatus(townRoot string) *danceStatus {
+	if townRoot == "" {
+		return nil
+	}
+	reg := shutdown.NewRegistry(townRoot)
+	status := &danceStatus{PoolSize: shutdown.PoolSizeFromEnv()}
+	status.Active, _ = reg.Active()
+	status.Pending, _ = reg.Pending()
+	status.Recent, _ = reg.Completed(recentDanceLimit)
+	return status
+}
+
+// printDanceStatus prints the shutdown dance section.
+func printDanceStatus(status *danceStatus) {
+	if status == nil {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(style.Bold.Render("Shutdown Dances"))
+	fmt.Println()
+	fmt.Printf("  Dog pool: %d/%d active\n", len(status.Active), status.PoolSize)
+
+	for _, d := range status.Active {
+		target := ""
+		if d.Warrant != nil {
+			target = d.Warrant.Target
+		}
+		detail := string(d.State)
+		if d.State == shutdown.StateInterrogating {
+			detail = fmt.Sprintf("interrogating (%d/%d)", d.Attempt, shutdown.MaxAttempts)
+			if !d.NextTimeout.IsZero() {
+				if remaining := time.Until(d.NextTimeout); remaining > 0 {
+					detail += fmt.Sprintf(", timeout in %s", remaining.Round(time.Second))
+				}
+			}
+		}
+		fmt.Printf("    %s → %s: %s\n", d.ID, target, detail)
+	}
+
+	if len(status.Pending) > 0 {
+		fmt.Printf("\n  Pending warrants: %d\n", len(status.Pending))
+		for i, w := range status.Pending {
+			fmt.Printf("    %d. %s: %s (%s)\n", i+1, w.ID, w.Target, w.Reason)
+		}
+	}
+
+	if len(status.Recent) > 0 {
+		fmt.Println("\n  Recent outcomes:")
+		for _, d := range status.Recent {
+			target := ""
+			if d.Warrant != nil {
+				target = d.Warrant.Target
+			}
+			outcome := string(d.Outcome)
+			switch d.Outcome {
+			case shutdown.OutcomePardoned:
+				outcome = style.Success.Render(outcome)
+			case shutdown.OutcomeExecuted:
+				outcome = style.Warning.Render(outcome)
+			case shutdown.OutcomeFailed:
+				outcome = style.Error.Render(outcome)
+			}
+			fmt.Printf("    %s %s %s (%s)\n", outcome, target,
+				style.Dim.Render(dogFormatTimeAgo(d.CompletedAt)), d.Details)
+		}
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/steveyegge/gastown/internal/polecat"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/trace"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -136,6 +137,11 @@ func runDone(cmd *cobra.Command, args []string) error {
 		polecatName = parts[len(parts)-1]
 	}
 
+	// Continue the trace started by gt sling (see gt trace)
+	doneSpan := trace.Start("done", trace.Resume(townRoot, issueID))
+	doneSpan.Bead = issueID
+	doneSpan.Actor = sender
+
 	// Get agent bead ID for cross-referencing
 	var agentBeadID string
 	if roleInfo, err := GetRoleWithContext(cwd, townRoot); err == nil {
@@ -221,6 +227,7 @@ func runDone(cmd *cobra.Command, args []string) error {
 			if agentBeadID != "" {
 				description += fmt.Sprintf("\nagent_bead: %s", agentBeadID)
 			}
+			description += fmt.Sprintf("\ntrace_parent: %s", doneSpan.Context().TraceParent())
 
 			// Add conflict resolution tracking fields (initialized, updated by Refinery)
 			description += "\nretry_count: 0"
@@ -238,6 +245,7 @@ func runDone(cmd *cobra.Command, args []string) error {
 				return fmt.Errorf("creating merge request bead: %w", err)
 			}
 			mrID = mrIssue.ID
+			trace.Mark("mr_submitted", doneSpan.Context(), mrID, sender, branch)
 
 			// Update agent bead with active_mr reference (for traceability)
 			if agentBeadID != "" {
@@ -339,6 +347,8 @@ func runDone(cmd *cobra.Command, args []string) error {
 	// Log done event (townlog and activity feed)
 	_ = LogDone(townRoot, sender, issueID)
 	_ = events.LogFeed(events.TypeDone, sender, events.DonePayload(issueID, branch))
+	doneSpan.Detail = exitType
+	doneSpan.Finish(nil)
 
 	// Update agent bead state (ZFC: self-report completion)
 	updateAgentStateOnDone(cwd, townRoot, exitType, issueID)
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var eventsSchemaList bool
+
+var eventsCmd = &cobra.Command{
+	Use:     "events",
+	GroupID: GroupDiag,
+	Short:   "Inspect the event schema registry",
+	Long: `Inspect the typed event registry behind .events.jsonl and logs/town.log.
+
+Every event type has a registered payload schema. Payloads are validated when
+written, and each event records the schema version it was written with ("v").
+
+Subcommands:
+  schema    Dump JSON Schema for event types`,
+	RunE: requireSubcommand,
+}
+
+var eventsSchemaCmd = &cobra.Command{
+	Use:   "schema [event-type]",
+	Short: "Dump JSON Schema for event types",
+	Long: `Dump JSON Schema (draft 2020-12) describing .events.jsonl lines.
+
+With no argument, prints one document whose $defs hold a schema per event
+type, combined with oneOf. With an event type, prints that type's schema.
+
+Examples:
+  gt events schema                # All event types
+  gt events schema sling          # One event type
+  gt events schema --list         # List registered event types`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEventsSchema,
+}
+
+func init() {
+	eventsSchemaCmd.Flags().BoolVar(&eventsSchemaList, "list", false, "List registered event types instead of printing schemas")
+
+	eventsCmd.AddCommand(eventsSchemaCmd)
+	rootCmd.AddCommand(eventsCmd)
+}
+
+func runEventsSchema(cmd *cobra.Command, args []string) error {
+	if eventsSchemaList {
+		fmt.Printf("%s (schema v%d)\n", style.Bold.Render("Event types"), events.SchemaVersion)
+		for _, s := range events.Specs() {
+			where := "events"
+			if s.Payload == nil {
+				where = "town log"
+			} else if s.TownLog {
+				where = "events, town log"
+			}
+			fmt.Printf("  %-18s %s %s\n", s.Type, s.Description, style.Dim.Render("("+where+")"))
+		}
+		return nil
+	}
+
+	var doc map[string]interface{}
+	if len(args) == 1 {
+		spec, ok := events.Lookup(args[0])
+		if !ok {
+			return fmt.Errorf("%w: %s (see gt events schema --list)", events.ErrUnknownType, args[0])
+		}
+		doc = spec.JSONSchema()
+	} else {
+		doc = allEventsSchema()
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// allEventsSchema combines the schemas of every registered event type.
+func allEventsSchema() map[string]interface{} {
+	defs := map[string]interface{}{}
+	var oneOf []interface{}
+	for _, s := range events.Specs() {
+		schema := s.JSONSchema()
+		delete(schema, "$schema")
+		defs[s.Type] = schema
+		oneOf = append(oneOf, map[string]interface{}{"$ref": "#/$defs/" + s.Type})
+	}
+	return map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "Gas Town event",
+		"description": fmt.Sprintf("A line of .events.jsonl (schema v%d)", events.SchemaVersion),
+		"oneOf":       oneOf,
+		"$defs":       defs,
+	}
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+func TestAllEventsSchema(t *testing.T) {
+	doc := allEventsSchema()
+	defs := doc["$defs"].(map[string]interface{})
+	oneOf := doc["oneOf"].([]interface{})
+	if len(defs) != len(events.Specs()) || len(oneOf) != len(defs) {
+		t.Fatalf("schema has %d defs, %d oneOf; want %d", len(defs), len(oneOf), len(events.Specs()))
+	}
+	sling, ok := defs[events.TypeSling].(map[string]interface{})
+	if !ok {
+		t.Fatal("missing sling schema")
+	}
+	if _, nested := sling["$schema"]; nested {
+		t.Error("nested schema repeats $schema")
+	}
+}
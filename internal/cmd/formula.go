@@ -4,14 +4,19 @@ import (
 	"bufio"
 	"crypto/rand"
 	"encoding/base32"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/formula"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
 	"golang.org/x/text/cases"
@@ -20,12 +25,16 @@ import (
 
 // Formula command flags
 var (
-	formulaListJSON   bool
-	formulaShowJSON   bool
-	formulaRunPR      int
-	formulaRunRig     string
-	formulaRunDryRun  bool
-	formulaCreateType string
+	formulaListJSON     bool
+	formulaListSource   bool
+	formulaShowJSON     bool
+	formulaShowResolved bool
+	formulaRunPR        int
+	formulaRunRig       string
+	formulaRunDryRun    bool
+	formulaRunVars      []string
+	formulaRunVarsFile  string
+	formulaCreateType   string
 )
 
 var formulaCmd = &cobra.Command{
@@ -45,11 +54,15 @@ Commands:
   show    Display formula details (steps, variables, composition)
   run     Execute a formula (pour and dispatch)
   create  Create a new formula template
+  test    Run a workflow formula against a scripted fake agent
+  install Install formulas from a git repository (update, uninstall)
 
-Search paths (in order):
-  1. .beads/formulas/ (project)
-  2. ~/.beads/formulas/ (user)
-  3. $GT_ROOT/.beads/formulas/ (orchestrator)
+Search paths (in order of precedence):
+  1. .beads/formulas/ (rig or project)
+  2. $GT_ROOT/.beads/formulas/ (town)
+  3. $GT_ROOT/.beads/formulas/installed/ (installed packages)
+  4. ~/.beads/formulas/ (user)
+  5. Formulas embedded in gt
 
 Examples:
   gt formula list                    # List all formulas
@@ -68,8 +81,13 @@ Searches for formula files (.formula.toml, .formula.json) in:
   2. ~/.beads/formulas/ (user)
   3. $GT_ROOT/.beads/formulas/ (orchestrator)
 
+With --source, lists gt's own view instead: where each formula is found,
+by precedence rig > town > installed > user > embedded, and which
+lower-precedence sources it shadows.
+
 Examples:
   gt formula list            # List all formulas
+  gt formula list --source   # Show where each formula comes from
   gt formula list --json     # JSON output`,
 	RunE: runFormulaList,
 }
@@ -85,9 +103,15 @@ Shows:
   - Steps with dependencies
   - Composition rules (extends, aspects)
 
+With --resolved, extends and include are applied and the flattened formula
+is printed as TOML (or JSON with --json): inherited steps, overrides,
+inserted steps and included fragments, with their final needs.
+Aspects and expansions under [compose] are applied by bd cook, not here.
+
 Examples:
   gt formula show shiny
-  gt formula show rule-of-five --json`,
+  gt formula show rule-of-five --json
+  gt formula show shiny-secure --resolved`,
 	Args: cobra.ExactArgs(1),
 	RunE: runFormulaShow,
 }
@@ -108,15 +132,22 @@ If no formula name is provided, uses the default formula configured in
 the rig's settings/config.json under workflow.default_formula.
 
 Options:
-  --pr=N      Run formula on GitHub PR #N
-  --rig=NAME  Target specific rig (default: current or gastown)
-  --dry-run   Show what would happen without executing
+  --pr=N            Run formula on GitHub PR #N (sets the pr input)
+  --rig=NAME        Target specific rig (default: current or gastown)
+  --var KEY=VALUE   Set a formula var or input (repeatable)
+  --vars-file FILE  Read vars from a JSON or TOML file (--var wins)
+  --dry-run         Show what would happen without executing
+
+Vars are checked against the formula's typed vars and inputs (string,
+number, bool, enum, bead-id, rig-name, glob, duration) and defaults are
+applied. Missing required vars are prompted for when run from a terminal.
 
 Examples:
   gt formula run shiny                    # Run formula in current rig
   gt formula run                          # Run default formula from rig config
   gt formula run shiny --pr=123           # Run on PR #123
   gt formula run security-audit --rig=beads  # Run in specific rig
+  gt formula run design --var problem="rate limiting" --var scope=small
   gt formula run release --dry-run        # Preview execution`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runFormulaRun,
@@ -146,14 +177,18 @@ Examples:
 func init() {
 	// List flags
 	formulaListCmd.Flags().BoolVar(&formulaListJSON, "json", false, "Output as JSON")
+	formulaListCmd.Flags().BoolVar(&formulaListSource, "source", false, "Show the source of each formula and what it shadows")
 
 	// Show flags
 	formulaShowCmd.Flags().BoolVar(&formulaShowJSON, "json", false, "Output as JSON")
+	formulaShowCmd.Flags().BoolVar(&formulaShowResolved, "resolved", false, "Print the formula with extends and include applied")
 
 	// Run flags
 	formulaRunCmd.Flags().IntVar(&formulaRunPR, "pr", 0, "GitHub PR number to run formula on")
 	formulaRunCmd.Flags().StringVar(&formulaRunRig, "rig", "", "Target rig (default: current or gastown)")
 	formulaRunCmd.Flags().BoolVar(&formulaRunDryRun, "dry-run", false, "Preview execution without running")
+	formulaRunCmd.Flags().StringArrayVar(&formulaRunVars, "var", nil, "Formula variable (key=value), can be repeated")
+	formulaRunCmd.Flags().StringVar(&formulaRunVarsFile, "vars-file", "", "JSON or TOML file of formula variables (--var wins)")
 
 	// Create flags
 	formulaCreateCmd.Flags().StringVar(&formulaCreateType, "type", "task", "Formula type: task, workflow, or patrol")
@@ -169,6 +204,9 @@ func init() {
 
 // runFormulaList delegates to bd formula list
 func runFormulaList(cmd *cobra.Command, args []string) error {
+	if formulaListSource {
+		return listFormulaSources()
+	}
 	bdArgs := []string{"formula", "list"}
 	if formulaListJSON {
 		bdArgs = append(bdArgs, "--json")
@@ -183,6 +221,9 @@ func runFormulaList(cmd *cobra.Command, args []string) error {
 // runFormulaShow delegates to bd formula show
 func runFormulaShow(cmd *cobra.Command, args []string) error {
 	formulaName := args[0]
+	if formulaShowResolved {
+		return showResolvedFormula(formulaName)
+	}
 	bdArgs := []string{"formula", "show", formulaName}
 	if formulaShowJSON {
 		bdArgs = append(bdArgs, "--json")
@@ -194,6 +235,42 @@ func runFormulaShow(cmd *cobra.Command, args []string) error {
 	return bdCmd.Run()
 }
 
+// showResolvedFormula prints a formula flattened by formula.Resolve.
+func showResolvedFormula(name string) error {
+	f, err := loadResolvedFormula(name)
+	if err != nil {
+		return err
+	}
+	if formulaShowJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	}
+	return toml.NewEncoder(os.Stdout).Encode(f)
+}
+
+// loadResolvedFormula finds a formula in the search paths and parses it,
+// resolving extends and include from the same search paths.
+func loadResolvedFormula(name string) (*formula.Formula, error) {
+	var data []byte
+	path, err := findFormulaFile(name)
+	if err == nil {
+		data, err = os.ReadFile(path) //nolint:gosec // G304: path is from the formula search paths
+		if err != nil {
+			return nil, fmt.Errorf("reading formula: %w", err)
+		}
+	} else if embedded, embErr := formula.EmbeddedLoader(name); embErr == nil {
+		data = embedded
+	} else {
+		return nil, fmt.Errorf("finding formula: %w", err)
+	}
+	f, err := formula.ParseWith(data, formula.SearchLoader(formulaSearchPaths()...))
+	if err != nil {
+		return nil, fmt.Errorf("parsing formula %s: %w", name, err)
+	}
+	return f, nil
+}
+
 // runFormulaRun executes a formula by spawning a convoy of polecats.
 // For convoy-type formulas, it creates a convoy bead, creates leg beads,
 // and slings each leg to a separate polecat with leg-specific prompts.
@@ -256,9 +333,15 @@ func runFormulaRun(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("parsing formula: %w", err)
 	}
 
+	// Validate vars against the formula's typed vars and inputs
+	vars, err := formulaRunBindVars(formulaName)
+	if err != nil {
+		return err
+	}
+
 	// Handle dry-run mode
 	if formulaRunDryRun {
-		return dryRunFormula(f, formulaName, targetRig)
+		return dryRunFormula(f, formulaName, targetRig, vars)
 	}
 
 	// Currently only convoy formulas are supported for execution
@@ -271,15 +354,41 @@ func runFormulaRun(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  2. Cook to proto:  bd cook %s\n", formulaName)
 		fmt.Printf("  3. Pour molecule:  bd pour %s\n", formulaName)
 		fmt.Printf("  4. Sling to rig:   gt sling <mol-id> %s\n", targetRig)
+		if len(vars) > 0 {
+			fmt.Printf("\nWith vars: --var %s\n", strings.Join(formulaVarArgs(vars), " --var "))
+		}
 		return nil
 	}
 
 	// Execute convoy formula
-	return executeConvoyFormula(f, formulaName, targetRig)
+	return executeConvoyFormula(f, formulaName, targetRig, vars)
+}
+
+// formulaRunBindVars parses --var and --vars-file for gt formula run and
+// binds them to the formula's params. --pr sets the pr input if the
+// formula has one.
+func formulaRunBindVars(formulaName string) (map[string]string, error) {
+	vars, err := parseFormulaVars(formulaRunVars, formulaRunVarsFile)
+	if err != nil {
+		return nil, err
+	}
+	f, err := loadResolvedFormula(formulaName)
+	if err != nil {
+		return nil, err
+	}
+	if _, set := vars["pr"]; formulaRunPR > 0 && !set {
+		for _, p := range f.Params() {
+			if p.Name == "pr" {
+				vars["pr"] = strconv.Itoa(formulaRunPR)
+			}
+		}
+	}
+	townRoot, _ := workspace.FindFromCwd()
+	return bindFormulaVars(f, vars, townRoot)
 }
 
 // dryRunFormula shows what would happen without executing
-func dryRunFormula(f *formulaData, formulaName, targetRig string) error {
+func dryRunFormula(f *formulaData, formulaName, targetRig string, vars map[string]string) error {
 	fmt.Printf("%s Would execute formula:\n", style.Dim.Render("[dry-run]"))
 	fmt.Printf("  Formula: %s\n", style.Bold.Render(formulaName))
 	fmt.Printf("  Type:    %s\n", f.Type)
@@ -287,6 +396,9 @@ func dryRunFormula(f *formulaData, formulaName, targetRig string) error {
 	if formulaRunPR > 0 {
 		fmt.Printf("  PR:      #%d\n", formulaRunPR)
 	}
+	for _, v := range formulaVarArgs(vars) {
+		fmt.Printf("  Var:     %s\n", v)
+	}
 
 	if f.Type == "convoy" && len(f.Legs) > 0 {
 		fmt.Printf("\n  Legs (%d parallel):\n", len(f.Legs))
@@ -303,7 +415,7 @@ func dryRunFormula(f *formulaData, formulaName, targetRig string) error {
 }
 
 // executeConvoyFormula spawns a convoy of polecats to execute a convoy formula
-func executeConvoyFormula(f *formulaData, formulaName, targetRig string) error {
+func executeConvoyFormula(f *formulaData, formulaName, targetRig string, vars map[string]string) error {
 	fmt.Printf("%s Executing convoy formula: %s\n\n",
 		style.Bold.Render("🚚"), formulaName)
 
@@ -327,6 +439,9 @@ func executeConvoyFormula(f *formulaData, formulaName, targetRig string) error {
 	if formulaRunPR > 0 {
 		description += fmt.Sprintf("\nPR: #%d", formulaRunPR)
 	}
+	for _, v := range formulaVarArgs(vars) {
+		description += "\nVar: " + v
+	}
 
 	createArgs := []string{
 		"create",
@@ -357,6 +472,7 @@ func executeConvoyFormula(f *formulaData, formulaName, targetRig string) error {
 				legDesc = fmt.Sprintf("%s\n\n---\nBase Prompt:\n%s", leg.Description, basePrompt)
 			}
 		}
+		legDesc = beads.ExpandTemplateVars(legDesc, vars)
 
 		legArgs := []string{
 			"create",
@@ -505,29 +621,86 @@ type formulaSynthesis struct {
 	DependsOn   []string
 }
 
-// findFormulaFile searches for a formula file by name
-func findFormulaFile(name string) (string, error) {
-	// Search paths in order
-	searchPaths := []string{}
+// formulaSources returns the directories formulas are looked up in, in
+// order of precedence: rig (or project), town, installed packages, user.
+// The embedded formulas come last.
+func formulaSources() []formula.Source {
+	var sources []formula.Source
+	add := func(name, dir string) {
+		for _, src := range sources {
+			if src.Dir == dir {
+				return
+			}
+		}
+		sources = append(sources, formula.Source{Name: name, Dir: dir})
+	}
+
+	townDir := ""
+	if townRoot, err := workspace.FindFromCwd(); err == nil && townRoot != "" {
+		townDir = filepath.Join(townRoot, ".beads", "formulas")
+	}
 
-	// 1. Project .beads/formulas/
+	// 1. Rig or project .beads/formulas/ (unless we're at the town root)
 	if cwd, err := os.Getwd(); err == nil {
-		searchPaths = append(searchPaths, filepath.Join(cwd, ".beads", "formulas"))
+		if dir := filepath.Join(cwd, ".beads", "formulas"); dir != townDir {
+			add(formula.SourceRig, dir)
+		}
 	}
 
-	// 2. Town .beads/formulas/
-	if townRoot, err := workspace.FindFromCwd(); err == nil {
-		searchPaths = append(searchPaths, filepath.Join(townRoot, ".beads", "formulas"))
+	// 2. Town .beads/formulas/, then its installed packages
+	if townDir != "" {
+		add(formula.SourceTown, townDir)
+		if lock, err := formula.LoadLock(townDir); err == nil {
+			for _, src := range lock.Sources(townDir) {
+				add(src.Name, src.Dir)
+			}
+		}
 	}
 
 	// 3. User ~/.beads/formulas/
 	if home, err := os.UserHomeDir(); err == nil {
-		searchPaths = append(searchPaths, filepath.Join(home, ".beads", "formulas"))
+		add(formula.SourceUser, filepath.Join(home, ".beads", "formulas"))
 	}
 
+	return sources
+}
+
+// formulaSearchPaths returns the directories of formulaSources.
+func formulaSearchPaths() []string {
+	var searchPaths []string
+	for _, src := range formulaSources() {
+		searchPaths = append(searchPaths, src.Dir)
+	}
+	return searchPaths
+}
+
+// listFormulaSources prints each formula with the source it is found in.
+func listFormulaSources() error {
+	listed, err := formula.ListSources(formulaSources())
+	if err != nil {
+		return err
+	}
+	if formulaListJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listed)
+	}
+
+	for _, f := range listed {
+		line := fmt.Sprintf("  %-32s %-10s", f.Name, f.Source)
+		if len(f.Shadows) > 0 {
+			line += style.Dim.Render(" shadows " + strings.Join(f.Shadows, ", "))
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// findFormulaFile searches for a formula file by name
+func findFormulaFile(name string) (string, error) {
 	// Try each path with common extensions
 	extensions := []string{".formula.toml", ".formula.json"}
-	for _, basePath := range searchPaths {
+	for _, basePath := range formulaSearchPaths() {
 		for _, ext := range extensions {
 			path := filepath.Join(basePath, name+ext)
 			if _, err := os.Stat(path); err == nil {
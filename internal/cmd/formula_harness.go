@@ -0,0 +1,584 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFormulaTestTurns bounds a formula test whose script sets no max_turns.
+const defaultFormulaTestTurns = 100
+
+// Results of a scripted step run.
+const (
+	scriptRunDone    = "done"
+	scriptRunFail    = "fail"
+	scriptRunTimeout = "timeout" // The agent hangs; the step is failed as timed out
+)
+
+// Fates of the children a scripted step bonds.
+const (
+	scriptChildrenDone = "done"
+	scriptChildrenHang = "hang" // Left open, holding any waits_for all-children join
+)
+
+var formulaTestCmd = &cobra.Command{
+	Use:   "test <name>",
+	Short: "Run a workflow formula against a scripted fake agent",
+	Long: `Run a workflow formula end to end without live agents.
+
+The formula is poured into a throwaway beads database and driven by a fake
+agent that works the ready steps one at a time, the way 'gt mol step done'
+would: conditions, repeat_until, on_failure, outputs and waits_for gates all
+apply. The test fails if the molecule deadlocks, if any step is never
+reached (run or skipped), or if the final state differs from what the
+script expects.
+
+The script is YAML:
+
+  vars:
+    feature: auth
+  max_turns: 50              # Default 100
+  steps:
+    fix-tests:
+      runs: [fail, done]     # Result of each run: done (default), fail, timeout
+      outputs:
+        status: green
+    fan-out:
+      bond: 3                # Beads to bond under the molecule when it runs
+      children: hang         # done (default), or hang to hold all-children joins
+    per-package:
+      items: [auth, billing] # Items an expand step fans out over, instead of
+                             # gathering them (children applies too)
+  expect:
+    complete: true           # Default: true unless deadlock is expected
+    deadlock: false
+    states:                  # done, failed, skipped, open or in_progress
+      escalate: skipped
+
+Without a script every step succeeds on its first run.
+
+Requires bd. Use --keep to keep the beads database for inspection.
+
+Examples:
+  gt formula test mol-witness-patrol
+  gt formula test shiny --script tests/shiny-retry.yaml
+  gt formula test code-review --var pr=123 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFormulaTest,
+}
+
+var (
+	formulaTestScript string
+	formulaTestVars   []string
+	formulaTestKeep   bool
+	formulaTestJSON   bool
+)
+
+func init() {
+	formulaTestCmd.Flags().StringVar(&formulaTestScript, "script", "", "YAML script for the fake agent")
+	formulaTestCmd.Flags().StringArrayVar(&formulaTestVars, "var", nil, "Variable to set (key=value, overrides the script)")
+	formulaTestCmd.Flags().BoolVar(&formulaTestKeep, "keep", false, "Keep the throwaway beads database")
+	formulaTestCmd.Flags().BoolVar(&formulaTestJSON, "json", false, "Output as JSON")
+
+	formulaCmd.AddCommand(formulaTestCmd)
+}
+
+// formulaScript drives the fake agent of gt formula test.
+type formulaScript struct {
+	Vars     map[string]string       `yaml:"vars"`
+	MaxTurns int                     `yaml:"max_turns"`
+	Steps    map[string]scriptedStep `yaml:"steps"`
+	Expect   formulaExpect           `yaml:"expect"`
+}
+
+// scriptedStep is what the fake agent does when it works a step.
+type scriptedStep struct {
+	Runs     []string          `yaml:"runs"`     // Result of each run; later runs are done
+	Outputs  map[string]string `yaml:"outputs"`  // Recorded on every run
+	Bond     int               `yaml:"bond"`     // Beads bonded under the molecule
+	Items    []string          `yaml:"items"`    // For_each items of an expand step
+	Children string            `yaml:"children"` // What becomes of the bonded or expanded beads
+}
+
+// formulaExpect is the outcome a formula script expects.
+type formulaExpect struct {
+	Complete *bool             `yaml:"complete"`
+	Deadlock bool              `yaml:"deadlock"`
+	States   map[string]string `yaml:"states"` // Formula step ID -> final state
+}
+
+// FormulaTestResult is the result of gt formula test.
+type FormulaTestResult struct {
+	Formula    string            `json:"formula"`
+	Molecule   string            `json:"molecule"`
+	BeadsDir   string            `json:"beads_dir,omitempty"` // Set with --keep
+	Turns      int               `json:"turns"`
+	Complete   bool              `json:"complete"`
+	Deadlock   []string          `json:"deadlock,omitempty"` // Stuck steps and what they wait on
+	States     map[string]string `json:"states"`             // Formula step ID -> final state
+	Transcript []string          `json:"transcript"`
+	Failures   []string          `json:"failures,omitempty"` // Failed assertions
+	Passed     bool              `json:"passed"`
+}
+
+func runFormulaTest(cmd *cobra.Command, args []string) error {
+	if _, err := exec.LookPath("bd"); err != nil {
+		return fmt.Errorf("gt formula test requires bd: %w", err)
+	}
+
+	script, err := loadFormulaScript(formulaTestScript)
+	if err != nil {
+		return err
+	}
+	f, err := loadResolvedFormula(args[0])
+	if err != nil {
+		return err
+	}
+	if f.Type != formula.TypeWorkflow {
+		return fmt.Errorf("gt formula test runs workflow formulas (%s is %s)", f.Name, f.Type)
+	}
+	if err := script.validate(f); err != nil {
+		return err
+	}
+
+	vars, err := parseFormulaVars(formulaTestVars, "")
+	if err != nil {
+		return err
+	}
+	for k, v := range script.Vars {
+		if _, ok := vars[k]; !ok {
+			vars[k] = v
+		}
+	}
+	if vars, err = f.BindParams(vars, formula.BindOptions{}); err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "gt-formula-test-")
+	if err != nil {
+		return fmt.Errorf("creating beads directory: %w", err)
+	}
+	if !formulaTestKeep {
+		defer func() { _ = os.RemoveAll(dir) }()
+	}
+
+	b, molID, err := pourTestMolecule(dir, f, vars)
+	if err != nil {
+		return err
+	}
+	result, err := driveFormulaTest(b, molID, f, vars, script)
+	if err != nil {
+		return err
+	}
+	if formulaTestKeep {
+		result.BeadsDir = dir
+	}
+	result.Failures = script.Expect.check(result)
+	result.Passed = len(result.Failures) == 0
+
+	if formulaTestJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		printFormulaTestResult(result)
+	}
+	if !result.Passed {
+		return NewSilentExit(1)
+	}
+	return nil
+}
+
+// loadFormulaScript reads a formula test script. An empty path is a script
+// in which every step succeeds.
+func loadFormulaScript(path string) (*formulaScript, error) {
+	script := &formulaScript{}
+	if path == "" {
+		return script, nil
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is given by the user
+	if err != nil {
+		return nil, fmt.Errorf("reading script: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(script); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing script %s: %w", path, err)
+	}
+	return script, nil
+}
+
+// validate checks the script against the formula it drives.
+func (s *formulaScript) validate(f *formula.Formula) error {
+	if s.MaxTurns < 0 {
+		return fmt.Errorf("script: max_turns must not be negative")
+	}
+	for id, step := range s.Steps {
+		if f.GetStep(id) == nil {
+			return fmt.Errorf("script: formula %s has no step %q", f.Name, id)
+		}
+		for _, run := range step.Runs {
+			switch run {
+			case scriptRunDone, scriptRunFail, scriptRunTimeout:
+			default:
+				return fmt.Errorf("script: step %s: unknown run result %q (want done, fail or timeout)", id, run)
+			}
+		}
+		switch step.Children {
+		case "", scriptChildrenDone, scriptChildrenHang:
+		default:
+			return fmt.Errorf("script: step %s: unknown children %q (want done or hang)", id, step.Children)
+		}
+		if step.Bond < 0 {
+			return fmt.Errorf("script: step %s: bond must not be negative", id)
+		}
+		if len(step.Items) > 0 && f.GetStep(id).Expand == "" {
+			return fmt.Errorf("script: step %s: items is only for expand steps", id)
+		}
+	}
+	for id, state := range s.Expect.States {
+		if f.GetStep(id) == nil {
+			return fmt.Errorf("script: expect names unknown step %q", id)
+		}
+		switch state {
+		case string(formula.OutcomeDone), string(formula.OutcomeFailed), string(formula.OutcomeSkipped), "open", "in_progress":
+		default:
+			return fmt.Errorf("script: expect %s: unknown state %q", id, state)
+		}
+	}
+	return nil
+}
+
+// run returns the result of the n-th run (from 0) of a step.
+func (s *formulaScript) run(id string, n int) string {
+	runs := s.Steps[id].Runs
+	if n < len(runs) {
+		return runs[n]
+	}
+	return scriptRunDone
+}
+
+// pourTestMolecule creates a throwaway beads database in dir and pours the
+// formula into it: a root bead recording the formula and a child bead per
+// step, with the steps' needs as dependencies.
+func pourTestMolecule(dir string, f *formula.Formula, vars map[string]string) (*beads.Beads, string, error) {
+	initCmd := exec.Command("bd", "init", "--quiet", "--prefix", "ft")
+	initCmd.Dir = dir
+	if out, err := initCmd.CombinedOutput(); err != nil {
+		return nil, "", fmt.Errorf("bd init: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	b := beads.NewWithBeadsDir(dir, filepath.Join(dir, ".beads"))
+
+	root, err := b.Create(beads.CreateOptions{
+		Title:       f.Name,
+		Type:        "molecule",
+		Priority:    2,
+		Description: beads.FormatMoleculeFields(&beads.MoleculeFields{Formula: f.Name, Vars: vars}),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("creating molecule: %w", err)
+	}
+
+	beadIDs := make(map[string]string)
+	for _, step := range f.Steps {
+		title := step.Title
+		if title == "" {
+			title = step.ID
+		}
+		desc := beads.SetStepFields(&beads.Issue{Description: beads.ExpandTemplateVars(step.Description, vars)},
+			&beads.StepFields{FormulaStep: step.ID})
+		issue, err := b.Create(beads.CreateOptions{
+			Title:       beads.ExpandTemplateVars(title, vars),
+			Priority:    2,
+			Description: desc,
+			Parent:      root.ID,
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("creating step %s: %w", step.ID, err)
+		}
+		beadIDs[step.ID] = issue.ID
+	}
+	for _, step := range f.Steps {
+		for _, need := range step.Needs {
+			if err := b.AddDependency(beadIDs[step.ID], beadIDs[need]); err != nil {
+				return nil, "", fmt.Errorf("adding dependency %s -> %s: %w", step.ID, need, err)
+			}
+		}
+	}
+	return b, root.ID, nil
+}
+
+// driveFormulaTest works the molecule's ready steps as scripted until it
+// completes, deadlocks or runs out of turns. The flow is reloaded from the
+// database each turn, as a fresh agent session would.
+func driveFormulaTest(b *beads.Beads, molID string, f *formula.Formula, vars map[string]string, script *formulaScript) (*FormulaTestResult, error) {
+	result := &FormulaTestResult{Formula: f.Name, Molecule: molID}
+	maxTurns := script.MaxTurns
+	if maxTurns == 0 {
+		maxTurns = defaultFormulaTestTurns
+	}
+	logf := func(format string, args ...interface{}) {
+		result.Transcript = append(result.Transcript, fmt.Sprintf(format, args...))
+	}
+
+	runs := make(map[string]int)
+	var flow *moleculeFlow
+	for {
+		children, err := b.List(beads.ListOptions{Parent: molID, Status: "all", Priority: -1})
+		if err != nil {
+			return nil, fmt.Errorf("listing molecule steps: %w", err)
+		}
+		flow = newMoleculeFlow(f, vars, children)
+		flow.root = molID
+		flow.gather = script.gather
+		flow.dispatch = func(_, rig string, beadIDs, _ []string) (string, error) {
+			logf("dispatch %d beads to %s", len(beadIDs), rig)
+			return "", nil
+		}
+
+		known := len(flow.children)
+		ready, skipped, expanded, err := advanceFormulaFlow(b, flow)
+		if err != nil {
+			return nil, err
+		}
+		for _, bead := range skipped {
+			logf("skip %s: %s", flow.stepIDs[bead.ID], flow.describeSkip(bead))
+		}
+		if len(expanded) > 0 {
+			if err := workExpandedBeads(b, flow, expanded, flow.children[known:], script, logf); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if len(ready) == 0 {
+			if flow.allClosed() {
+				result.Complete = true
+			} else {
+				result.Deadlock = stuckSteps(flow)
+			}
+			break
+		}
+		if result.Turns >= maxTurns {
+			logf("stop: max_turns (%d) reached", maxTurns)
+			break
+		}
+		result.Turns++
+
+		bead := ready[0]
+		id := flow.stepIDs[bead.ID]
+		run := script.run(id, runs[id])
+		runs[id]++
+		if err := workScriptedStep(b, flow, molID, bead, script.Steps[id], run, logf); err != nil {
+			return nil, err
+		}
+	}
+
+	result.States = make(map[string]string, len(f.Steps))
+	for _, step := range f.Steps {
+		if outcome, ok := flow.progress.Outcomes[step.ID]; ok {
+			result.States[step.ID] = string(outcome)
+		} else if bead := flow.steps[step.ID]; bead != nil {
+			result.States[step.ID] = bead.Status
+		}
+	}
+	return result, nil
+}
+
+// workScriptedStep has the fake agent claim a step, bond the scripted
+// children, and finish the step with the scripted result.
+func workScriptedStep(b *beads.Beads, flow *moleculeFlow, molID string, bead *beads.Issue, step scriptedStep, run string, logf func(string, ...interface{})) error {
+	id := flow.stepIDs[bead.ID]
+	inProgress := "in_progress"
+	if err := b.Update(bead.ID, beads.UpdateOptions{Status: &inProgress}); err != nil {
+		return fmt.Errorf("claiming step %s: %w", id, err)
+	}
+
+	for i := 1; i <= step.Bond; i++ {
+		child, err := b.Create(beads.CreateOptions{
+			Title:    fmt.Sprintf("%s: bonded work %d", id, i),
+			Priority: 2,
+			Parent:   molID,
+		})
+		if err != nil {
+			return fmt.Errorf("bonding child of %s: %w", id, err)
+		}
+		if step.Children == scriptChildrenHang {
+			continue
+		}
+		if err := b.Close(child.ID); err != nil {
+			return fmt.Errorf("closing bonded child %s: %w", child.ID, err)
+		}
+	}
+
+	failed := run != scriptRunDone
+	if err := flow.formula.CheckOutputs(id, step.Outputs); err != nil {
+		return fmt.Errorf("script: %w", err)
+	}
+	if missing := flow.formula.MissingOutputs(id, step.Outputs); len(missing) > 0 && !failed {
+		return fmt.Errorf("script: step %s requires output %s", id, strings.Join(missing, ", "))
+	}
+	fields, repeat := flow.finish(bead, failed, step.Outputs)
+	if err := saveFormulaStep(b, bead, fields, repeat); err != nil {
+		return err
+	}
+
+	switch {
+	case repeat:
+		logf("run %s: done, repeats (iteration %d)", id, fields.Iteration)
+	case run == scriptRunTimeout:
+		logf("run %s: timed out, failed", id)
+	default:
+		logf("run %s: %s", id, fields.Outcome)
+	}
+	return nil
+}
+
+// gather returns the scripted items of an expand step. Without them only
+// a literal items source is used; gathering live data would make the
+// test depend on its surroundings.
+func (s *formulaScript) gather(id string, fe *formula.ForEach, vars map[string]string) ([]formula.Item, error) {
+	if items := s.Steps[id].Items; len(items) > 0 {
+		return gatherForEachItems(&formula.ForEach{Items: items}, vars)
+	}
+	if fe.Source() == formula.ForEachItems {
+		return gatherForEachItems(fe, vars)
+	}
+	return nil, fmt.Errorf("script: step %s fans out over %s; give its items in the script", id, fe.Source())
+}
+
+// workExpandedBeads has the fake agent finish the beads expand steps
+// created, unless the script leaves them hanging.
+func workExpandedBeads(b *beads.Beads, flow *moleculeFlow, expanded, created []*beads.Issue, script *formulaScript, logf func(string, ...interface{})) error {
+	hang := false
+	for _, bead := range expanded {
+		id := flow.stepIDs[bead.ID]
+		logf("expand %s: %s", id, flow.describeExpand(bead))
+		hang = hang || script.Steps[id].Children == scriptChildrenHang
+	}
+	if hang {
+		return nil
+	}
+	for _, child := range created {
+		if err := b.Close(child.ID); err != nil {
+			return fmt.Errorf("closing expanded bead %s: %w", child.ID, err)
+		}
+	}
+	return nil
+}
+
+// stuckSteps describes the open steps of a molecule that cannot progress.
+func stuckSteps(flow *moleculeFlow) []string {
+	var stuck []string
+	for _, step := range flow.formula.Steps {
+		bead := flow.steps[step.ID]
+		if bead == nil || bead.Status == "closed" {
+			continue
+		}
+		var reasons []string
+		for _, need := range step.Needs {
+			switch flow.progress.Outcomes[need] {
+			case formula.OutcomeDone, formula.OutcomeSkipped:
+			case formula.OutcomeFailed:
+				reasons = append(reasons, need+" failed")
+			default:
+				reasons = append(reasons, "needs "+need)
+			}
+		}
+		if waiting := flow.waitingOn(step.ID); len(waiting) > 0 {
+			reasons = append(reasons, "waits for all-children ("+strings.Join(waiting, ", ")+")")
+		}
+		if len(reasons) == 0 {
+			reasons = append(reasons, bead.Status)
+		}
+		stuck = append(stuck, step.ID+": "+strings.Join(reasons, ", "))
+	}
+	return stuck
+}
+
+// check compares a formula test result with the expected outcome, and
+// returns the failed assertions.
+func (e formulaExpect) check(r *FormulaTestResult) []string {
+	var failures []string
+	switch {
+	case len(r.Deadlock) > 0 && !e.Deadlock:
+		failures = append(failures, "deadlock: "+strings.Join(r.Deadlock, "; "))
+	case len(r.Deadlock) == 0 && e.Deadlock:
+		failures = append(failures, "expected a deadlock")
+	}
+	wantComplete := !e.Deadlock
+	if e.Complete != nil {
+		wantComplete = *e.Complete
+	}
+	if r.Complete != wantComplete {
+		failures = append(failures, fmt.Sprintf("complete = %v, want %v", r.Complete, wantComplete))
+	}
+
+	ids := make([]string, 0, len(r.States))
+	for id := range r.States {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		state := r.States[id]
+		want, ok := e.States[id]
+		switch {
+		case ok && state != want:
+			failures = append(failures, fmt.Sprintf("step %s is %s, want %s", id, state, want))
+		case !ok && (state == "open" || state == "in_progress"):
+			failures = append(failures, fmt.Sprintf("step %s never reached", id))
+		}
+	}
+	return failures
+}
+
+// printFormulaTestResult prints a formula test transcript and verdict.
+func printFormulaTestResult(r *FormulaTestResult) {
+	fmt.Printf("%s %s (molecule %s)\n\n", style.Bold.Render("Testing formula"), r.Formula, r.Molecule)
+	for _, line := range r.Transcript {
+		fmt.Printf("  %s\n", line)
+	}
+
+	fmt.Printf("\n%s\n", style.Bold.Render("Final states:"))
+	ids := make([]string, 0, len(r.States))
+	for id := range r.States {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Printf("  %-24s %s\n", id, r.States[id])
+	}
+	if len(r.Deadlock) > 0 {
+		fmt.Printf("\n%s\n", style.Bold.Render("Deadlocked:"))
+		for _, d := range r.Deadlock {
+			fmt.Printf("  %s\n", d)
+		}
+	}
+	if r.BeadsDir != "" {
+		fmt.Printf("\n%s\n", style.Dim.Render("Beads database kept at "+r.BeadsDir))
+	}
+
+	fmt.Println()
+	if r.Passed {
+		fmt.Printf("%s Passed in %d turns\n", style.Success.Render("✓"), r.Turns)
+		return
+	}
+	for _, failure := range r.Failures {
+		fmt.Printf("%s %s\n", style.Error.Render("✗"), failure)
+	}
+}
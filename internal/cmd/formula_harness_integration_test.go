@@ -0,0 +1,109 @@
+//go:build integration
+
+// Package cmd contains integration tests for gt formula test.
+//
+// Run with: go test -tags=integration ./internal/cmd -run TestFormulaTestHarness -v
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+func TestFormulaTestHarness(t *testing.T) {
+	if _, err := exec.LookPath("bd"); err != nil {
+		t.Skip("bd not installed")
+	}
+	f, err := formula.Parse([]byte(testFlowFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	vars := map[string]string{"feature": "auth"}
+
+	// fix-tests fails once; escalate handles it and submit still runs
+	script := &formulaScript{Steps: map[string]scriptedStep{"fix-tests": {Runs: []string{scriptRunTimeout}}}}
+	b, molID, err := pourTestMolecule(t.TempDir(), f, vars)
+	if err != nil {
+		t.Fatalf("pourTestMolecule: %v", err)
+	}
+	result, err := driveFormulaTest(b, molID, f, vars, script)
+	if err != nil {
+		t.Fatalf("driveFormulaTest: %v", err)
+	}
+	if failures := (formulaExpect{}).check(result); len(failures) != 0 {
+		t.Errorf("failures = %v\ntranscript: %v", failures, result.Transcript)
+	}
+	if result.States["fix-tests"] != "failed" || result.States["escalate"] != "done" || result.States["submit"] != "done" {
+		t.Errorf("states = %v", result.States)
+	}
+}
+
+func TestFormulaTestHarnessFanOut(t *testing.T) {
+	if _, err := exec.LookPath("bd"); err != nil {
+		t.Skip("bd not installed")
+	}
+	dir := t.TempDir()
+	formulasDir := filepath.Join(dir, ".beads", "formulas")
+	if err := os.MkdirAll(formulasDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	expansion := `
+formula = "per-item"
+type = "expansion"
+
+[for_each]
+items = ["a", "b", "c"]
+max_concurrent = 2
+
+[[template]]
+id = "work"
+title = "Work on {item}"
+`
+	if err := os.WriteFile(filepath.Join(formulasDir, "per-item.formula.toml"), []byte(expansion), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	f, err := formula.Parse([]byte(`
+formula = "fan"
+type = "workflow"
+
+[[steps]]
+id = "fan-out"
+title = "Fan out"
+expand = "per-item"
+
+[[steps]]
+id = "join"
+title = "Join"
+needs = ["fan-out"]
+waits_for = ["all-children"]
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	for _, tc := range []struct {
+		script   *formulaScript
+		complete bool
+	}{
+		{&formulaScript{}, true},
+		{&formulaScript{Steps: map[string]scriptedStep{"fan-out": {Items: []string{"x"}, Children: scriptChildrenHang}}}, false},
+	} {
+		b, molID, err := pourTestMolecule(t.TempDir(), f, nil)
+		if err != nil {
+			t.Fatalf("pourTestMolecule: %v", err)
+		}
+		result, err := driveFormulaTest(b, molID, f, nil, tc.script)
+		if err != nil {
+			t.Fatalf("driveFormulaTest: %v", err)
+		}
+		if result.Complete != tc.complete || result.States["fan-out"] != "done" {
+			t.Errorf("complete = %v, states = %v\ntranscript: %v", result.Complete, result.States, result.Transcript)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+func TestLoadFormulaScript(t *testing.T) {
+	f, err := formula.Parse([]byte(testFlowFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	script, err := loadFormulaScript(write("ok.yaml", `
+vars:
+  feature: auth
+steps:
+  fix-tests:
+    runs: [fail]
+expect:
+  states:
+    escalate: done
+`))
+	if err != nil {
+		t.Fatalf("loadFormulaScript: %v", err)
+	}
+	if err := script.validate(f); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+	if script.run("fix-tests", 0) != scriptRunFail || script.run("fix-tests", 1) != scriptRunDone || script.run("scan", 0) != scriptRunDone {
+		t.Errorf("runs = %v", script.Steps)
+	}
+
+	empty, err := loadFormulaScript(write("empty.yaml", ""))
+	if err != nil || empty.validate(f) != nil {
+		t.Errorf("empty script: %v", err)
+	}
+	if _, err := loadFormulaScript(write("typo.yaml", "stepz: {}\n")); err == nil {
+		t.Error("unknown field accepted")
+	}
+
+	for _, bad := range []string{
+		"steps:\n  nope: {}\n",
+		"steps:\n  scan:\n    runs: [crash]\n",
+		"steps:\n  scan:\n    children: lost\n",
+		"expect:\n  states:\n    scan: finished\n",
+	} {
+		script, err := loadFormulaScript(write("bad.yaml", bad))
+		if err != nil {
+			t.Fatalf("loadFormulaScript(%q): %v", bad, err)
+		}
+		if err := script.validate(f); err == nil || !strings.HasPrefix(err.Error(), "script:") {
+			t.Errorf("%q: err = %v", bad, err)
+		}
+	}
+}
+
+func TestFormulaExpectCheck(t *testing.T) {
+	result := &FormulaTestResult{
+		Complete: true,
+		States:   map[string]string{"scan": "skipped", "fix-tests": "done", "escalate": "skipped", "submit": "done"},
+	}
+	if failures := (formulaExpect{}).check(result); len(failures) != 0 {
+		t.Errorf("passing result failed: %v", failures)
+	}
+	if failures := (formulaExpect{States: map[string]string{"scan": "done"}}).check(result); len(failures) != 1 ||
+		failures[0] != "step scan is skipped, want done" {
+		t.Errorf("state failures = %v", failures)
+	}
+
+	stuck := &FormulaTestResult{
+		Deadlock: []string{"submit: needs fix-tests"},
+		States:   map[string]string{"fix-tests": "in_progress", "submit": "open"},
+	}
+	failures := (formulaExpect{}).check(stuck)
+	if len(failures) != 4 || !strings.HasPrefix(failures[0], "deadlock: ") || failures[3] != "step submit never reached" {
+		t.Errorf("deadlock failures = %v", failures)
+	}
+	expected := formulaExpect{Deadlock: true, States: map[string]string{"fix-tests": "in_progress", "submit": "open"}}
+	if failures := expected.check(stuck); len(failures) != 0 {
+		t.Errorf("expected deadlock failed: %v", failures)
+	}
+}
+
+func TestStuckSteps(t *testing.T) {
+	f, err := formula.Parse([]byte(`
+formula = "fan"
+type = "workflow"
+
+[[steps]]
+id = "spawn"
+title = "Spawn"
+
+[[steps]]
+id = "join"
+title = "Join"
+needs = ["spawn"]
+waits_for = ["all-children"]
+
+[[steps]]
+id = "report"
+title = "Report"
+needs = ["join"]
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	children := []*beads.Issue{
+		{ID: "ft-m.1", Title: "Spawn", Status: "closed"},
+		{ID: "ft-m.2", Title: "Join", Status: "open"},
+		{ID: "ft-m.3", Title: "Report", Status: "open"},
+		{ID: "ft-m.4", Title: "spawn: bonded work 1", Status: "open"},
+	}
+	flow := newMoleculeFlow(f, nil, children)
+	if ready, _ := flow.plan(); len(ready) != 0 {
+		t.Errorf("ready = %v, want join held by its open child", ready)
+	}
+	stuck := stuckSteps(flow)
+	want := []string{"join: waits for all-children (ft-m.4)", "report: needs join"}
+	if strings.Join(stuck, "; ") != strings.Join(want, "; ") {
+		t.Errorf("stuckSteps = %v, want %v", stuck, want)
+	}
+
+	children[3].Status = "closed"
+	if ready, _ := flow.plan(); len(ready) != 1 || ready[0].ID != "ft-m.2" {
+		t.Errorf("ready = %v, want join once its child closes", ready)
+	}
+}
@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var formulaInstallCmd = &cobra.Command{
+	Use:   "install [<git-url>[@ref]]",
+	Short: "Install formulas from a git repository",
+	Long: `Install a formula package from a git repository into the town.
+
+The package's formulas (*.formula.toml at its root, in formulas/ or in
+.beads/formulas/) are validated and copied into the town's
+.beads/formulas/installed/<package>/, and the commit they came from is
+pinned in .beads/formulas/formulas.lock.json. Without a ref the package
+follows its default branch on update.
+
+A package must be verified: --verify checks the commit (or tag) signature
+with git, now and on every update. --insecure installs it without a
+signature check, and is remembered for its updates. A SHA256SUMS file in
+the package, if present, must match every formula; it comes from the same
+repository, so it only catches corruption and doesn't stand in for a
+signature.
+
+Without arguments, installs every package in the lockfile at its pinned
+commit, checking the formulas against the lockfile checksums.
+
+Precedence is rig > town > installed > user > embedded: a town formula of
+the same name overrides the package's without changing it.
+See 'gt formula list --source'.
+
+Examples:
+  gt formula install https://github.com/acme/gt-formulas
+  gt formula install git@github.com:acme/gt-formulas.git@v1.2.0 --verify
+  gt formula install                          # Restore from the lockfile`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runFormulaInstall,
+}
+
+var formulaUpdateCmd = &cobra.Command{
+	Use:   "update [package[@ref]...]",
+	Short: "Update installed formula packages",
+	Long: `Update installed formula packages to the latest commit of their ref.
+
+A package installed without a ref follows its default branch; give
+package@ref to move it to another ref. Installed formulas that were
+edited since install are kept unless --force is set.
+
+Examples:
+  gt formula update                 # Update all packages
+  gt formula update gt-formulas@v2.0.0`,
+	RunE: runFormulaUpdate,
+}
+
+var formulaUninstallCmd = &cobra.Command{
+	Use:   "uninstall <package>",
+	Short: "Remove an installed formula package",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runFormulaUninstall,
+}
+
+var (
+	formulaInstallVerify   bool
+	formulaInstallInsecure bool
+	formulaInstallForce    bool
+)
+
+func init() {
+	formulaInstallCmd.Flags().BoolVar(&formulaInstallVerify, "verify", false, "Require a valid git signature on the commit or tag")
+	formulaInstallCmd.Flags().BoolVar(&formulaInstallInsecure, "insecure", false, "Install without a signature check")
+	formulaInstallCmd.Flags().BoolVar(&formulaInstallForce, "force", false, "Overwrite installed formulas that were edited")
+	formulaUpdateCmd.Flags().BoolVar(&formulaInstallVerify, "verify", false, "Require a valid git signature from now on")
+	formulaUpdateCmd.Flags().BoolVar(&formulaInstallInsecure, "insecure", false, "Update without a signature check")
+	formulaUpdateCmd.Flags().BoolVar(&formulaInstallForce, "force", false, "Overwrite installed formulas that were edited")
+
+	formulaCmd.AddCommand(formulaInstallCmd)
+	formulaCmd.AddCommand(formulaUpdateCmd)
+	formulaCmd.AddCommand(formulaUninstallCmd)
+}
+
+func runFormulaInstall(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	dir := filepath.Join(townRoot, ".beads", "formulas")
+	lock, err := formula.LoadLock(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		if len(lock.Packages) == 0 {
+			fmt.Println("No formula packages in the lockfile")
+			return nil
+		}
+		for _, pkg := range lock.Packages {
+			files, _, err := fetchFormulaPackage(pkg.URL, pkg.Ref, pkg.Commit, pkg.Verified, pkg.Insecure)
+			if err != nil {
+				return fmt.Errorf("%s: %w", pkg.Name, err)
+			}
+			if err := pkg.CheckLocked(files); err != nil {
+				return err
+			}
+			if err := lock.Install(dir, pkg, files, formulaInstallForce); err != nil {
+				return err
+			}
+			fmt.Printf("%s %s @ %s\n", style.Success.Render("✓"), pkg.Name, shortRev(pkg.Commit))
+		}
+		return lock.Save(dir)
+	}
+
+	url, ref := parseFormulaPackageRef(args[0])
+	name := formulaPackageName(url)
+	if existing := lock.Get(name); existing != nil && existing.URL != url {
+		return fmt.Errorf("a package named %s is already installed from %s", name, existing.URL)
+	}
+	files, commit, err := fetchFormulaPackage(url, ref, "", formulaInstallVerify, formulaInstallInsecure)
+	if err != nil {
+		return err
+	}
+	pkg := formula.LockedPackage{
+		Name:        name,
+		URL:         url,
+		Ref:         ref,
+		Commit:      commit,
+		Verified:    formulaInstallVerify,
+		Insecure:    formulaInstallInsecure && !formulaInstallVerify,
+		InstalledAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := lock.Install(dir, pkg, files, formulaInstallForce); err != nil {
+		return err
+	}
+	if err := lock.Save(dir); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Installed %s @ %s: %s\n", style.Success.Render("✓"), name, shortRev(commit), strings.Join(formulaFileNames(files), ", "))
+	warnShadowedFormulas(dir, lock.Get(name))
+	return nil
+}
+
+func runFormulaUpdate(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	dir := filepath.Join(townRoot, ".beads", "formulas")
+	lock, err := formula.LoadLock(dir)
+	if err != nil {
+		return err
+	}
+
+	refs := make(map[string]string) // Package -> new ref
+	var names []string
+	for _, arg := range args {
+		name, ref, hasRef := strings.Cut(arg, "@")
+		if lock.Get(name) == nil {
+			return fmt.Errorf("formula package %s is not installed", name)
+		}
+		if hasRef {
+			refs[name] = ref
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		for _, pkg := range lock.Packages {
+			names = append(names, pkg.Name)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Println("No formula packages installed")
+		return nil
+	}
+
+	for _, name := range names {
+		pkg := *lock.Get(name)
+		if ref, ok := refs[name]; ok {
+			pkg.Ref = ref
+		}
+		pkg.Verified = pkg.Verified || formulaInstallVerify
+		pkg.Insecure = (pkg.Insecure || formulaInstallInsecure) && !pkg.Verified
+
+		files, commit, err := fetchFormulaPackage(pkg.URL, pkg.Ref, "", pkg.Verified, pkg.Insecure)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if commit == pkg.Commit {
+			fmt.Printf("%s %s is up to date (%s)\n", style.Dim.Render("·"), name, shortRev(commit))
+			continue
+		}
+		old := pkg.Commit
+		pkg.Commit = commit
+		pkg.InstalledAt = time.Now().UTC().Format(time.RFC3339)
+		if err := lock.Install(dir, pkg, files, formulaInstallForce); err != nil {
+			return err
+		}
+		fmt.Printf("%s %s %s → %s\n", style.Success.Render("✓"), name, shortRev(old), shortRev(commit))
+	}
+	return lock.Save(dir)
+}
+
+func runFormulaUninstall(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	dir := filepath.Join(townRoot, ".beads", "formulas")
+	lock, err := formula.LoadLock(dir)
+	if err != nil {
+		return err
+	}
+	if err := lock.Uninstall(dir, args[0]); err != nil {
+		return err
+	}
+	if err := lock.Save(dir); err != nil {
+		return err
+	}
+	fmt.Printf("%s Uninstalled %s\n", style.Success.Render("✓"), args[0])
+	return nil
+}
+
+// fetchFormulaPackage clones a formula package and checks out commit, or
+// else ref, or else the default branch. The package's formulas are checked
+// against its SHA256SUMS file and validated, and with verify the signature
+// of the ref (or commit) is checked. SHA256SUMS is integrity only, since
+// whoever can push the formulas can push the sums, so without verify the
+// package is refused unless insecure is set, or a commit is given (its
+// formulas are then checked against the lock by the caller). It returns
+// the formulas by filename and the commit they came from.
+func fetchFormulaPackage(url, ref, commit string, verify, insecure bool) (map[string][]byte, string, error) {
+	tmp, err := os.MkdirTemp("", "gt-formula-pkg-")
+	if err != nil {
+		return nil, "", fmt.Errorf("creating temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	dest := filepath.Join(tmp, "pkg")
+	if err := git.NewGit(tmp).Clone(url, dest); err != nil {
+		return nil, "", fmt.Errorf("cloning %s: %w", url, err)
+	}
+	g := git.NewGit(dest)
+	checkout := commit
+	if checkout == "" {
+		checkout = ref
+	}
+	if checkout != "" {
+		if err := g.Checkout(checkout); err != nil {
+			return nil, "", fmt.Errorf("checking out %s: %w", checkout, err)
+		}
+	}
+	resolved, err := g.Rev("HEAD")
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving commit: %w", err)
+	}
+
+	if verify {
+		// Verify the tag when the ref is a tag of this commit
+		target := "HEAD"
+		if ref != "" {
+			if rev, err := g.Rev(ref + "^{commit}"); err == nil && rev == resolved {
+				target = ref
+			}
+		}
+		if err := g.VerifySignature(target); err != nil {
+			return nil, "", fmt.Errorf("signature verification failed for %s: %w", target, err)
+		}
+	}
+
+	files, paths, err := formula.PackageFormulas(dest)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := formula.VerifyChecksums(dest, files, paths); err != nil {
+		return nil, "", err
+	}
+	if !verify && !insecure && commit == "" {
+		return nil, "", fmt.Errorf("%s is unverified: use --verify to require a git signature, or --insecure to install it without one", url)
+	}
+	if err := validatePackageFormulas(files); err != nil {
+		return nil, "", err
+	}
+	return files, resolved, nil
+}
+
+// validatePackageFormulas parses a package's TOML formulas, resolving
+// extends and include against the package first, then the search path.
+func validatePackageFormulas(files map[string][]byte) error {
+	search := formula.SearchLoader(formulaSearchPaths()...)
+	load := func(name string) ([]byte, error) {
+		if data, ok := files[name+".formula.toml"]; ok {
+			return data, nil
+		}
+		return search(name)
+	}
+	for _, file := range formulaFileNames(files) {
+		if !strings.HasSuffix(file, ".formula.toml") {
+			continue
+		}
+		if _, err := formula.ParseWith(files[file], load); err != nil {
+			return fmt.Errorf("invalid formula %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// warnShadowedFormulas warns about formulas of a package that the town's
+// own formulas (in dir) or the current rig's take precedence over.
+func warnShadowedFormulas(dir string, pkg *formula.LockedPackage) {
+	rigDir := ""
+	if cwd, err := os.Getwd(); err == nil {
+		if d := filepath.Join(cwd, ".beads", "formulas"); d != dir {
+			rigDir = d
+		}
+	}
+	files := make([]string, 0, len(pkg.Formulas))
+	for file := range pkg.Formulas {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			style.PrintWarning("%s is shadowed by the town's own %s", file, filepath.Join(dir, file))
+		} else if rigDir != "" {
+			if _, err := os.Stat(filepath.Join(rigDir, file)); err == nil {
+				style.PrintWarning("%s is shadowed in this rig by %s", file, filepath.Join(rigDir, file))
+			}
+		}
+	}
+}
+
+// parseFormulaPackageRef splits url@ref. An @ before the path (as in
+// git@github.com:org/repo) is part of the URL.
+func parseFormulaPackageRef(arg string) (url, ref string) {
+	at := strings.LastIndex(arg, "@")
+	if at < 0 || at < strings.LastIndexAny(arg, "/:") {
+		return arg, ""
+	}
+	return arg[:at], arg[at+1:]
+}
+
+// formulaPackageName names a package after its repository.
+func formulaPackageName(url string) string {
+	url = strings.TrimRight(url, "/")
+	if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+		url = url[i+1:]
+	}
+	return strings.TrimSuffix(url, ".git")
+}
+
+func formulaFileNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func shortRev(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFormulaPackageRef(t *testing.T) {
+	tests := []struct {
+		arg, url, ref, name string
+	}{
+		{"https://github.com/acme/gt-formulas", "https://github.com/acme/gt-formulas", "", "gt-formulas"},
+		{"https://github.com/acme/gt-formulas@v1.2.0", "https://github.com/acme/gt-formulas", "v1.2.0", "gt-formulas"},
+		{"git@github.com:acme/gt-formulas.git", "git@github.com:acme/gt-formulas.git", "", "gt-formulas"},
+		{"git@github.com:acme/gt-formulas.git@main", "git@github.com:acme/gt-formulas.git", "main", "gt-formulas"},
+		{"/srv/formulas/", "/srv/formulas/", "", "formulas"},
+	}
+	for _, tt := range tests {
+		url, ref := parseFormulaPackageRef(tt.arg)
+		if url != tt.url || ref != tt.ref {
+			t.Errorf("parseFormulaPackageRef(%q) = %q, %q, want %q, %q", tt.arg, url, ref, tt.url, tt.ref)
+		}
+		if name := formulaPackageName(url); name != tt.name {
+			t.Errorf("formulaPackageName(%q) = %q, want %q", url, name, tt.name)
+		}
+	}
+}
+
+func TestFetchFormulaPackage(t *testing.T) {
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@test.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("formulas/acme-review.formula.toml", `
+formula = "acme-review"
+type = "workflow"
+
+[[steps]]
+id = "review"
+title = "Review"
+`)
+	git("add", ".")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1")
+	v1 := git("rev-parse", "HEAD")
+	write("formulas/acme-broken.formula.toml", "formula = \"acme-broken\"\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "v2")
+
+	if _, _, err := fetchFormulaPackage(repo, "v1", "", false, false); err == nil || !strings.Contains(err.Error(), "--insecure") {
+		t.Errorf("unverifiable package err = %v", err)
+	}
+	if _, _, err := fetchFormulaPackage(repo, "", v1, false, false); err != nil {
+		t.Errorf("fetchFormulaPackage(pinned %s): %v", v1, err)
+	}
+
+	files, commit, err := fetchFormulaPackage(repo, "v1", "", false, true)
+	if err != nil {
+		t.Fatalf("fetchFormulaPackage(v1): %v", err)
+	}
+	if commit != v1 || len(files) != 1 || files["acme-review.formula.toml"] == nil {
+		t.Errorf("fetched %v @ %s, want acme-review @ %s", formulaFileNames(files), commit, v1)
+	}
+
+	if _, _, err := fetchFormulaPackage(repo, "", "", false, true); err == nil || !strings.Contains(err.Error(), "acme-broken") {
+		t.Errorf("invalid formula err = %v", err)
+	}
+	if _, _, err := fetchFormulaPackage(repo, "v1", "", true, false); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("unsigned tag err = %v", err)
+	}
+
+	// SHA256SUMS from the package's own repo is integrity, not a signature.
+	git("rm", "-q", "formulas/acme-broken.formula.toml")
+	review, err := os.ReadFile(filepath.Join(repo, "formulas", "acme-review.formula.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("SHA256SUMS", fmt.Sprintf("%x  formulas/acme-review.formula.toml\n", sha256.Sum256(review)))
+	git("add", ".")
+	git("commit", "-q", "-m", "v3")
+	if _, _, err := fetchFormulaPackage(repo, "", "", false, false); err == nil || !strings.Contains(err.Error(), "--insecure") {
+		t.Errorf("package with only SHA256SUMS err = %v", err)
+	}
+	if _, _, err := fetchFormulaPackage(repo, "", "", false, true); err != nil {
+		t.Errorf("fetchFormulaPackage(SHA256SUMS, insecure): %v", err)
+	}
+}
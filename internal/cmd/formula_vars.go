@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+	"golang.org/x/term"
+)
+
+// parseFormulaVars merges a vars file with --var key=value flags, which
+// win. The file is JSON if it ends in .json, otherwise TOML; either holds a
+// flat table of values.
+func parseFormulaVars(vars []string, varsFile string) (map[string]string, error) {
+	result := make(map[string]string)
+	if varsFile != "" {
+		fileVars, err := readVarsFile(varsFile)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileVars {
+			result[k] = v
+		}
+	}
+	for _, v := range vars {
+		key, value, ok := strings.Cut(v, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q (expected key=value)", v)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// readVarsFile reads a JSON or TOML table of vars.
+func readVarsFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is given by the user
+	if err != nil {
+		return nil, fmt.Errorf("reading vars file: %w", err)
+	}
+
+	raw := make(map[string]interface{})
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &raw)
+	} else {
+		_, err = toml.Decode(string(data), &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing vars file %s: %w", path, err)
+	}
+
+	vars := make(map[string]string, len(raw))
+	for k, v := range raw {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}, []map[string]interface{}:
+			return nil, fmt.Errorf("vars file %s: %s must be a string, number or bool", path, k)
+		}
+		vars[k] = fmt.Sprint(v)
+	}
+	return vars, nil
+}
+
+// bindFormulaVars validates vars against a formula's typed vars and inputs
+// and applies defaults. Missing required values are prompted for when stdin
+// is a terminal.
+func bindFormulaVars(f *formula.Formula, vars map[string]string, townRoot string) (map[string]string, error) {
+	opts := formula.BindOptions{}
+	if townRoot != "" {
+		if rigs, err := config.TownRigNames(townRoot); err == nil {
+			opts.Rigs = rigs
+		}
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		reader := bufio.NewReader(os.Stdin)
+		opts.Prompt = func(p formula.Param) (string, error) {
+			return promptFormulaParam(reader, p, opts.Rigs)
+		}
+	}
+	return f.BindParams(vars, opts)
+}
+
+// promptFormulaParam asks for a param until a valid value is entered.
+func promptFormulaParam(reader *bufio.Reader, p formula.Param, rigs []string) (string, error) {
+	label := p.Name
+	if p.Type != "" && p.Type != formula.ParamString {
+		label += " (" + p.Type + ")"
+	}
+	if p.Description != "" {
+		fmt.Printf("%s\n", style.Dim.Render(p.Description))
+	}
+	if p.Type == formula.ParamEnum {
+		fmt.Printf("%s\n", style.Dim.Render("One of: "+strings.Join(p.Values, ", ")))
+	}
+	for {
+		fmt.Printf("%s: ", style.Bold.Render(label))
+		line, err := reader.ReadString('\n')
+		value := strings.TrimSpace(line)
+		if value == "" && err != nil {
+			return "", fmt.Errorf("reading %s: %w", p.Name, err)
+		}
+		if value == "" {
+			continue
+		}
+		if _, checkErr := p.Check(value, rigs); checkErr != nil {
+			fmt.Printf("%s %v\n", style.Dim.Render("✗"), checkErr)
+			continue
+		}
+		return value, nil
+	}
+}
+
+// formulaVarArgs returns vars as sorted key=value strings, for bd --var.
+func formulaVarArgs(vars map[string]string) []string {
+	args := make([]string, 0, len(vars))
+	for k, v := range vars {
+		args = append(args, k+"="+v)
+	}
+	sort.Strings(args)
+	return args
+}
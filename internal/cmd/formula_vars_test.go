@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFormulaVars(t *testing.T) {
+	dir := t.TempDir()
+	tomlFile := filepath.Join(dir, "vars.toml")
+	if err := os.WriteFile(tomlFile, []byte("disks = 3\nverbose = true\nname = \"towers\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jsonFile := filepath.Join(dir, "vars.json")
+	if err := os.WriteFile(jsonFile, []byte(`{"disks": 4, "name": "hanoi"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := parseFormulaVars([]string{"name=from-flag", "expr=a=b"}, tomlFile)
+	if err != nil {
+		t.Fatalf("parseFormulaVars: %v", err)
+	}
+	if got := strings.Join(formulaVarArgs(vars), " "); got != "disks=3 expr=a=b name=from-flag verbose=true" {
+		t.Errorf("toml vars = %s", got)
+	}
+
+	vars, err = parseFormulaVars(nil, jsonFile)
+	if err != nil || vars["disks"] != "4" || vars["name"] != "hanoi" {
+		t.Errorf("json vars = %v, %v", vars, err)
+	}
+
+	if _, err := parseFormulaVars([]string{"novalue"}, ""); err == nil {
+		t.Error("--var without = accepted")
+	}
+	nested := filepath.Join(dir, "nested.toml")
+	if err := os.WriteFile(nested, []byte("[table]\nx = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseFormulaVars(nil, nested); err == nil || !strings.Contains(err.Error(), "table must be") {
+		t.Errorf("nested vars err = %v", err)
+	}
+}
@@ -114,6 +114,7 @@ func runHandoff(cmd *cobra.Command, args []string) error {
 
 	// Determine target session and check for bead hook
 	targetSession := currentSession
+	var tier string // Step tier of the hooked bead, which picks the runtime
 	if len(args) > 0 {
 		arg := args[0]
 
@@ -123,6 +124,7 @@ func runHandoff(cmd *cobra.Command, args []string) error {
 			if err := hookBeadForHandoff(arg); err != nil {
 				return fmt.Errorf("hooking bead: %w", err)
 			}
+			tier = beadTier(arg)
 			// Update subject if not set
 			if handoffSubject == "" {
 				handoffSubject = fmt.Sprintf("🪝 HOOKED: %s", arg)
@@ -137,7 +139,7 @@ func runHandoff(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build the restart command
-	restartCmd, err := buildRestartCommand(targetSession)
+	restartCmd, err := buildRestartCommand(targetSession, tier)
 	if err != nil {
 		return err
 	}
@@ -338,7 +340,9 @@ var claudeEnvVars = []string{
 // buildRestartCommand creates the command to run when respawning a session's pane.
 // This needs to be the actual command to execute (e.g., claude), not a session attach command.
 // The command includes a cd to the correct working directory for the role.
-func buildRestartCommand(sessionName string) (string, error) {
+// A step tier, if set, selects the agent, model and account mapped to it in
+// town and rig settings (see config.TierConfig).
+func buildRestartCommand(sessionName, tier string) (string, error) {
 	// Detect town root from current directory
 	townRoot := detectTownRootFromCwd()
 	if townRoot == "" {
@@ -374,6 +378,13 @@ func buildRestartCommand(sessionName string) (string, error) {
 	// 4. run claude with the startup beacon (triggers immediate context loading)
 	// Use exec to ensure clean process replacement.
 	runtimeCmd := config.GetRuntimeCommandWithPrompt("", beacon)
+	var tierExports []string
+	if tier != "" {
+		runtimeCmd, tierExports, err = tierRestartCommand(townRoot, identity.Rig, tier, beacon)
+		if err != nil {
+			return "", err
+		}
+	}
 
 	// Build environment exports - role vars first, then Claude vars
 	var exports []string
@@ -394,6 +405,7 @@ func buildRestartCommand(sessionName string) (string, error) {
 			exports = append(exports, fmt.Sprintf("%s=%q", name, val))
 		}
 	}
+	exports = append(exports, tierExports...)
 
 	if len(exports) > 0 {
 		return fmt.Sprintf("cd %s && export %s && exec %s", workDir, strings.Join(exports, " "), runtimeCmd), nil
@@ -401,6 +413,32 @@ func buildRestartCommand(sessionName string) (string, error) {
 	return fmt.Sprintf("cd %s && exec %s", workDir, runtimeCmd), nil
 }
 
+// tierRestartCommand builds the runtime command for a session working a
+// step of the given tier, and the exports it needs: GT_TIER, and the
+// config dir of the tier's account.
+func tierRestartCommand(townRoot, rig, tier, prompt string) (string, []string, error) {
+	rigPath := ""
+	if rig != "" {
+		rigPath = filepath.Join(townRoot, rig)
+	}
+	rc, tc, err := config.ResolveTierRuntime(townRoot, rigPath, tier, "")
+	if err != nil {
+		return "", nil, err
+	}
+	command := rc.BuildCommandWithPrompt(prompt) // Also fills in rc's session defaults
+	exports := []string{config.EnvTier + "=" + tier}
+	if tc != nil && tc.Account != "" {
+		configDir, _, err := config.ResolveAccountConfigDir(constants.MayorAccountsPath(townRoot), tc.Account)
+		if err != nil {
+			return "", nil, fmt.Errorf("tier %s: %w", tier, err)
+		}
+		if configDir != "" && rc.Session != nil && rc.Session.ConfigDirEnv != "" {
+			exports = append(exports, fmt.Sprintf("%s=%q", rc.Session.ConfigDirEnv, configDir))
+		}
+	}
+	return command, exports, nil
+}
+
 // sessionWorkDir returns the correct working directory for a session.
 // This is the canonical home for each role type.
 func sessionWorkDir(sessionName, townRoot string) (string, error) {
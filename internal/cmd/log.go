@@ -99,23 +99,6 @@ func runLog(cmd *cobra.Command, args []string) error {
 		return followLog(logPath)
 	}
 
-	// Check if log file exists
-	if _, err := os.Stat(logPath); os.IsNotExist(err) {
-		fmt.Printf("%s No log file yet (no events recorded)\n", style.Dim.Render("○"))
-		return nil
-	}
-
-	// Read events
-	events, err := townlog.ReadEvents(townRoot)
-	if err != nil {
-		return fmt.Errorf("reading events: %w", err)
-	}
-
-	if len(events) == 0 {
-		fmt.Printf("%s No events in log\n", style.Dim.Render("○"))
-		return nil
-	}
-
 	// Build filter
 	filter := townlog.Filter{}
 
@@ -135,6 +118,17 @@ func runLog(cmd *cobra.Command, args []string) error {
 		filter.Since = time.Now().Add(-duration)
 	}
 
+	// Read events (rotated segments older than --since are skipped)
+	events, err := townlog.ReadEventsSince(townRoot, filter.Since)
+	if err != nil {
+		return fmt.Errorf("reading events: %w", err)
+	}
+
+	if len(events) == 0 {
+		fmt.Printf("%s No events in log\n", style.Dim.Render("○"))
+		return nil
+	}
+
 	// Apply filter
 	events = townlog.FilterEvents(events, filter)
 
@@ -171,7 +165,8 @@ func followLog(logPath string) error {
 
 	fmt.Printf("%s Following %s (Ctrl+C to stop)\n\n", style.Dim.Render("○"), logPath)
 
-	tailCmd := exec.Command("tail", "-f", logPath)
+	// -F reopens the log after the daemon rotates it
+	tailCmd := exec.Command("tail", "-F", logPath)
 	tailCmd.Stdout = os.Stdout
 	tailCmd.Stderr = os.Stderr
 
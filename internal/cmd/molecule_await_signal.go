@@ -11,7 +11,10 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/daemon"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 var (
@@ -21,6 +24,8 @@ var (
 	awaitSignalBackoffMax  string
 	awaitSignalQuiet       bool
 	awaitSignalAgentBead   string
+	awaitSignalStep        string
+	awaitSignalPark        bool
 )
 
 var moleculeAwaitSignalCmd = &cobra.Command{
@@ -46,6 +51,16 @@ The idle_cycles value is read from the agent bead's "idle" label, enabling
 exponential backoff that persists across invocations. When a signal is
 received, the caller should reset idle:0 on the agent bead.
 
+With --step, the backoff is read from the step bead's "Backoff:" line
+unless --backoff-base is given.
+
+PARKING:
+With --park the wait is handed to the daemon and the session ends instead
+of sleeping. The daemon watches the feed and the backoff deadline, and
+when either fires it updates idle:N as above, restarts the session and
+nudges the agent with a WAIT_DONE message. With --step, only activity on
+the step or its molecule counts. Requires a running daemon.
+
 EXIT CODES:
   0 - Signal received or timeout (check output for which)
   1 - Error starting feed subscription
@@ -62,7 +77,10 @@ EXAMPLES:
   # On signal, caller should reset: gt agent state gt-gastown-witness --set idle=0
 
   # Quiet mode (no output, for scripting)
-  gt mol await-signal --timeout 30s --quiet`,
+  gt mol await-signal --timeout 30s --quiet
+
+  # Park a wait step with the daemon and exit the session
+  gt mol step await-signal --agent-bead gt-gastown-witness --step gt-abc.3 --park`,
 	RunE: runMoleculeAwaitSignal,
 }
 
@@ -85,6 +103,10 @@ func init() {
 		"Maximum interval cap for backoff (e.g., 10m)")
 	moleculeAwaitSignalCmd.Flags().StringVar(&awaitSignalAgentBead, "agent-bead", "",
 		"Agent bead ID for tracking idle cycles (reads/writes idle:N label)")
+	moleculeAwaitSignalCmd.Flags().StringVar(&awaitSignalStep, "step", "",
+		"Wait-type step bead; its Backoff line sets the backoff")
+	moleculeAwaitSignalCmd.Flags().BoolVar(&awaitSignalPark, "park", false,
+		"Hand the wait to the daemon and end the session")
 	moleculeAwaitSignalCmd.Flags().BoolVar(&awaitSignalQuiet, "quiet", false,
 		"Suppress output (for scripting)")
 	moleculeAwaitSignalCmd.Flags().BoolVar(&moleculeJSON, "json", false,
@@ -119,12 +141,29 @@ func runMoleculeAwaitSignal(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Take the backoff from the step unless given on the command line
+	if awaitSignalStep != "" && awaitSignalBackoffBase == "" {
+		step, err := beads.New(workDir).Show(awaitSignalStep)
+		if err != nil {
+			return fmt.Errorf("getting step: %w", err)
+		}
+		if cfg := beads.StepBackoff(step.Description); cfg != nil {
+			awaitSignalBackoffBase = cfg.Base
+			awaitSignalBackoffMult = cfg.Multiplier
+			awaitSignalBackoffMax = cfg.Max
+		}
+	}
+
 	// Calculate effective timeout (uses idle cycles if backoff mode)
 	timeout, err := calculateEffectiveTimeout(idleCycles)
 	if err != nil {
 		return fmt.Errorf("invalid timeout configuration: %w", err)
 	}
 
+	if awaitSignalPark {
+		return parkAwaitSignal(workDir, timeout, idleCycles)
+	}
+
 	if !awaitSignalQuiet && !moleculeJSON {
 		if awaitSignalAgentBead != "" {
 			fmt.Printf("%s Awaiting signal (timeout: %v, idle: %d)...\n",
@@ -291,6 +330,52 @@ func waitForActivitySignal(ctx context.Context, workDir string) (*AwaitSignalRes
 	}
 }
 
+// parkAwaitSignal hands the wait to the daemon and ends the current
+// session; the daemon restarts it when the feed signals or timeout passes.
+func parkAwaitSignal(workDir string, timeout time.Duration, idleCycles int) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	if running, _, err := daemon.IsRunning(townRoot); err != nil || !running {
+		return fmt.Errorf("daemon is not running; wait in the session instead (without --park)")
+	}
+	sessionName, err := getCurrentTmuxSession()
+	if err != nil || sessionName == "" {
+		return fmt.Errorf("parking needs an agent tmux session")
+	}
+
+	// The step's molecule: activity on it wakes the wait as well
+	var molecule string
+	if awaitSignalStep != "" {
+		if step, err := beads.New(workDir).Show(awaitSignalStep); err == nil {
+			molecule = step.Parent
+		}
+	}
+
+	now := time.Now()
+	wait := &daemon.ParkedWait{
+		Session:    sessionName,
+		Step:       awaitSignalStep,
+		Molecule:   molecule,
+		AgentBead:  awaitSignalAgentBead,
+		WorkDir:    workDir,
+		IdleCycles: idleCycles,
+		ParkedAt:   now,
+		Deadline:   now.Add(timeout),
+	}
+	if err := daemon.ParkWait(townRoot, wait); err != nil {
+		return fmt.Errorf("parking wait: %w", err)
+	}
+
+	if !awaitSignalQuiet {
+		fmt.Printf("%s Parked with the daemon (wakes on activity or in %v)\n",
+			style.Bold.Render("✓"), timeout)
+		fmt.Printf("  Session %s ending; the daemon will restart it.\n", sessionName)
+	}
+	return tmux.NewTmux().KillSession(sessionName)
+}
+
 // GetCurrentStepBackoff retrieves backoff config from the current step.
 // This is used by patrol agents to get the timeout for await-signal.
 func GetCurrentStepBackoff(workDir string) (*beads.BackoffConfig, error) {
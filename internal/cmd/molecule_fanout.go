@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// expandFormulaStep instantiates the expansion formula of an expand step
+// under the molecule, once per item of its for_each data, and closes the
+// step with outputs count (the number of items) and, when the items are
+// slung to polecats, convoy. The new beads are added to the flow's
+// children, so a waits_for = ["all-children"] step joins on them.
+func expandFormulaStep(b *beads.Beads, flow *moleculeFlow, bead *beads.Issue) error {
+	id := flow.stepIDs[bead.ID]
+	step := flow.formula.GetStep(id)
+	exp, err := loadResolvedFormula(step.Expand)
+	if err != nil {
+		return fmt.Errorf("step %s: %w", id, err)
+	}
+	vars := flow.progress.Vars
+
+	var items []formula.Item
+	if exp.ForEach != nil {
+		if items, err = flow.gather(id, exp.ForEach, vars); err != nil {
+			return fmt.Errorf("step %s: gathering %s items: %w", id, exp.ForEach.Source(), err)
+		}
+	}
+	instances, err := exp.Expand(items)
+	if err != nil {
+		return fmt.Errorf("step %s: %w", id, err)
+	}
+	count := len(items)
+	if exp.ForEach == nil {
+		count = 1
+	}
+	outputs := map[string]string{"count": strconv.Itoa(count)}
+
+	if moleculeStepDryRun {
+		fmt.Printf("[dry-run] Would expand step %s: %s over %d items (%d beads)\n", bead.ID, step.Expand, count, len(instances))
+		flow.progress.Outcomes[id] = formula.OutcomeDone
+		flow.markClosed(bead.ID)
+		return nil
+	}
+
+	beadIDs := make([]string, len(instances))
+	var ready []string
+	for i, inst := range instances {
+		child, err := b.Create(beads.CreateOptions{
+			Title:       beads.ExpandTemplateVars(inst.Title, vars),
+			Type:        "task",
+			Priority:    2,
+			Description: beads.ExpandTemplateVars(inst.Description, vars),
+			Parent:      flow.root,
+		})
+		if err != nil {
+			return fmt.Errorf("step %s: creating %s: %w", id, inst.Template, err)
+		}
+		beadIDs[i] = child.ID
+		flow.children = append(flow.children, child)
+		if len(inst.Needs) == 0 && len(inst.After) == 0 {
+			ready = append(ready, child.ID)
+		}
+	}
+	for i, inst := range instances {
+		for _, deps := range [][]int{inst.Needs, inst.After} {
+			for _, dep := range deps {
+				if err := b.AddDependency(beadIDs[i], beadIDs[dep]); err != nil {
+					return fmt.Errorf("step %s: adding dependency %s -> %s: %w", id, beadIDs[i], beadIDs[dep], err)
+				}
+			}
+		}
+	}
+
+	if exp.ForEach != nil && exp.ForEach.Sling != "" && len(beadIDs) > 0 {
+		rig := beads.ExpandTemplateVars(exp.ForEach.Sling, vars)
+		convoy, err := flow.dispatch(bead.Title, rig, beadIDs, ready)
+		if err != nil {
+			return fmt.Errorf("step %s: dispatching to %s: %w", id, rig, err)
+		}
+		if convoy != "" {
+			outputs["convoy"] = convoy
+		}
+	}
+
+	fields, _ := flow.finish(bead, false, outputs)
+	if err := saveFormulaStep(b, bead, fields, false); err != nil {
+		return err
+	}
+	flow.markClosed(bead.ID)
+	return nil
+}
+
+// advancePouredMolecule expands the steps of a freshly poured molecule
+// that are ready from the start (and skips those the formula skips), so
+// fan-outs over pour-time data begin without waiting for an agent.
+func advancePouredMolecule(moleculeID, dir string) error {
+	b := beads.New(dir) // "" runs bd in the current directory
+	flow, err := loadMoleculeFlow(b, moleculeID)
+	if err != nil || flow == nil {
+		return err
+	}
+	_, _, expanded, err := advanceFormulaFlow(b, flow)
+	if err != nil {
+		return err
+	}
+	for _, bead := range expanded {
+		fmt.Printf("%s Expanded step %s: %s\n", style.Bold.Render("→"), bead.ID, flow.describeExpand(bead))
+	}
+	return nil
+}
+
+// gatherForEachItems gathers the items of a for_each source, working in
+// the current directory. Shell commands get vars in their environment
+// rather than expanded into them.
+func gatherForEachItems(fe *formula.ForEach, vars map[string]string) ([]formula.Item, error) {
+	expand := func(s string) string { return beads.ExpandTemplateVars(s, vars) }
+	var items []formula.Item
+	switch fe.Source() {
+	case formula.ForEachItems:
+		for _, item := range fe.Items {
+			for _, key := range strings.Split(expand(item), ",") {
+				if key = strings.TrimSpace(key); key != "" {
+					items = append(items, formula.Item{Key: key})
+				}
+			}
+		}
+
+	case formula.ForEachGlob:
+		matches, err := filepath.Glob(expand(fe.Glob))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			items = append(items, formula.Item{Key: match, Fields: map[string]string{
+				"name": filepath.Base(match),
+				"dir":  filepath.Dir(match),
+			}})
+		}
+
+	case formula.ForEachLabel:
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		issues, err := beads.New(cwd).List(beads.ListOptions{Label: expand(fe.Label), Status: "open", Priority: -1})
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			items = append(items, formula.Item{Key: issue.ID, Fields: map[string]string{
+				"id":    issue.ID,
+				"title": issue.Title,
+			}})
+		}
+
+	case formula.ForEachRigs:
+		townRoot, err := workspace.FindFromCwdOrError()
+		if err != nil {
+			return nil, err
+		}
+		names, err := config.TownRigNames(townRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			items = append(items, formula.Item{Key: name, Fields: map[string]string{
+				"path": filepath.Join(townRoot, name),
+			}})
+		}
+
+	case formula.ForEachFailingTests:
+		out, runErr := forEachShell(fe.FailingTests, vars).CombinedOutput()
+		items = parseFailingTests(string(out))
+		if runErr != nil && len(items) == 0 {
+			return nil, fmt.Errorf("%s failed without failing tests: %w\n%s", fe.FailingTests, runErr, lastLines(string(out), 10))
+		}
+
+	case formula.ForEachCommand:
+		out, err := forEachShell(fe.Command, vars).Output()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fe.Command, err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				items = append(items, formula.Item{Key: line})
+			}
+		}
+	}
+	return items, nil
+}
+
+// forEachShell returns a for_each shell command with vars in its
+// environment.
+func forEachShell(command string, vars map[string]string) *exec.Cmd {
+	cmd := exec.Command("sh", "-c", command) //nolint:gosec // G204: command comes from the formula
+	cmd.Env = append(os.Environ(), formula.ShellVarEnv(vars)...)
+	return cmd
+}
+
+var (
+	failedTestPattern    = regexp.MustCompile(`^--- FAIL: (\S+)`)
+	failedPackagePattern = regexp.MustCompile(`^FAIL\s+(\S+)`)
+)
+
+// parseFailingTests finds the failing top-level tests in go test output.
+// A test's package is named on the FAIL line that follows its failures.
+func parseFailingTests(output string) []formula.Item {
+	var items []formula.Item
+	pending := 0 // Failures not yet assigned a package
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := failedTestPattern.FindStringSubmatch(line); m != nil {
+			items = append(items, formula.Item{Key: m[1], Fields: map[string]string{"name": m[1], "package": ""}})
+			pending++
+			continue
+		}
+		if m := failedPackagePattern.FindStringSubmatch(line); m != nil {
+			for i := len(items) - pending; i < len(items); i++ {
+				items[i].Fields["package"] = m[1]
+			}
+			pending = 0
+		}
+	}
+	return items
+}
+
+// lastLines returns the last n lines of s.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dispatchFanOut tracks the beads of a fan-out in a convoy and slings the
+// ready ones to new polecats in rig. The Deacon's convoy feed dispatches
+// the rest as they become ready. It returns the convoy ID.
+func dispatchFanOut(title, rig string, beadIDs, ready []string) (string, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return "", err
+	}
+	townBeads := filepath.Join(townRoot, ".beads")
+
+	convoyID := fmt.Sprintf("hq-cv-%s", slingGenerateShortID())
+	createCmd := exec.Command("bd", "--no-daemon", "create",
+		"--type=convoy",
+		"--id="+convoyID,
+		"--title=Fan-out: "+title,
+		"--description="+fmt.Sprintf("Fan-out of %d beads to %s", len(beadIDs), rig))
+	createCmd.Dir = townBeads
+	createCmd.Stderr = os.Stderr
+	if err := createCmd.Run(); err != nil {
+		return "", fmt.Errorf("creating convoy: %w", err)
+	}
+	for _, id := range beadIDs {
+		depCmd := exec.Command("bd", "--no-daemon", "dep", "add", convoyID, formatTrackBeadID(id), "--type=tracks")
+		depCmd.Dir = townBeads
+		if err := depCmd.Run(); err != nil {
+			fmt.Printf("%s Could not track %s in %s: %v\n", style.Dim.Render("Warning:"), id, convoyID, err)
+		}
+	}
+
+	slung := 0
+	for _, id := range ready {
+		if _, err := slingBeadToNewPolecat(id, rig, townBeads, detectActor()); err != nil {
+			fmt.Printf("%s Could not sling %s: %v (the convoy feed will retry)\n", style.Dim.Render("Warning:"), id, err)
+			continue
+		}
+		slung++
+	}
+	if slung > 0 {
+		wakeRigAgents(rig)
+	}
+	return convoyID, nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+func itemKeys(items []formula.Item) string {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = item.Key
+	}
+	return strings.Join(keys, " ")
+}
+
+func TestGatherForEachItems(t *testing.T) {
+	vars := map[string]string{"pkgs": "auth, billing"}
+	items, err := gatherForEachItems(&formula.ForEach{Items: []string{"{{pkgs}}", "web"}}, vars)
+	if err != nil || itemKeys(items) != "auth billing web" {
+		t.Errorf("items = %q, %v", itemKeys(items), err)
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items, err = gatherForEachItems(&formula.ForEach{Glob: filepath.Join(dir, "*.go")}, nil)
+	if err != nil || len(items) != 2 || items[1].Fields["name"] != "b.go" || items[1].Fields["dir"] != dir {
+		t.Errorf("glob items = %+v, %v", items, err)
+	}
+
+	items, err = gatherForEachItems(&formula.ForEach{Command: "printf 'one\\n\\ntwo\\n'"}, nil)
+	if err != nil || itemKeys(items) != "one two" {
+		t.Errorf("command items = %q, %v", itemKeys(items), err)
+	}
+	marker := filepath.Join(dir, "pwned")
+	vars = map[string]string{"base-ref": "main; touch " + marker + " $(touch " + marker + ")"}
+	items, err = gatherForEachItems(&formula.ForEach{Command: `echo "$GT_VAR_BASE_REF"`}, vars)
+	if err != nil || len(items) != 1 || items[0].Key != vars["base-ref"] {
+		t.Errorf("command with hostile var = %+v, %v", items, err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("var value ran as shell")
+	}
+	if _, err := gatherForEachItems(&formula.ForEach{Command: "exit 3"}, nil); err == nil {
+		t.Error("failing command accepted")
+	}
+
+	items, err = gatherForEachItems(&formula.ForEach{FailingTests: "printf -- '--- FAIL: TestA (0.00s)\\nFAIL\\tpkg/a\\t0.1s\\n'; exit 1"}, nil)
+	if err != nil || itemKeys(items) != "TestA" || items[0].Fields["package"] != "pkg/a" {
+		t.Errorf("failing_tests items = %+v, %v", items, err)
+	}
+	if _, err := gatherForEachItems(&formula.ForEach{FailingTests: "echo 'build failed'; exit 2"}, nil); err == nil ||
+		!strings.Contains(err.Error(), "build failed") {
+		t.Errorf("broken test command err = %v", err)
+	}
+}
+
+func TestParseFailingTests(t *testing.T) {
+	output := `=== RUN   TestA
+--- FAIL: TestA (0.00s)
+    --- FAIL: TestA/sub (0.00s)
+--- FAIL: TestB (0.01s)
+FAIL
+FAIL	github.com/x/a	0.012s
+ok  	github.com/x/b	0.003s
+--- FAIL: TestC (0.00s)
+FAIL	github.com/x/c	0.020s
+`
+	items := parseFailingTests(output)
+	if itemKeys(items) != "TestA TestB TestC" {
+		t.Fatalf("tests = %q", itemKeys(items))
+	}
+	for i, pkg := range []string{"github.com/x/a", "github.com/x/a", "github.com/x/c"} {
+		if items[i].Fields["package"] != pkg {
+			t.Errorf("%s package = %q, want %q", items[i].Key, items[i].Fields["package"], pkg)
+		}
+	}
+	if items := parseFailingTests("ok  \tgithub.com/x/b\t0.003s\n"); len(items) != 0 {
+		t.Errorf("passing run: %v", items)
+	}
+}
+
+func TestFormulaScriptGather(t *testing.T) {
+	script := &formulaScript{Steps: map[string]scriptedStep{"fan-out": {Items: []string{"a", "b"}}}}
+	items, err := script.gather("fan-out", &formula.ForEach{Rigs: true}, nil)
+	if err != nil || itemKeys(items) != "a b" {
+		t.Errorf("scripted items = %q, %v", itemKeys(items), err)
+	}
+	items, err = script.gather("other", &formula.ForEach{Items: []string{"x"}}, nil)
+	if err != nil || itemKeys(items) != "x" {
+		t.Errorf("literal items = %q, %v", itemKeys(items), err)
+	}
+	if _, err := script.gather("other", &formula.ForEach{Rigs: true}, nil); err == nil || !strings.HasPrefix(err.Error(), "script:") {
+		t.Errorf("unscripted rigs err = %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+// moleculeFlow maps a molecule's step beads onto the workflow formula it
+// was poured from, so the step runner can apply when, repeat_until and
+// on_failure.
+type moleculeFlow struct {
+	formula  *formula.Formula
+	progress *formula.Progress
+	root     string                  // Molecule root bead ID
+	steps    map[string]*beads.Issue // Formula step ID -> step bead
+	stepIDs  map[string]string       // Step bead ID -> formula step ID
+	children []*beads.Issue
+
+	// How expand steps gather their for_each items and dispatch them
+	gather   func(stepID string, fe *formula.ForEach, vars map[string]string) ([]formula.Item, error)
+	dispatch func(title, rig string, beadIDs, ready []string) (string, error)
+}
+
+// loadMoleculeFlow loads the formula recorded on a molecule's root bead.
+// It returns nil if the molecule records no workflow formula.
+func loadMoleculeFlow(b *beads.Beads, moleculeID string) (*moleculeFlow, error) {
+	root, err := b.Show(moleculeID)
+	if err != nil {
+		return nil, fmt.Errorf("loading molecule: %w", err)
+	}
+	fields := beads.ParseMoleculeFields(root)
+	if fields == nil {
+		return nil, nil
+	}
+	f, err := loadResolvedFormula(fields.Formula)
+	if err != nil {
+		return nil, err
+	}
+	if f.Type != formula.TypeWorkflow {
+		return nil, nil
+	}
+	children, err := b.List(beads.ListOptions{
+		Parent:   moleculeID,
+		Status:   "all",
+		Priority: -1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing molecule steps: %w", err)
+	}
+	flow := newMoleculeFlow(f, fields.Vars, children)
+	flow.root = moleculeID
+	return flow, nil
+}
+
+// newMoleculeFlow builds the flow's progress from the step beads. Closed
+// steps count as done unless they record another outcome.
+func newMoleculeFlow(f *formula.Formula, vars map[string]string, children []*beads.Issue) *moleculeFlow {
+	m := &moleculeFlow{
+		formula: f,
+		progress: &formula.Progress{
+			Outcomes:   make(map[string]formula.StepOutcome),
+			Iterations: make(map[string]int),
+			Vars:       vars,
+			Outputs:    make(map[string]map[string]string),
+		},
+		steps:    make(map[string]*beads.Issue),
+		stepIDs:  make(map[string]string),
+		children: children,
+		gather: func(_ string, fe *formula.ForEach, vars map[string]string) ([]formula.Item, error) {
+			return gatherForEachItems(fe, vars)
+		},
+		dispatch: dispatchFanOut,
+	}
+	for _, child := range children {
+		fields := beads.ParseStepFields(child)
+		id := formulaStepID(f, child, fields)
+		if id == "" {
+			continue
+		}
+		m.steps[id] = child
+		m.stepIDs[child.ID] = id
+		if fields != nil {
+			m.progress.Iterations[id] = fields.Iteration
+			if len(fields.Outputs) > 0 {
+				m.progress.Outputs[id] = fields.Outputs
+			}
+		}
+		if child.Status == "closed" {
+			outcome := formula.OutcomeDone
+			if fields != nil && fields.Outcome != "" {
+				outcome = formula.StepOutcome(fields.Outcome)
+			}
+			m.progress.Outcomes[id] = outcome
+		}
+	}
+	return m
+}
+
+// formulaStepID finds the formula step a bead was poured from: the
+// formula_step field if set, else the step whose title matches, with
+// {{vars}} matching anything.
+func formulaStepID(f *formula.Formula, bead *beads.Issue, fields *beads.StepFields) string {
+	if fields != nil && fields.FormulaStep != "" {
+		return fields.FormulaStep
+	}
+	for _, step := range f.Steps {
+		if step.Title == bead.Title {
+			return step.ID
+		}
+	}
+	for _, step := range f.Steps {
+		if titlePattern(step.Title).MatchString(bead.Title) {
+			return step.ID
+		}
+	}
+	return ""
+}
+
+// templateVarPattern matches a {{var}} in a regexp-quoted title. Names may
+// have dots (quoted as \.) and dashes, as in {{steps.build-web.outputs.url}}.
+var templateVarPattern = regexp.MustCompile(`\\\{\\\{\w(?:[\w-]|\\\.)*\\\}\\\}`)
+
+// titlePattern matches a step title with its {{vars}} expanded.
+func titlePattern(title string) *regexp.Regexp {
+	return regexp.MustCompile("^" + templateVarPattern.ReplaceAllString(regexp.QuoteMeta(title), ".*") + "$")
+}
+
+// checkOutputs validates the outputs a step bead records. Required
+// outputs may be left out of a failed step. Artifact outputs must name
+// files that exist, relative to dir.
+func (m *moleculeFlow) checkOutputs(step *beads.Issue, outputs map[string]string, failed bool, dir string) error {
+	id := m.stepIDs[step.ID]
+	if err := m.formula.CheckOutputs(id, outputs); err != nil {
+		return err
+	}
+	if missing := m.formula.MissingOutputs(id, outputs); len(missing) > 0 && !failed {
+		return fmt.Errorf("step %s requires output %s (set with --output key=value)", id, strings.Join(missing, ", "))
+	}
+	if s := m.formula.GetStep(id); s != nil {
+		for key, decl := range s.Outputs {
+			value, ok := outputs[key]
+			if !ok || !decl.Artifact {
+				continue
+			}
+			path := value
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("output %s: artifact %s not found", key, value)
+			}
+		}
+	}
+	return nil
+}
+
+// finish records a step as done or failed, with the outputs it recorded.
+// It returns the step's new fields, and whether a repeat_until step should
+// run again instead of closing.
+func (m *moleculeFlow) finish(step *beads.Issue, failed bool, outputs map[string]string) (*beads.StepFields, bool) {
+	fields := beads.ParseStepFields(step)
+	if fields == nil {
+		fields = &beads.StepFields{}
+	}
+	if len(outputs) > 0 {
+		if fields.Outputs == nil {
+			fields.Outputs = make(map[string]string)
+		}
+		for k, v := range outputs {
+			fields.Outputs[k] = v
+		}
+	}
+	id, ok := m.stepIDs[step.ID]
+	if !ok {
+		return fields, false
+	}
+	fields.FormulaStep = id
+	if len(fields.Outputs) > 0 {
+		m.progress.Outputs[id] = fields.Outputs
+	}
+
+	if failed {
+		fields.Outcome = string(formula.OutcomeFailed)
+		m.progress.Outcomes[id] = formula.OutcomeFailed
+		return fields, false
+	}
+
+	fields.Iteration++
+	m.progress.Iterations[id] = fields.Iteration
+	if m.formula.Repeat(id, m.progress) {
+		return fields, true
+	}
+	fields.Outcome = string(formula.OutcomeDone)
+	m.progress.Outcomes[id] = formula.OutcomeDone
+	return fields, false
+}
+
+// plan returns the open step beads that are ready and those to skip.
+// Ready expand steps are for gt to expand; see isExpand.
+func (m *moleculeFlow) plan() (ready, skip []*beads.Issue) {
+	readyIDs, skipIDs := m.formula.Plan(m.progress)
+	for _, id := range skipIDs {
+		if bead := m.steps[id]; bead != nil && bead.Status != "closed" {
+			skip = append(skip, bead)
+		}
+	}
+	for _, id := range readyIDs {
+		if bead := m.steps[id]; bead != nil && bead.Status == "open" && len(m.waitingOn(id)) == 0 {
+			ready = append(ready, bead)
+		}
+	}
+	return ready, skip
+}
+
+// isExpand reports whether a step bead is an expand step.
+func (m *moleculeFlow) isExpand(bead *beads.Issue) bool {
+	step := m.formula.GetStep(m.stepIDs[bead.ID])
+	return step != nil && step.Expand != ""
+}
+
+// waitingOn returns the open beads a step's waits_for gates hold it for:
+// with all-children, the beads bonded under the molecule outside its
+// formula steps.
+func (m *moleculeFlow) waitingOn(id string) []string {
+	step := m.formula.GetStep(id)
+	if step == nil {
+		return nil
+	}
+	var open []string
+	for _, gate := range step.WaitsFor {
+		if gate != formula.WaitAllChildren {
+			continue
+		}
+		for _, child := range m.children {
+			if _, isStep := m.stepIDs[child.ID]; !isStep && child.Status != "closed" {
+				open = append(open, child.ID)
+			}
+		}
+	}
+	return open
+}
+
+// expandOutputs fills in the {{steps.<id>.outputs.<key>}} references in a
+// step bead's description from the outputs recorded so far. It returns the
+// expanded description, and whether it changed.
+func (m *moleculeFlow) expandOutputs(bead *beads.Issue) (string, bool) {
+	if len(m.progress.Outputs) == 0 {
+		return bead.Description, false
+	}
+	desc := beads.ExpandTemplateVars(bead.Description, formula.OutputContext(m.progress.Outputs))
+	return desc, desc != bead.Description
+}
+
+// failedSteps returns failed steps that have no on_failure handler.
+func (m *moleculeFlow) failedSteps() []string {
+	var failed []string
+	for _, step := range m.formula.Steps {
+		if m.progress.Outcomes[step.ID] == formula.OutcomeFailed && step.OnFailure == "" {
+			failed = append(failed, step.ID)
+		}
+	}
+	return failed
+}
+
+// markClosed updates the in-memory status of beads closed by the runner.
+func (m *moleculeFlow) markClosed(ids ...string) {
+	closed := make(map[string]bool)
+	for _, id := range ids {
+		closed[id] = true
+	}
+	for _, child := range m.children {
+		if closed[child.ID] {
+			child.Status = "closed"
+		}
+	}
+}
+
+// allClosed reports whether every step bead is closed.
+func (m *moleculeFlow) allClosed() bool {
+	for _, child := range m.children {
+		if child.Status != "closed" {
+			return false
+		}
+	}
+	return true
+}
+
+// describeSkip explains why a step bead is being skipped.
+func (m *moleculeFlow) describeSkip(bead *beads.Issue) string {
+	step := m.formula.GetStep(m.stepIDs[bead.ID])
+	if step != nil && step.When != "" {
+		return "when is false: " + step.When
+	}
+	return "failure handler not needed"
+}
+
+// describeExpand summarizes what an expand step created.
+func (m *moleculeFlow) describeExpand(bead *beads.Issue) string {
+	id := m.stepIDs[bead.ID]
+	outputs := m.progress.Outputs[id]
+	desc := fmt.Sprintf("%s over %s items", m.formula.GetStep(id).Expand, outputs["count"])
+	if convoy := outputs["convoy"]; convoy != "" {
+		desc += ", dispatched in convoy " + convoy
+	}
+	return desc
+}
+
+// stepIDList joins formula step IDs for display.
+func stepIDList(ids []string) string {
+	return strings.Join(ids, ", ")
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+const testFlowFormula = `
+formula = "fix"
+type = "workflow"
+
+[[steps]]
+id = "scan"
+title = "Scan {{feature}} for secrets"
+when = "feature =~ 'auth'"
+
+[[steps]]
+id = "fix-tests"
+title = "Fix tests"
+needs = ["scan"]
+repeat_until = "steps.fix-tests.iterations >= 2"
+on_failure = "escalate"
+
+[[steps]]
+id = "escalate"
+title = "Escalate"
+
+[[steps]]
+id = "submit"
+title = "Submit"
+needs = ["fix-tests"]
+`
+
+func TestMoleculeFlow(t *testing.T) {
+	f, err := formula.Parse([]byte(testFlowFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	children := []*beads.Issue{
+		{ID: "gt-m.1", Title: "Scan billing for secrets", Status: "open"},
+		{ID: "gt-m.2", Title: "Fix tests", Status: "open"},
+		{ID: "gt-m.3", Title: "Page the overseer", Status: "open", Description: "formula_step: escalate"},
+		{ID: "gt-m.4", Title: "Submit", Status: "open"},
+	}
+	flow := newMoleculeFlow(f, map[string]string{"feature": "billing"}, children)
+
+	for bead, want := range map[string]string{"gt-m.1": "scan", "gt-m.2": "fix-tests", "gt-m.3": "escalate", "gt-m.4": "submit"} {
+		if got := flow.stepIDs[bead]; got != want {
+			t.Errorf("%s maps to %q, want %q", bead, got, want)
+		}
+	}
+
+	// scan is skipped (feature has no auth), so fix-tests is next
+	ready, skip := flow.plan()
+	if len(skip) != 1 || skip[0].ID != "gt-m.1" || len(ready) != 1 || ready[0].ID != "gt-m.2" {
+		t.Fatalf("plan = %v / %v, want gt-m.2 / gt-m.1", ready, skip)
+	}
+	flow.progress.Outcomes["scan"] = formula.OutcomeSkipped
+	flow.markClosed("gt-m.1")
+
+	// fix-tests repeats once, then closes
+	fields, repeat := flow.finish(children[1], false, nil)
+	if !repeat || fields.Iteration != 1 || fields.Outcome != "" {
+		t.Errorf("first finish = %+v, repeat %v", fields, repeat)
+	}
+	children[1].Description = beads.SetStepFields(children[1], fields)
+	fields, repeat = flow.finish(children[1], false, nil)
+	if repeat || fields.Iteration != 2 || fields.Outcome != "done" || fields.FormulaStep != "fix-tests" {
+		t.Errorf("second finish = %+v, repeat %v", fields, repeat)
+	}
+	flow.markClosed("gt-m.2")
+
+	ready, skip = flow.plan()
+	if len(ready) != 1 || ready[0].ID != "gt-m.4" || len(skip) != 1 || skip[0].ID != "gt-m.3" {
+		t.Errorf("after fix-tests = %v / %v, want submit / escalate", ready, skip)
+	}
+}
+
+func TestMoleculeFlow_Failure(t *testing.T) {
+	f, err := formula.Parse([]byte(testFlowFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	children := []*beads.Issue{
+		{ID: "gt-m.1", Title: "Scan auth for secrets", Status: "closed"},
+		{ID: "gt-m.2", Title: "Fix tests", Status: "in_progress"},
+		{ID: "gt-m.3", Title: "Escalate", Status: "open"},
+		{ID: "gt-m.4", Title: "Submit", Status: "open"},
+	}
+	flow := newMoleculeFlow(f, map[string]string{"feature": "auth"}, children)
+
+	fields, repeat := flow.finish(children[1], true, nil)
+	if repeat || fields.Outcome != "failed" {
+		t.Errorf("finish = %+v, repeat %v", fields, repeat)
+	}
+	flow.markClosed("gt-m.2")
+	if ready, _ := flow.plan(); len(ready) != 1 || ready[0].ID != "gt-m.3" {
+		t.Errorf("after failure ready = %v, want escalate", ready)
+	}
+	if failed := flow.failedSteps(); len(failed) != 0 {
+		t.Errorf("failedSteps = %v, want none (fix-tests has a handler)", failed)
+	}
+}
+
+func TestMoleculeFlow_Outputs(t *testing.T) {
+	f, err := formula.Parse([]byte(`
+formula = "release"
+type = "workflow"
+
+[[steps]]
+id = "build"
+title = "Build"
+
+[steps.outputs.tarball]
+artifact = true
+required = true
+
+[[steps]]
+id = "publish"
+title = "Publish"
+description = "Upload {{steps.build.outputs.tarball}}"
+needs = ["build"]
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	children := []*beads.Issue{
+		{ID: "gt-m.1", Title: "Build", Status: "in_progress"},
+		{ID: "gt-m.2", Title: "Publish", Status: "open", Description: "Upload {{steps.build.outputs.tarball}}"},
+	}
+	flow := newMoleculeFlow(f, nil, children)
+
+	dir := t.TempDir()
+	if err := flow.checkOutputs(children[0], nil, false, dir); err == nil {
+		t.Error("missing required output accepted")
+	}
+	if err := flow.checkOutputs(children[0], nil, true, dir); err != nil {
+		t.Errorf("failed step without outputs: %v", err)
+	}
+	outputs := map[string]string{"tarball": "x.tgz"}
+	if err := flow.checkOutputs(children[0], outputs, false, dir); err == nil {
+		t.Error("missing artifact accepted")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "x.tgz"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := flow.checkOutputs(children[0], outputs, false, dir); err != nil {
+		t.Errorf("checkOutputs: %v", err)
+	}
+
+	fields, _ := flow.finish(children[0], false, outputs)
+	if fields.Outputs["tarball"] != "x.tgz" {
+		t.Errorf("fields = %+v", fields)
+	}
+	desc, changed := flow.expandOutputs(children[1])
+	if !changed || desc != "Upload x.tgz" {
+		t.Errorf("expandOutputs = %q, %v", desc, changed)
+	}
+
+	// Outputs recorded on closed steps are loaded with the flow
+	children[0].Description = beads.SetStepFields(children[0], fields)
+	children[0].Status = "closed"
+	flow = newMoleculeFlow(f, nil, children)
+	if desc, _ := flow.expandOutputs(children[1]); desc != "Upload x.tgz" {
+		t.Errorf("reloaded expandOutputs = %q", desc)
+	}
+}
+
+func TestTitlePattern(t *testing.T) {
+	tests := []struct {
+		title, bead string
+		want        bool
+	}{
+		{"Build {{feature}}", "Build login", true},
+		{"Ship {{steps.build-web.outputs.url}}", "Ship https://x.example/1", true},
+		{"Review {{pr-number}} on {{rig}}", "Review 42 on gastown", true},
+		{"Build {{feature}}", "Deploy login", false},
+		{"Ship v1.2", "Ship v132", false}, // Literal dots stay literal
+	}
+	for _, tt := range tests {
+		if got := titlePattern(tt.title).MatchString(tt.bead); got != tt.want {
+			t.Errorf("titlePattern(%q) matches %q = %v, want %v", tt.title, tt.bead, got, tt.want)
+		}
+	}
+}
+
+func TestParseStepOutputs(t *testing.T) {
+	outputs, err := parseStepOutputs([]string{"status=green", "query=a=b"})
+	if err != nil || outputs["status"] != "green" || outputs["query"] != "a=b" {
+		t.Errorf("parseStepOutputs = %v, %v", outputs, err)
+	}
+	for _, bad := range []string{"novalue", "bad-key=x", "k=a\nb"} {
+		if _, err := parseStepOutputs([]string{bad}); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
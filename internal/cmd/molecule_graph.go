@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+)
+
+// Graph output formats.
+const (
+	graphFormatASCII   = "ascii"
+	graphFormatDOT     = "dot"
+	graphFormatMermaid = "mermaid"
+)
+
+var graphFormat string
+
+var formulaGraphCmd = &cobra.Command{
+	Use:   "graph <name>",
+	Short: "Render a formula's step graph",
+	Long: `Render the steps of a formula and the order they run in.
+
+Workflow steps are drawn with their needs, on_failure handlers (dashed) and
+flow control (when, repeat_until, expand). Extends and include are applied
+first.
+
+Formats:
+  ascii     Steps grouped into stages (default)
+  dot       Graphviz DOT; pipe to 'dot -Tsvg'
+  mermaid   Mermaid flowchart, for Markdown that renders it
+
+Examples:
+  gt formula graph shiny
+  gt formula graph shiny --format dot | dot -Tsvg > shiny.svg`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFormulaGraph,
+}
+
+var moleculeGraphCmd = &cobra.Command{
+	Use:   "graph <molecule-id>",
+	Short: "Render a molecule's step graph with live status",
+	Long: `Render the step beads of a molecule and their dependencies, colored by
+status: done, in progress, blocked (waiting on open steps) or ready.
+
+Formats are as for 'gt formula graph': ascii (default), dot and mermaid.
+
+Examples:
+  gt mol graph gt-abc
+  gt mol graph gt-abc --format mermaid`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMoleculeGraph,
+}
+
+func init() {
+	formulaGraphCmd.Flags().StringVar(&graphFormat, "format", graphFormatASCII, "Output format: ascii, dot or mermaid")
+	moleculeGraphCmd.Flags().StringVar(&graphFormat, "format", graphFormatASCII, "Output format: ascii, dot or mermaid")
+	formulaCmd.AddCommand(formulaGraphCmd)
+	moleculeCmd.AddCommand(moleculeGraphCmd)
+}
+
+func runFormulaGraph(cmd *cobra.Command, args []string) error {
+	f, err := loadResolvedFormula(args[0])
+	if err != nil {
+		return err
+	}
+	g, err := f.Graph()
+	if err != nil {
+		return err
+	}
+	return printGraph(g, graphFormat)
+}
+
+func runMoleculeGraph(cmd *cobra.Command, args []string) error {
+	moleculeID := args[0]
+	workDir, err := findLocalBeadsDir()
+	if err != nil {
+		return fmt.Errorf("not in a beads workspace: %w", err)
+	}
+	b := beads.New(workDir)
+
+	root, err := b.Show(moleculeID)
+	if err != nil {
+		return fmt.Errorf("getting molecule: %w", err)
+	}
+	children, err := b.List(beads.ListOptions{
+		Parent:   moleculeID,
+		Status:   "all",
+		Priority: -1,
+	})
+	if err != nil {
+		return fmt.Errorf("listing steps: %w", err)
+	}
+	if len(children) == 0 {
+		return fmt.Errorf("no steps found for %s (not a molecule root?)", moleculeID)
+	}
+	return printGraph(moleculeGraph(root, children), graphFormat)
+}
+
+// moleculeGraph builds the live graph of a molecule's step beads.
+func moleculeGraph(root *beads.Issue, children []*beads.Issue) *formula.Graph {
+	nodes := make([]formula.LiveNode, len(children))
+	for i, child := range children {
+		nodes[i] = formula.LiveNode{
+			ID:        child.ID,
+			Title:     child.Title,
+			Status:    child.Status,
+			DependsOn: child.DependsOn,
+		}
+	}
+	return formula.LiveGraph(fmt.Sprintf("%s: %s", root.ID, root.Title), nodes)
+}
+
+// printGraph writes a graph in the given format.
+func printGraph(g *formula.Graph, format string) error {
+	switch format {
+	case graphFormatASCII:
+		fmt.Print(g.ASCII())
+	case graphFormatDOT:
+		fmt.Print(g.DOT())
+	case graphFormatMermaid:
+		fmt.Print(g.Mermaid())
+	default:
+		return fmt.Errorf("unknown format %q (want ascii, dot or mermaid)", format)
+	}
+	return nil
+}
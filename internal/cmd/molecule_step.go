@@ -6,11 +6,14 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/trace"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -33,33 +36,62 @@ This command handles the step-to-step transition for polecats:
    - Sends POLECAT_DONE to witness
    - Exits the session
 
+For molecules poured from a workflow formula, the formula's control flow
+is applied:
+- when: steps whose condition is false are closed as skipped
+- repeat_until: the step stays open and runs again until its condition
+  holds, up to max_iterations (default 3)
+- on_failure: with --failed, the step is closed as failed and its
+  on_failure step runs next; its dependents continue once that finishes
+
+Steps with expand are not worked by agents: when one becomes ready, gt
+instantiates its expansion formula under the molecule (once per for_each
+item) and closes it.
+
+Use --output key=value to record values for later steps. They are stored
+on the step bead, and {{steps.<id>.outputs.<key>}} in the descriptions of
+later steps is filled in as they become ready. Outputs a step declares as
+required must be given (unless --failed), and artifact outputs must name
+files that exist.
+
 IMPORTANT: This is the canonical way to complete molecule steps. Do NOT manually
 close steps with 'bd close' - it skips the auto-continuation logic.
 
-Example:
-  gt mol step done gt-abc.1    # Complete step 1 of molecule gt-abc`,
+Examples:
+  gt mol step done gt-abc.1             # Complete step 1 of molecule gt-abc
+  gt mol step done gt-abc.2 --failed    # Step 2 failed; run its on_failure step
+  gt mol step done gt-abc.3 --output report=out/report.md --output status=green`,
 	Args: cobra.ExactArgs(1),
 	RunE: runMoleculeStepDone,
 }
 
 var (
-	moleculeStepDryRun bool
+	moleculeStepDryRun  bool
+	moleculeStepFailed  bool
+	moleculeStepOutputs []string
 )
 
 func init() {
 	moleculeStepDoneCmd.Flags().BoolVarP(&moleculeStepDryRun, "dry-run", "n", false, "Show what would be done without executing")
+	moleculeStepDoneCmd.Flags().BoolVar(&moleculeStepFailed, "failed", false, "Record the step as failed (runs its on_failure step)")
+	moleculeStepDoneCmd.Flags().StringArrayVar(&moleculeStepOutputs, "output", nil, "Record a step output (key=value, can be repeated)")
 	moleculeStepDoneCmd.Flags().BoolVar(&moleculeJSON, "json", false, "Output as JSON")
 }
 
 // StepDoneResult is the result of a step done operation.
 type StepDoneResult struct {
-	StepID       string `json:"step_id"`
-	MoleculeID   string `json:"molecule_id"`
-	StepClosed   bool   `json:"step_closed"`
-	NextStepID   string `json:"next_step_id,omitempty"`
-	NextStepTitle string `json:"next_step_title,omitempty"`
-	Complete     bool   `json:"complete"`
-	Action       string `json:"action"` // "continue", "done", "no_more_ready"
+	StepID        string            `json:"step_id"`
+	MoleculeID    string            `json:"molecule_id"`
+	StepClosed    bool              `json:"step_closed"`
+	Outcome       string            `json:"outcome,omitempty"`   // Formula outcome: done, failed
+	Iteration     int               `json:"iteration,omitempty"` // Runs of a repeat_until step
+	Skipped       []string          `json:"skipped,omitempty"`   // Steps closed as skipped
+	Expanded      []string          `json:"expanded,omitempty"`  // Expand steps instantiated and closed
+	Outputs       map[string]string `json:"outputs,omitempty"`
+	NextStepID    string            `json:"next_step_id,omitempty"`
+	NextStepTitle string            `json:"next_step_title,omitempty"`
+	Complete      bool              `json:"complete"`
+	Action        string            `json:"action"` // "continue", "repeat", "done", "no_more_ready", "failed"
 }
 
 func runMoleculeStepDone(cmd *cobra.Command, args []string) error {
@@ -99,9 +131,27 @@ func runMoleculeStepDone(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("cannot extract molecule ID from step %s (expected format: gt-xxx.N)", stepID)
 	}
 
+	outputs, err := parseStepOutputs(moleculeStepOutputs)
+	if err != nil {
+		return err
+	}
+
 	result := StepDoneResult{
 		StepID:     stepID,
 		MoleculeID: moleculeID,
+		Outputs:    outputs,
+	}
+
+	// Molecules poured from a workflow formula follow its control flow
+	flow, err := loadMoleculeFlow(b, moleculeID)
+	if err != nil {
+		style.PrintWarning("formula control flow unavailable: %v", err)
+	}
+	if flow != nil {
+		return runFormulaStepDone(cwd, townRoot, workDir, b, flow, step, outputs, &result)
+	}
+	if moleculeStepFailed {
+		return fmt.Errorf("--failed requires a molecule poured from a workflow formula")
 	}
 
 	// Step 3: Close the step
@@ -109,11 +159,18 @@ func runMoleculeStepDone(cmd *cobra.Command, args []string) error {
 		fmt.Printf("[dry-run] Would close step: %s\n", stepID)
 		result.StepClosed = true
 	} else {
+		if len(outputs) > 0 {
+			desc := beads.SetStepFields(step, &beads.StepFields{Outputs: outputs})
+			if err := b.Update(stepID, beads.UpdateOptions{Description: &desc}); err != nil {
+				return fmt.Errorf("recording step outputs: %w", err)
+			}
+		}
 		if err := b.Close(stepID); err != nil {
 			return fmt.Errorf("closing step: %w", err)
 		}
 		result.StepClosed = true
 		fmt.Printf("%s Closed step %s: %s\n", style.Bold.Render("✓"), stepID, step.Title)
+		recordStepSpan(townRoot, moleculeID, step)
 	}
 
 	// Step 4: Find the next ready step
@@ -159,6 +216,242 @@ func runMoleculeStepDone(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runFormulaStepDone completes a step of a molecule poured from a workflow
+// formula: it records the step's outcome and outputs, repeats or closes it,
+// closes steps the formula skips, and continues to the next ready step.
+func runFormulaStepDone(cwd, townRoot, workDir string, b *beads.Beads, flow *moleculeFlow, step *beads.Issue, outputs map[string]string, result *StepDoneResult) error {
+	if err := flow.checkOutputs(step, outputs, moleculeStepFailed, cwd); err != nil {
+		return err
+	}
+	fields, repeat := flow.finish(step, moleculeStepFailed, outputs)
+	result.Outcome = fields.Outcome
+	result.Iteration = fields.Iteration
+
+	switch {
+	case moleculeStepDryRun && repeat:
+		fmt.Printf("[dry-run] Would repeat step: %s (iteration %d)\n", step.ID, fields.Iteration+1)
+	case moleculeStepDryRun:
+		fmt.Printf("[dry-run] Would close step: %s (%s)\n", step.ID, fields.Outcome)
+		result.StepClosed = true
+	case repeat:
+		if err := saveFormulaStep(b, step, fields, true); err != nil {
+			return err
+		}
+		fmt.Printf("%s Step %s repeats: repeat_until not yet met (iteration %d done)\n",
+			style.Bold.Render("↻"), step.ID, fields.Iteration)
+		recordStepSpan(townRoot, result.MoleculeID, step)
+	default:
+		if err := saveFormulaStep(b, step, fields, false); err != nil {
+			return err
+		}
+		result.StepClosed = true
+		fmt.Printf("%s Closed step %s (%s): %s\n", style.Bold.Render("✓"), step.ID, fields.Outcome, step.Title)
+		recordStepSpan(townRoot, result.MoleculeID, step)
+	}
+
+	var next *beads.Issue
+	var ready []*beads.Issue
+	if repeat {
+		next = step
+		result.Action = "repeat"
+	} else {
+		flow.markClosed(step.ID)
+		var skipped, expanded []*beads.Issue
+		var err error
+		ready, skipped, expanded, err = advanceFormulaFlow(b, flow)
+		if err != nil {
+			return err
+		}
+		for _, bead := range skipped {
+			result.Skipped = append(result.Skipped, bead.ID)
+			if !moleculeJSON && !moleculeStepDryRun {
+				fmt.Printf("%s Skipped step %s: %s\n", style.Dim.Render("⊘"), bead.ID, flow.describeSkip(bead))
+			}
+		}
+		for _, bead := range expanded {
+			result.Expanded = append(result.Expanded, bead.ID)
+			if !moleculeJSON && !moleculeStepDryRun {
+				fmt.Printf("%s Expanded step %s: %s\n", style.Bold.Render("→"), bead.ID, flow.describeExpand(bead))
+			}
+		}
+		if len(ready) > 0 {
+			next = ready[0]
+		}
+
+		switch {
+		case next != nil:
+			result.Action = "continue"
+		case flow.allClosed():
+			result.Action = "done"
+			result.Complete = true
+		case len(flow.failedSteps()) > 0:
+			result.Action = "failed"
+		default:
+			result.Action = "no_more_ready"
+		}
+	}
+	if next != nil {
+		result.NextStepID = next.ID
+		result.NextStepTitle = next.Title
+	}
+
+	// Fill in the outputs of finished steps where ready steps use them
+	for _, bead := range ready {
+		desc, changed := flow.expandOutputs(bead)
+		if !changed {
+			continue
+		}
+		if moleculeStepDryRun {
+			fmt.Printf("[dry-run] Would fill in step outputs: %s\n", bead.ID)
+			continue
+		}
+		if err := b.Update(bead.ID, beads.UpdateOptions{Description: &desc}); err != nil {
+			return fmt.Errorf("filling in outputs for %s: %w", bead.ID, err)
+		}
+		bead.Description = desc
+	}
+
+	if moleculeJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	switch result.Action {
+	case "continue", "repeat":
+		return handleStepContinue(cwd, townRoot, workDir, next, moleculeStepDryRun)
+	case "done":
+		return handleMoleculeComplete(cwd, townRoot, result.MoleculeID, moleculeStepDryRun)
+	case "failed":
+		fmt.Printf("\n%s Molecule %s is stuck: failed with no on_failure step: %s\n",
+			style.Bold.Render("✗"), result.MoleculeID, stepIDList(flow.failedSteps()))
+		fmt.Printf("Reopen a failed step with 'bd update <id> --status=open' to retry\n")
+		return nil
+	default:
+		fmt.Printf("\n%s All remaining steps are blocked - waiting on dependencies\n",
+			style.Dim.Render("ℹ"))
+		fmt.Printf("Run 'gt mol progress %s' to see blocked steps\n", result.MoleculeID)
+		return nil
+	}
+}
+
+// saveFormulaStep stores a finished step's fields on its bead, and either
+// reopens it to run again or closes it.
+func saveFormulaStep(b *beads.Beads, step *beads.Issue, fields *beads.StepFields, repeat bool) error {
+	desc := beads.SetStepFields(step, fields)
+	if repeat {
+		open := "open"
+		if err := b.Update(step.ID, beads.UpdateOptions{Description: &desc, Status: &open}); err != nil {
+			return fmt.Errorf("reopening step: %w", err)
+		}
+		return nil
+	}
+	if err := b.Update(step.ID, beads.UpdateOptions{Description: &desc}); err != nil {
+		return fmt.Errorf("recording step outcome: %w", err)
+	}
+	if err := b.Close(step.ID); err != nil {
+		return fmt.Errorf("closing step: %w", err)
+	}
+	return nil
+}
+
+// advanceFormulaFlow closes the steps the formula skips and expands the
+// ready expand steps, until none are left, and returns the ready, skipped
+// and expanded step beads.
+func advanceFormulaFlow(b *beads.Beads, flow *moleculeFlow) (ready, skipped, expanded []*beads.Issue, err error) {
+	for {
+		planned, skip := flow.plan()
+		var expand []*beads.Issue
+		ready = nil
+		for _, bead := range planned {
+			if flow.isExpand(bead) {
+				expand = append(expand, bead)
+			} else {
+				ready = append(ready, bead)
+			}
+		}
+		if len(skip) == 0 && len(expand) == 0 {
+			return ready, skipped, expanded, nil
+		}
+		for _, bead := range skip {
+			if err := skipFormulaStep(b, flow, bead); err != nil {
+				return nil, nil, nil, err
+			}
+			skipped = append(skipped, bead)
+		}
+		for _, bead := range expand {
+			if err := expandFormulaStep(b, flow, bead); err != nil {
+				return nil, nil, nil, err
+			}
+			expanded = append(expanded, bead)
+		}
+	}
+}
+
+// parseStepOutputs parses --output key=value flags.
+func parseStepOutputs(args []string) (map[string]string, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	outputs := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return nil, fmt.Errorf("invalid --output %q (expected key=value)", arg)
+		}
+		if err := formula.CheckOutputKey(key); err != nil {
+			return nil, err
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("output %s: value must be a single line", key)
+		}
+		outputs[key] = strings.TrimSpace(value)
+	}
+	return outputs, nil
+}
+
+// skipFormulaStep closes a step bead the formula skips.
+func skipFormulaStep(b *beads.Beads, flow *moleculeFlow, bead *beads.Issue) error {
+	id := flow.stepIDs[bead.ID]
+	flow.progress.Outcomes[id] = formula.OutcomeSkipped
+	flow.markClosed(bead.ID)
+	reason := flow.describeSkip(bead)
+	if moleculeStepDryRun {
+		fmt.Printf("[dry-run] Would skip step: %s (%s)\n", bead.ID, reason)
+		return nil
+	}
+
+	fields := beads.ParseStepFields(bead)
+	if fields == nil {
+		fields = &beads.StepFields{}
+	}
+	fields.FormulaStep = id
+	fields.Outcome = string(formula.OutcomeSkipped)
+	desc := beads.SetStepFields(bead, fields)
+	if err := b.Update(bead.ID, beads.UpdateOptions{Description: &desc}); err != nil {
+		return fmt.Errorf("recording skipped step %s: %w", bead.ID, err)
+	}
+	if err := b.CloseWithReason("skipped: "+reason, bead.ID); err != nil {
+		return fmt.Errorf("closing skipped step %s: %w", bead.ID, err)
+	}
+	return nil
+}
+
+// recordStepSpan records a closed molecule step in the molecule's trace.
+// The step is taken to have started when it was last updated before closing
+// (when it was marked in progress).
+func recordStepSpan(townRoot, moleculeID string, step *beads.Issue) {
+	span := trace.Start("step", trace.Resume(townRoot, moleculeID))
+	if started, err := time.Parse(time.RFC3339, step.UpdatedAt); err == nil && started.Before(span.Start) {
+		span.Start = started.UTC()
+	}
+	span.Bead = step.ID
+	span.Actor = detectSender()
+	span.Detail = step.Title
+	span.Finish(nil)
+}
+
 // extractMoleculeIDFromStep extracts the molecule ID from a step ID.
 // Step IDs have format: mol-id.N where N is the step number.
 // Examples:
@@ -313,7 +606,7 @@ func handleStepContinue(cwd, townRoot, _ string, nextStep *beads.Issue, dryRun b
 		return fmt.Errorf("getting session name: %w", err)
 	}
 
-	restartCmd, err := buildRestartCommand(currentSession)
+	restartCmd, err := buildRestartCommand(currentSession, beads.StepTier(nextStep.Description))
 	if err != nil {
 		return fmt.Errorf("building restart command: %w", err)
 	}
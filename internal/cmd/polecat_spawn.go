@@ -14,6 +14,7 @@ import (
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/trace"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -39,6 +40,11 @@ type SlingSpawnOptions struct {
 	Create   bool   // Create polecat if it doesn't exist (currently always true for sling)
 	HookBead string // Bead ID to set as hook_bead at spawn time (atomic assignment)
 	Agent    string // Agent override for this spawn (e.g., "gemini", "codex", "claude-haiku")
+	Tier     string // Step tier of the hooked bead (haiku, sonnet, opus); see config.TierConfig
+
+	// TraceParent is the dispatching span's traceparent; the spawn is recorded
+	// as its child and the polecat session inherits it as GT_TRACEPARENT.
+	TraceParent string
 }
 
 // SpawnPolecatForSling creates a fresh polecat and optionally starts its session.
@@ -139,9 +145,18 @@ func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolec
 		}, nil
 	}
 
+	// A step tier picks the account unless one was given
+	account := opts.Account
+	if tier := config.ResolveTier(townRoot, r.Path, opts.Tier); tier != nil {
+		fmt.Printf("Step tier: %s\n", describeTier(opts.Tier, tier))
+		if account == "" {
+			account = tier.Account
+		}
+	}
+
 	// Resolve account for runtime config
 	accountsPath := constants.MayorAccountsPath(townRoot)
-	claudeConfigDir, accountHandle, err := config.ResolveAccountConfigDir(accountsPath, opts.Account)
+	claudeConfigDir, accountHandle, err := config.ResolveAccountConfigDir(accountsPath, account)
 	if err != nil {
 		return nil, fmt.Errorf("resolving account: %w", err)
 	}
@@ -159,15 +174,25 @@ func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolec
 		fmt.Printf("Starting session for %s/%s...\n", rigName, polecatName)
 		startOpts := polecat.SessionStartOptions{
 			RuntimeConfigDir: claudeConfigDir,
+			TraceParent:      opts.TraceParent,
 		}
-		if opts.Agent != "" {
-			cmd, err := config.BuildPolecatStartupCommandWithAgentOverride(rigName, polecatName, r.Path, "", opts.Agent)
+		if opts.Agent != "" || opts.Tier != "" {
+			cmd, err := config.BuildPolecatStartupCommandForTier(rigName, polecatName, r.Path, "", opts.Agent, opts.Tier)
 			if err != nil {
 				return nil, err
 			}
 			startOpts.Command = cmd
 		}
-		if err := polecatSessMgr.Start(polecatName, startOpts); err != nil {
+		parent, _ := trace.ParseTraceParent(opts.TraceParent)
+		span := trace.Start("spawn", parent)
+		span.Bead = opts.HookBead
+		span.Actor = "gt"
+		span.Detail = fmt.Sprintf("%s/polecats/%s", rigName, polecatName)
+		err := polecatSessMgr.Start(polecatName, startOpts)
+		if parent.IsValid() {
+			span.Finish(err)
+		}
+		if err != nil {
 			return nil, fmt.Errorf("starting session: %w", err)
 		}
 	}
@@ -193,6 +218,24 @@ func SpawnPolecatForSling(rigName string, opts SlingSpawnOptions) (*SpawnedPolec
 	}, nil
 }
 
+// describeTier summarizes the runtime a step tier maps to.
+func describeTier(name string, tier *config.TierConfig) string {
+	var parts []string
+	if tier.Agent != "" {
+		parts = append(parts, "agent "+tier.Agent)
+	}
+	if tier.Model != "" {
+		parts = append(parts, "model "+tier.Model)
+	}
+	if tier.Account != "" {
+		parts = append(parts, "account "+tier.Account)
+	}
+	if len(parts) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(parts, ", "))
+}
+
 // IsRigName checks if a target string is a rig name (not a role or path).
 // Returns the rig name and true if it's a valid rig.
 func IsRigName(target string) (string, bool) {
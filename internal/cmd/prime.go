@@ -376,14 +376,12 @@ func runMailCheckInject(workDir string) {
 	}
 }
 
-// checkSlungWork checks for hooked work on the agent's hook.
-// If found, displays AUTONOMOUS WORK MODE and tells the agent to execute immediately.
-// Returns true if hooked work was found (caller should skip normal startup directive).
-func checkSlungWork(ctx RoleContext) bool {
+// findHookedBead returns the work on the agent's hook, or nil if there is none.
+func findHookedBead(ctx RoleContext) *beads.Issue {
 	// Determine agent identity
 	agentID := getAgentIdentity(ctx)
 	if agentID == "" {
-		return false
+		return nil
 	}
 
 	// Check for hooked beads (work on the agent's hook)
@@ -394,7 +392,7 @@ func checkSlungWork(ctx RoleContext) bool {
 		Priority: -1,
 	})
 	if err != nil {
-		return false
+		return nil
 	}
 
 	// If no hooked beads found, also check in_progress beads assigned to this agent.
@@ -407,13 +405,23 @@ func checkSlungWork(ctx RoleContext) bool {
 			Priority: -1,
 		})
 		if err != nil || len(inProgressBeads) == 0 {
-			return false
+			return nil
 		}
 		hookedBeads = inProgressBeads
 	}
 
 	// Use the first hooked bead (agents typically have one)
-	hookedBead := hookedBeads[0]
+	return hookedBeads[0]
+}
+
+// checkSlungWork checks for hooked work on the agent's hook.
+// If found, displays AUTONOMOUS WORK MODE and tells the agent to execute immediately.
+// Returns true if hooked work was found (caller should skip normal startup directive).
+func checkSlungWork(ctx RoleContext) bool {
+	hookedBead := findHookedBead(ctx)
+	if hookedBead == nil {
+		return false
+	}
 
 	// Build the role announcement string
 	roleAnnounce := buildRoleAnnouncement(ctx)
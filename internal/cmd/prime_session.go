@@ -12,6 +12,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/runtime"
+	"github.com/steveyegge/gastown/internal/trace"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -173,6 +174,15 @@ func emitSessionEvent(ctx RoleContext) {
 	// Emit the event
 	payload := events.SessionPayload(sessionID, actor, topic, ctx.WorkDir)
 	_ = events.LogFeed(events.TypeSessionStart, actor, payload)
+
+	// Sessions started for slung work join the hooked bead's trace
+	var hooked []string
+	if b := findHookedBead(ctx); b != nil {
+		hooked = append(hooked, b.ID)
+	}
+	if parent := trace.Resume(ctx.TownRoot, hooked...); parent.IsValid() {
+		trace.Mark("session_start", parent, "", actor, sessionID)
+	}
 }
 
 // outputSessionMetadata prints a structured metadata line for seance discovery.
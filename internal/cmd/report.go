@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/report"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	reportMonthly bool
+	reportPeriods int
+	reportRig     string
+	reportJSON    bool
+	reportHTML    string
+)
+
+var reportCmd = &cobra.Command{
+	Use:     "report",
+	GroupID: GroupDiag,
+	Short:   "Show weekly or monthly throughput, cycle time and failure rates",
+	Long: `Summarize town history by week (or month), per rig and per role.
+
+For each period the report shows:
+- Beads closed (tasks, bugs, features and chores, by assignee)
+- Median sling-to-merge time (last sling of a bead to its MR merging)
+- Merge failure and conflict rates from the rigs' merge queue event logs
+- Polecat crash rate (crashes in the town log per polecat spawned)
+- Handoffs per closed bead
+- Cost and cost per closed bead, from session cost digests
+
+Rows are given for the whole town, each rig, and each role within a rig.
+Town-level agents (mayor, deacon) appear under rig "town".
+
+Examples:
+  gt report                      # Last 4 weeks
+  gt report --monthly --periods 6
+  gt report --rig gastown --json
+  gt report --html report.html   # Write a static HTML page`,
+	RunE: runReport,
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().BoolVar(&reportMonthly, "monthly", false, "Bucket by calendar month instead of ISO week")
+	reportCmd.Flags().IntVar(&reportPeriods, "periods", 4, "Number of periods to report, ending with the current one")
+	reportCmd.Flags().StringVar(&reportRig, "rig", "", "Only report this rig")
+	reportCmd.Flags().BoolVar(&reportJSON, "json", false, "Output as JSON")
+	reportCmd.Flags().StringVar(&reportHTML, "html", "", "Write the report as a static HTML page to this file")
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	if reportPeriods < 1 {
+		return fmt.Errorf("--periods must be at least 1")
+	}
+
+	opts := report.Options{
+		Period:  report.PeriodWeek,
+		Periods: reportPeriods,
+		Rig:     reportRig,
+		Now:     time.Now(),
+	}
+	if reportMonthly {
+		opts.Period = report.PeriodMonth
+	}
+
+	sources := report.LiveSources(townRoot)
+	sources.Costs = reportSessionCosts
+	r := report.Build(sources, opts)
+
+	if reportHTML != "" {
+		f, err := os.Create(reportHTML)
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", reportHTML, err)
+		}
+		if err := report.WriteHTML(f, r); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("writing %s: %w", reportHTML, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("writing %s: %w", reportHTML, err)
+		}
+		fmt.Printf("%s Wrote %s\n", style.Success.Render("✓"), reportHTML)
+		return nil
+	}
+
+	if reportJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+
+	title := "Weekly report"
+	if reportMonthly {
+		title = "Monthly report"
+	}
+	if reportRig != "" {
+		title += ": " + reportRig
+	}
+	fmt.Printf("%s\n\n", style.Bold.Render("📈 "+title))
+	return report.WriteText(os.Stdout, r)
+}
+
+// reportSessionCosts returns session costs recorded since the given time,
+// for gt report.
+func reportSessionCosts(since time.Time) ([]report.Cost, error) {
+	entries, err := sessionCostsSince(since)
+	if err != nil {
+		return nil, err
+	}
+	costs := make([]report.Cost, 0, len(entries))
+	for _, e := range entries {
+		costs = append(costs, report.Cost{Role: e.Role, Rig: e.Rig, USD: e.CostUSD, EndedAt: e.EndedAt})
+	}
+	return costs, nil
+}
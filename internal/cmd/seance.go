@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -13,6 +12,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/logrotate"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
@@ -236,21 +236,15 @@ func runSeanceTalk(sessionID, prompt string) error {
 func discoverSessions(townRoot string) ([]sessionEvent, error) {
 	eventsPath := filepath.Join(townRoot, events.EventsFile)
 
-	file, err := os.Open(eventsPath)
+	// Read across rotated segments so older sessions stay discoverable
+	file, err := logrotate.Open(eventsPath, time.Time{})
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
 	defer file.Close()
 
 	var sessions []sessionEvent
-	scanner := bufio.NewScanner(file)
-
-	// Increase buffer for large lines
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+	scanner := logrotate.NewScanner(file)
 
 	for scanner.Scan() {
 		var event sessionEvent
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/daemon"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/townlog"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var sessionUnquarantineCmd = &cobra.Command{
+	Use:   "unquarantine [identity|session]",
+	Short: "Allow the daemon to restart a crash-looping session again",
+	Long: `Release a session from crash-loop quarantine.
+
+The daemon backs off exponentially between restarts of a session that keeps
+dying. After 5 deaths within 15 minutes it stops restarting the session,
+files a crash report bead with the last pane output and environment, and
+quarantines it until released with this command.
+
+With no argument, lists quarantined sessions.
+
+Examples:
+  gt session unquarantine                          # List quarantined sessions
+  gt session unquarantine gastown/polecats/Toast   # Release by identity
+  gt session unquarantine gt-gastown-Toast         # Release by session name`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSessionUnquarantine,
+}
+
+func init() {
+	sessionCmd.AddCommand(sessionUnquarantineCmd)
+}
+
+func runSessionUnquarantine(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	history, err := daemon.LoadRestartHistory(townRoot)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		quarantined := history.Quarantined()
+		if len(quarantined) == 0 {
+			fmt.Println("No quarantined sessions")
+			return nil
+		}
+		fmt.Println(style.Bold.Render("Quarantined sessions"))
+		for _, r := range quarantined {
+			report := ""
+			if r.CrashReport != "" {
+				report = " report: " + r.CrashReport
+			}
+			fmt.Printf("  %s %s (%s)%s\n", style.Error.Render("✗"), r.Identity, r.Session,
+				style.Dim.Render(fmt.Sprintf(" since %s%s", r.QuarantinedAt.Local().Format(time.DateTime), report)))
+		}
+		return nil
+	}
+
+	// Re-read under the lock: the daemon may have recorded deaths meanwhile
+	var r *daemon.RestartRecord
+	if err := daemon.UpdateRestartHistory(townRoot, func(h *daemon.RestartHistory) error {
+		r, err = h.Unquarantine(args[0])
+		return err
+	}); err != nil {
+		return err
+	}
+
+	_ = townlog.NewLogger(townRoot).Log(townlog.EventUnquarantine, r.Identity, "")
+
+	fmt.Printf("%s Released %s from quarantine\n", style.Bold.Render("✓"), r.Identity)
+	fmt.Println(style.Dim.Render("  The daemon will restart it on its next heartbeat if it has work."))
+	return nil
+}
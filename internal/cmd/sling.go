@@ -20,6 +20,7 @@ import (
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/trace"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -103,6 +104,10 @@ executor is an LLM, it interprets these instructions naturally.
 Formula Slinging:
   gt sling mol-release mayor/           # Cook + wisp + attach + nudge
   gt sling towers-of-hanoi --var disks=3
+  gt sling shiny --vars-file vars.toml  # Vars from a JSON or TOML file
+
+Vars are checked against the formula's typed vars and inputs, and defaults
+applied. Missing required vars are prompted for when run from a terminal.
 
 Formula-on-Bead (--on flag):
   gt sling mol-review --on gt-abc       # Apply formula to existing work
@@ -130,6 +135,7 @@ var (
 	slingDryRun   bool
 	slingOnTarget string   // --on flag: target bead when slinging a formula
 	slingVars     []string // --var flag: formula variables (key=value)
+	slingVarsFile string   // --vars-file flag: JSON or TOML file of formula variables
 	slingArgs     string   // --args flag: natural language instructions for executor
 
 	// Flags migrated for polecat spawning (used by sling for work assignment
@@ -147,6 +153,7 @@ func init() {
 	slingCmd.Flags().BoolVarP(&slingDryRun, "dry-run", "n", false, "Show what would be done")
 	slingCmd.Flags().StringVar(&slingOnTarget, "on", "", "Apply formula to existing bead (implies wisp scaffolding)")
 	slingCmd.Flags().StringArrayVar(&slingVars, "var", nil, "Formula variable (key=value), can be repeated")
+	slingCmd.Flags().StringVar(&slingVarsFile, "vars-file", "", "JSON or TOML file of formula variables (--var wins)")
 	slingCmd.Flags().StringVarP(&slingArgs, "args", "a", "", "Natural language instructions for the executor (e.g., 'patch release')")
 
 	// Flags for polecat spawning (when target is a rig)
@@ -175,7 +182,7 @@ func runSling(cmd *cobra.Command, args []string) error {
 	townBeadsDir := filepath.Join(townRoot, ".beads")
 
 	// --var is only for standalone formula mode, not formula-on-bead mode
-	if slingOnTarget != "" && len(slingVars) > 0 {
+	if slingOnTarget != "" && (len(slingVars) > 0 || slingVarsFile != "") {
 		return fmt.Errorf("--var cannot be used with --on (formula-on-bead mode doesn't support variables)")
 	}
 
@@ -230,6 +237,10 @@ func runSling(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Trace the work from dispatch to merge (see gt trace)
+	slingSpan := trace.Start("sling", trace.SpanContext{})
+	slingSpan.Bead = beadID
+
 	// Determine target agent (self or specified)
 	var targetAgent string
 	var targetPane string
@@ -258,7 +269,7 @@ func runSling(cmd *cobra.Command, args []string) error {
 				targetPane = "<dog-pane>"
 			} else {
 				// Dispatch to dog
-				dispatchInfo, dispatchErr := DispatchToDog(dogName, slingCreate)
+				dispatchInfo, dispatchErr := DispatchToDog(dogName, slingCreate, beadTier(beadID))
 				if dispatchErr != nil {
 					return fmt.Errorf("dispatching to dog: %w", dispatchErr)
 				}
@@ -280,12 +291,14 @@ func runSling(cmd *cobra.Command, args []string) error {
 				// Spawn a fresh polecat in the rig
 				fmt.Printf("Target is rig '%s', spawning fresh polecat...\n", rigName)
 				spawnOpts := SlingSpawnOptions{
-					Force:    slingForce,
-					Naked:    slingNaked,
-					Account:  slingAccount,
-					Create:   slingCreate,
-					HookBead: beadID, // Set atomically at spawn time
-					Agent:    slingAgent,
+					Force:       slingForce,
+					Naked:       slingNaked,
+					Account:     slingAccount,
+					Create:      slingCreate,
+					HookBead:    beadID, // Set atomically at spawn time
+					Agent:       slingAgent,
+					Tier:        beadTier(beadID),
+					TraceParent: slingSpan.Context().TraceParent(),
 				}
 				spawnInfo, spawnErr := SpawnPolecatForSling(rigName, spawnOpts)
 				if spawnErr != nil {
@@ -424,6 +437,11 @@ func runSling(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("parsing wisp output: %w", err)
 		}
 		fmt.Printf("%s Formula wisp created: %s\n", style.Bold.Render("✓"), wispRootID)
+		if err := storeFormulaInBead(wispRootID, formulaName, map[string]string{"feature": info.Title}, formulaWorkDir); err != nil {
+			fmt.Printf("%s Could not store formula in wisp: %v\n", style.Dim.Render("Warning:"), err)
+		} else if err := advancePouredMolecule(wispRootID, formulaWorkDir); err != nil {
+			fmt.Printf("%s Could not expand fan-out steps: %v\n", style.Dim.Render("Warning:"), err)
+		}
 
 		// Step 3: Bond wisp to original bead (creates compound)
 		// Use --no-daemon for mol bond (requires direct database access)
@@ -468,6 +486,9 @@ func runSling(cmd *cobra.Command, args []string) error {
 	// Log sling event to activity feed
 	actor := detectActor()
 	_ = events.LogFeed(events.TypeSling, actor, events.SlingPayload(beadID, targetAgent))
+	slingSpan.Actor = actor
+	slingSpan.Detail = targetAgent
+	slingSpan.Finish(nil)
 
 	// Update agent bead's hook_bead field (ZFC: agents track their current work)
 	updateAgentHookBead(targetAgent, beadID, hookWorkDir, townBeadsDir)
@@ -496,6 +517,7 @@ func runSling(cmd *cobra.Command, args []string) error {
 		// message arrives before Claude has fully started - see issue #115)
 		sessionName := getSessionFromPane(targetPane)
 		if sessionName != "" {
+			setSessionTraceParent(sessionName, slingSpan)
 			if err := ensureAgentReady(sessionName); err != nil {
 				// Non-fatal: warn and continue, agent will discover work via gt prime
 				fmt.Printf("%s Could not verify agent ready: %v\n", style.Dim.Render("○"), err)
@@ -556,6 +578,24 @@ func storeArgsInBead(beadID, args string) error {
 	return nil
 }
 
+// storeFormulaInBead records the formula a molecule was poured from, and its
+// vars, on the molecule's root bead. gt mol step done uses them to apply the
+// formula's control flow.
+func storeFormulaInBead(beadID, formulaName string, vars map[string]string, dir string) error {
+	b := beads.New(dir) // "" runs bd in the current directory
+	issue, err := b.Show(beadID)
+	if err != nil {
+		return fmt.Errorf("fetching bead: %w", err)
+	}
+
+	fields := &beads.MoleculeFields{Formula: formulaName, Vars: vars}
+	desc := beads.SetMoleculeFields(issue, fields)
+	if err := b.Update(beadID, beads.UpdateOptions{Description: &desc}); err != nil {
+		return fmt.Errorf("updating bead description: %w", err)
+	}
+	return nil
+}
+
 // storeDispatcherInBead stores the dispatcher agent ID in the bead's description.
 // This enables polecats to notify the dispatcher when work is complete.
 func storeDispatcherInBead(beadID, dispatcher string) error {
@@ -629,6 +669,16 @@ func injectStartPrompt(pane, beadID, subject, args string) error {
 	return t.NudgePane(pane, prompt)
 }
 
+// setSessionTraceParent carries the sling's trace into the target's session.
+// An agent that was already running never saw GT_TRACEPARENT in its own
+// environment; trace.FromEnv finds it in the session environment instead.
+func setSessionTraceParent(sessionName string, span *trace.Span) {
+	if sessionName == "" {
+		return
+	}
+	_ = tmux.NewTmux().SetEnvironment(sessionName, trace.EnvTraceParent, span.Context().TraceParent())
+}
+
 // getSessionFromPane extracts session name from a pane target.
 // Pane targets can be:
 // - "%9" (pane ID) - need to query tmux for session
@@ -746,9 +796,20 @@ func verifyBeadExists(beadID string) error {
 
 // beadInfo holds status and assignee for a bead.
 type beadInfo struct {
-	Title    string `json:"title"`
-	Status   string `json:"status"`
-	Assignee string `json:"assignee"`
+	Title       string `json:"title"`
+	Status      string `json:"status"`
+	Assignee    string `json:"assignee"`
+	Description string `json:"description"`
+}
+
+// beadTier returns the step tier of a bead (see beads.StepTier), or "" if
+// it has none or can't be read.
+func beadTier(beadID string) string {
+	info, err := getBeadInfo(beadID)
+	if err != nil {
+		return ""
+	}
+	return beads.StepTier(info.Description)
 }
 
 // getBeadInfo returns status and assignee for a bead.
@@ -847,6 +908,7 @@ func verifyFormulaExists(formulaName string) error {
 // Flow: cook → wisp → attach to hook → nudge
 func runSlingFormula(args []string) error {
 	formulaName := args[0]
+	slingSpan := trace.Start("sling", trace.SpanContext{})
 
 	// Get town root early - needed for BEADS_DIR when running bd commands
 	townRoot, err := workspace.FindFromCwd()
@@ -886,7 +948,7 @@ func runSlingFormula(args []string) error {
 				targetPane = "<dog-pane>"
 			} else {
 				// Dispatch to dog
-				dispatchInfo, dispatchErr := DispatchToDog(dogName, slingCreate)
+				dispatchInfo, dispatchErr := DispatchToDog(dogName, slingCreate, "")
 				if dispatchErr != nil {
 					return fmt.Errorf("dispatching to dog: %w", dispatchErr)
 				}
@@ -908,11 +970,12 @@ func runSlingFormula(args []string) error {
 				// Spawn a fresh polecat in the rig
 				fmt.Printf("Target is rig '%s', spawning fresh polecat...\n", rigName)
 				spawnOpts := SlingSpawnOptions{
-					Force:   slingForce,
-					Naked:   slingNaked,
-					Account: slingAccount,
-					Create:  slingCreate,
-					Agent:   slingAgent,
+					Force:       slingForce,
+					Naked:       slingNaked,
+					Account:     slingAccount,
+					Create:      slingCreate,
+					Agent:       slingAgent,
+					TraceParent: slingSpan.Context().TraceParent(),
 				}
 				spawnInfo, spawnErr := SpawnPolecatForSling(rigName, spawnOpts)
 				if spawnErr != nil {
@@ -947,10 +1010,24 @@ func runSlingFormula(args []string) error {
 
 	fmt.Printf("%s Slinging formula %s to %s...\n", style.Bold.Render("🎯"), formulaName, targetAgent)
 
+	// Validate vars against the formula's typed vars and inputs
+	vars, err := parseFormulaVars(slingVars, slingVarsFile)
+	if err != nil {
+		return err
+	}
+	if f, loadErr := loadResolvedFormula(formulaName); loadErr == nil {
+		if vars, err = bindFormulaVars(f, vars, townRoot); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%s Could not load formula to check vars: %v\n", style.Dim.Render("Warning:"), loadErr)
+	}
+	varArgs := formulaVarArgs(vars)
+
 	if slingDryRun {
 		fmt.Printf("Would cook formula: %s\n", formulaName)
 		fmt.Printf("Would create wisp and pin to: %s\n", targetAgent)
-		for _, v := range slingVars {
+		for _, v := range varArgs {
 			fmt.Printf("  --var %s\n", v)
 		}
 		fmt.Printf("Would nudge pane: %s\n", targetPane)
@@ -969,7 +1046,7 @@ func runSlingFormula(args []string) error {
 	// Step 2: Create wisp instance (ephemeral)
 	fmt.Printf("  Creating wisp...\n")
 	wispArgs := []string{"--no-daemon", "mol", "wisp", formulaName}
-	for _, v := range slingVars {
+	for _, v := range varArgs {
 		wispArgs = append(wispArgs, "--var", v)
 	}
 	wispArgs = append(wispArgs, "--json")
@@ -988,6 +1065,11 @@ func runSlingFormula(args []string) error {
 	}
 
 	fmt.Printf("%s Wisp created: %s\n", style.Bold.Render("✓"), wispRootID)
+	if err := storeFormulaInBead(wispRootID, formulaName, vars, ""); err != nil {
+		fmt.Printf("%s Could not store formula in wisp: %v\n", style.Dim.Render("Warning:"), err)
+	} else if err := advancePouredMolecule(wispRootID, ""); err != nil {
+		fmt.Printf("%s Could not expand fan-out steps: %v\n", style.Dim.Render("Warning:"), err)
+	}
 
 	// Step 3: Hook the wisp bead using bd update.
 	// See: https://github.com/steveyegge/gastown/issues/148
@@ -1004,6 +1086,10 @@ func runSlingFormula(args []string) error {
 	payload := events.SlingPayload(wispRootID, targetAgent)
 	payload["formula"] = formulaName
 	_ = events.LogFeed(events.TypeSling, actor, payload)
+	slingSpan.Bead = wispRootID
+	slingSpan.Actor = actor
+	slingSpan.Detail = targetAgent
+	slingSpan.Finish(nil)
 
 	// Update agent bead's hook_bead field (ZFC: agents track their current work)
 	// Note: formula slinging uses town root as workDir (no polecat-specific path)
@@ -1036,6 +1122,7 @@ func runSlingFormula(args []string) error {
 	} else {
 		prompt = fmt.Sprintf("Formula %s slung. Run `gt hook` to see your hook, then execute the steps.", formulaName)
 	}
+	setSessionTraceParent(getSessionFromPane(targetPane), slingSpan)
 	t := tmux.NewTmux()
 	if err := t.NudgePane(targetPane, prompt); err != nil {
 		// Graceful fallback for no-tmux mode
@@ -1196,7 +1283,8 @@ type DogDispatchInfo struct {
 // DispatchToDog finds or spawns a dog for work dispatch.
 // If dogName is empty, finds an idle dog from the pool.
 // If create is true and no dogs exist, creates one.
-func DispatchToDog(dogName string, create bool) (*DogDispatchInfo, error) {
+// A step tier that maps to a runtime restarts the dog's session on it.
+func DispatchToDog(dogName string, create bool, tier string) (*DogDispatchInfo, error) {
 	townRoot, err := workspace.FindFromCwd()
 	if err != nil {
 		return nil, fmt.Errorf("finding town root: %w", err)
@@ -1270,6 +1358,12 @@ func DispatchToDog(dogName string, create bool) (*DogDispatchInfo, error) {
 		// Get the pane from the session
 		pane, _ = getSessionPane(sessionName)
 	}
+	if tc := config.ResolveTier(townRoot, "", tier); tc != nil && pane != "" {
+		fmt.Printf("Step tier: %s\n", describeTier(tier, tc))
+		if err := restartDogForTier(t, sessionName, pane, targetDog.Path, townRoot, tier); err != nil {
+			return nil, fmt.Errorf("restarting dog %s for tier %s: %w", targetDog.Name, tier, err)
+		}
+	}
 
 	return &DogDispatchInfo{
 		DogName: targetDog.Name,
@@ -1279,6 +1373,24 @@ func DispatchToDog(dogName string, create bool) (*DogDispatchInfo, error) {
 	}, nil
 }
 
+// restartDogForTier respawns a dog's pane on the runtime a step tier maps
+// to, and waits until it is ready for the sling's nudge.
+func restartDogForTier(t *tmux.Tmux, sessionName, pane, workDir, townRoot, tier string) error {
+	rc, _, err := config.ResolveTierRuntime(townRoot, "", tier, "")
+	if err != nil {
+		return err
+	}
+	command, exports, err := tierRestartCommand(townRoot, "", tier, "")
+	if err != nil {
+		return err
+	}
+	restartCmd := fmt.Sprintf("cd %s && export %s && exec %s", workDir, strings.Join(exports, " "), command)
+	if err := t.RespawnPane(pane, restartCmd); err != nil {
+		return err
+	}
+	return t.WaitForRuntimeReady(sessionName, rc, constants.ClaudeStartTimeout)
+}
+
 // generateDogName creates a unique dog name for pool expansion.
 func generateDogName(mgr *dog.Manager) string {
 	// Use Greek alphabet for dog names
@@ -1433,114 +1545,129 @@ func runBatchSling(beadIDs []string, rigName string, townBeadsDir string) error
 	for i, beadID := range beadIDs {
 		fmt.Printf("\n[%d/%d] Slinging %s...\n", i+1, len(beadIDs), beadID)
 
-		// Check bead status
-		info, err := getBeadInfo(beadID)
+		polecatName, err := slingBeadToNewPolecat(beadID, rigName, townBeadsDir, detectActor())
 		if err != nil {
-			results = append(results, slingResult{beadID: beadID, success: false, errMsg: err.Error()})
-			fmt.Printf("  %s Could not get bead info: %v\n", style.Dim.Render("✗"), err)
+			results = append(results, slingResult{beadID: beadID, polecat: polecatName, success: false, errMsg: err.Error()})
 			continue
 		}
+		results = append(results, slingResult{beadID: beadID, polecat: polecatName, success: true})
+	}
 
-		if info.Status == "pinned" && !slingForce {
-			results = append(results, slingResult{beadID: beadID, success: false, errMsg: "already pinned"})
-			fmt.Printf("  %s Already pinned (use --force to re-sling)\n", style.Dim.Render("✗"))
-			continue
-		}
+	// Wake witness and refinery once at the end
+	wakeRigAgents(rigName)
 
-		// Spawn a fresh polecat
-		spawnOpts := SlingSpawnOptions{
-			Force:    slingForce,
-			Naked:    slingNaked,
-			Account:  slingAccount,
-			Create:   slingCreate,
-			HookBead: beadID, // Set atomically at spawn time
-			Agent:    slingAgent,
-		}
-		spawnInfo, err := SpawnPolecatForSling(rigName, spawnOpts)
-		if err != nil {
-			results = append(results, slingResult{beadID: beadID, success: false, errMsg: err.Error()})
-			fmt.Printf("  %s Failed to spawn polecat: %v\n", style.Dim.Render("✗"), err)
-			continue
+	// Print summary
+	successCount := 0
+	for _, r := range results {
+		if r.success {
+			successCount++
 		}
+	}
 
-		targetAgent := spawnInfo.AgentID()
-		hookWorkDir := spawnInfo.ClonePath
-
-		// Auto-convoy: check if issue is already tracked
-		if !slingNoConvoy {
-			existingConvoy := isTrackedByConvoy(beadID)
-			if existingConvoy == "" {
-				convoyID, err := createAutoConvoy(beadID, info.Title)
-				if err != nil {
-					fmt.Printf("  %s Could not create auto-convoy: %v\n", style.Dim.Render("Warning:"), err)
-				} else {
-					fmt.Printf("  %s Created convoy 🚚 %s\n", style.Bold.Render("→"), convoyID)
-				}
-			} else {
-				fmt.Printf("  %s Already tracked by convoy %s\n", style.Dim.Render("○"), existingConvoy)
+	fmt.Printf("\n%s Batch sling complete: %d/%d succeeded\n", style.Bold.Render("📊"), successCount, len(beadIDs))
+	if successCount < len(beadIDs) {
+		for _, r := range results {
+			if !r.success {
+				fmt.Printf("  %s %s: %s\n", style.Dim.Render("✗"), r.beadID, r.errMsg)
 			}
 		}
+	}
 
-		// Hook the bead. See: https://github.com/steveyegge/gastown/issues/148
-		townRoot := filepath.Dir(townBeadsDir)
-		hookCmd := exec.Command("bd", "--no-daemon", "update", beadID, "--status=hooked", "--assignee="+targetAgent)
-		hookCmd.Dir = beads.ResolveHookDir(townRoot, beadID, hookWorkDir)
-		hookCmd.Stderr = os.Stderr
-		if err := hookCmd.Run(); err != nil {
-			results = append(results, slingResult{beadID: beadID, polecat: spawnInfo.PolecatName, success: false, errMsg: "hook failed"})
-			fmt.Printf("  %s Failed to hook bead: %v\n", style.Dim.Render("✗"), err)
-			continue
-		}
-
-		fmt.Printf("  %s Work attached to %s\n", style.Bold.Render("✓"), spawnInfo.PolecatName)
+	return nil
+}
 
-		// Log sling event
-		actor := detectActor()
-		_ = events.LogFeed(events.TypeSling, actor, events.SlingPayload(beadID, targetAgent))
+// slingBeadToNewPolecat spawns a fresh polecat in the rig and hooks the bead
+// to it, logging the sling as actor. Returns the polecat's name, which is set
+// once the polecat exists even if hooking fails. The caller wakes the rig's
+// agents.
+func slingBeadToNewPolecat(beadID, rigName, townBeadsDir, actor string) (string, error) {
+	// Check bead status
+	info, err := getBeadInfo(beadID)
+	if err != nil {
+		fmt.Printf("  %s Could not get bead info: %v\n", style.Dim.Render("✗"), err)
+		return "", err
+	}
 
-		// Update agent bead state
-		updateAgentHookBead(targetAgent, beadID, hookWorkDir, townBeadsDir)
+	if info.Status == "pinned" && !slingForce {
+		fmt.Printf("  %s Already pinned (use --force to re-sling)\n", style.Dim.Render("✗"))
+		return "", fmt.Errorf("already pinned")
+	}
+
+	// Spawn a fresh polecat
+	slingSpan := trace.Start("sling", trace.SpanContext{})
+	slingSpan.Bead = beadID
+	spawnOpts := SlingSpawnOptions{
+		Force:       slingForce,
+		Naked:       slingNaked,
+		Account:     slingAccount,
+		Create:      slingCreate,
+		HookBead:    beadID, // Set atomically at spawn time
+		Agent:       slingAgent,
+		Tier:        beads.StepTier(info.Description),
+		TraceParent: slingSpan.Context().TraceParent(),
+	}
+	spawnInfo, err := SpawnPolecatForSling(rigName, spawnOpts)
+	if err != nil {
+		fmt.Printf("  %s Failed to spawn polecat: %v\n", style.Dim.Render("✗"), err)
+		return "", err
+	}
 
-		// Store args if provided
-		if slingArgs != "" {
-			if err := storeArgsInBead(beadID, slingArgs); err != nil {
-				fmt.Printf("  %s Could not store args: %v\n", style.Dim.Render("Warning:"), err)
-			}
-		}
+	targetAgent := spawnInfo.AgentID()
+	hookWorkDir := spawnInfo.ClonePath
 
-		// Nudge the polecat
-		if spawnInfo.Pane != "" {
-			if err := injectStartPrompt(spawnInfo.Pane, beadID, slingSubject, slingArgs); err != nil {
-				fmt.Printf("  %s Could not nudge (agent will discover via gt prime)\n", style.Dim.Render("○"))
+	// Auto-convoy: check if issue is already tracked
+	if !slingNoConvoy {
+		existingConvoy := isTrackedByConvoy(beadID)
+		if existingConvoy == "" {
+			convoyID, err := createAutoConvoy(beadID, info.Title)
+			if err != nil {
+				fmt.Printf("  %s Could not create auto-convoy: %v\n", style.Dim.Render("Warning:"), err)
 			} else {
-				fmt.Printf("  %s Start prompt sent\n", style.Bold.Render("▶"))
+				fmt.Printf("  %s Created convoy 🚚 %s\n", style.Bold.Render("→"), convoyID)
 			}
+		} else {
+			fmt.Printf("  %s Already tracked by convoy %s\n", style.Dim.Render("○"), existingConvoy)
 		}
+	}
 
-		results = append(results, slingResult{beadID: beadID, polecat: spawnInfo.PolecatName, success: true})
+	// Hook the bead. See: https://github.com/steveyegge/gastown/issues/148
+	townRoot := filepath.Dir(townBeadsDir)
+	hookCmd := exec.Command("bd", "--no-daemon", "update", beadID, "--status=hooked", "--assignee="+targetAgent)
+	hookCmd.Dir = beads.ResolveHookDir(townRoot, beadID, hookWorkDir)
+	hookCmd.Stderr = os.Stderr
+	if err := hookCmd.Run(); err != nil {
+		fmt.Printf("  %s Failed to hook bead: %v\n", style.Dim.Render("✗"), err)
+		return spawnInfo.PolecatName, fmt.Errorf("hook failed")
 	}
 
-	// Wake witness and refinery once at the end
-	wakeRigAgents(rigName)
+	fmt.Printf("  %s Work attached to %s\n", style.Bold.Render("✓"), spawnInfo.PolecatName)
 
-	// Print summary
-	successCount := 0
-	for _, r := range results {
-		if r.success {
-			successCount++
+	// Log sling event
+	_ = events.LogFeed(events.TypeSling, actor, events.SlingPayload(beadID, targetAgent))
+	slingSpan.Actor = actor
+	slingSpan.Detail = targetAgent
+	slingSpan.Finish(nil)
+
+	// Update agent bead state
+	updateAgentHookBead(targetAgent, beadID, hookWorkDir, townBeadsDir)
+
+	// Store args if provided
+	if slingArgs != "" {
+		if err := storeArgsInBead(beadID, slingArgs); err != nil {
+			fmt.Printf("  %s Could not store args: %v\n", style.Dim.Render("Warning:"), err)
 		}
 	}
 
-	fmt.Printf("\n%s Batch sling complete: %d/%d succeeded\n", style.Bold.Render("📊"), successCount, len(beadIDs))
-	if successCount < len(beadIDs) {
-		for _, r := range results {
-			if !r.success {
-				fmt.Printf("  %s %s: %s\n", style.Dim.Render("✗"), r.beadID, r.errMsg)
-			}
+	// Nudge the polecat
+	if spawnInfo.Pane != "" {
+		if err := injectStartPrompt(spawnInfo.Pane, beadID, slingSubject, slingArgs); err != nil {
+			fmt.Printf("  %s Could not nudge (agent will discover via gt prime)\n", style.Dim.Render("○"))
+		} else {
+			fmt.Printf("  %s Start prompt sent\n", style.Bold.Render("▶"))
 		}
 	}
 
-	return nil
+	return spawnInfo.PolecatName, nil
 }
 
 // formatTrackBeadID formats a bead ID for use in convoy tracking dependencies.
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/trace"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Trace command flags
+var (
+	traceJSON       bool
+	traceAll        bool
+	traceOTLP       string
+	traceSince      string
+	traceExportOut  string
+	traceMarkDetail string
+	traceMarkError  bool
+)
+
+var traceCmd = &cobra.Command{
+	Use:     "trace <bead-id>",
+	GroupID: GroupDiag,
+	Short:   "Show how work moved from sling to merge",
+	Long: `Show the trace of a bead from dispatch to merge.
+
+gt sling starts a trace for the work it dispatches. Spans are recorded in the
+events log as the work moves through the polecat's session, molecule steps,
+gt done, merge request submission and the refinery merge. The trace context
+travels with the work via GT_TRACEPARENT in the polecat's environment and the
+trace_parent field of merge-request beads.
+
+Shows every span with its offset from the start of the trace, then the
+critical path: the chain of spans that determined the end-to-end time, with
+the idle time spent waiting before each.
+
+Traces can be exported as OTLP JSON for any OpenTelemetry backend; no live
+collector is needed.
+
+Examples:
+  gt trace gt-abc                      # Latest trace for gt-abc
+  gt trace gt-abc --all                # Every trace (re-slung work)
+  gt trace gt-abc --otlp trace.json    # Export as OTLP JSON
+  gt trace export --since 24h -o traces.json
+  gt trace mark gt-abc merged          # Record a milestone (for agents)`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTrace,
+}
+
+var traceExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export recorded spans as OTLP JSON",
+	Long: `Export spans from the events log as an OTLP JSON file.
+
+The output is an ExportTraceServiceRequest in OTLP/JSON encoding, which
+OpenTelemetry collectors and most tracing backends can import.
+
+Examples:
+  gt trace export                         # All spans to stdout
+  gt trace export --since 24h -o out.json # Last day to a file`,
+	Args: cobra.NoArgs,
+	RunE: runTraceExport,
+}
+
+var traceMarkCmd = &cobra.Command{
+	Use:   "mark <bead-id> <name>",
+	Short: "Record a milestone in a bead's trace",
+	Long: `Record a milestone span in the trace of a bead.
+
+For steps performed by agents rather than gt commands, such as the refinery
+pushing a merge to main. The span joins the latest trace recorded for the
+bead, otherwise the trace in GT_TRACEPARENT if set.
+
+Examples:
+  gt trace mark gt-abc merged --detail "$(git rev-parse --short HEAD)"
+  gt trace mark gt-abc merge --error --detail "tests failed"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runTraceMark,
+}
+
+func init() {
+	traceCmd.Flags().BoolVar(&traceJSON, "json", false, "Output spans as JSON")
+	traceCmd.Flags().BoolVar(&traceAll, "all", false, "Show every trace for the bead, not just the latest")
+	traceCmd.Flags().StringVar(&traceOTLP, "otlp", "", "Write the trace as OTLP JSON to this file (- for stdout)")
+
+	traceExportCmd.Flags().StringVar(&traceSince, "since", "", "Only export spans from this far back (e.g., 24h, 7d)")
+	traceExportCmd.Flags().StringVarP(&traceExportOut, "output", "o", "-", "Output file (- for stdout)")
+
+	traceMarkCmd.Flags().StringVar(&traceMarkDetail, "detail", "", "Detail to attach (commit SHA, reason, ...)")
+	traceMarkCmd.Flags().BoolVar(&traceMarkError, "error", false, "Mark the milestone as failed")
+
+	traceCmd.AddCommand(traceExportCmd)
+	traceCmd.AddCommand(traceMarkCmd)
+	rootCmd.AddCommand(traceCmd)
+}
+
+func runTrace(cmd *cobra.Command, args []string) error {
+	beadID := args[0]
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	spans, err := trace.Load(townRoot, time.Time{})
+	if err != nil {
+		return fmt.Errorf("reading events: %w", err)
+	}
+	traces := trace.ForBead(spans, beadID)
+	if len(traces) == 0 {
+		return fmt.Errorf("no trace recorded for %s (traces start at gt sling)", beadID)
+	}
+	shown := traces
+	if !traceAll {
+		shown = traces[len(traces)-1:]
+	}
+
+	if traceOTLP != "" {
+		var all []*trace.Span
+		for _, t := range shown {
+			all = append(all, t.Spans...)
+		}
+		if err := trace.WriteOTLP(traceOTLP, all); err != nil {
+			return fmt.Errorf("writing OTLP: %w", err)
+		}
+		if traceOTLP != "-" {
+			fmt.Printf("%s Wrote %d spans to %s\n", style.Success.Render("✓"), len(all), traceOTLP)
+		}
+		return nil
+	}
+
+	if traceJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(shown)
+	}
+
+	for i, t := range shown {
+		if i > 0 {
+			fmt.Println()
+		}
+		printTrace(beadID, t)
+	}
+	if !traceAll && len(traces) > 1 {
+		fmt.Printf("\n%s\n", style.Dim.Render(fmt.Sprintf("%d earlier traces for %s; use --all to show them", len(traces)-1, beadID)))
+	}
+	return nil
+}
+
+// printTrace renders a trace's timeline and critical path.
+func printTrace(beadID string, t *trace.Trace) {
+	start := t.Start()
+	fmt.Printf("%s %s %s\n", style.Bold.Render("Trace"), t.ID, style.Dim.Render("for "+beadID))
+	fmt.Printf("  %s → %s  (%s end to end)\n\n",
+		start.Local().Format(time.DateTime), t.End().Local().Format(time.DateTime),
+		formatDuration(t.End().Sub(start)))
+
+	for _, s := range t.Spans {
+		fmt.Printf("  %10s  %-14s %8s  %s\n",
+			"+"+formatDuration(s.Start.Sub(start)), s.Name, spanDuration(s), describeSpan(s))
+	}
+
+	path := t.CriticalPath()
+	var work, wait time.Duration
+	fmt.Printf("\n%s\n", style.Bold.Render("Critical path"))
+	for _, step := range path {
+		if step.Wait > 0 {
+			fmt.Printf("  %s\n", style.Warning.Render(fmt.Sprintf("⏳ waited %s", formatDuration(step.Wait))))
+			wait += step.Wait
+		}
+		work += step.Span.Duration()
+		fmt.Printf("  %-14s %8s  %s\n", step.Span.Name, spanDuration(step.Span), describeSpan(step.Span))
+	}
+	fmt.Printf("\n  %s\n", style.Dim.Render(fmt.Sprintf("working %s, waiting %s", formatDuration(work), formatDuration(wait))))
+}
+
+func spanDuration(s *trace.Span) string {
+	if d := s.Duration(); d > 0 {
+		return formatDuration(d)
+	}
+	return "·"
+}
+
+func describeSpan(s *trace.Span) string {
+	desc := s.Actor
+	if s.Bead != "" {
+		desc += " " + s.Bead
+	}
+	if s.Detail != "" {
+		desc += " " + style.Dim.Render(s.Detail)
+	}
+	if s.Status == trace.StatusError {
+		desc = style.Error.Render("✗ ") + desc
+	}
+	return desc
+}
+
+func runTraceExport(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	var since time.Time
+	if traceSince != "" {
+		d, err := parseDuration(traceSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		since = time.Now().Add(-d)
+	}
+
+	spans, err := trace.Load(townRoot, since)
+	if err != nil {
+		return fmt.Errorf("reading events: %w", err)
+	}
+	if err := trace.WriteOTLP(traceExportOut, spans); err != nil {
+		return fmt.Errorf("writing OTLP: %w", err)
+	}
+	if traceExportOut != "-" {
+		fmt.Printf("%s Exported %d spans to %s\n", style.Success.Render("✓"), len(spans), traceExportOut)
+	}
+	return nil
+}
+
+func runTraceMark(cmd *cobra.Command, args []string) error {
+	beadID, name := args[0], args[1]
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	span := trace.Start(name, trace.Resume(townRoot, beadID))
+	span.Bead = beadID
+	span.Actor = detectSender()
+	span.Detail = traceMarkDetail
+	var markErr error
+	if traceMarkError {
+		markErr = errors.New(traceMarkDetail)
+	}
+	span.Finish(markErr)
+
+	fmt.Printf("%s Marked %s on %s\n", style.Success.Render("✓"), name, beadID)
+	return nil
+}
@@ -3,8 +3,10 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 )
@@ -67,8 +69,71 @@ type AgentPresetInfo struct {
 	// Claude-only feature for seance command.
 	SupportsForkSession bool `json:"supports_fork_session,omitempty"`
 
+	// ModelFlag is the flag that selects the model (e.g., "--model").
+	// Empty if the agent can't be told which model to use.
+	ModelFlag string `json:"model_flag,omitempty"`
+
 	// NonInteractive contains settings for non-interactive mode.
 	NonInteractive *NonInteractiveConfig `json:"non_interactive,omitempty"`
+
+	// PromptRules describe interactive prompts the runtime may block on and
+	// how health checks should answer them. Rules are tried in order; the
+	// first whose pattern matches the captured pane wins.
+	PromptRules []PromptRule `json:"prompt_rules,omitempty"`
+}
+
+// PromptAction is what a health check does when a prompt rule matches.
+type PromptAction string
+
+const (
+	// PromptSendKeys sends Keys to the session (tmux key names, e.g. "Down", "Enter").
+	PromptSendKeys PromptAction = "send_keys"
+	// PromptEscalate reports the prompt for a human to answer.
+	PromptEscalate PromptAction = "escalate"
+	// PromptRestart restarts the session, optionally under another account.
+	PromptRestart PromptAction = "restart"
+)
+
+// PromptRule maps an interactive prompt to an automatic response.
+type PromptRule struct {
+	// Name identifies the rule in logs and events (e.g., "trust-folder").
+	Name string `json:"name"`
+
+	// Pattern is a regular expression matched against the tail of the pane.
+	Pattern string `json:"pattern"`
+
+	// Action is what to do when the pattern matches.
+	Action PromptAction `json:"action"`
+
+	// Keys are sent in order for send_keys rules.
+	Keys []string `json:"keys,omitempty"`
+
+	// Account is the account handle to restart under for restart rules.
+	// "next" rotates to the next configured account; empty keeps the current one.
+	Account string `json:"account,omitempty"`
+}
+
+// Validate checks that a prompt rule is usable.
+func (r PromptRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("prompt rule: name is required")
+	}
+	if r.Pattern == "" {
+		return fmt.Errorf("prompt rule %q: pattern is required", r.Name)
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("prompt rule %q: invalid pattern: %w", r.Name, err)
+	}
+	switch r.Action {
+	case PromptSendKeys:
+		if len(r.Keys) == 0 {
+			return fmt.Errorf("prompt rule %q: send_keys requires keys", r.Name)
+		}
+	case PromptEscalate, PromptRestart:
+	default:
+		return fmt.Errorf("prompt rule %q: unknown action %q", r.Name, r.Action)
+	}
+	return nil
 }
 
 // NonInteractiveConfig contains settings for running agents non-interactively.
@@ -96,6 +161,41 @@ type AgentRegistry struct {
 // CurrentAgentRegistryVersion is the current schema version.
 const CurrentAgentRegistryVersion = 1
 
+// claudePromptRules answer the dialogs Claude Code shows at startup and the
+// states that block it mid-session. Patterns are kept to Claude's own wording
+// so that an agent quoting similar text doesn't trip a restart.
+var claudePromptRules = []PromptRule{
+	{
+		Name:    "bypass-permissions",
+		Pattern: `Bypass Permissions mode`,
+		Action:  PromptSendKeys,
+		Keys:    []string{"Down", "Enter"}, // Select "Yes, I accept"
+	},
+	{
+		Name:    "trust-folder",
+		Pattern: `(?i)do you trust the files in this folder`,
+		Action:  PromptSendKeys,
+		Keys:    []string{"Enter"}, // Default option is "Yes, proceed"
+	},
+	{
+		Name:    "update-available",
+		Pattern: `(?i)update available.*(press enter|continue)`,
+		Action:  PromptSendKeys,
+		Keys:    []string{"Enter"},
+	},
+	{
+		Name:    "rate-limit",
+		Pattern: `(?i)(usage limit reached|limit reached ∙ resets|/upgrade to increase your usage limit)`,
+		Action:  PromptRestart,
+		Account: "next",
+	},
+	{
+		Name:    "login-expired",
+		Pattern: `(?i)(please run /login|oauth token has expired)`,
+		Action:  PromptEscalate,
+	},
+}
+
 // builtinPresets contains the default presets for supported agents.
 var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 	AgentClaude: {
@@ -108,7 +208,9 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 		ResumeStyle:         "flag",
 		SupportsHooks:       true,
 		SupportsForkSession: true,
+		ModelFlag:           "--model",
 		NonInteractive:      nil, // Claude is native non-interactive
+		PromptRules:         claudePromptRules,
 	},
 	AgentGemini: {
 		Name:                AgentGemini,
@@ -120,6 +222,7 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 		ResumeStyle:         "flag",
 		SupportsHooks:       true,
 		SupportsForkSession: false,
+		ModelFlag:           "--model",
 		NonInteractive: &NonInteractiveConfig{
 			PromptFlag: "-p",
 			OutputFlag: "--output-format json",
@@ -135,6 +238,7 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 		ResumeStyle:         "subcommand",
 		SupportsHooks:       false, // Use env/files instead
 		SupportsForkSession: false,
+		ModelFlag:           "--model",
 		NonInteractive: &NonInteractiveConfig{
 			Subcommand: "exec",
 			OutputFlag: "--json",
@@ -150,6 +254,7 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 		ResumeStyle:         "flag",
 		SupportsHooks:       false, // TODO: verify hooks support
 		SupportsForkSession: false,
+		ModelFlag:           "--model",
 		NonInteractive: &NonInteractiveConfig{
 			PromptFlag: "-p",
 			OutputFlag: "--output-format json",
@@ -238,6 +343,14 @@ func loadAgentRegistryFromPathLocked(path string) error {
 		return err
 	}
 
+	for name, preset := range userRegistry.Agents {
+		for _, rule := range preset.PromptRules {
+			if err := rule.Validate(); err != nil {
+				return fmt.Errorf("agent %q: %w", name, err)
+			}
+		}
+	}
+
 	for name, preset := range userRegistry.Agents {
 		preset.Name = AgentPreset(name)
 		globalRegistry.Agents[name] = preset
@@ -391,6 +504,32 @@ func GetProcessNames(agentName string) []string {
 	return info.ProcessNames
 }
 
+// GetModelFlag returns the model flag of the preset a runtime command
+// belongs to. Returns empty string if no preset runs the command or it
+// declares no model flag.
+func GetModelFlag(command string) string {
+	ensureRegistry()
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	base := filepath.Base(command)
+	for _, info := range globalRegistry.Agents {
+		if info.Command == base && info.ModelFlag != "" {
+			return info.ModelFlag
+		}
+	}
+	return ""
+}
+
+// GetPromptRules returns the prompt rules for an agent.
+// Returns nil if the agent is unknown or defines no rules.
+func GetPromptRules(agentName string) []PromptRule {
+	info := GetAgentPresetByName(agentName)
+	if info == nil {
+		return nil
+	}
+	return info.PromptRules
+}
+
 // MergeWithPreset applies preset defaults to a RuntimeConfig.
 // User-specified values take precedence over preset defaults.
 // Returns a new RuntimeConfig without modifying the original.
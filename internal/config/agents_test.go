@@ -171,6 +171,38 @@ func TestLoadAgentRegistry(t *testing.T) {
 	ResetRegistryForTesting()
 }
 
+func TestLoadAgentRegistryRejectsInvalidPromptRule(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "agents.json")
+	data := `{"version":1,"agents":{"my-agent":{"command":"my-agent-bin",` +
+		`"prompt_rules":[{"name":"broken","pattern":"(","action":"escalate"}]}}}`
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ResetRegistryForTesting()
+	t.Cleanup(ResetRegistryForTesting)
+
+	if err := LoadAgentRegistry(configPath); err == nil {
+		t.Fatal("LoadAgentRegistry accepted an invalid prompt rule")
+	}
+	if GetAgentPresetByName("my-agent") != nil {
+		t.Error("agent with invalid prompt rule was registered")
+	}
+}
+
+func TestBuiltinPromptRulesValid(t *testing.T) {
+	t.Parallel()
+	rules := GetPromptRules("claude")
+	if len(rules) == 0 {
+		t.Fatal("claude has no prompt rules")
+	}
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			t.Errorf("builtin rule invalid: %v", err)
+		}
+	}
+}
+
 func TestAgentPresetYOLOFlags(t *testing.T) {
 	t.Parallel()
 	// Verify YOLO flags are set correctly for each E2E tested agent
@@ -382,6 +414,35 @@ func TestGetProcessNames(t *testing.T) {
 	}
 }
 
+func TestWithModel(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		rc     *RuntimeConfig
+		want   string
+		wantOK bool
+	}{
+		{"claude", &RuntimeConfig{Command: "claude", Args: []string{"--dangerously-skip-permissions"}},
+			"claude --dangerously-skip-permissions --model haiku", true},
+		{"replaces model", &RuntimeConfig{Command: "/usr/bin/claude", Args: []string{"--model", "opus", "--verbose"}},
+			"/usr/bin/claude --verbose --model haiku", true},
+		{"replaces model=", &RuntimeConfig{Command: "codex", Args: []string{"--model=o3", "--yolo"}},
+			"codex --yolo --model haiku", true},
+		{"no model flag", &RuntimeConfig{Command: "auggie", Args: []string{"--allow-indexing"}},
+			"auggie --allow-indexing", false},
+		{"unknown command", &RuntimeConfig{Command: "aider", Args: []string{}}, "aider", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc, ok := tt.rc.WithModel("haiku")
+			if got := rc.BuildCommand(); got != tt.want || ok != tt.wantOK {
+				t.Errorf("WithModel = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
 func TestListAgentPresetsMatchesConstants(t *testing.T) {
 	t.Parallel()
 	// Ensure all AgentPreset constants are returned by ListAgentPresets
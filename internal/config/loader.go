@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -93,6 +94,20 @@ func LoadRigsConfig(path string) (*RigsConfig, error) {
 	return &config, nil
 }
 
+// TownRigNames returns the names of the rigs registered in a town, sorted.
+func TownRigNames(townRoot string) ([]string, error) {
+	cfg, err := LoadRigsConfig(constants.MayorRigsPath(townRoot))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(cfg.Rigs))
+	for name := range cfg.Rigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // SaveRigsConfig saves a rigs registry to a file.
 func SaveRigsConfig(path string, config *RigsConfig) error {
 	if err := validateRigsConfig(config); err != nil {
@@ -426,6 +441,76 @@ func validateDaemonPatrolConfig(c *DaemonPatrolConfig) error {
 	if c.Version > CurrentDaemonPatrolConfigVersion {
 		return fmt.Errorf("%w: got %d, max supported %d", ErrInvalidVersion, c.Version, CurrentDaemonPatrolConfigVersion)
 	}
+	if c.Heartbeat != nil && c.Heartbeat.Interval != "" {
+		if err := validateDaemonInterval(c.Heartbeat.Interval); err != nil {
+			return fmt.Errorf("invalid heartbeat interval: %w", err)
+		}
+	}
+	for name, p := range c.Patrols {
+		if p.Interval == "" {
+			continue
+		}
+		if err := validateDaemonInterval(p.Interval); err != nil {
+			return fmt.Errorf("invalid interval for patrol %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// MinDaemonInterval is the shortest heartbeat or patrol interval accepted.
+// Anything shorter would have the daemon hammering tmux and bd.
+const MinDaemonInterval = 10 * time.Second
+
+// validateDaemonInterval checks that s parses as a duration of at least
+// MinDaemonInterval.
+func validateDaemonInterval(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if d < MinDaemonInterval {
+		return fmt.Errorf("%s is below the minimum of %s", d, MinDaemonInterval)
+	}
+	return nil
+}
+
+// ParseRetentionDuration parses a duration that may use a "d" suffix for days.
+func ParseRetentionDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// ValidateLogRetention checks that a log retention config has non-negative
+// limits and parseable durations.
+func ValidateLogRetention(c *LogRetentionConfig) error {
+	if c.MaxSizeMB < 0 {
+		return fmt.Errorf("max_size_mb must not be negative, got %d", c.MaxSizeMB)
+	}
+	if c.MaxSegments < 0 {
+		return fmt.Errorf("max_segments must not be negative, got %d", c.MaxSegments)
+	}
+	for _, f := range []struct{ field, value string }{
+		{"max_age", c.MaxAge},
+		{"retain_for", c.RetainFor},
+	} {
+		field, value := f.field, f.value
+		if value == "" {
+			continue
+		}
+		d, err := ParseRetentionDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("%s must not be negative, got %s", field, value)
+		}
+	}
 	return nil
 }
 
@@ -532,6 +617,37 @@ func (c *AccountsConfig) GetDefaultAccount() *Account {
 	return c.GetAccount(c.Default)
 }
 
+// NextAccount returns the account handle after current in sorted handle order,
+// wrapping around. Used to rotate sessions off an account that hit its usage limit.
+// Returns empty string if fewer than two accounts are configured.
+func (c *AccountsConfig) NextAccount(current string) string {
+	if len(c.Accounts) < 2 {
+		return ""
+	}
+	handles := make([]string, 0, len(c.Accounts))
+	for h := range c.Accounts {
+		handles = append(handles, h)
+	}
+	sort.Strings(handles)
+	for i, h := range handles {
+		if h == current {
+			return handles[(i+1)%len(handles)]
+		}
+	}
+	// Current account unknown - start from the first other than current
+	return handles[0]
+}
+
+// AccountConfigDir returns the expanded CLAUDE_CONFIG_DIR for an account,
+// or empty string if the account is not configured.
+func (c *AccountsConfig) AccountConfigDir(handle string) string {
+	acct := c.GetAccount(handle)
+	if acct == nil {
+		return ""
+	}
+	return expandPath(acct.ConfigDir)
+}
+
 // ResolveAccountConfigDir resolves the CLAUDE_CONFIG_DIR for account selection.
 // Priority order:
 //  1. GT_ACCOUNT environment variable
@@ -764,6 +880,11 @@ func SaveTownSettings(path string, settings *TownSettings) error {
 	if settings.Version > CurrentTownSettingsVersion {
 		return fmt.Errorf("%w: got %d, max supported %d", ErrInvalidVersion, settings.Version, CurrentTownSettingsVersion)
 	}
+	if settings.LogRetention != nil {
+		if err := ValidateLogRetention(settings.LogRetention); err != nil {
+			return fmt.Errorf("invalid log_retention: %w", err)
+		}
+	}
 
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return fmt.Errorf("creating directory: %w", err)
@@ -832,6 +953,23 @@ func ResolveAgentConfig(townRoot, rigPath string) *RuntimeConfig {
 	return lookupAgentConfig(agentName, townSettings, rigSettings)
 }
 
+// ResolveAgentName returns the name of the agent a rig runs, using the same
+// precedence as ResolveAgentConfig (rig agent, then town default_agent, then claude).
+// Custom agent registries are loaded so their presets are visible to callers.
+func ResolveAgentName(townRoot, rigPath string) string {
+	_ = LoadAgentRegistry(DefaultAgentRegistryPath(townRoot))
+	if rigPath != "" {
+		_ = LoadRigAgentRegistry(RigAgentRegistryPath(rigPath))
+		if rigSettings, err := LoadRigSettings(RigSettingsPath(rigPath)); err == nil && rigSettings.Agent != "" {
+			return rigSettings.Agent
+		}
+	}
+	if townSettings, err := LoadOrCreateTownSettings(TownSettingsPath(townRoot)); err == nil && townSettings.DefaultAgent != "" {
+		return townSettings.DefaultAgent
+	}
+	return "claude"
+}
+
 // ResolveAgentConfigWithOverride resolves the agent configuration for a rig, with an optional override.
 // If agentOverride is non-empty, it is used instead of rig/town defaults.
 // Returns the resolved RuntimeConfig, the selected agent name, and an error if the override name
@@ -898,6 +1036,68 @@ func ResolveAgentConfigWithOverride(townRoot, rigPath, agentOverride string) (*R
 	return lookupAgentConfig(agentName, townSettings, rigSettings), agentName, nil
 }
 
+// ResolveTier returns the runtime a molecule step tier maps to in a rig:
+// the rig's tiers entry, with empty fields filled from the town's. rigPath
+// may be empty for town-level agents. Returns nil if the tier is empty or
+// mapped nowhere.
+func ResolveTier(townRoot, rigPath, tier string) *TierConfig {
+	if tier == "" {
+		return nil
+	}
+	var entries []*TierConfig
+	if rigPath != "" {
+		if rigSettings, err := LoadRigSettings(RigSettingsPath(rigPath)); err == nil && rigSettings.Tiers != nil {
+			entries = append(entries, rigSettings.Tiers[tier])
+		}
+	}
+	if townSettings, err := LoadOrCreateTownSettings(TownSettingsPath(townRoot)); err == nil && townSettings.Tiers != nil {
+		entries = append(entries, townSettings.Tiers[tier])
+	}
+
+	var resolved *TierConfig
+	for _, tc := range entries {
+		if tc == nil {
+			continue
+		}
+		if resolved == nil {
+			resolved = &TierConfig{}
+		}
+		if resolved.Agent == "" {
+			resolved.Agent = tc.Agent
+		}
+		if resolved.Model == "" {
+			resolved.Model = tc.Model
+		}
+		if resolved.Account == "" {
+			resolved.Account = tc.Account
+		}
+	}
+	return resolved
+}
+
+// ResolveTierRuntime resolves the agent configuration for a session working
+// a step of the given tier. agentOverride, if set, wins over the tier's
+// agent and model. Returns the tier's mapping too, nil if it has none, in
+// which case the runtime is the rig's usual one.
+func ResolveTierRuntime(townRoot, rigPath, tier, agentOverride string) (*RuntimeConfig, *TierConfig, error) {
+	tc := ResolveTier(townRoot, rigPath, tier)
+	agent := agentOverride
+	if agent == "" && tc != nil {
+		agent = tc.Agent
+	}
+	rc, _, err := ResolveAgentConfigWithOverride(townRoot, rigPath, agent)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tier %s: %w", tier, err)
+	}
+	if agentOverride == "" && tc != nil && tc.Model != "" {
+		var ok bool
+		if rc, ok = rc.WithModel(tc.Model); !ok {
+			return nil, nil, fmt.Errorf("tier %s: %s can't select model %s", tier, rc.Command, tc.Model)
+		}
+	}
+	return rc, tc, nil
+}
+
 // lookupAgentConfig looks up an agent by name.
 // Checks rig-level custom agents first, then town's custom agents, then built-in presets from agents.go.
 func lookupAgentConfig(name string, townSettings *TownSettings, rigSettings *RigSettings) *RuntimeConfig {
@@ -1204,6 +1404,23 @@ func BuildPolecatStartupCommandWithAgentOverride(rigName, polecatName, rigPath,
 	return BuildStartupCommandWithAgentOverride(AgentEnvSimple("polecat", rigName, polecatName), rigPath, prompt, agentOverride)
 }
 
+// BuildPolecatStartupCommandForTier is like BuildPolecatStartupCommandWithAgentOverride,
+// but runs the agent and model a molecule step tier maps to, and sets GT_TIER.
+func BuildPolecatStartupCommandForTier(rigName, polecatName, rigPath, prompt, agentOverride, tier string) (string, error) {
+	rc, _, err := ResolveTierRuntime(filepath.Dir(rigPath), rigPath, tier, agentOverride)
+	if err != nil {
+		return "", err
+	}
+	env := AgentEnvSimple("polecat", rigName, polecatName)
+	if tier != "" {
+		env[EnvTier] = tier
+	}
+	if prompt != "" {
+		return PrependEnv(rc.BuildCommandWithPrompt(prompt), env), nil
+	}
+	return PrependEnv(rc.BuildCommand(), env), nil
+}
+
 // BuildCrewStartupCommand builds the startup command for a crew member.
 // Sets GT_ROLE, GT_RIG, GT_CREW, BD_ACTOR, and GIT_AUTHOR_NAME.
 func BuildCrewStartupCommand(rigName, crewName, rigPath, prompt string) string {
@@ -533,6 +533,30 @@ func TestAccountsConfigValidation(t *testing.T) {
 	}
 }
 
+func TestAccountsConfigNextAccount(t *testing.T) {
+	t.Parallel()
+	cfg := NewAccountsConfig()
+	if got := cfg.NextAccount("work"); got != "" {
+		t.Errorf("no accounts: NextAccount = %q, want empty", got)
+	}
+
+	cfg.Accounts["work"] = Account{ConfigDir: "/a"}
+	cfg.Accounts["personal"] = Account{ConfigDir: "/b"}
+	cfg.Accounts["spare"] = Account{ConfigDir: "/c"}
+
+	tests := map[string]string{
+		"personal": "spare",
+		"spare":    "work",
+		"work":     "personal",
+		"":         "personal",
+	}
+	for current, want := range tests {
+		if got := cfg.NextAccount(current); got != want {
+			t.Errorf("NextAccount(%q) = %q, want %q", current, got, want)
+		}
+	}
+}
+
 func TestLoadAccountsConfigNotFound(t *testing.T) {
 	t.Parallel()
 	_, err := LoadAccountsConfig("/nonexistent/path.json")
@@ -1083,6 +1107,68 @@ func TestBuildPolecatStartupCommandWithAgentOverride(t *testing.T) {
 	}
 }
 
+func TestResolveTierRuntime(t *testing.T) {
+	t.Parallel()
+	townRoot := t.TempDir()
+	rigPath := filepath.Join(townRoot, "testrig")
+
+	townSettings := NewTownSettings()
+	townSettings.Tiers = map[string]*TierConfig{
+		TierHaiku: {Model: "claude-haiku-4-5", Account: "batch"},
+		TierOpus:  {Agent: "gemini"},
+		"batch":   {Agent: "auggie", Model: "fast"},
+	}
+	if err := SaveTownSettings(TownSettingsPath(townRoot), townSettings); err != nil {
+		t.Fatalf("SaveTownSettings: %v", err)
+	}
+	rigSettings := NewRigSettings()
+	rigSettings.Tiers = map[string]*TierConfig{
+		TierHaiku: {Model: "haiku-rig"},
+	}
+	if err := SaveRigSettings(RigSettingsPath(rigPath), rigSettings); err != nil {
+		t.Fatalf("SaveRigSettings: %v", err)
+	}
+
+	// The rig's model wins; the account comes from the town
+	tc := ResolveTier(townRoot, rigPath, TierHaiku)
+	if tc == nil || tc.Model != "haiku-rig" || tc.Account != "batch" {
+		t.Fatalf("ResolveTier(haiku) = %+v", tc)
+	}
+	if tc := ResolveTier(townRoot, rigPath, TierSonnet); tc != nil {
+		t.Errorf("ResolveTier(sonnet) = %+v, want nil", tc)
+	}
+
+	rc, _, err := ResolveTierRuntime(townRoot, rigPath, TierHaiku, "")
+	if err != nil {
+		t.Fatalf("ResolveTierRuntime: %v", err)
+	}
+	if got := rc.BuildCommand(); got != "claude --dangerously-skip-permissions --model haiku-rig" {
+		t.Errorf("haiku command = %q", got)
+	}
+	rc, _, err = ResolveTierRuntime(townRoot, rigPath, TierOpus, "")
+	if err != nil || rc.Command != "gemini" {
+		t.Errorf("opus runtime = %+v, %v", rc, err)
+	}
+	if _, _, err := ResolveTierRuntime(townRoot, rigPath, "batch", ""); err == nil || !strings.Contains(err.Error(), "can't select model") {
+		t.Errorf("model for an agent without a model flag: err = %v", err)
+	}
+	// An explicit agent wins over the tier's agent and model
+	rc, _, err = ResolveTierRuntime(townRoot, rigPath, TierHaiku, "codex")
+	if err != nil || rc.Command != "codex" || strings.Contains(rc.BuildCommand(), "--model") {
+		t.Errorf("override runtime = %+v, %v", rc, err)
+	}
+
+	cmd, err := BuildPolecatStartupCommandForTier("testrig", "toast", rigPath, "", "", TierHaiku)
+	if err != nil {
+		t.Fatalf("BuildPolecatStartupCommandForTier: %v", err)
+	}
+	for _, want := range []string{"GT_POLECAT=toast", EnvTier + "=haiku", "--model haiku-rig"} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("command %q missing %q", cmd, want)
+		}
+	}
+}
+
 func TestBuildAgentStartupCommandWithAgentOverride(t *testing.T) {
 	townRoot := t.TempDir()
 
@@ -1282,7 +1368,6 @@ func TestDaemonPatrolConfigRoundTrip(t *testing.T) {
 
 	original := NewDaemonPatrolConfig()
 	original.Patrols["custom"] = PatrolConfig{
-		Enabled:  true,
 		Interval: "10m",
 		Agent:    "custom-agent",
 	}
@@ -1349,6 +1434,37 @@ func TestDaemonPatrolConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unparseable heartbeat interval",
+			config: &DaemonPatrolConfig{
+				Type:      "daemon-patrol-config",
+				Version:   1,
+				Heartbeat: &HeartbeatConfig{Enabled: true, Interval: "soon"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-positive patrol interval",
+			config: &DaemonPatrolConfig{
+				Type:    "daemon-patrol-config",
+				Version: 1,
+				Patrols: map[string]PatrolConfig{
+					"witness": {Interval: "0s"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "patrol interval below the daemon minimum",
+			config: &DaemonPatrolConfig{
+				Type:    "daemon-patrol-config",
+				Version: 1,
+				Patrols: map[string]PatrolConfig{
+					"witness": {Interval: "5s"},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1425,7 +1541,7 @@ func TestEnsureDaemonPatrolConfig(t *testing.T) {
 			Type:    "daemon-patrol-config",
 			Version: 1,
 			Patrols: map[string]PatrolConfig{
-				"custom-only": {Enabled: true, Agent: "custom"},
+				"custom-only": {Agent: "custom"},
 			},
 		}
 		if err := SaveDaemonPatrolConfig(path, existing); err != nil {
@@ -1480,9 +1596,12 @@ func TestNewDaemonPatrolConfig(t *testing.T) {
 			t.Errorf("missing %s patrol", name)
 			continue
 		}
-		if !patrol.Enabled {
+		if !patrol.IsEnabled() {
 			t.Errorf("%s patrol should be enabled by default", name)
 		}
+		if patrol.Interval != cfg.Heartbeat.Interval {
+			t.Errorf("%s patrol Interval = %q, want the heartbeat's %q", name, patrol.Interval, cfg.Heartbeat.Interval)
+		}
 		if patrol.Agent != name {
 			t.Errorf("%s patrol Agent = %q, want %q", name, patrol.Agent, name)
 		}
@@ -1750,3 +1869,45 @@ func TestLookupAgentConfigWithRigSettings(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRetentionDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"24h", 24 * time.Hour, false},
+		{"30d", 30 * 24 * time.Hour, false},
+		{"0", 0, false},
+		{"xd", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRetentionDuration(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRetentionDuration(%q) = %s, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestSaveTownSettings_ValidatesLogRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings", "config.json")
+	settings := NewTownSettings()
+	settings.LogRetention = &LogRetentionConfig{RetainFor: "-1d"}
+	if err := SaveTownSettings(path, settings); err == nil {
+		t.Error("SaveTownSettings accepted a negative retain_for")
+	}
+
+	settings.LogRetention = &LogRetentionConfig{MaxSizeMB: 16, RetainFor: "7d"}
+	if err := SaveTownSettings(path, settings); err != nil {
+		t.Fatalf("SaveTownSettings: %v", err)
+	}
+	loaded, err := LoadOrCreateTownSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := loaded.LogRetention.WithDefaults()
+	if r.MaxSizeMB != 16 || r.RetainFor != "7d" || r.MaxAge != DefaultLogMaxAge {
+		t.Errorf("loaded retention = %+v", r)
+	}
+}
@@ -49,6 +49,53 @@ type TownSettings struct {
 	// Values override or extend the built-in presets.
 	// Example: {"gemini": {"command": "/custom/path/to/gemini"}}
 	Agents map[string]*RuntimeConfig `json:"agents,omitempty"`
+
+	// Tiers maps molecule step tiers (haiku, sonnet, opus) to the runtime
+	// that works steps of that tier, so cheap mechanical steps can run on
+	// cheaper models. Rig settings override these per field.
+	// Example: {"haiku": {"model": "claude-haiku-4-5", "account": "batch"}}
+	Tiers map[string]*TierConfig `json:"tiers,omitempty"`
+
+	// LogRetention controls rotation of the town's append-only logs
+	// (.events.jsonl, .feed.jsonl, logs/town.log). Nil uses the defaults.
+	LogRetention *LogRetentionConfig `json:"log_retention,omitempty"`
+}
+
+// Log retention defaults.
+const (
+	DefaultLogMaxSizeMB = 64
+	DefaultLogMaxAge    = "24h"
+	DefaultLogRetainFor = "30d"
+)
+
+// LogRetentionConfig controls when the daemon rotates town logs into
+// compressed segments and how long segments are kept.
+// Durations accept Go syntax plus a "d" suffix for days ("30d").
+// Empty or zero fields use the defaults; a duration of "0" disables that limit.
+type LogRetentionConfig struct {
+	MaxSizeMB   int    `json:"max_size_mb,omitempty"`  // Rotate at this size (default 64)
+	MaxAge      string `json:"max_age,omitempty"`      // Rotate once the oldest line is this old (default "24h")
+	RetainFor   string `json:"retain_for,omitempty"`   // Delete segments older than this (default "30d")
+	MaxSegments int    `json:"max_segments,omitempty"` // Keep at most this many segments per log (default unlimited)
+}
+
+// WithDefaults returns a copy of c with empty fields set to the defaults.
+// Safe to call on a nil config.
+func (c *LogRetentionConfig) WithDefaults() LogRetentionConfig {
+	var r LogRetentionConfig
+	if c != nil {
+		r = *c
+	}
+	if r.MaxSizeMB == 0 {
+		r.MaxSizeMB = DefaultLogMaxSizeMB
+	}
+	if r.MaxAge == "" {
+		r.MaxAge = DefaultLogMaxAge
+	}
+	if r.RetainFor == "" {
+		r.RetainFor = DefaultLogRetainFor
+	}
+	return r
 }
 
 // NewTownSettings creates a new TownSettings with defaults.
@@ -84,13 +131,28 @@ type HeartbeatConfig struct {
 
 // PatrolConfig represents a single patrol configuration.
 type PatrolConfig struct {
-	Enabled  bool   `json:"enabled"`            // whether this patrol is enabled
+	Enabled  *bool  `json:"enabled,omitempty"`  // whether this patrol is enabled (default true)
 	Interval string `json:"interval,omitempty"` // e.g., "5m"
 	Agent    string `json:"agent,omitempty"`    // agent that runs this patrol
 }
 
+// IsEnabled reports whether the patrol is enabled; unset means enabled.
+func (p PatrolConfig) IsEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
 // CurrentDaemonPatrolConfigVersion is the current schema version for DaemonPatrolConfig.
-const CurrentDaemonPatrolConfigVersion = 1
+// Version 1 files were written before the daemon applied patrol intervals;
+// their intervals are ignored (see PatrolIntervalsApplied).
+const CurrentDaemonPatrolConfigVersion = 2
+
+// PatrolIntervalsApplied reports whether the daemon applies the patrol
+// intervals of the config. Version 1 files carry the 5m defaults gt start
+// used to write, which the daemon never ran on, so they keep following the
+// heartbeat until the file is moved to version 2.
+func (c *DaemonPatrolConfig) PatrolIntervalsApplied() bool {
+	return c.Version >= 2
+}
 
 // DaemonPatrolConfigFileName is the filename for daemon patrol configuration.
 const DaemonPatrolConfigFileName = "daemon.json"
@@ -106,18 +168,15 @@ func NewDaemonPatrolConfig() *DaemonPatrolConfig {
 		},
 		Patrols: map[string]PatrolConfig{
 			"deacon": {
-				Enabled:  true,
-				Interval: "5m",
+				Interval: "3m",
 				Agent:    "deacon",
 			},
 			"witness": {
-				Enabled:  true,
-				Interval: "5m",
+				Interval: "3m",
 				Agent:    "witness",
 			},
 			"refinery": {
-				Enabled:  true,
-				Interval: "5m",
+				Interval: "3m",
 				Agent:    "refinery",
 			},
 		},
@@ -209,6 +268,28 @@ type RigSettings struct {
 	// Similar to TownSettings.Agents but applies to this rig only.
 	// Allows per-rig custom agents for polecats and crew members.
 	Agents map[string]*RuntimeConfig `json:"agents,omitempty"`
+
+	// Tiers overrides the town's step tier mapping for this rig.
+	Tiers map[string]*TierConfig `json:"tiers,omitempty"`
+}
+
+// Molecule step tiers, from a step's "Tier:" line.
+const (
+	TierHaiku  = "haiku"
+	TierSonnet = "sonnet"
+	TierOpus   = "opus"
+)
+
+// EnvTier names the step tier a session was started for, so its cost can
+// be reported per tier.
+const EnvTier = "GT_TIER"
+
+// TierConfig selects the runtime for molecule steps of a tier. Empty
+// fields keep what the session would otherwise use.
+type TierConfig struct {
+	Agent   string `json:"agent,omitempty"`   // Agent preset or custom agent name
+	Model   string `json:"model,omitempty"`   // Passed to the agent as --model
+	Account string `json:"account,omitempty"` // Account handle (see mayor/accounts.json)
 }
 
 // CrewConfig represents crew workspace settings for a rig.
@@ -347,6 +428,33 @@ func (rc *RuntimeConfig) BuildCommandWithPrompt(prompt string) string {
 	return base + " " + quoteForShell(p)
 }
 
+// WithModel returns a copy of the config that runs the given model,
+// replacing any model already in its args. The second result is false,
+// and the config unchanged, if the agent's preset declares no model flag.
+func (rc *RuntimeConfig) WithModel(model string) (*RuntimeConfig, bool) {
+	var c RuntimeConfig
+	if rc != nil {
+		c = *rc
+	}
+	resolved := normalizeRuntimeConfig(&c)
+	flag := GetModelFlag(resolved.Command)
+	if flag == "" {
+		return resolved, false
+	}
+	args := make([]string, 0, len(resolved.Args)+2)
+	for i := 0; i < len(resolved.Args); i++ {
+		switch arg := resolved.Args[i]; {
+		case arg == flag:
+			i++ // Skip its value too
+		case strings.HasPrefix(arg, flag+"="):
+		default:
+			args = append(args, arg)
+		}
+	}
+	resolved.Args = append(args, flag, model)
+	return resolved, true
+}
+
 // BuildArgsWithPrompt returns the runtime command and args suitable for exec.
 func (rc *RuntimeConfig) BuildArgsWithPrompt(prompt string) []string {
 	resolved := normalizeRuntimeConfig(rc)
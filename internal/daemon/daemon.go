@@ -3,6 +3,7 @@ package daemon
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -24,9 +25,11 @@ import (
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/feed"
 	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/prompts"
 	"github.com/steveyegge/gastown/internal/refinery"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/shutdown"
 	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/wisp"
 	"github.com/steveyegge/gastown/internal/witness"
@@ -38,15 +41,52 @@ import (
 // The daemon is the safety net for dead sessions, GUPP violations, and orphaned work.
 type Daemon struct {
 	config  *Config
-	tmux    *tmux.Tmux
+	tmux    Sessions
 	logger  *log.Logger
 	ctx     context.Context
 	cancel  context.CancelFunc
 	curator *feed.Curator
+	dogs    *shutdown.Pool
+	prompts *prompts.Answerer
+
+	// Live schedule, reloaded when mayor/config.json or mayor/daemon.json change.
+	// Only touched from the Run goroutine.
+	runtime *RuntimeConfig
+	watcher *configWatcher
+	lastRun map[string]time.Time
 
 	// Mass death detection: track recent session deaths
 	deathsMu     sync.Mutex
 	recentDeaths []sessionDeath
+	deadSince    map[string]time.Time // Dead sessions already recorded, by when first seen dead
+
+	// Parked waits and the feed subscriptions that wake them.
+	// Only touched from the Run goroutine; feeds report on waitSignals.
+	waits       *ParkedWaits
+	waitFeeds   map[string]*waitFeed
+	waitSignals chan waitSignal
+	ownWrites   map[string]time.Time // Beads the daemon updated, by when; their feed activity wakes nothing
+}
+
+// Sessions is the subset of tmux operations the daemon uses.
+// *tmux.Tmux satisfies it; tests substitute a fake.
+type Sessions interface {
+	IsAvailable() bool
+	HasSession(name string) (bool, error)
+	EnsureSessionFresh(name, workDir string) error
+	KillSession(name string) error
+	IsClaudeRunning(session string) bool
+	WaitForCommand(session string, excludeCommands []string, timeout time.Duration) error
+	AcceptBypassPermissionsWarning(session string) error
+	ConfigureGasTownSession(session string, theme tmux.Theme, rig, worker, role string) error
+	SetPaneDiedHook(session, agentID string) error
+	GetEnvironment(session, key string) (string, error)
+	GetAllEnvironment(session string) (map[string]string, error)
+	SetEnvironment(session, key, value string) error
+	CapturePane(session string, lines int) (string, error)
+	SendKeys(session, keys string) error
+	SendKeysRaw(session, keys string) error
+	NudgeSession(session, message string) error
 }
 
 // sessionDeath records a detected session death for mass death analysis.
@@ -78,13 +118,32 @@ func New(config *Config) (*Daemon, error) {
 	logger := log.New(logFile, "", log.LstdFlags)
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Daemon{
-		config: config,
-		tmux:   tmux.NewTmux(),
-		logger: logger,
-		ctx:    ctx,
-		cancel: cancel,
-	}, nil
+	// Start from a valid schedule even if the config on disk is broken;
+	// the operator can fix it and the watcher will pick it up.
+	runtime, err := LoadRuntimeConfig(config.TownRoot)
+	if err != nil {
+		logger.Printf("Warning: invalid daemon config, using defaults: %v", err)
+		runtime = DefaultRuntimeConfig()
+	}
+
+	d := &Daemon{
+		config:  config,
+		tmux:    tmux.NewTmux(),
+		logger:  logger,
+		ctx:     ctx,
+		cancel:  cancel,
+		runtime: runtime,
+		watcher: newConfigWatcher(configPaths(config.TownRoot)...),
+		lastRun: make(map[string]time.Time),
+
+		waits:       &ParkedWaits{Waits: make(map[string]*ParkedWait)},
+		waitFeeds:   make(map[string]*waitFeed),
+		waitSignals: make(chan waitSignal, waitSignalBuffer),
+	}
+	d.prompts = prompts.NewAnswerer(d.tmux, "daemon")
+	d.prompts.Escalate = d.escalatePrompt
+	d.prompts.Restart = d.restartForPrompt
+	return d, nil
 }
 
 // Run starts the daemon main loop.
@@ -126,25 +185,36 @@ func (d *Daemon) Run() error {
 
 	// Handle signals
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
-
-	// Fixed recovery-focused heartbeat (no activity-based backoff)
-	// Normal wake is handled by feed subscription (bd activity --follow)
-	timer := time.NewTimer(recoveryHeartbeatInterval)
-	defer timer.Stop()
-
-	d.logger.Printf("Daemon running, recovery heartbeat interval %v", recoveryHeartbeatInterval)
-
-	// Start feed curator goroutine
-	d.curator = feed.NewCurator(d.config.TownRoot)
-	if err := d.curator.Start(); err != nil {
-		d.logger.Printf("Warning: failed to start feed curator: %v", err)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1, syscall.SIGHUP)
+
+	// Recovery-focused schedule (no activity-based backoff).
+	// Normal wake is handled by feed subscription (bd activity --follow).
+	d.logger.Printf("Daemon running, recovery heartbeat interval %v", d.runtime.HeartbeatInterval)
+
+	// Start feed curator goroutine (unless disabled by config)
+	d.applyCuratorConfig()
+
+	// Start the dog pool: shutdown dances run as goroutines, resuming any
+	// dances left in flight by a previous daemon.
+	d.dogs = shutdown.NewPool(shutdown.NewRegistry(d.config.TownRoot), d.tmux, shutdown.PoolSizeFromEnv())
+	d.dogs.Logf = d.logger.Printf
+	if err := d.dogs.Start(); err != nil {
+		d.logger.Printf("Warning: failed to start dog pool: %v", err)
+		d.dogs = nil
 	} else {
-		d.logger.Println("Feed curator started")
+		d.logger.Printf("Dog pool started (%d dogs)", d.dogs.Size())
 	}
 
-	// Initial heartbeat
-	d.heartbeat(state)
+	// Config files are polled so patrol changes apply without a restart,
+	// preserving in-memory state such as session-death tracking.
+	configTicker := time.NewTicker(configPollInterval)
+	defer configTicker.Stop()
+
+	// Initial heartbeat: everything is due on the first tick
+	d.tick(state)
+
+	timer := time.NewTimer(d.nextWake(time.Now()))
+	defer timer.Stop()
 
 	for {
 		select {
@@ -157,36 +227,80 @@ func (d *Daemon) Run() error {
 				// SIGUSR1: immediate lifecycle processing (from gt handoff)
 				d.logger.Println("Received SIGUSR1, processing lifecycle requests immediately")
 				d.processLifecycleRequests()
+			} else if sig == syscall.SIGHUP {
+				// SIGHUP: reload config now instead of waiting for the poll
+				d.logger.Println("Received SIGHUP, reloading config")
+				_ = d.watcher.Changed() // resync stamps so the poll doesn't reload twice
+				if d.reloadConfig() {
+					timer.Reset(d.nextWake(time.Now()))
+				}
 			} else {
 				d.logger.Printf("Received signal %v, shutting down", sig)
 				return d.shutdown(state)
 			}
 
-		case <-timer.C:
-			d.heartbeat(state)
+		case <-configTicker.C:
+			if d.watcher.Changed() {
+				d.reloadConfig()
+			}
+			// Agents park waits from their own processes, so pick those up too.
+			// Reschedule against the new intervals and wait deadlines.
+			d.syncWaits()
+			timer.Reset(d.nextWake(time.Now()))
+
+		case sig := <-d.waitSignals:
+			d.wakeSignaledWaits(sig)
+			timer.Reset(d.nextWake(time.Now()))
 
-			// Fixed recovery interval (no activity-based backoff)
-			timer.Reset(recoveryHeartbeatInterval)
+		case <-timer.C:
+			d.tick(state)
+			timer.Reset(d.nextWake(time.Now()))
 		}
 	}
 }
 
-// recoveryHeartbeatInterval is the fixed interval for recovery-focused daemon.
+// recoveryHeartbeatInterval is the default interval for recovery-focused daemon.
 // Normal wake is handled by feed subscription (bd activity --follow).
 // The daemon is a safety net for dead sessions, GUPP violations, and orphaned work.
 // 3 minutes is fast enough to detect stuck agents promptly while avoiding excessive overhead.
+// It can be overridden in mayor/config.json or mayor/daemon.json (see RuntimeConfig).
 const recoveryHeartbeatInterval = 3 * time.Minute
 
-// heartbeat performs one heartbeat cycle.
-// The daemon is recovery-focused: it ensures agents are running and detects failures.
-// Normal wake is handled by feed subscription (bd activity --follow).
-// The daemon is the safety net for edge cases:
-// - Dead sessions that need restart
-// - Agents with work-on-hook not progressing (GUPP violation)
-// - Orphaned work (assigned to dead agents)
-func (d *Daemon) heartbeat(state *State) {
-	d.logger.Println("Heartbeat starting (recovery-focused)")
+// tick wakes parked waits whose backoff has run out, then runs every patrol
+// and heartbeat whose interval has elapsed. Patrols run in the same order
+// the heartbeat always used.
+func (d *Daemon) tick(state *State) {
+	now := time.Now()
+
+	// Wake parked waits first, so patrols see their sessions restarted
+	d.syncWaits()
+	d.wakeDueWaits(now)
+
+	if d.patrolDue(PatrolDeacon, now) {
+		d.lastRun[PatrolDeacon] = now
+		d.deaconPatrol()
+	}
 
+	if d.patrolDue(PatrolWitness, now) {
+		d.lastRun[PatrolWitness] = now
+		// Ensure Witnesses are running for all rigs (restart if dead)
+		d.ensureWitnessesRunning()
+	}
+
+	if d.patrolDue(PatrolRefinery, now) {
+		d.lastRun[PatrolRefinery] = now
+		// Ensure Refineries are running for all rigs (restart if dead)
+		d.ensureRefineriesRunning()
+	}
+
+	if d.heartbeatDue(now) {
+		d.lastRun[scheduleHeartbeat] = now
+		d.heartbeat(state)
+	}
+}
+
+// deaconPatrol keeps the Deacon alive and responsive.
+func (d *Daemon) deaconPatrol() {
 	// 1. Ensure Deacon is running (restart if dead)
 	d.ensureDeaconRunning()
 
@@ -197,12 +311,19 @@ func (d *Daemon) heartbeat(state *State) {
 	// 3. Direct Deacon heartbeat check (belt-and-suspenders)
 	// Boot may not detect all stuck states; this provides a fallback
 	d.checkDeaconHeartbeat()
+}
 
-	// 4. Ensure Witnesses are running for all rigs (restart if dead)
-	d.ensureWitnessesRunning()
+// heartbeat performs one heartbeat cycle.
+// The daemon is recovery-focused: it ensures agents are running and detects failures.
+// Normal wake is handled by feed subscription (bd activity --follow).
+// The daemon is the safety net for edge cases:
+// - Dead sessions that need restart
+// - Agents with work-on-hook not progressing (GUPP violation)
+// - Orphaned work (assigned to dead agents)
+func (d *Daemon) heartbeat(state *State) {
+	d.logger.Println("Heartbeat starting (recovery-focused)")
 
-	// 5. Ensure Refineries are running for all rigs (restart if dead)
-	d.ensureRefineriesRunning()
+	// 1-5. Deacon, Witness and Refinery patrols run on their own schedules (see tick)
 
 	// 6. Trigger pending polecat spawns (bootstrap mode - ZFC violation acceptable)
 	// This ensures polecats get nudged even when Deacon isn't in a patrol cycle.
@@ -224,6 +345,13 @@ func (d *Daemon) heartbeat(state *State) {
 	// This validates tmux sessions are still alive for polecats with work-on-hook
 	d.checkPolecatSessionHealth()
 
+	// 12. Answer interactive prompts blocking live sessions
+	// (trust dialogs, rate limits, expired logins) per agent preset rules
+	d.answerPrompts()
+
+	// 13. Rotate and prune append-only logs (.events.jsonl, .feed.jsonl, town.log)
+	d.rotateLogs()
+
 	// Update state
 	state.LastHeartbeat = time.Now()
 	state.HeartbeatCount++
@@ -311,6 +439,13 @@ func (d *Daemon) runDegradedBootTriage(b *boot.Boot) {
 // ensureDeaconRunning ensures the Deacon is running.
 // Uses deacon.Manager for consistent startup behavior (WaitForShellReady, GUPP, etc.).
 func (d *Daemon) ensureDeaconRunning() {
+	if d.isParked(d.getDeaconSessionName()) {
+		return // Exited on a parked wait; woken by wakeParked
+	}
+	if !d.guardPatrolStart("deacon", d.getDeaconSessionName()) {
+		return
+	}
+
 	mgr := deacon.NewManager(d.config.TownRoot)
 
 	if err := mgr.Start(""); err != nil {
@@ -322,6 +457,7 @@ func (d *Daemon) ensureDeaconRunning() {
 		return
 	}
 
+	d.clearSessionDeath(d.getDeaconSessionName())
 	d.logger.Println("Deacon started successfully")
 }
 
@@ -394,6 +530,12 @@ func (d *Daemon) ensureWitnessRunning(rigName string) {
 		d.logger.Printf("Skipping witness auto-start for %s: %s", rigName, reason)
 		return
 	}
+	if d.isParked(session.WitnessSessionName(rigName)) {
+		return // Exited on a parked wait; woken by wakeParked
+	}
+	if !d.guardPatrolStart(rigName+"/witness", session.WitnessSessionName(rigName)) {
+		return
+	}
 
 	// Manager.Start() handles: zombie detection, session creation, env vars, theming,
 	// startup readiness waits, and crucially - startup/propulsion nudges (GUPP).
@@ -413,6 +555,7 @@ func (d *Daemon) ensureWitnessRunning(rigName string) {
 		return
 	}
 
+	d.clearSessionDeath(session.WitnessSessionName(rigName))
 	d.logger.Printf("Witness session for %s started successfully", rigName)
 }
 
@@ -433,6 +576,12 @@ func (d *Daemon) ensureRefineryRunning(rigName string) {
 		d.logger.Printf("Skipping refinery auto-start for %s: %s", rigName, reason)
 		return
 	}
+	if d.isParked(session.RefinerySessionName(rigName)) {
+		return // Exited on a parked wait; woken by wakeParked
+	}
+	if !d.guardPatrolStart(rigName+"/refinery", session.RefinerySessionName(rigName)) {
+		return
+	}
 
 	// Manager.Start() handles: zombie detection, session creation, env vars, theming,
 	// WaitForClaudeReady, and crucially - startup/propulsion nudges (GUPP).
@@ -452,9 +601,30 @@ func (d *Daemon) ensureRefineryRunning(rigName string) {
 		return
 	}
 
+	d.clearSessionDeath(session.RefinerySessionName(rigName))
 	d.logger.Printf("Refinery session for %s started successfully", rigName)
 }
 
+// guardPatrolStart applies crash-loop protection before a patrol starts an
+// agent whose session is gone. Returns false while the restart backs off or
+// the agent is quarantined. A running session passes through: Start()
+// discovers it and does nothing. A session still gone from a death already
+// counted (e.g., its start failed) isn't counted again until it has run.
+func (d *Daemon) guardPatrolStart(identity, sessionName string) bool {
+	alive, err := d.tmux.HasSession(sessionName)
+	if err != nil {
+		return true
+	}
+	if alive {
+		d.clearSessionDeath(sessionName)
+		return true
+	}
+	if !d.markSessionDead(sessionName) {
+		return !d.restartHeld(identity)
+	}
+	return d.guardRestart(identity, sessionName) == nil
+}
+
 // getKnownRigs returns list of registered rig names.
 func (d *Daemon) getKnownRigs() []string {
 	rigsPath := filepath.Join(d.config.TownRoot, "mayor", "rigs.json")
@@ -579,6 +749,17 @@ func (d *Daemon) shutdown(state *State) error { //nolint:unparam // error return
 		d.logger.Println("Feed curator stopped")
 	}
 
+	// Stop dog pool; in-flight dances stay on disk and resume on next start
+	if d.dogs != nil {
+		d.dogs.Stop()
+		d.logger.Println("Dog pool stopped")
+	}
+
+	// Stop feed subscriptions; parked waits stay on disk for the next start
+	for _, feed := range d.waitFeeds {
+		feed.cancel()
+	}
+
 	state.Running = false
 	if err := SaveState(d.config.TownRoot, state); err != nil {
 		d.logger.Printf("Warning: failed to save final state: %v", err)
@@ -667,6 +848,29 @@ func StopDaemon(townRoot string) error {
 	return nil
 }
 
+// ReloadDaemon asks the running daemon to re-read its config immediately.
+// The daemon also picks up config changes on its own within a few seconds;
+// this just skips the wait.
+func ReloadDaemon(townRoot string) error {
+	running, pid, err := IsRunning(townRoot)
+	if err != nil {
+		return err
+	}
+	if !running {
+		return fmt.Errorf("daemon is not running")
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("finding process: %w", err)
+	}
+
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		return fmt.Errorf("sending SIGHUP: %w", err)
+	}
+	return nil
+}
+
 // checkPolecatSessionHealth proactively validates polecat tmux sessions.
 // This detects crashed polecats that:
 // 1. Have work-on-hook (assigned work)
@@ -732,6 +936,11 @@ func (d *Daemon) checkPolecatHealth(rigName, polecatName string) {
 
 	if sessionAlive {
 		// Session is alive - nothing to do
+		d.clearSessionDeath(sessionName)
+		return
+	}
+	if d.isParked(sessionName) {
+		// Exited on a parked wait, not a crash - woken by wakeParked
 		return
 	}
 
@@ -759,22 +968,37 @@ func (d *Daemon) checkPolecatHealth(rigName, polecatName string) {
 	// Track this death for mass death detection
 	d.recordSessionDeath(sessionName)
 
+	// Crash-loop protection: back off between restarts, quarantine repeat offenders
+	identity := fmt.Sprintf("%s/polecats/%s", rigName, polecatName)
+	if err := d.guardRestart(identity, sessionName); err != nil {
+		if errors.Is(err, ErrCrashLoop) {
+			d.notifyWitnessOfCrashedPolecat(rigName, polecatName, info.HookBead, err)
+		}
+		return
+	}
+
 	// Auto-restart the polecat
-	if err := d.restartPolecatSession(rigName, polecatName, sessionName); err != nil {
+	if err := d.restartPolecatSession(rigName, polecatName, sessionName, ""); err != nil {
 		d.logger.Printf("Error restarting polecat %s/%s: %v", rigName, polecatName, err)
 		// Notify witness as fallback
 		d.notifyWitnessOfCrashedPolecat(rigName, polecatName, info.HookBead, err)
 	} else {
 		d.logger.Printf("Successfully restarted crashed polecat %s/%s", rigName, polecatName)
+		d.clearSessionDeath(sessionName)
 	}
 }
 
 // recordSessionDeath records a session death and checks for mass death pattern.
+// A session still dead from an already recorded death (e.g., while its restart
+// backs off) is not counted again until clearSessionDeath sees it restarted.
 func (d *Daemon) recordSessionDeath(sessionName string) {
 	d.deathsMu.Lock()
 	defer d.deathsMu.Unlock()
 
-	now := time.Now()
+	if !d.markDeadLocked(sessionName) {
+		return
+	}
+	now := d.deadSince[sessionName]
 
 	// Add this death
 	d.recentDeaths = append(d.recentDeaths, sessionDeath{
@@ -798,6 +1022,34 @@ func (d *Daemon) recordSessionDeath(sessionName string) {
 	}
 }
 
+// markSessionDead notes that a session is dead, returning false if this
+// death was already noted. Unlike recordSessionDeath, it doesn't count
+// toward mass death detection.
+func (d *Daemon) markSessionDead(sessionName string) bool {
+	d.deathsMu.Lock()
+	defer d.deathsMu.Unlock()
+	return d.markDeadLocked(sessionName)
+}
+
+func (d *Daemon) markDeadLocked(sessionName string) bool {
+	if _, recorded := d.deadSince[sessionName]; recorded {
+		return false
+	}
+	if d.deadSince == nil {
+		d.deadSince = make(map[string]time.Time)
+	}
+	d.deadSince[sessionName] = time.Now()
+	return true
+}
+
+// clearSessionDeath forgets the recorded death of a session that is running
+// again, so its next death counts.
+func (d *Daemon) clearSessionDeath(sessionName string) {
+	d.deathsMu.Lock()
+	defer d.deathsMu.Unlock()
+	delete(d.deadSince, sessionName)
+}
+
 // emitMassDeathEvent logs a mass death event when multiple sessions die in a short window.
 func (d *Daemon) emitMassDeathEvent() {
 	// Collect session names
@@ -820,7 +1072,8 @@ func (d *Daemon) emitMassDeathEvent() {
 }
 
 // restartPolecatSession restarts a crashed polecat session.
-func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName string) error {
+// A non-empty account runs the new session under that account handle.
+func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName, account string) error {
 	// Check rig operational state before auto-restarting
 	if operational, reason := d.isRigOperational(rigName); !operational {
 		return fmt.Errorf("cannot restart polecat: %s", reason)
@@ -861,6 +1114,19 @@ func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName string)
 	envVars["BEADS_NO_DAEMON"] = "1"
 	envVars["BEADS_AGENT_NAME"] = fmt.Sprintf("%s/%s", rigName, polecatName)
 
+	if account != "" {
+		accounts, err := config.LoadAccountsConfig(constants.MayorAccountsPath(d.config.TownRoot))
+		if err != nil {
+			return fmt.Errorf("loading accounts: %w", err)
+		}
+		configDir := accounts.AccountConfigDir(account)
+		if configDir == "" {
+			return fmt.Errorf("account %q not found", account)
+		}
+		envVars["GT_ACCOUNT"] = account
+		envVars["CLAUDE_CONFIG_DIR"] = configDir
+	}
+
 	// Set all env vars in tmux session (for debugging) and they'll also be exported to Claude
 	for k, v := range envVars {
 		_ = d.tmux.SetEnvironment(sessionName, k, v)
@@ -274,3 +274,22 @@ func TestLifecycleRequest_Serialization(t *testing.T) {
 		t.Errorf("Action mismatch: got %q, want %q", loaded.Action, request.Action)
 	}
 }
+
+func TestRecordSessionDeath_OncePerDeath(t *testing.T) {
+	d := testDaemon()
+
+	// A session still dead on later heartbeats (restart backing off) is one death
+	for i := 0; i < 3; i++ {
+		d.recordSessionDeath("gt-gastown-nux")
+	}
+	if len(d.recentDeaths) != 1 {
+		t.Fatalf("recorded %d deaths, want 1", len(d.recentDeaths))
+	}
+
+	// Once restarted, its next death counts
+	d.clearSessionDeath("gt-gastown-nux")
+	d.recordSessionDeath("gt-gastown-nux")
+	if len(d.recentDeaths) != 2 {
+		t.Errorf("recorded %d deaths after restart, want 2", len(d.recentDeaths))
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
@@ -195,6 +196,9 @@ func (d *Daemon) executeLifecycleAction(request *LifecycleRequest) error {
 
 			// Wait a moment
 			time.Sleep(constants.ShutdownNotifyDelay)
+		} else if err := d.guardRestart(request.From, sessionName); err != nil {
+			// Session already dead: count it toward crash-loop protection
+			return fmt.Errorf("restarting session: %w", err)
 		}
 
 		// Restart the session
@@ -393,11 +397,11 @@ func (d *Daemon) restartSession(sessionName, identity string) error {
 	// GUPP: Gas Town Universal Propulsion Principle
 	// Send startup nudge for predecessor discovery via /resume
 	recipient := identityToBDActor(identity)
-	_ = session.StartupNudge(d.tmux, sessionName, session.StartupNudgeConfig{
+	_ = d.tmux.NudgeSession(sessionName, session.FormatStartupNudge(session.StartupNudgeConfig{
 		Recipient: recipient,
 		Sender:    "deacon",
 		Topic:     "lifecycle-restart",
-	}) // Non-fatal
+	})) // Non-fatal
 
 	// Send propulsion nudge to trigger autonomous execution.
 	// Wait for beacon to be fully processed (needs to be separate prompt)
@@ -803,6 +807,8 @@ func (d *Daemon) checkRigGUPPViolations(rigName string) {
 			if age > GUPPViolationTimeout {
 				d.logger.Printf("GUPP violation: agent %s has hook_bead=%s but hasn't updated in %v (timeout: %v)",
 					agent.ID, agent.HookBead, age.Round(time.Minute), GUPPViolationTimeout)
+				_ = events.LogFeed(events.TypeGUPPViolation, "daemon",
+					events.GUPPViolationPayload(agent.ID, agent.HookBead, age.Round(time.Minute).String()))
 
 				// Notify the witness for this rig
 				d.notifyWitnessOfGUPP(rigName, agent.ID, agent.HookBead, age)
@@ -887,6 +893,11 @@ func (d *Daemon) checkRigOrphanedWork(rigName string) {
 			continue
 		}
 
+		// Parked on a wait step = not orphaned (the daemon will wake it)
+		if d.isParked(sessionName) {
+			continue
+		}
+
 		// Session dead but has hooked work = orphaned!
 		d.logger.Printf("Orphaned work detected: agent %s session is dead but has hook_bead=%s",
 			agent.ID, agent.HookBead)
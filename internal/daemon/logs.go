@@ -0,0 +1,85 @@
+package daemon
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/feed"
+	"github.com/steveyegge/gastown/internal/logrotate"
+	"github.com/steveyegge/gastown/internal/townlog"
+)
+
+// rotateLogs rotates the town's append-only logs once they outgrow the
+// log_retention policy in settings/config.json, and prunes old segments.
+// Readers (gt log, gt audit, gt feed, the curator) read across segments,
+// so rotation is invisible to them.
+func (d *Daemon) rotateLogs() {
+	policy := d.logRetentionPolicy()
+	now := time.Now()
+
+	for _, log := range townLogs(d.config.TownRoot) {
+		rotated, err := log.Rotate(policy, now)
+		if err != nil {
+			d.logger.Printf("Log rotation: %s: %v", filepath.Base(log.Path), err)
+			continue
+		}
+		if rotated {
+			d.logger.Printf("Log rotation: rotated %s", filepath.Base(log.Path))
+		}
+	}
+}
+
+// townLogs returns the logs subject to rotation.
+func townLogs(townRoot string) []logrotate.Log {
+	return []logrotate.Log{
+		{Path: filepath.Join(townRoot, events.EventsFile), Timestamp: jsonLineTime},
+		{Path: filepath.Join(townRoot, feed.FeedFile), Timestamp: jsonLineTime},
+		{Path: townlog.Path(townRoot), Timestamp: townlog.LineTime},
+	}
+}
+
+// jsonLineTime extracts the "ts" field of a JSONL event line.
+func jsonLineTime(line []byte) (time.Time, bool) {
+	var event struct {
+		Timestamp string `json:"ts"`
+	}
+	if err := json.Unmarshal(line, &event); err != nil {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(time.RFC3339, event.Timestamp)
+	return ts, err == nil
+}
+
+// logRetentionPolicy loads the log retention policy from town settings,
+// falling back to the defaults if the settings can't be read.
+func (d *Daemon) logRetentionPolicy() logrotate.Policy {
+	var retention *config.LogRetentionConfig
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(d.config.TownRoot))
+	if err != nil {
+		d.logger.Printf("Log rotation: loading town settings: %v (using defaults)", err)
+	} else if settings.LogRetention != nil {
+		if err := config.ValidateLogRetention(settings.LogRetention); err != nil {
+			d.logger.Printf("Log rotation: invalid log_retention: %v (using defaults)", err)
+		} else {
+			retention = settings.LogRetention
+		}
+	}
+	return retentionPolicy(retention)
+}
+
+// retentionPolicy converts a log retention config into a rotation policy.
+// The config must already be validated; nil uses the defaults.
+func retentionPolicy(c *config.LogRetentionConfig) logrotate.Policy {
+	r := c.WithDefaults()
+	maxAge, _ := config.ParseRetentionDuration(r.MaxAge)
+	retain, _ := config.ParseRetentionDuration(r.RetainFor)
+	return logrotate.Policy{
+		MaxSize:     int64(r.MaxSizeMB) * 1024 * 1024,
+		MaxAge:      maxAge,
+		Retain:      retain,
+		MaxSegments: r.MaxSegments,
+	}
+}
@@ -0,0 +1,30 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	p := retentionPolicy(nil)
+	if p.MaxSize != config.DefaultLogMaxSizeMB*1024*1024 || p.MaxAge != 24*time.Hour || p.Retain != 30*24*time.Hour {
+		t.Errorf("default policy = %+v", p)
+	}
+
+	p = retentionPolicy(&config.LogRetentionConfig{MaxAge: "0", RetainFor: "7d", MaxSegments: 3})
+	if p.MaxAge != 0 || p.Retain != 7*24*time.Hour || p.MaxSegments != 3 {
+		t.Errorf("policy = %+v", p)
+	}
+}
+
+func TestJSONLineTime(t *testing.T) {
+	ts, ok := jsonLineTime([]byte(`{"ts":"2026-01-02T03:04:05Z","type":"sling"}`))
+	if !ok || !ts.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("jsonLineTime = %s, %v", ts, ok)
+	}
+	if _, ok := jsonLineTime([]byte("not json")); ok {
+		t.Error("jsonLineTime parsed a non-JSON line")
+	}
+}
@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/prompts"
+	"github.com/steveyegge/gastown/internal/session"
+)
+
+// answerPrompts checks the sessions the daemon supervises for interactive
+// prompts (trust dialogs, rate limits, expired logins) and answers them using
+// the prompt rules of each session's agent preset.
+func (d *Daemon) answerPrompts() {
+	if rules := d.promptRules(""); len(rules) > 0 {
+		d.answerSessionPrompts(d.getDeaconSessionName(), "deacon", rules)
+	}
+
+	for _, rigName := range d.getKnownRigs() {
+		rules := d.promptRules(filepath.Join(d.config.TownRoot, rigName))
+		if len(rules) == 0 {
+			continue
+		}
+
+		d.answerSessionPrompts(session.WitnessSessionName(rigName), rigName+"/witness", rules)
+		d.answerSessionPrompts(session.RefinerySessionName(rigName), rigName+"/refinery", rules)
+
+		polecats, err := listPolecatWorktrees(filepath.Join(d.config.TownRoot, rigName, "polecats"))
+		if err != nil {
+			continue
+		}
+		for _, name := range polecats {
+			d.answerSessionPrompts(session.PolecatSessionName(rigName, name),
+				fmt.Sprintf("%s/polecats/%s", rigName, name), rules)
+		}
+	}
+}
+
+// promptRules returns the compiled prompt rules for the agent a rig runs.
+// An empty rigPath resolves the town default agent.
+func (d *Daemon) promptRules(rigPath string) []prompts.Rule {
+	agentName := config.ResolveAgentName(d.config.TownRoot, rigPath)
+	rules, err := prompts.RulesFor(agentName)
+	if err != nil {
+		d.logger.Printf("Warning: invalid prompt rules for agent %s: %v", agentName, err)
+		return nil
+	}
+	return rules
+}
+
+// answerSessionPrompts runs prompt rules against one session if it exists.
+func (d *Daemon) answerSessionPrompts(sessionName, identity string, rules []prompts.Rule) {
+	alive, err := d.tmux.HasSession(sessionName)
+	if err != nil || !alive {
+		return
+	}
+
+	det, err := d.prompts.Check(sessionName, identity, rules)
+	if det == nil {
+		return
+	}
+	if err != nil {
+		d.logger.Printf("Prompt %s in %s: %s failed: %v", det.Rule, sessionName, det.Action, err)
+		return
+	}
+	d.logger.Printf("Prompt %s in %s: %s", det.Rule, sessionName, det.Action)
+}
+
+// escalatePrompt asks a human to answer a prompt the rules can't handle.
+func (d *Daemon) escalatePrompt(det prompts.Detection) error {
+	topic := fmt.Sprintf("%s blocked on %s prompt", det.Agent, det.Rule)
+	body := fmt.Sprintf(`Session %s is waiting on an interactive prompt.
+
+rule: %s
+matched: %s
+
+Attach with: tmux attach -t %s`, det.Session, det.Rule, det.Matched, det.Session)
+
+	cmd := exec.Command("gt", "escalate", "-s", "HIGH", topic, "-m", body) //nolint:gosec // G204: args are constructed internally
+	cmd.Dir = d.config.TownRoot
+	return cmd.Run()
+}
+
+// restartForPrompt restarts a session blocked on a prompt.
+// Polecats are restarted in place, under another account if the rule asks for
+// one. Other agents are killed and brought back by their patrol.
+func (d *Daemon) restartForPrompt(det prompts.Detection) error {
+	if !strings.Contains(det.Agent, "/polecats/") {
+		return d.tmux.KillSession(det.Session)
+	}
+	parts := strings.SplitN(det.Agent, "/polecats/", 2)
+	rigName, polecatName := parts[0], parts[1]
+
+	account := d.resolvePromptAccount(det)
+	if err := d.guardRestart(det.Agent, det.Session); err != nil {
+		return err
+	}
+	if err := d.tmux.KillSession(det.Session); err != nil {
+		return fmt.Errorf("killing session: %w", err)
+	}
+	d.recordSessionDeath(det.Session)
+	if err := d.restartPolecatSession(rigName, polecatName, det.Session, account); err != nil {
+		return err
+	}
+	d.clearSessionDeath(det.Session)
+	return nil
+}
+
+// resolvePromptAccount turns a restart rule's account into a handle.
+// "next" rotates away from the session's current account. Returns empty
+// string to keep the default account.
+func (d *Daemon) resolvePromptAccount(det prompts.Detection) string {
+	if det.Account == "" {
+		return ""
+	}
+	if det.Account != "next" {
+		return det.Account
+	}
+
+	cfg, err := config.LoadAccountsConfig(constants.MayorAccountsPath(d.config.TownRoot))
+	if err != nil {
+		return ""
+	}
+	current, _ := d.tmux.GetEnvironment(det.Session, "GT_ACCOUNT")
+	if current == "" {
+		current = cfg.Default
+	}
+	return cfg.NextAccount(current)
+}
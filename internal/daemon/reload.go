@@ -0,0 +1,359 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/feed"
+	"github.com/steveyegge/gastown/internal/townlog"
+)
+
+// Patrol names recognized in mayor/daemon.json.
+const (
+	PatrolDeacon   = "deacon"
+	PatrolWitness  = "witness"
+	PatrolRefinery = "refinery"
+	PatrolCurator  = "curator"
+)
+
+// scheduleHeartbeat is the schedule key for the core recovery heartbeat
+// (spawns, lifecycle, GUPP, orphans, polecat health).
+const scheduleHeartbeat = "heartbeat"
+
+// configPollInterval is how often the daemon checks its config files for changes.
+const configPollInterval = 5 * time.Second
+
+// minScheduleInterval is the shortest heartbeat or patrol interval accepted,
+// the same minimum config validation enforces.
+const minScheduleInterval = config.MinDaemonInterval
+
+// PatrolSchedule is the resolved schedule for a single patrol.
+type PatrolSchedule struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
+// RuntimeConfig is the live, reloadable subset of daemon configuration.
+// It is resolved from mayor/config.json (daemon section) and mayor/daemon.json,
+// and re-resolved whenever either file changes.
+type RuntimeConfig struct {
+	HeartbeatEnabled  bool
+	HeartbeatInterval time.Duration
+	Patrols           map[string]PatrolSchedule
+}
+
+// DefaultRuntimeConfig returns the schedule used when no config files exist:
+// every patrol enabled and running at the recovery heartbeat interval.
+func DefaultRuntimeConfig() *RuntimeConfig {
+	rc := &RuntimeConfig{
+		HeartbeatEnabled:  true,
+		HeartbeatInterval: recoveryHeartbeatInterval,
+		Patrols:           make(map[string]PatrolSchedule),
+	}
+	for _, name := range knownPatrols() {
+		rc.Patrols[name] = PatrolSchedule{Enabled: true, Interval: recoveryHeartbeatInterval}
+	}
+	return rc
+}
+
+// knownPatrols returns the patrols the daemon schedules, in execution order.
+func knownPatrols() []string {
+	return []string{PatrolDeacon, PatrolWitness, PatrolRefinery, PatrolCurator}
+}
+
+// mayorConfigPath returns the path to mayor/config.json.
+func mayorConfigPath(townRoot string) string {
+	return filepath.Join(townRoot, constants.DirMayor, constants.FileConfigJSON)
+}
+
+// configPaths returns the files the daemon watches for live reload.
+func configPaths(townRoot string) []string {
+	return []string{
+		mayorConfigPath(townRoot),
+		config.DaemonPatrolConfigPath(townRoot),
+	}
+}
+
+// LoadRuntimeConfig resolves the daemon schedule from the town's config files.
+// Precedence: defaults < mayor/config.json daemon.heartbeat_interval < mayor/daemon.json.
+// Missing files are not an error; invalid files are, so a bad edit never
+// replaces a working schedule.
+func LoadRuntimeConfig(townRoot string) (*RuntimeConfig, error) {
+	rc := DefaultRuntimeConfig()
+
+	mayorCfg, err := config.LoadMayorConfig(mayorConfigPath(townRoot))
+	if err != nil && !errors.Is(err, config.ErrNotFound) {
+		return nil, err
+	}
+	if mayorCfg != nil && mayorCfg.Daemon != nil && mayorCfg.Daemon.HeartbeatInterval != "" {
+		interval, err := parseScheduleInterval(mayorCfg.Daemon.HeartbeatInterval)
+		if err != nil {
+			return nil, fmt.Errorf("mayor config daemon.heartbeat_interval: %w", err)
+		}
+		rc.HeartbeatInterval = interval
+	}
+
+	patrolCfg, err := config.LoadDaemonPatrolConfig(config.DaemonPatrolConfigPath(townRoot))
+	if err != nil && !errors.Is(err, config.ErrNotFound) {
+		return nil, err
+	}
+	if patrolCfg != nil && patrolCfg.Heartbeat != nil {
+		rc.HeartbeatEnabled = patrolCfg.Heartbeat.Enabled
+		if patrolCfg.Heartbeat.Interval != "" {
+			interval, err := parseScheduleInterval(patrolCfg.Heartbeat.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("heartbeat interval: %w", err)
+			}
+			rc.HeartbeatInterval = interval
+		}
+	}
+
+	// Patrols without their own interval follow the heartbeat.
+	for name := range rc.Patrols {
+		rc.Patrols[name] = PatrolSchedule{Enabled: true, Interval: rc.HeartbeatInterval}
+	}
+	if patrolCfg != nil {
+		for name, p := range patrolCfg.Patrols {
+			sched := PatrolSchedule{Enabled: p.IsEnabled(), Interval: rc.HeartbeatInterval}
+			if p.Interval != "" && patrolCfg.PatrolIntervalsApplied() {
+				interval, err := parseScheduleInterval(p.Interval)
+				if err != nil {
+					return nil, fmt.Errorf("patrol %q interval: %w", name, err)
+				}
+				sched.Interval = interval
+			}
+			rc.Patrols[name] = sched
+		}
+	}
+
+	return rc, nil
+}
+
+// parseScheduleInterval parses an interval and enforces minScheduleInterval.
+func parseScheduleInterval(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < minScheduleInterval {
+		return 0, fmt.Errorf("%s is below the minimum of %s", d, minScheduleInterval)
+	}
+	return d, nil
+}
+
+// PatrolEnabled reports whether the named patrol should run.
+// Unknown patrols are treated as enabled so new patrols default to on.
+func (rc *RuntimeConfig) PatrolEnabled(name string) bool {
+	p, ok := rc.Patrols[name]
+	return !ok || p.Enabled
+}
+
+// PatrolInterval returns the interval for the named patrol,
+// falling back to the heartbeat interval.
+func (rc *RuntimeConfig) PatrolInterval(name string) time.Duration {
+	if p, ok := rc.Patrols[name]; ok && p.Interval > 0 {
+		return p.Interval
+	}
+	return rc.HeartbeatInterval
+}
+
+// Diff returns human-readable descriptions of what changed from rc to next,
+// e.g. "patrols.witness.enabled: true -> false". Returns nil if nothing changed.
+func (rc *RuntimeConfig) Diff(next *RuntimeConfig) []string {
+	var changes []string
+	if rc.HeartbeatEnabled != next.HeartbeatEnabled {
+		changes = append(changes, fmt.Sprintf("heartbeat.enabled: %t -> %t", rc.HeartbeatEnabled, next.HeartbeatEnabled))
+	}
+	if rc.HeartbeatInterval != next.HeartbeatInterval {
+		changes = append(changes, fmt.Sprintf("heartbeat.interval: %s -> %s", rc.HeartbeatInterval, next.HeartbeatInterval))
+	}
+
+	names := make(map[string]bool)
+	for name := range rc.Patrols {
+		names[name] = true
+	}
+	for name := range next.Patrols {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		if rc.PatrolEnabled(name) != next.PatrolEnabled(name) {
+			changes = append(changes, fmt.Sprintf("patrols.%s.enabled: %t -> %t",
+				name, rc.PatrolEnabled(name), next.PatrolEnabled(name)))
+		}
+		if rc.PatrolInterval(name) != next.PatrolInterval(name) {
+			changes = append(changes, fmt.Sprintf("patrols.%s.interval: %s -> %s",
+				name, rc.PatrolInterval(name), next.PatrolInterval(name)))
+		}
+	}
+	return changes
+}
+
+// fileStamp identifies a version of a file on disk.
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// configWatcher detects changes to config files by polling their stat info.
+// Polling keeps the daemon free of platform-specific notification APIs and
+// is cheap at configPollInterval.
+type configWatcher struct {
+	paths  []string
+	stamps map[string]fileStamp
+}
+
+// newConfigWatcher creates a watcher primed with the current state of paths.
+func newConfigWatcher(paths ...string) *configWatcher {
+	w := &configWatcher{
+		paths:  paths,
+		stamps: make(map[string]fileStamp, len(paths)),
+	}
+	for _, p := range paths {
+		w.stamps[p] = statFile(p)
+	}
+	return w
+}
+
+// Changed reports whether any watched file was created, removed or modified
+// since the last call.
+func (w *configWatcher) Changed() bool {
+	changed := false
+	for _, p := range w.paths {
+		stamp := statFile(p)
+		if stamp != w.stamps[p] {
+			w.stamps[p] = stamp
+			changed = true
+		}
+	}
+	return changed
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, modTime: info.ModTime(), size: info.Size()}
+}
+
+// reloadConfig re-resolves the runtime config and applies it if it changed.
+// Invalid config is logged and ignored so the daemon keeps its last good schedule.
+// Returns true if the schedule changed.
+func (d *Daemon) reloadConfig() bool {
+	next, err := LoadRuntimeConfig(d.config.TownRoot)
+	if err != nil {
+		d.logger.Printf("Config reload rejected, keeping current config: %v", err)
+		return false
+	}
+
+	changes := d.runtime.Diff(next)
+	if len(changes) == 0 {
+		return false
+	}
+
+	d.runtime = next
+	summary := strings.Join(changes, "; ")
+	d.logger.Printf("Config reloaded: %s", summary)
+	if err := townlog.NewLogger(d.config.TownRoot).Log(townlog.EventConfigReload, "daemon", summary); err != nil {
+		d.logger.Printf("Warning: failed to write config reload to town log: %v", err)
+	}
+
+	d.applyCuratorConfig()
+	return true
+}
+
+// applyCuratorConfig starts or stops the feed curator to match the curator patrol.
+func (d *Daemon) applyCuratorConfig() {
+	enabled := d.runtime.PatrolEnabled(PatrolCurator)
+
+	if enabled && d.curator == nil {
+		curator := feed.NewCurator(d.config.TownRoot)
+		if err := curator.Start(); err != nil {
+			d.logger.Printf("Warning: failed to start feed curator: %v", err)
+			return
+		}
+		d.curator = curator
+		d.logger.Println("Feed curator started")
+		return
+	}
+
+	if !enabled && d.curator != nil {
+		d.curator.Stop()
+		d.curator = nil
+		d.logger.Println("Feed curator stopped (disabled by config)")
+	}
+}
+
+// isDue reports whether the schedule entry key has not run within interval.
+func (d *Daemon) isDue(key string, interval time.Duration, now time.Time) bool {
+	last, ok := d.lastRun[key]
+	return !ok || now.Sub(last) >= interval
+}
+
+// patrolDue reports whether a patrol is enabled and due to run.
+func (d *Daemon) patrolDue(name string, now time.Time) bool {
+	if !d.runtime.PatrolEnabled(name) {
+		return false
+	}
+	return d.isDue(name, d.runtime.PatrolInterval(name), now)
+}
+
+// heartbeatDue reports whether the core heartbeat is enabled and due to run.
+func (d *Daemon) heartbeatDue(now time.Time) bool {
+	if !d.runtime.HeartbeatEnabled {
+		return false
+	}
+	return d.isDue(scheduleHeartbeat, d.runtime.HeartbeatInterval, now)
+}
+
+// nextWake returns how long to sleep until the next heartbeat or patrol is
+// due, or a parked wait's backoff runs out.
+// If nothing is enabled, the daemon still wakes every heartbeat interval so
+// the loop stays responsive; config changes are picked up separately.
+func (d *Daemon) nextWake(now time.Time) time.Duration {
+	wait := d.runtime.HeartbeatInterval
+	consider := func(key string, interval time.Duration) {
+		last, ok := d.lastRun[key]
+		if !ok {
+			wait = 0
+			return
+		}
+		if w := last.Add(interval).Sub(now); w < wait {
+			wait = w
+		}
+	}
+
+	if d.runtime.HeartbeatEnabled {
+		consider(scheduleHeartbeat, d.runtime.HeartbeatInterval)
+	}
+	for _, name := range knownPatrols() {
+		if name == PatrolCurator || !d.runtime.PatrolEnabled(name) {
+			continue // curator is a long-running goroutine, not a scheduled patrol
+		}
+		consider(name, d.runtime.PatrolInterval(name))
+	}
+	if deadline, ok := d.waits.NextDeadline(); ok {
+		if w := deadline.Sub(now); w < wait {
+			wait = w
+		}
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
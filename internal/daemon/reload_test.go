@@ -0,0 +1,210 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func writePatrolConfig(t *testing.T, townRoot string, cfg *config.DaemonPatrolConfig) {
+	t.Helper()
+	if err := config.SaveDaemonPatrolConfig(config.DaemonPatrolConfigPath(townRoot), cfg); err != nil {
+		t.Fatalf("SaveDaemonPatrolConfig: %v", err)
+	}
+}
+
+func TestLoadRuntimeConfig_NoFiles(t *testing.T) {
+	rc, err := LoadRuntimeConfig(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadRuntimeConfig: %v", err)
+	}
+	if !rc.HeartbeatEnabled || rc.HeartbeatInterval != recoveryHeartbeatInterval {
+		t.Errorf("heartbeat = %v/%v, want enabled/%v", rc.HeartbeatEnabled, rc.HeartbeatInterval, recoveryHeartbeatInterval)
+	}
+	for _, name := range knownPatrols() {
+		if !rc.PatrolEnabled(name) {
+			t.Errorf("patrol %s should default to enabled", name)
+		}
+		if rc.PatrolInterval(name) != recoveryHeartbeatInterval {
+			t.Errorf("patrol %s interval = %v, want %v", name, rc.PatrolInterval(name), recoveryHeartbeatInterval)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig_PatrolConfig(t *testing.T) {
+	townRoot := t.TempDir()
+	disabled := false
+	writePatrolConfig(t, townRoot, &config.DaemonPatrolConfig{
+		Type:      "daemon-patrol-config",
+		Version:   config.CurrentDaemonPatrolConfigVersion,
+		Heartbeat: &config.HeartbeatConfig{Enabled: true, Interval: "1m"},
+		Patrols: map[string]config.PatrolConfig{
+			"witness":  {Enabled: &disabled, Interval: "10m"},
+			"refinery": {Agent: "refinery"}, // No "enabled": on
+		},
+	})
+
+	rc, err := LoadRuntimeConfig(townRoot)
+	if err != nil {
+		t.Fatalf("LoadRuntimeConfig: %v", err)
+	}
+	if rc.HeartbeatInterval != time.Minute {
+		t.Errorf("HeartbeatInterval = %v, want 1m", rc.HeartbeatInterval)
+	}
+	if rc.PatrolEnabled(PatrolWitness) {
+		t.Error("witness patrol should be disabled")
+	}
+	if rc.PatrolInterval(PatrolWitness) != 10*time.Minute {
+		t.Errorf("witness interval = %v, want 10m", rc.PatrolInterval(PatrolWitness))
+	}
+	// No interval: follows heartbeat
+	if rc.PatrolInterval(PatrolRefinery) != time.Minute {
+		t.Errorf("refinery interval = %v, want 1m", rc.PatrolInterval(PatrolRefinery))
+	}
+	// Not listed: enabled at heartbeat interval
+	if !rc.PatrolEnabled(PatrolDeacon) || rc.PatrolInterval(PatrolDeacon) != time.Minute {
+		t.Errorf("deacon = %v/%v, want enabled/1m", rc.PatrolEnabled(PatrolDeacon), rc.PatrolInterval(PatrolDeacon))
+	}
+}
+
+func TestLoadRuntimeConfig_IgnoresVersion1Intervals(t *testing.T) {
+	townRoot := t.TempDir()
+	path := config.DaemonPatrolConfigPath(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// As gt start wrote it before the daemon applied patrol intervals
+	data := `{"type":"daemon-patrol-config","version":1,"heartbeat":{"enabled":true,"interval":"3m"},
+"patrols":{"deacon":{"enabled":true,"interval":"5m"},"witness":{"enabled":false,"interval":"5m"}}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := LoadRuntimeConfig(townRoot)
+	if err != nil {
+		t.Fatalf("LoadRuntimeConfig: %v", err)
+	}
+	if rc.PatrolInterval(PatrolDeacon) != 3*time.Minute {
+		t.Errorf("deacon interval = %v, want the heartbeat's 3m", rc.PatrolInterval(PatrolDeacon))
+	}
+	if rc.PatrolEnabled(PatrolWitness) {
+		t.Error("witness patrol should be disabled")
+	}
+}
+
+func TestLoadRuntimeConfig_RejectsInvalid(t *testing.T) {
+	townRoot := t.TempDir()
+	path := config.DaemonPatrolConfigPath(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Below the minimum interval
+	data := `{"type":"daemon-patrol-config","version":1,"patrols":{"witness":{"enabled":true,"interval":"1s"}}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRuntimeConfig(townRoot); err == nil {
+		t.Error("expected error for interval below minimum")
+	}
+
+	// Malformed JSON
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRuntimeConfig(townRoot); err == nil {
+		t.Error("expected error for malformed config")
+	}
+}
+
+func TestRuntimeConfigDiff(t *testing.T) {
+	old := DefaultRuntimeConfig()
+	next := DefaultRuntimeConfig()
+
+	if changes := old.Diff(next); len(changes) != 0 {
+		t.Errorf("identical configs should not differ, got %v", changes)
+	}
+
+	next.HeartbeatInterval = time.Minute
+	next.Patrols[PatrolWitness] = PatrolSchedule{Enabled: false, Interval: recoveryHeartbeatInterval}
+
+	changes := old.Diff(next)
+	want := []string{
+		"heartbeat.interval: 3m0s -> 1m0s",
+		"patrols.witness.enabled: true -> false",
+	}
+	if strings.Join(changes, "|") != strings.Join(want, "|") {
+		t.Errorf("Diff = %v, want %v", changes, want)
+	}
+}
+
+func TestConfigWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "daemon.json")
+
+	w := newConfigWatcher(path)
+	if w.Changed() {
+		t.Error("no change expected before file exists")
+	}
+
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Changed() {
+		t.Error("expected change after file created")
+	}
+	if w.Changed() {
+		t.Error("change should only be reported once")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Changed() {
+		t.Error("expected change after file modified")
+	}
+}
+
+func TestScheduling(t *testing.T) {
+	d := &Daemon{
+		runtime: DefaultRuntimeConfig(),
+		lastRun: make(map[string]time.Time),
+	}
+	now := time.Now()
+
+	if got := d.nextWake(now); got != 0 {
+		t.Errorf("nextWake before first run = %v, want 0", got)
+	}
+	if !d.patrolDue(PatrolWitness, now) {
+		t.Error("witness should be due before first run")
+	}
+
+	for _, key := range []string{scheduleHeartbeat, PatrolDeacon, PatrolWitness, PatrolRefinery} {
+		d.lastRun[key] = now
+	}
+	if d.patrolDue(PatrolWitness, now.Add(time.Minute)) {
+		t.Error("witness should not be due one minute after running")
+	}
+	if got := d.nextWake(now); got != recoveryHeartbeatInterval {
+		t.Errorf("nextWake = %v, want %v", got, recoveryHeartbeatInterval)
+	}
+
+	// Shorter witness interval reschedules the wake
+	d.runtime.Patrols[PatrolWitness] = PatrolSchedule{Enabled: true, Interval: 30 * time.Second}
+	if got := d.nextWake(now); got != 30*time.Second {
+		t.Errorf("nextWake = %v, want 30s", got)
+	}
+
+	// Disabled patrols are never due and don't affect the wake
+	d.runtime.Patrols[PatrolWitness] = PatrolSchedule{Enabled: false, Interval: 30 * time.Second}
+	if d.patrolDue(PatrolWitness, now.Add(time.Hour)) {
+		t.Error("disabled patrol should never be due")
+	}
+	if got := d.nextWake(now); got != recoveryHeartbeatInterval {
+		t.Errorf("nextWake = %v, want %v", got, recoveryHeartbeatInterval)
+	}
+}
@@ -0,0 +1,387 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/townlog"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Crash-loop protection parameters.
+const (
+	// CrashLoopWindow is how far back deaths count toward a crash loop.
+	CrashLoopWindow = 15 * time.Minute
+
+	// CrashLoopThreshold is how many deaths within the window quarantine an identity.
+	CrashLoopThreshold = 5
+
+	// restartBackoffBase is the delay before the second restart in a window;
+	// it doubles for each further death.
+	restartBackoffBase = 30 * time.Second
+
+	// restartBackoffMax caps the delay between restarts.
+	restartBackoffMax = 10 * time.Minute
+
+	// crashReportPaneLines is how much pane output a crash report keeps.
+	crashReportPaneLines = 50
+)
+
+// ErrRestartBackoff is returned when a restart is held back by backoff.
+var ErrRestartBackoff = errors.New("restart backing off")
+
+// ErrCrashLoop is returned when a death completes a crash loop and the
+// identity is quarantined.
+var ErrCrashLoop = errors.New("crash loop detected")
+
+// ErrQuarantined is returned when an identity is already in crash-loop quarantine.
+var ErrQuarantined = errors.New("session quarantined after crash loop")
+
+// ErrNotQuarantined is returned when unquarantining an identity that isn't quarantined.
+var ErrNotQuarantined = errors.New("not quarantined")
+
+// RestartRecord is the restart history of one agent identity.
+type RestartRecord struct {
+	Identity string `json:"identity"`
+	Session  string `json:"session"`
+
+	// Deaths are the deaths within CrashLoopWindow, oldest first.
+	Deaths []time.Time `json:"deaths,omitempty"`
+
+	// NextRestart is the earliest time the next restart may run.
+	NextRestart time.Time `json:"next_restart,omitempty"`
+
+	// Quarantine state: no restarts until gt session unquarantine.
+	Quarantined   bool      `json:"quarantined,omitempty"`
+	QuarantinedAt time.Time `json:"quarantined_at,omitempty"`
+	CrashReport   string    `json:"crash_report,omitempty"` // Bead ID
+
+	// Last observed output and environment, captured at each death while the
+	// session still existed. Used for the crash report.
+	LastPane string            `json:"last_pane,omitempty"`
+	LastEnv  map[string]string `json:"last_env,omitempty"`
+}
+
+// RestartHistory is the per-identity restart history (daemon/restarts.json).
+type RestartHistory struct {
+	Agents map[string]*RestartRecord `json:"agents"`
+}
+
+// RestartHistoryFile returns the path to the restart history file.
+func RestartHistoryFile(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "restarts.json")
+}
+
+// LoadRestartHistory loads the restart history. A missing file is empty history.
+func LoadRestartHistory(townRoot string) (*RestartHistory, error) {
+	h := &RestartHistory{Agents: make(map[string]*RestartRecord)}
+	data, err := os.ReadFile(RestartHistoryFile(townRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("parsing restart history: %w", err)
+	}
+	if h.Agents == nil {
+		h.Agents = make(map[string]*RestartRecord)
+	}
+	return h, nil
+}
+
+// SaveRestartHistory writes the restart history.
+func SaveRestartHistory(townRoot string, h *RestartHistory) error {
+	path := RestartHistoryFile(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(path, h)
+}
+
+// lockRestartHistory takes the restart history file lock, so the daemon and
+// gt session unquarantine don't lose each other's writes. The caller must
+// unlock it.
+func lockRestartHistory(townRoot string) (*flock.Flock, error) {
+	path := RestartHistoryFile(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return nil, fmt.Errorf("locking restart history: %w", err)
+	}
+	return lock, nil
+}
+
+// UpdateRestartHistory applies fn to the restart history under a file lock.
+// The history is written back unless fn returns an error, which is returned.
+func UpdateRestartHistory(townRoot string, fn func(*RestartHistory) error) error {
+	lock, err := lockRestartHistory(townRoot)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	h, err := LoadRestartHistory(townRoot)
+	if err != nil {
+		return err
+	}
+	if err := fn(h); err != nil {
+		return err
+	}
+	return SaveRestartHistory(townRoot, h)
+}
+
+// Record returns the record for an identity, creating it if needed.
+func (h *RestartHistory) Record(identity, session string) *RestartRecord {
+	r, ok := h.Agents[identity]
+	if !ok {
+		r = &RestartRecord{Identity: identity}
+		h.Agents[identity] = r
+	}
+	if session != "" {
+		r.Session = session
+	}
+	return r
+}
+
+// Find returns the record matching an identity or session name.
+func (h *RestartHistory) Find(name string) *RestartRecord {
+	if r, ok := h.Agents[name]; ok {
+		return r
+	}
+	for _, r := range h.Agents {
+		if r.Session == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// Quarantined returns quarantined records, sorted by identity.
+func (h *RestartHistory) Quarantined() []*RestartRecord {
+	var out []*RestartRecord
+	for _, r := range h.Agents {
+		if r.Quarantined {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Identity < out[j].Identity })
+	return out
+}
+
+// Unquarantine clears quarantine and history for an identity or session name.
+func (h *RestartHistory) Unquarantine(name string) (*RestartRecord, error) {
+	r := h.Find(name)
+	if r == nil || !r.Quarantined {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotQuarantined)
+	}
+	r.Quarantined = false
+	r.QuarantinedAt = time.Time{}
+	r.Deaths = nil
+	r.NextRestart = time.Time{}
+	return r, nil
+}
+
+// RecordDeath records a death at now and decides whether a restart may run.
+// Returns ErrRestartBackoff if the previous restart's backoff hasn't elapsed
+// (the death is not counted again), ErrCrashLoop if this death completes a
+// crash loop, or ErrQuarantined if already quarantined. On nil, the caller
+// should restart; the backoff for the next death has been set.
+func (r *RestartRecord) RecordDeath(now time.Time) error {
+	if r.Quarantined {
+		return ErrQuarantined
+	}
+	if now.Before(r.NextRestart) {
+		return ErrRestartBackoff
+	}
+
+	cutoff := now.Add(-CrashLoopWindow)
+	recent := r.Deaths[:0]
+	for _, t := range r.Deaths {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	r.Deaths = append(recent, now)
+
+	if len(r.Deaths) >= CrashLoopThreshold {
+		r.Quarantined = true
+		r.QuarantinedAt = now
+		return ErrCrashLoop
+	}
+
+	r.NextRestart = now.Add(RestartBackoff(len(r.Deaths)))
+	return nil
+}
+
+// RestartBackoff returns the wait required after the nth death in a window
+// before another restart: the base after the first, doubling with each
+// further death up to the cap. Zero deaths need no wait.
+func RestartBackoff(deaths int) time.Duration {
+	if deaths < 1 {
+		return 0
+	}
+	d := restartBackoffBase
+	for i := 1; i < deaths; i++ {
+		d *= 2
+		if d >= restartBackoffMax {
+			return restartBackoffMax
+		}
+	}
+	return d
+}
+
+// guardRestart consults the restart history before restarting a session.
+// Returns nil if the restart may proceed. On quarantine, a crash report bead
+// is filed and events are emitted (once, when the loop is detected).
+func (d *Daemon) guardRestart(identity, sessionName string) error {
+	lock, err := lockRestartHistory(d.config.TownRoot)
+	if err != nil {
+		d.logger.Printf("Warning: %v", err)
+	} else {
+		defer func() { _ = lock.Unlock() }()
+	}
+
+	h, err := LoadRestartHistory(d.config.TownRoot)
+	if err != nil {
+		// Corrupt history must not block recovery
+		d.logger.Printf("Warning: %v (starting fresh restart history)", err)
+		h = &RestartHistory{Agents: make(map[string]*RestartRecord)}
+	}
+	r := h.Record(identity, sessionName)
+	if r.Quarantined {
+		return ErrQuarantined
+	}
+
+	d.captureCrashContext(r, sessionName)
+
+	err = r.RecordDeath(time.Now())
+	switch {
+	case errors.Is(err, ErrRestartBackoff):
+		d.logger.Printf("Restart of %s backing off until %s", identity, r.NextRestart.Format(time.RFC3339))
+	case errors.Is(err, ErrCrashLoop):
+		d.logger.Printf("CRASH LOOP: %s died %d times in %s, quarantined", identity, len(r.Deaths), CrashLoopWindow)
+		r.CrashReport = d.fileCrashReport(r)
+		d.emitCrashLoop(r)
+	}
+
+	if saveErr := SaveRestartHistory(d.config.TownRoot, h); saveErr != nil {
+		d.logger.Printf("Warning: failed to save restart history: %v", saveErr)
+	}
+	return err
+}
+
+// restartHeld reports whether an identity's restart is held back by backoff
+// or quarantine, without recording a death.
+func (d *Daemon) restartHeld(identity string) bool {
+	h, err := LoadRestartHistory(d.config.TownRoot)
+	if err != nil {
+		return false
+	}
+	r := h.Find(identity)
+	return r != nil && (r.Quarantined || time.Now().Before(r.NextRestart))
+}
+
+// captureCrashContext snapshots the session's pane and environment if the
+// session (or its zombie shell) still exists.
+func (d *Daemon) captureCrashContext(r *RestartRecord, sessionName string) {
+	if alive, _ := d.tmux.HasSession(sessionName); !alive {
+		return
+	}
+	if pane, err := d.tmux.CapturePane(sessionName, crashReportPaneLines); err == nil && strings.TrimSpace(pane) != "" {
+		r.LastPane = pane
+	}
+	if env, err := d.tmux.GetAllEnvironment(sessionName); err == nil {
+		r.LastEnv = redactEnv(env)
+	}
+}
+
+// secretEnvPattern matches environment variable names that may hold credentials.
+var secretEnvPattern = regexp.MustCompile(`(?i)(key|token|secret|password|credential)`)
+
+// redactEnv masks values of credential-like variables.
+func redactEnv(env map[string]string) map[string]string {
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		if secretEnvPattern.MatchString(k) {
+			v = "[redacted]"
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// fileCrashReport creates a crash report bead for a quarantined identity.
+// Returns the bead ID, or empty string if the bead couldn't be created.
+func (d *Daemon) fileCrashReport(r *RestartRecord) string {
+	issue, err := beads.New(d.config.TownRoot).Create(beads.CreateOptions{
+		Title:       fmt.Sprintf("Crash loop: %s", r.Identity),
+		Type:        "bug",
+		Priority:    1,
+		Description: formatCrashReport(r),
+		Actor:       "daemon",
+	})
+	if err != nil {
+		d.logger.Printf("Warning: failed to file crash report for %s: %v", r.Identity, err)
+		return ""
+	}
+	return issue.ID
+}
+
+// formatCrashReport renders the crash report bead description.
+func formatCrashReport(r *RestartRecord) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Session %s (%s) died %d times within %s and has been quarantined.\n",
+		r.Session, r.Identity, len(r.Deaths), CrashLoopWindow)
+	sb.WriteString("The daemon will not restart it until: gt session unquarantine " + r.Identity + "\n\n")
+
+	sb.WriteString("## Deaths\n\n")
+	for _, t := range r.Deaths {
+		fmt.Fprintf(&sb, "- %s\n", t.UTC().Format(time.RFC3339))
+	}
+
+	sb.WriteString("\n## Last pane output\n\n```\n")
+	if r.LastPane != "" {
+		sb.WriteString(strings.TrimRight(r.LastPane, "\n"))
+	} else {
+		sb.WriteString("(session was gone before output could be captured)")
+	}
+	sb.WriteString("\n```\n")
+
+	if len(r.LastEnv) > 0 {
+		keys := make([]string, 0, len(r.LastEnv))
+		for k := range r.LastEnv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sb.WriteString("\n## Environment\n\n```\n")
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "%s=%s\n", k, r.LastEnv[k])
+		}
+		sb.WriteString("```\n")
+	}
+	return sb.String()
+}
+
+// emitCrashLoop records the quarantine in the feed and town log.
+func (d *Daemon) emitCrashLoop(r *RestartRecord) {
+	_ = events.LogFeed(events.TypeCrashLoop, "daemon",
+		events.CrashLoopPayload(r.Session, r.Identity, len(r.Deaths), CrashLoopWindow.String(), r.CrashReport))
+
+	logger := townlog.NewLogger(d.config.TownRoot)
+	_ = logger.Log(townlog.EventCrashLoop, r.Identity,
+		fmt.Sprintf("%d deaths in %s", len(r.Deaths), CrashLoopWindow))
+}
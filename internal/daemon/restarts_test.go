@@ -0,0 +1,211 @@
+package daemon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	tests := []struct {
+		deaths int
+		want   time.Duration
+	}{
+		{0, 0},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, restartBackoffMax},
+	}
+	for _, tt := range tests {
+		if got := RestartBackoff(tt.deaths); got != tt.want {
+			t.Errorf("RestartBackoff(%d) = %s, want %s", tt.deaths, got, tt.want)
+		}
+	}
+}
+
+func TestRecordDeath_BackoffThenQuarantine(t *testing.T) {
+	r := &RestartRecord{Identity: "gastown/polecats/Toast"}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := r.RecordDeath(now); err != nil {
+		t.Fatalf("first death: %v", err)
+	}
+
+	// Dying again immediately is held back, and not counted twice.
+	if err := r.RecordDeath(now.Add(time.Second)); !errors.Is(err, ErrRestartBackoff) {
+		t.Fatalf("death during backoff = %v, want ErrRestartBackoff", err)
+	}
+	if len(r.Deaths) != 1 {
+		t.Fatalf("deaths = %d, want 1", len(r.Deaths))
+	}
+
+	var err error
+	for i := 2; i <= CrashLoopThreshold; i++ {
+		now = r.NextRestart
+		err = r.RecordDeath(now)
+		if i < CrashLoopThreshold && err != nil {
+			t.Fatalf("death %d: %v", i, err)
+		}
+	}
+	if !errors.Is(err, ErrCrashLoop) || !r.Quarantined {
+		t.Fatalf("death %d = %v (quarantined=%v), want ErrCrashLoop", CrashLoopThreshold, err, r.Quarantined)
+	}
+	if err := r.RecordDeath(now.Add(time.Hour)); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("death after quarantine = %v, want ErrQuarantined", err)
+	}
+}
+
+func TestRecordDeath_WindowForgetsOldDeaths(t *testing.T) {
+	r := &RestartRecord{Identity: "gastown/witness"}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Deaths spread out beyond the window never add up to a crash loop.
+	for i := 0; i < CrashLoopThreshold*2; i++ {
+		if err := r.RecordDeath(now); err != nil {
+			t.Fatalf("death %d: %v", i, err)
+		}
+		now = now.Add(CrashLoopWindow + time.Minute)
+	}
+	if len(r.Deaths) != 1 {
+		t.Errorf("deaths in window = %d, want 1", len(r.Deaths))
+	}
+}
+
+func TestRestartHistory_RoundTripAndUnquarantine(t *testing.T) {
+	townRoot := t.TempDir()
+	h, err := LoadRestartHistory(townRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := h.Record("gastown/polecats/Toast", "gt-gastown-Toast")
+	r.Quarantined = true
+	r.Deaths = []time.Time{time.Now()}
+	if err := SaveRestartHistory(townRoot, h); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadRestartHistory(townRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Quarantined(); len(got) != 1 {
+		t.Fatalf("quarantined = %d, want 1", len(got))
+	}
+
+	if _, err := loaded.Unquarantine("gt-gastown-Toast"); err != nil {
+		t.Fatalf("Unquarantine by session: %v", err)
+	}
+	if _, err := loaded.Unquarantine("gastown/polecats/Toast"); !errors.Is(err, ErrNotQuarantined) {
+		t.Errorf("second Unquarantine = %v, want ErrNotQuarantined", err)
+	}
+	if rec := loaded.Find("gastown/polecats/Toast"); len(rec.Deaths) != 0 {
+		t.Errorf("deaths after unquarantine = %d, want 0", len(rec.Deaths))
+	}
+}
+
+func TestFormatCrashReport_RedactsSecrets(t *testing.T) {
+	r := &RestartRecord{
+		Identity: "gastown/polecats/Toast",
+		Session:  "gt-gastown-Toast",
+		Deaths:   []time.Time{time.Now()},
+		LastPane: "Error: cannot find module\n",
+		LastEnv:  redactEnv(map[string]string{"GT_ROLE": "polecat", "ANTHROPIC_API_KEY": "sk-123"}),
+	}
+	report := formatCrashReport(r)
+	for _, want := range []string{"cannot find module", "GT_ROLE=polecat", "gt session unquarantine gastown/polecats/Toast"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	if strings.Contains(report, "sk-123") {
+		t.Error("report leaked a secret")
+	}
+}
+
+func TestUpdateRestartHistory_SkipsWriteOnError(t *testing.T) {
+	townRoot := t.TempDir()
+	if err := UpdateRestartHistory(townRoot, func(h *RestartHistory) error {
+		r := h.Record("gastown/polecats/Toast", "gt-gastown-Toast")
+		r.Quarantined = true
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateRestartHistory: %v", err)
+	}
+
+	err := UpdateRestartHistory(townRoot, func(h *RestartHistory) error {
+		h.Agents = nil
+		_, err := h.Unquarantine("gastown/witness")
+		return err
+	})
+	if !errors.Is(err, ErrNotQuarantined) {
+		t.Fatalf("UpdateRestartHistory = %v, want ErrNotQuarantined", err)
+	}
+
+	h, err := LoadRestartHistory(townRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Quarantined(); len(got) != 1 {
+		t.Errorf("quarantined = %d, want 1 (failed update must not write)", len(got))
+	}
+}
+
+func TestGuardPatrolStart_CountsEachAbsenceOnce(t *testing.T) {
+	d, fake := testWaitDaemon(t)
+	const sess = "gt-gastown-witness"
+
+	// Gone: the first tick counts a death and lets the start run.
+	if !d.guardPatrolStart("gastown/witness", sess) {
+		t.Fatal("first absence held back, want start")
+	}
+	// The start failed and the session is still gone: not a second death,
+	// and the retry waits for the backoff.
+	for i := 0; i < 3; i++ {
+		if d.guardPatrolStart("gastown/witness", sess) {
+			t.Fatal("retry during backoff allowed")
+		}
+	}
+	endBackoff(t, d.config.TownRoot, "gastown/witness")
+	if !d.guardPatrolStart("gastown/witness", sess) {
+		t.Fatal("retry after backoff held back")
+	}
+	if got := witnessDeaths(t, d.config.TownRoot); got != 1 {
+		t.Fatalf("deaths = %d, want 1", got)
+	}
+
+	// Once the session has run, its next absence is a new death.
+	fake.alive[sess] = true
+	if !d.guardPatrolStart("gastown/witness", sess) {
+		t.Fatal("running session held back")
+	}
+	delete(fake.alive, sess)
+	endBackoff(t, d.config.TownRoot, "gastown/witness")
+	if !d.guardPatrolStart("gastown/witness", sess) {
+		t.Fatal("new absence held back, want start")
+	}
+	if got := witnessDeaths(t, d.config.TownRoot); got != 2 {
+		t.Errorf("deaths = %d, want 2", got)
+	}
+}
+
+// endBackoff moves an identity's next restart into the past.
+func endBackoff(t *testing.T, townRoot, identity string) {
+	t.Helper()
+	if err := UpdateRestartHistory(townRoot, func(h *RestartHistory) error {
+		h.Record(identity, "").NextRestart = time.Now().Add(-time.Second)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func witnessDeaths(t *testing.T, townRoot string) int {
+	t.Helper()
+	h, err := LoadRestartHistory(townRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(h.Find("gastown/witness").Deaths)
+}
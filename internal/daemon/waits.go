@@ -0,0 +1,416 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Wake reasons of a parked wait, as for gt mol step await-signal.
+const (
+	WakeSignal  = "signal"
+	WakeTimeout = "timeout"
+)
+
+// ParkedWait is a wait-type step whose agent exited its session instead of
+// sleeping in it. The daemon wakes the agent when the beads feed of WorkDir
+// shows activity or Deadline passes, whichever comes first. A wait on a
+// step only wakes on activity on the step or its molecule.
+type ParkedWait struct {
+	Session    string    `json:"session"`
+	Step       string    `json:"step,omitempty"`       // Step bead being waited on
+	Molecule   string    `json:"molecule,omitempty"`   // The step's molecule
+	AgentBead  string    `json:"agent_bead,omitempty"` // Its idle:N label counts timeouts
+	WorkDir    string    `json:"work_dir"`             // Beads workspace whose feed is the signal
+	IdleCycles int       `json:"idle_cycles,omitempty"`
+	ParkedAt   time.Time `json:"parked_at"`
+	Deadline   time.Time `json:"deadline"`
+}
+
+// ParkedWaits are the parked waits by session (daemon/waits.json).
+type ParkedWaits struct {
+	Waits map[string]*ParkedWait `json:"waits"`
+}
+
+// WaitsFile returns the path to the parked waits file.
+func WaitsFile(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "waits.json")
+}
+
+// LoadWaits loads the parked waits. A missing file has none.
+func LoadWaits(townRoot string) (*ParkedWaits, error) {
+	w := &ParkedWaits{Waits: make(map[string]*ParkedWait)}
+	data, err := os.ReadFile(WaitsFile(townRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return w, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, fmt.Errorf("parsing parked waits: %w", err)
+	}
+	if w.Waits == nil {
+		w.Waits = make(map[string]*ParkedWait)
+	}
+	return w, nil
+}
+
+// updateWaits applies fn to the parked waits under a file lock, so agents
+// parking and the daemon waking them don't lose each other's writes.
+func updateWaits(townRoot string, fn func(*ParkedWaits)) error {
+	path := WaitsFile(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	lock := flock.New(path + ".lock")
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("locking parked waits: %w", err)
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	w, err := LoadWaits(townRoot)
+	if err != nil {
+		return err
+	}
+	fn(w)
+	return util.AtomicWriteJSON(path, w)
+}
+
+// ParkWait hands a wait to the daemon, replacing any earlier wait of the
+// same session.
+func ParkWait(townRoot string, wait *ParkedWait) error {
+	return updateWaits(townRoot, func(w *ParkedWaits) {
+		w.Waits[wait.Session] = wait
+	})
+}
+
+// UnparkWait removes the parked wait of a session and returns it, or nil if
+// the session has none.
+func UnparkWait(townRoot, sessionName string) (*ParkedWait, error) {
+	var removed *ParkedWait
+	err := updateWaits(townRoot, func(w *ParkedWaits) {
+		removed = w.Waits[sessionName]
+		delete(w.Waits, sessionName)
+	})
+	return removed, err
+}
+
+// Sorted returns the parked waits ordered by deadline.
+func (w *ParkedWaits) Sorted() []*ParkedWait {
+	if w == nil {
+		return nil
+	}
+	waits := make([]*ParkedWait, 0, len(w.Waits))
+	for _, wait := range w.Waits {
+		waits = append(waits, wait)
+	}
+	sort.Slice(waits, func(i, j int) bool {
+		if !waits[i].Deadline.Equal(waits[j].Deadline) {
+			return waits[i].Deadline.Before(waits[j].Deadline)
+		}
+		return waits[i].Session < waits[j].Session
+	})
+	return waits
+}
+
+// Due returns the waits whose deadline has passed, ordered by deadline.
+func (w *ParkedWaits) Due(now time.Time) []*ParkedWait {
+	var due []*ParkedWait
+	for _, wait := range w.Sorted() {
+		if !wait.Deadline.After(now) {
+			due = append(due, wait)
+		}
+	}
+	return due
+}
+
+// NextDeadline returns the earliest deadline, and false if nothing is parked.
+func (w *ParkedWaits) NextDeadline() (time.Time, bool) {
+	waits := w.Sorted()
+	if len(waits) == 0 {
+		return time.Time{}, false
+	}
+	return waits[0].Deadline, true
+}
+
+// wakesOn reports whether activity on a bead ends the wait. A wait without
+// a step wakes on any activity, like await-signal in the session does.
+func (w *ParkedWait) wakesOn(bead string) bool {
+	if w.Step == "" {
+		return true
+	}
+	if bead == w.Step {
+		return true
+	}
+	return w.Molecule != "" && (bead == w.Molecule || strings.HasPrefix(bead, w.Molecule+"."))
+}
+
+// waitFeed is a bd activity --follow subscription for the parked waits of
+// one beads workspace.
+type waitFeed struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// waitSignal is a line of activity on the beads feed of a workspace.
+type waitSignal struct {
+	workDir string
+	bead    string // Bead the activity is on, "" if the line names none
+}
+
+const (
+	// waitSignalBuffer is how many feed lines may queue for the Run
+	// goroutine before further lines are dropped.
+	waitSignalBuffer = 64
+
+	// ownWriteWindow is how long after the daemon updates a bead its
+	// activity on the feed is taken to be the daemon's own.
+	ownWriteWindow = time.Minute
+)
+
+// feedBeadPattern matches the bead of a bd activity line:
+// [HH:MM:SS] SYMBOL BEAD_ID action · description
+var feedBeadPattern = regexp.MustCompile(`^\[\d{2}:\d{2}:\d{2}\]\s+\S+\s+(\S+)`)
+
+// feedLineBead returns the bead a line of bd activity is on, or "".
+func feedLineBead(line string) string {
+	if m := feedBeadPattern.FindStringSubmatch(line); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// isParked reports whether a session's agent is parked on a wait, so that
+// patrols and crash detection leave its dead session alone.
+func (d *Daemon) isParked(sessionName string) bool {
+	if d.waits == nil {
+		return false
+	}
+	_, ok := d.waits.Waits[sessionName]
+	return ok
+}
+
+// syncWaits reloads the parked waits and keeps a feed subscription running
+// for each workspace with waits on it.
+func (d *Daemon) syncWaits() {
+	waits, err := LoadWaits(d.config.TownRoot)
+	if err != nil {
+		d.logger.Printf("Warning: failed to load parked waits: %v", err)
+		return
+	}
+	d.waits = waits
+
+	needed := make(map[string]bool)
+	for _, wait := range waits.Waits {
+		needed[wait.WorkDir] = true
+	}
+	for workDir, feed := range d.waitFeeds {
+		select {
+		case <-feed.done:
+			delete(d.waitFeeds, workDir) // Subscription died; restarted below if still needed
+			continue
+		default:
+		}
+		if !needed[workDir] {
+			feed.cancel()
+			delete(d.waitFeeds, workDir)
+		}
+	}
+	for workDir := range needed {
+		if _, ok := d.waitFeeds[workDir]; !ok {
+			d.waitFeeds[workDir] = d.followWaitFeed(workDir)
+		}
+	}
+}
+
+// followWaitFeed subscribes to the beads feed of a workspace and reports
+// each line of activity on d.waitSignals.
+func (d *Daemon) followWaitFeed(workDir string) *waitFeed {
+	ctx, cancel := context.WithCancel(d.ctx)
+	feed := &waitFeed{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(feed.done)
+		cmd := exec.CommandContext(ctx, "bd", "activity", "--follow")
+		cmd.Dir = workDir
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			d.logger.Printf("Warning: feed subscription for parked waits in %s: %v", workDir, err)
+			return
+		}
+		if err := cmd.Start(); err != nil {
+			d.logger.Printf("Warning: feed subscription for parked waits in %s: %v", workDir, err)
+			return
+		}
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			select {
+			case d.waitSignals <- waitSignal{workDir: workDir, bead: feedLineBead(scanner.Text())}:
+			default: // Backlogged; the deadlines still wake the waits
+			}
+		}
+		_ = cmd.Wait()
+	}()
+	return feed
+}
+
+// wakeSignaledWaits wakes the waits parked on a workspace's feed that the
+// signaled activity concerns. Activity the daemon caused itself, such as
+// its idle:N updates, wakes nothing.
+func (d *Daemon) wakeSignaledWaits(sig waitSignal) {
+	if d.isOwnWrite(sig.bead, time.Now()) {
+		return
+	}
+	d.syncWaits()
+	woke := false
+	for _, wait := range d.waits.Sorted() {
+		if wait.WorkDir == sig.workDir && wait.wakesOn(sig.bead) {
+			d.wakeParked(wait, WakeSignal)
+			woke = true
+		}
+	}
+	if woke {
+		d.syncWaits()
+	}
+}
+
+// markOwnWrite records that the daemon updated a bead.
+func (d *Daemon) markOwnWrite(bead string, now time.Time) {
+	if d.ownWrites == nil {
+		d.ownWrites = make(map[string]time.Time)
+	}
+	d.ownWrites[bead] = now
+}
+
+// isOwnWrite reports whether activity on a bead is the daemon's own
+// update, forgetting updates older than ownWriteWindow.
+func (d *Daemon) isOwnWrite(bead string, now time.Time) bool {
+	for b, at := range d.ownWrites {
+		if now.Sub(at) > ownWriteWindow {
+			delete(d.ownWrites, b)
+		}
+	}
+	_, ok := d.ownWrites[bead]
+	return ok && bead != ""
+}
+
+// wakeDueWaits wakes the waits whose backoff has run out.
+func (d *Daemon) wakeDueWaits(now time.Time) {
+	due := d.waits.Due(now)
+	for _, wait := range due {
+		d.wakeParked(wait, WakeTimeout)
+	}
+	if len(due) > 0 {
+		d.syncWaits()
+	}
+}
+
+// wakeParked unparks a wait and brings its agent back: a live session is
+// nudged, a dead one restarted. On timeout the agent bead's idle:N label is
+// incremented, as await-signal does, so the next wait backs off further.
+func (d *Daemon) wakeParked(wait *ParkedWait, reason string) {
+	removed, err := UnparkWait(d.config.TownRoot, wait.Session)
+	if err != nil {
+		d.logger.Printf("Error unparking wait of %s: %v", wait.Session, err)
+		return
+	}
+	if removed == nil {
+		return // Already woken
+	}
+	delete(d.waits.Waits, wait.Session)
+
+	elapsed := time.Since(removed.ParkedAt).Round(time.Second)
+	d.logger.Printf("Waking %s from parked wait on %s: %s after %s", removed.Session, removed.Step, reason, elapsed)
+
+	if reason == WakeTimeout && removed.AgentBead != "" {
+		if err := d.setIdleCycles(removed, removed.IdleCycles+1); err != nil {
+			d.logger.Printf("Warning: failed to update idle count of %s: %v", removed.AgentBead, err)
+		}
+	}
+
+	running, err := d.tmux.HasSession(removed.Session)
+	if err != nil {
+		d.logger.Printf("Error checking session %s: %v", removed.Session, err)
+		return
+	}
+	if !running {
+		if err := d.restartParked(removed.Session); err != nil {
+			d.logger.Printf("Error restarting %s after parked wait: %v", removed.Session, err)
+			return
+		}
+	}
+	if err := d.tmux.NudgeSession(removed.Session, wakeMessage(removed, reason, elapsed)); err != nil {
+		d.logger.Printf("Warning: failed to nudge %s: %v", removed.Session, err)
+	}
+}
+
+// wakeMessage tells a woken agent why its wait ended.
+func wakeMessage(wait *ParkedWait, reason string, elapsed time.Duration) string {
+	step := wait.Step
+	if step == "" {
+		step = "wait step"
+	}
+	msg := fmt.Sprintf("WAIT_DONE: %s on %s after %s", reason, step, elapsed)
+	if reason == WakeTimeout && wait.AgentBead != "" {
+		msg += fmt.Sprintf(" (idle cycle %d)", wait.IdleCycles+1)
+	}
+	return msg + " - continue your molecule"
+}
+
+// restartParked starts the session of a parked agent the same way the
+// patrols and crash detection would have, had it not been parked.
+func (d *Daemon) restartParked(sessionName string) error {
+	identity, err := session.ParseSessionName(sessionName)
+	if err != nil {
+		return err
+	}
+	switch identity.Role {
+	case session.RoleDeacon:
+		d.ensureDeaconRunning()
+	case session.RoleWitness:
+		d.ensureWitnessRunning(identity.Rig)
+	case session.RoleRefinery:
+		d.ensureRefineryRunning(identity.Rig)
+	case session.RolePolecat:
+		return d.restartPolecatSession(identity.Rig, identity.Name, sessionName, "")
+	case session.RoleMayor:
+		return d.restartSession(sessionName, "mayor")
+	case session.RoleCrew:
+		return d.restartSession(sessionName, identity.Rig+"-crew-"+identity.Name)
+	default:
+		return fmt.Errorf("unknown role %q", identity.Role)
+	}
+	return nil
+}
+
+// setIdleCycles replaces the idle:N label of a parked wait's agent bead.
+func (d *Daemon) setIdleCycles(wait *ParkedWait, cycles int) error {
+	b := beads.New(wait.WorkDir)
+	issue, err := b.Show(wait.AgentBead)
+	if err != nil {
+		return err
+	}
+	var labels []string
+	for _, label := range issue.Labels {
+		if !strings.HasPrefix(label, "idle:") {
+			labels = append(labels, label)
+		}
+	}
+	labels = append(labels, fmt.Sprintf("idle:%d", cycles))
+	d.markOwnWrite(wait.AgentBead, time.Now())
+	return b.Update(wait.AgentBead, beads.UpdateOptions{SetLabels: labels})
+}
@@ -0,0 +1,289 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+func TestParkAndUnparkWait(t *testing.T) {
+	townRoot := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	waits := []*ParkedWait{
+		{Session: "gt-gastown-witness", Step: "gt-w1", WorkDir: "/town/gastown", ParkedAt: now, Deadline: now.Add(5 * time.Minute)},
+		{Session: "hq-deacon", Step: "hq-d1", WorkDir: "/town", ParkedAt: now, Deadline: now.Add(time.Minute)},
+	}
+	for _, w := range waits {
+		if err := ParkWait(townRoot, w); err != nil {
+			t.Fatalf("ParkWait(%s): %v", w.Session, err)
+		}
+	}
+
+	loaded, err := LoadWaits(townRoot)
+	if err != nil {
+		t.Fatalf("LoadWaits: %v", err)
+	}
+	if len(loaded.Waits) != 2 {
+		t.Fatalf("loaded %d waits, want 2", len(loaded.Waits))
+	}
+	if next, ok := loaded.NextDeadline(); !ok || !next.Equal(now.Add(time.Minute)) {
+		t.Errorf("NextDeadline = %v, %v; want %v", next, ok, now.Add(time.Minute))
+	}
+	if due := loaded.Due(now.Add(2 * time.Minute)); len(due) != 1 || due[0].Session != "hq-deacon" {
+		t.Errorf("Due after 2m = %v, want hq-deacon only", due)
+	}
+
+	removed, err := UnparkWait(townRoot, "hq-deacon")
+	if err != nil || removed == nil || removed.Step != "hq-d1" {
+		t.Fatalf("UnparkWait = %v, %v; want the deacon's wait", removed, err)
+	}
+	if again, err := UnparkWait(townRoot, "hq-deacon"); err != nil || again != nil {
+		t.Errorf("second UnparkWait = %v, %v; want nil", again, err)
+	}
+	loaded, _ = LoadWaits(townRoot)
+	if len(loaded.Waits) != 1 {
+		t.Errorf("%d waits left, want 1", len(loaded.Waits))
+	}
+}
+
+func TestNextDeadline_NothingParked(t *testing.T) {
+	var waits *ParkedWaits
+	if _, ok := waits.NextDeadline(); ok {
+		t.Error("NextDeadline of nil waits reported a deadline")
+	}
+}
+
+func TestWakeMessage(t *testing.T) {
+	w := &ParkedWait{Step: "gt-patrol-await", AgentBead: "gt-gastown-witness", IdleCycles: 2}
+	if got, want := wakeMessage(w, WakeTimeout, 4*time.Minute), "WAIT_DONE: timeout on gt-patrol-await after 4m0s (idle cycle 3) - continue your molecule"; got != want {
+		t.Errorf("wakeMessage(timeout) = %q, want %q", got, want)
+	}
+	if got, want := wakeMessage(w, WakeSignal, 30*time.Second), "WAIT_DONE: signal on gt-patrol-await after 30s - continue your molecule"; got != want {
+		t.Errorf("wakeMessage(signal) = %q, want %q", got, want)
+	}
+}
+
+// fakeSessions is an in-memory tmux: sessions in alive exist, and every
+// call is recorded as "Method session".
+type fakeSessions struct {
+	alive map[string]bool
+	calls []string
+}
+
+func (f *fakeSessions) record(method, session string) { f.calls = append(f.calls, method+" "+session) }
+
+func (f *fakeSessions) called(method, session string) bool {
+	return slices.Contains(f.calls, method+" "+session)
+}
+
+func (f *fakeSessions) IsAvailable() bool { return true }
+func (f *fakeSessions) HasSession(name string) (bool, error) {
+	f.record("HasSession", name)
+	return f.alive[name], nil
+}
+func (f *fakeSessions) EnsureSessionFresh(name, workDir string) error {
+	f.record("EnsureSessionFresh", name)
+	f.alive[name] = true
+	return nil
+}
+func (f *fakeSessions) KillSession(name string) error {
+	f.record("KillSession", name)
+	delete(f.alive, name)
+	return nil
+}
+func (f *fakeSessions) IsClaudeRunning(session string) bool {
+	f.record("IsClaudeRunning", session)
+	return f.alive[session]
+}
+func (f *fakeSessions) WaitForCommand(session string, excludeCommands []string, timeout time.Duration) error {
+	return nil
+}
+func (f *fakeSessions) AcceptBypassPermissionsWarning(session string) error { return nil }
+func (f *fakeSessions) ConfigureGasTownSession(session string, theme tmux.Theme, rig, worker, role string) error {
+	return nil
+}
+func (f *fakeSessions) SetPaneDiedHook(session, agentID string) error               { return nil }
+func (f *fakeSessions) GetEnvironment(session, key string) (string, error)          { return "", nil }
+func (f *fakeSessions) GetAllEnvironment(session string) (map[string]string, error) { return nil, nil }
+func (f *fakeSessions) SetEnvironment(session, key, value string) error             { return nil }
+func (f *fakeSessions) CapturePane(session string, lines int) (string, error)       { return "", nil }
+func (f *fakeSessions) SendKeys(session, keys string) error {
+	f.record("SendKeys", session)
+	return nil
+}
+func (f *fakeSessions) SendKeysRaw(session, keys string) error { return nil }
+func (f *fakeSessions) NudgeSession(session, message string) error {
+	f.record("NudgeSession", session)
+	return nil
+}
+
+// testWaitDaemon returns a daemon over a fake tmux with the given waits
+// parked in a fresh town.
+func testWaitDaemon(t *testing.T, waits ...*ParkedWait) (*Daemon, *fakeSessions) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	fake := &fakeSessions{alive: make(map[string]bool)}
+	d := &Daemon{
+		config:      &Config{TownRoot: t.TempDir()},
+		tmux:        fake,
+		logger:      log.New(io.Discard, "", 0),
+		ctx:         ctx,
+		waits:       &ParkedWaits{Waits: make(map[string]*ParkedWait)},
+		waitFeeds:   make(map[string]*waitFeed),
+		waitSignals: make(chan waitSignal, waitSignalBuffer),
+	}
+	for _, w := range waits {
+		if err := ParkWait(d.config.TownRoot, w); err != nil {
+			t.Fatalf("ParkWait(%s): %v", w.Session, err)
+		}
+	}
+	d.syncWaits()
+	return d, fake
+}
+
+func TestParkedWaitWakesOn(t *testing.T) {
+	onStep := &ParkedWait{Step: "gt-abc.3", Molecule: "gt-abc"}
+	anyActivity := &ParkedWait{}
+	for _, tc := range []struct {
+		wait *ParkedWait
+		bead string
+		want bool
+	}{
+		{onStep, "gt-abc.3", true},
+		{onStep, "gt-abc", true},
+		{onStep, "gt-abc.1", true},
+		{onStep, "gt-abcd", false},
+		{onStep, "gt-gastown-witness", false},
+		{onStep, "", false},
+		{&ParkedWait{Step: "gt-abc.3"}, "gt-abc.1", false},
+		{anyActivity, "gt-xyz", true},
+		{anyActivity, "", true},
+	} {
+		if got := tc.wait.wakesOn(tc.bead); got != tc.want {
+			t.Errorf("%+v wakesOn(%q) = %v, want %v", tc.wait, tc.bead, got, tc.want)
+		}
+	}
+}
+
+func TestFeedLineBead(t *testing.T) {
+	if got := feedLineBead("[14:02:11] → gt-abc.3 updated · status: in_progress"); got != "gt-abc.3" {
+		t.Errorf("feedLineBead = %q, want gt-abc.3", got)
+	}
+	if got := feedLineBead("something else"); got != "" {
+		t.Errorf("feedLineBead(unparsed) = %q, want empty", got)
+	}
+}
+
+func TestWakeSignaledWaits_FiltersActivity(t *testing.T) {
+	now := time.Now()
+	d, fake := testWaitDaemon(t,
+		&ParkedWait{Session: "gt-gastown-nux", Step: "gt-abc.3", Molecule: "gt-abc", WorkDir: "/town/gastown",
+			ParkedAt: now, Deadline: now.Add(time.Hour)},
+		&ParkedWait{Session: "gt-gastown-witness", AgentBead: "gt-gastown-witness", WorkDir: "/town/gastown",
+			ParkedAt: now, Deadline: now.Add(time.Hour)},
+	)
+	fake.alive["gt-gastown-nux"] = true
+	fake.alive["gt-gastown-witness"] = true
+
+	// The daemon's own idle:N update wakes nobody
+	d.markOwnWrite("gt-gastown-witness", now)
+	d.wakeSignaledWaits(waitSignal{workDir: "/town/gastown", bead: "gt-gastown-witness"})
+	if len(d.waits.Waits) != 2 {
+		t.Fatalf("own write woke waits: %d left, want 2", len(d.waits.Waits))
+	}
+
+	// Activity elsewhere wakes the witness, not the step wait
+	d.wakeSignaledWaits(waitSignal{workDir: "/town/gastown", bead: "gt-other"})
+	if !fake.called("NudgeSession", "gt-gastown-witness") || fake.called("NudgeSession", "gt-gastown-nux") {
+		t.Fatalf("after unrelated activity: calls = %v", fake.calls)
+	}
+
+	// Activity in another workspace wakes nothing; in the molecule, the step wait
+	d.wakeSignaledWaits(waitSignal{workDir: "/town/other", bead: "gt-abc.1"})
+	if fake.called("NudgeSession", "gt-gastown-nux") {
+		t.Fatal("activity in another workspace woke the step wait")
+	}
+	d.wakeSignaledWaits(waitSignal{workDir: "/town/gastown", bead: "gt-abc.1"})
+	if !fake.called("NudgeSession", "gt-gastown-nux") {
+		t.Errorf("molecule activity didn't wake the step wait: calls = %v", fake.calls)
+	}
+	if loaded, _ := LoadWaits(d.config.TownRoot); len(loaded.Waits) != 0 {
+		t.Errorf("%d waits still parked, want 0", len(loaded.Waits))
+	}
+}
+
+func TestWakeParked_LiveSessionNudged(t *testing.T) {
+	now := time.Now()
+	wait := &ParkedWait{Session: "gt-gastown-witness", WorkDir: "/town/gastown", ParkedAt: now, Deadline: now}
+	d, fake := testWaitDaemon(t, wait)
+	fake.alive[wait.Session] = true
+
+	d.wakeParked(wait, WakeSignal)
+	if !fake.called("NudgeSession", wait.Session) || fake.called("EnsureSessionFresh", wait.Session) {
+		t.Errorf("calls = %v, want a nudge without restart", fake.calls)
+	}
+	if d.isParked(wait.Session) {
+		t.Error("session still parked after waking")
+	}
+
+	// A second wake of the same wait does nothing
+	fake.calls = nil
+	d.wakeParked(wait, WakeSignal)
+	if len(fake.calls) != 0 {
+		t.Errorf("second wake: calls = %v", fake.calls)
+	}
+}
+
+func TestWakeParked_DeadSessionRestarted(t *testing.T) {
+	now := time.Now()
+	wait := &ParkedWait{Session: "gt-gastown-nux", WorkDir: "/town/gastown", ParkedAt: now, Deadline: now}
+	d, fake := testWaitDaemon(t, wait)
+	if err := os.MkdirAll(filepath.Join(d.config.TownRoot, "gastown", "polecats", "nux"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	d.wakeParked(wait, WakeSignal)
+	want := []string{"HasSession gt-gastown-nux", "EnsureSessionFresh gt-gastown-nux", "SendKeys gt-gastown-nux", "NudgeSession gt-gastown-nux"}
+	var got []string
+	for _, call := range fake.calls {
+		if slices.Contains(want, call) {
+			got = append(got, call)
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v in order", fake.calls, want)
+	}
+}
+
+func TestParkedSessionSkipsCrashDetectionAndPatrols(t *testing.T) {
+	now := time.Now()
+	var waits []*ParkedWait
+	for _, s := range []string{"gt-gastown-nux", "gt-gastown-witness", "gt-gastown-refinery", "hq-deacon"} {
+		waits = append(waits, &ParkedWait{Session: s, WorkDir: "/town/gastown", ParkedAt: now, Deadline: now.Add(time.Hour)})
+	}
+	d, fake := testWaitDaemon(t, waits...)
+
+	// The dead session is seen, but not taken for a crash
+	d.checkPolecatHealth("gastown", "nux")
+	if len(d.recentDeaths) != 0 || !slices.Equal(fake.calls, []string{"HasSession gt-gastown-nux"}) {
+		t.Errorf("crash detection of a parked polecat: deaths = %d, calls = %v", len(d.recentDeaths), fake.calls)
+	}
+
+	// Patrols leave parked sessions alone without touching tmux
+	fake.calls = nil
+	d.ensureDeaconRunning()
+	d.ensureWitnessRunning("gastown")
+	d.ensureRefineryRunning("gastown")
+	if len(fake.calls) != 0 {
+		t.Errorf("patrols of parked sessions: calls = %v", fake.calls)
+	}
+}
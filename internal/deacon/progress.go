@@ -0,0 +1,223 @@
+package deacon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Activity classifies what a session appears to be doing, based on how its
+// observable state changed over recent samples.
+type Activity string
+
+const (
+	// ActivityUnknown means there aren't enough samples to judge.
+	ActivityUnknown Activity = "unknown"
+	// ActivityWorking means the session is making observable progress
+	// (new pane output, commits, worktree edits, bead updates, or a runtime busy indicator).
+	ActivityWorking Activity = "working"
+	// ActivityIdle means nothing observable has changed.
+	ActivityIdle Activity = "idle"
+	// ActivityLooping means the pane keeps changing but cycles through the same
+	// few screens with no commits, edits or bead updates.
+	ActivityLooping Activity = "looping"
+	// ActivityWaitingInput means the session is blocked on an interactive prompt.
+	ActivityWaitingInput Activity = "waiting_for_input"
+)
+
+// Progress detection parameters.
+const (
+	// MaxProgressSamples bounds the sample history kept per agent.
+	MaxProgressSamples = 12
+
+	// minLoopSamples is how many samples are needed before calling a session looping.
+	minLoopSamples = 4
+
+	// maxLoopDistinctScreens is the most distinct pane fingerprints a looping
+	// session may cycle through within the sample window.
+	maxLoopDistinctScreens = 2
+
+	// progressTailLines is how many trailing pane lines are inspected for
+	// prompts and busy indicators.
+	progressTailLines = 15
+)
+
+// ProgressSample is one observation of an agent's externally visible state.
+// Only fingerprints are stored, never raw pane content.
+type ProgressSample struct {
+	Time          time.Time `json:"time"`
+	PaneHash      string    `json:"pane_hash,omitempty"`
+	GitHead       string    `json:"git_head,omitempty"`
+	WorktreeHash  string    `json:"worktree_hash,omitempty"`
+	BeadUpdatedAt time.Time `json:"bead_updated_at,omitempty"`
+	Busy          bool      `json:"busy,omitempty"`
+	WaitingInput  bool      `json:"waiting_input,omitempty"`
+}
+
+// ProgressInputs is the raw material for a sample, gathered by the caller.
+type ProgressInputs struct {
+	Pane          string    // Captured pane output
+	GitHead       string    // HEAD commit of the agent's worktree
+	WorktreeState string    // Any stable rendering of `git status` (hashed)
+	BeadUpdatedAt time.Time // Agent bead updated_at
+}
+
+// inputPromptPatterns match interactive prompts that block a session until a
+// human (or auto-answer rule) responds.
+var inputPromptPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)do you want to (proceed|continue|make this edit|create|run)`),
+	regexp.MustCompile(`(?i)\(y/n\)|\[y/n\]|\[Y/n\]|\[y/N\]`),
+	regexp.MustCompile(`(?i)press enter to continue`),
+	regexp.MustCompile(`(?i)do you trust the files in this folder`),
+	regexp.MustCompile(`❯\s*1\.\s*Yes`),
+}
+
+// busyIndicatorPatterns match runtime status lines shown while a tool or
+// model call is in flight (e.g., a long test run).
+var busyIndicatorPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)esc to interrupt`),
+	regexp.MustCompile(`(?i)ctrl\+c to interrupt`),
+}
+
+// NewProgressSample fingerprints the given inputs.
+func NewProgressSample(now time.Time, in ProgressInputs) ProgressSample {
+	tail := lastLines(in.Pane, progressTailLines)
+	return ProgressSample{
+		Time:          now.UTC(),
+		PaneHash:      fingerprint(normalizePane(in.Pane)),
+		GitHead:       in.GitHead,
+		WorktreeHash:  fingerprint(in.WorktreeState),
+		BeadUpdatedAt: in.BeadUpdatedAt,
+		Busy:          matchesAny(busyIndicatorPatterns, tail),
+		WaitingInput:  matchesAny(inputPromptPatterns, tail),
+	}
+}
+
+// RecordProgress appends a sample to the agent's history, keeping at most
+// MaxProgressSamples, and returns the updated classification.
+func (s *AgentHealthState) RecordProgress(sample ProgressSample) Activity {
+	s.Progress = append(s.Progress, sample)
+	if len(s.Progress) > MaxProgressSamples {
+		s.Progress = s.Progress[len(s.Progress)-MaxProgressSamples:]
+	}
+	s.Activity = ClassifyProgress(s.Progress)
+	return s.Activity
+}
+
+// ClassifyProgress classifies a session from its sample history (oldest first).
+//
+// The most recent sample decides prompts and busy indicators; otherwise the
+// last two samples are compared for progress signals, and the wider window
+// is used to tell genuine output from a session cycling between a couple of
+// screens.
+func ClassifyProgress(samples []ProgressSample) Activity {
+	if len(samples) == 0 {
+		return ActivityUnknown
+	}
+	latest := samples[len(samples)-1]
+
+	if latest.WaitingInput {
+		return ActivityWaitingInput
+	}
+	if latest.Busy {
+		return ActivityWorking
+	}
+	if len(samples) < 2 {
+		return ActivityUnknown
+	}
+
+	prev := samples[len(samples)-2]
+	if changed(prev.GitHead, latest.GitHead) ||
+		changed(prev.WorktreeHash, latest.WorktreeHash) ||
+		latest.BeadUpdatedAt.After(prev.BeadUpdatedAt) {
+		return ActivityWorking
+	}
+
+	if !changed(prev.PaneHash, latest.PaneHash) {
+		return ActivityIdle
+	}
+
+	// Pane changed but nothing else did. Over a long enough window, a session
+	// flipping between a couple of screens is looping, not working.
+	if len(samples) >= minLoopSamples && isLooping(samples) {
+		return ActivityLooping
+	}
+	return ActivityWorking
+}
+
+// RespondedToPing reports whether a sample taken after a health check ping
+// shows the session acting on it. Only commits, worktree edits, bead updates
+// and a busy indicator count: a changed pane may just be the ping wrapping or
+// scrolling the screen, which a hung session does too.
+func RespondedToPing(before, after ProgressSample) bool {
+	return after.Busy ||
+		changed(before.GitHead, after.GitHead) ||
+		changed(before.WorktreeHash, after.WorktreeHash) ||
+		after.BeadUpdatedAt.After(before.BeadUpdatedAt)
+}
+
+// isLooping reports whether every sample in the window has the same git and
+// bead state and the pane cycles through at most maxLoopDistinctScreens screens.
+func isLooping(samples []ProgressSample) bool {
+	first := samples[0]
+	screens := make(map[string]bool)
+	for _, s := range samples {
+		if s.GitHead != first.GitHead || s.WorktreeHash != first.WorktreeHash ||
+			!s.BeadUpdatedAt.Equal(first.BeadUpdatedAt) {
+			return false
+		}
+		screens[s.PaneHash] = true
+	}
+	return len(screens) <= maxLoopDistinctScreens
+}
+
+// changed reports whether a signal changed between samples. An empty value
+// means the signal couldn't be read, which is not evidence of progress.
+func changed(before, after string) bool {
+	return before != "" && after != "" && before != after
+}
+
+// healthCheckMarker identifies health check nudges echoed into the pane.
+const healthCheckMarker = "HEALTH_CHECK"
+
+// normalizePane strips trailing whitespace and blank lines so cursor and
+// resize noise doesn't register as progress. Echoed health checks are dropped
+// too, otherwise the ping itself would look like output.
+func normalizePane(pane string) string {
+	lines := strings.Split(pane, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line != "" && !strings.Contains(line, healthCheckMarker) {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func fingerprint(s string) string {
+	if s == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
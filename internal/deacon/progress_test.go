@@ -0,0 +1,169 @@
+package deacon
+
+import (
+	"testing"
+	"time"
+)
+
+func sampleAt(offset time.Duration, in ProgressInputs) ProgressSample {
+	return NewProgressSample(time.Unix(0, 0).Add(offset), in)
+}
+
+func TestClassifyProgress(t *testing.T) {
+	base := ProgressInputs{Pane: "$ go test ./...\nok", GitHead: "abc", WorktreeState: "clean"}
+
+	tests := []struct {
+		name    string
+		samples []ProgressSample
+		want    Activity
+	}{
+		{
+			name: "no samples",
+			want: ActivityUnknown,
+		},
+		{
+			name:    "single sample",
+			samples: []ProgressSample{sampleAt(0, base)},
+			want:    ActivityUnknown,
+		},
+		{
+			name:    "nothing changed",
+			samples: []ProgressSample{sampleAt(0, base), sampleAt(time.Minute, base)},
+			want:    ActivityIdle,
+		},
+		{
+			name: "new commit",
+			samples: []ProgressSample{
+				sampleAt(0, base),
+				sampleAt(time.Minute, ProgressInputs{Pane: base.Pane, GitHead: "def", WorktreeState: "clean"}),
+			},
+			want: ActivityWorking,
+		},
+		{
+			name: "bead updated",
+			samples: []ProgressSample{
+				sampleAt(0, base),
+				sampleAt(time.Minute, ProgressInputs{Pane: base.Pane, GitHead: "abc", WorktreeState: "clean",
+					BeadUpdatedAt: time.Unix(100, 0)}),
+			},
+			want: ActivityWorking,
+		},
+		{
+			name: "long test run shows busy indicator",
+			samples: []ProgressSample{
+				sampleAt(0, base),
+				sampleAt(time.Minute, ProgressInputs{Pane: "Running tests… (esc to interrupt)", GitHead: "abc"}),
+			},
+			want: ActivityWorking,
+		},
+		{
+			name: "permission prompt",
+			samples: []ProgressSample{
+				sampleAt(0, base),
+				sampleAt(time.Minute, ProgressInputs{Pane: "Bash(rm -rf build)\nDo you want to proceed?\n❯ 1. Yes\n  2. No"}),
+			},
+			want: ActivityWaitingInput,
+		},
+		{
+			name: "flipping between two screens",
+			samples: []ProgressSample{
+				sampleAt(0, ProgressInputs{Pane: "screen A", GitHead: "abc"}),
+				sampleAt(time.Minute, ProgressInputs{Pane: "screen B", GitHead: "abc"}),
+				sampleAt(2*time.Minute, ProgressInputs{Pane: "screen A", GitHead: "abc"}),
+				sampleAt(3*time.Minute, ProgressInputs{Pane: "screen B", GitHead: "abc"}),
+			},
+			want: ActivityLooping,
+		},
+		{
+			name: "fresh output each sample",
+			samples: []ProgressSample{
+				sampleAt(0, ProgressInputs{Pane: "line 1", GitHead: "abc"}),
+				sampleAt(time.Minute, ProgressInputs{Pane: "line 1\nline 2", GitHead: "abc"}),
+				sampleAt(2*time.Minute, ProgressInputs{Pane: "line 1\nline 2\nline 3", GitHead: "abc"}),
+				sampleAt(3*time.Minute, ProgressInputs{Pane: "line 1\nline 2\nline 3\nline 4", GitHead: "abc"}),
+			},
+			want: ActivityWorking,
+		},
+		{
+			name: "echoed health check is not progress",
+			samples: []ProgressSample{
+				sampleAt(0, base),
+				sampleAt(time.Minute, ProgressInputs{Pane: base.Pane + "\n> HEALTH_CHECK: respond", GitHead: "abc", WorktreeState: "clean"}),
+			},
+			want: ActivityIdle,
+		},
+		{
+			name: "capture failure is not progress",
+			samples: []ProgressSample{
+				sampleAt(0, base),
+				sampleAt(time.Minute, ProgressInputs{}),
+			},
+			want: ActivityIdle,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyProgress(tt.samples); got != tt.want {
+				t.Errorf("ClassifyProgress() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordProgress_BoundsHistory(t *testing.T) {
+	state := &AgentHealthState{AgentID: "gastown/polecats/max"}
+	for i := 0; i < MaxProgressSamples+5; i++ {
+		state.RecordProgress(sampleAt(time.Duration(i)*time.Minute, ProgressInputs{Pane: "same"}))
+	}
+	if len(state.Progress) != MaxProgressSamples {
+		t.Errorf("history length = %d, want %d", len(state.Progress), MaxProgressSamples)
+	}
+	if state.Activity != ActivityIdle {
+		t.Errorf("Activity = %s, want idle", state.Activity)
+	}
+}
+
+func TestRespondedToPing(t *testing.T) {
+	before := sampleAt(0, ProgressInputs{Pane: "❯ ", GitHead: "abc", WorktreeState: "clean"})
+
+	tests := []struct {
+		name  string
+		after ProgressInputs
+		want  bool
+	}{
+		{
+			name:  "pane redrawn only",
+			after: ProgressInputs{Pane: "❯ \n(wrapped ping text)", GitHead: "abc", WorktreeState: "clean"},
+			want:  false,
+		},
+		{
+			name:  "busy indicator",
+			after: ProgressInputs{Pane: "Thinking… (esc to interrupt)", GitHead: "abc", WorktreeState: "clean"},
+			want:  true,
+		},
+		{
+			name:  "new commit",
+			after: ProgressInputs{Pane: "❯ ", GitHead: "def", WorktreeState: "clean"},
+			want:  true,
+		},
+		{
+			name:  "worktree edited",
+			after: ProgressInputs{Pane: "❯ ", GitHead: "abc", WorktreeState: "M main.go"},
+			want:  true,
+		},
+		{
+			name:  "bead updated",
+			after: ProgressInputs{Pane: "❯ ", GitHead: "abc", WorktreeState: "clean", BeadUpdatedAt: time.Unix(100, 0)},
+			want:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RespondedToPing(before, sampleAt(time.Minute, tt.after)); got != tt.want {
+				t.Errorf("RespondedToPing() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
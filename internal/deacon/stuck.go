@@ -87,6 +87,12 @@ type AgentHealthState struct {
 
 	// ForceKillCount is total number of force-kills for this agent
 	ForceKillCount int `json:"force_kill_count"`
+
+	// Progress is the recent history of progress samples (oldest first)
+	Progress []ProgressSample `json:"progress,omitempty"`
+
+	// Activity is the classification from the latest progress sample
+	Activity Activity `json:"activity,omitempty"`
 }
 
 // HealthCheckState holds health check state for all monitored agents.
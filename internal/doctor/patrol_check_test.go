@@ -507,7 +507,7 @@ func TestPatrolHooksWiredCheck_FixPreservesExisting(t *testing.T) {
 		Type:    "daemon-patrol-config",
 		Version: 1,
 		Patrols: map[string]config.PatrolConfig{
-			"custom": {Enabled: true, Agent: "custom-agent"},
+			"custom": {Agent: "custom-agent"},
 		},
 	}
 	path := config.DaemonPatrolConfigPath(tmpDir)
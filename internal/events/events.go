@@ -1,7 +1,13 @@
 // Package events provides event logging for the gt activity feed.
 //
 // Events are written to ~/gt/.events.jsonl (raw audit log) and later
-// curated by the feed daemon into ~/.feed.jsonl (user-facing).
+// curated by the feed daemon into ~/.feed.jsonl (user-facing). Both logs are
+// rotated by the daemon; use logrotate to read across rotated segments.
+//
+// Every event type is registered in schema.go with a typed payload struct.
+// Payloads are validated on write, and ones that don't match are recorded
+// with an "invalid" field rather than dropped; consumers decode them with
+// Decode and Event.Data. `gt events schema` dumps the registry as JSON Schema.
 package events
 
 import (
@@ -12,17 +18,24 @@ import (
 	"sync"
 	"time"
 
+	"github.com/steveyegge/gastown/internal/logrotate"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 // Event represents an activity event in Gas Town.
 type Event struct {
 	Timestamp  string                 `json:"ts"`
+	Version    int                    `json:"v,omitempty"` // SchemaVersion; 0 for events written before versioning
 	Source     string                 `json:"source"`
 	Type       string                 `json:"type"`
 	Actor      string                 `json:"actor"`
 	Payload    map[string]interface{} `json:"payload,omitempty"`
 	Visibility string                 `json:"visibility"`
+
+	// Invalid holds the schema validation error of a payload that didn't
+	// match its registered schema. Such events are still recorded so they
+	// aren't lost, but Event.Data may fail to decode them.
+	Invalid string `json:"invalid,omitempty"`
 }
 
 // Visibility levels for events.
@@ -53,6 +66,10 @@ const (
 	// Session death events (for crash investigation)
 	TypeSessionDeath = "session_death" // Feed-visible session termination
 	TypeMassDeath    = "mass_death"    // Multiple sessions died in short window
+	TypeCrashLoop    = "crash_loop"    // Session quarantined after repeated deaths
+
+	// Propulsion events (emitted by the daemon)
+	TypeGUPPViolation = "gupp_violation" // Hooked work not progressing
 
 	// Witness patrol events
 	TypePatrolStarted   = "patrol_started"
@@ -66,6 +83,21 @@ const (
 	TypeMerged       = "merged"
 	TypeMergeFailed  = "merge_failed"
 	TypeMergeSkipped = "merge_skipped"
+
+	// Shutdown dance events (emitted by the daemon's dog pool)
+	TypeWarrantFiled  = "warrant_filed"
+	TypeDancePardoned = "dance_pardoned"
+	TypeDanceExecuted = "dance_executed"
+	TypeDanceFailed   = "dance_failed"
+
+	// Interactive prompt handling (emitted by health checks)
+	TypePromptAnswered = "prompt_answered"
+
+	// Tracing (see internal/trace)
+	TypeSpan = "span"
+
+	// Operator actions taken from the web dashboard (audit only)
+	TypeDashboardAction = "dashboard_action"
 )
 
 // EventsFile is the name of the raw events log.
@@ -76,17 +108,37 @@ var mutex sync.Mutex
 
 // Log writes an event to the events log.
 // The event is appended to ~/gt/.events.jsonl.
-// Returns nil if logging fails (events are best-effort).
+// Returns nil if logging fails (events are best-effort). A payload that
+// doesn't match its registered schema is a bug in the caller: the event is
+// still written, tagged invalid, a warning goes to stderr, and the
+// ErrInvalidPayload error is returned.
 func Log(eventType, actor string, payload map[string]interface{}, visibility string) error {
+	event := newEvent(eventType, actor, payload, visibility)
+	if event.Invalid != "" {
+		fmt.Fprintf(os.Stderr, "warning: recording invalid %s event: %s\n", eventType, event.Invalid)
+	}
+	if err := write(event); err != nil {
+		return err
+	}
+	return Validate(eventType, payload)
+}
+
+// newEvent builds an event stamped with the current time and schema version,
+// tagging it invalid if the payload doesn't match its schema.
+func newEvent(eventType, actor string, payload map[string]interface{}, visibility string) Event {
 	event := Event{
 		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		Version:    SchemaVersion,
 		Source:     "gt",
 		Type:       eventType,
 		Actor:      actor,
 		Payload:    payload,
 		Visibility: visibility,
 	}
-	return write(event)
+	if err := Validate(eventType, payload); err != nil {
+		event.Invalid = err.Error()
+	}
+	return event
 }
 
 // LogFeed is a convenience wrapper for feed-visible events.
@@ -121,13 +173,8 @@ func write(event Event) error {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	f, err := os.OpenFile(eventsPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) //nolint:gosec // G302: events file is non-sensitive operational data
-	if err != nil {
-		return fmt.Errorf("opening events file: %w", err)
-	}
-	defer f.Close()
-
-	if _, err := f.Write(data); err != nil {
+	// Append under the rotation lock so the daemon never rotates mid-write
+	if err := logrotate.Append(eventsPath, data, 0644); err != nil {
 		return fmt.Errorf("writing event: %w", err)
 	}
 
@@ -309,6 +356,123 @@ func MassDeathPayload(count int, window string, sessions []string, possibleCause
 	return p
 }
 
+// GUPPViolationPayload creates a payload for GUPP violation events.
+// agent: agent bead ID of the stuck agent
+// hookBead: the bead on the agent's hook
+// stuck: how long the agent has gone without progress (e.g., "45m0s")
+func GUPPViolationPayload(agent, hookBead, stuck string) map[string]interface{} {
+	return map[string]interface{}{
+		"agent":     agent,
+		"hook_bead": hookBead,
+		"stuck":     stuck,
+	}
+}
+
+// DashboardActionPayload creates a payload for dashboard action events.
+// action: the action taken (e.g., "sling", "mq_retry")
+// target: what it acted on (bead, MR, polecat address or convoy)
+// detail: action arguments such as a rig or reason (may be empty)
+// remote: the client address the request came from
+// err: the action's error, or nil if it succeeded
+func DashboardActionPayload(action, target, detail, remote string, err error) map[string]interface{} {
+	p := map[string]interface{}{
+		"action": action,
+		"target": target,
+		"remote": remote,
+		"result": "ok",
+	}
+	if detail != "" {
+		p["detail"] = detail
+	}
+	if err != nil {
+		p["result"] = "error"
+		p["error"] = err.Error()
+	}
+	return p
+}
+
+// CrashLoopPayload creates a payload for crash loop events.
+// session: tmux session that kept dying
+// agent: Gas Town agent identity
+// deaths: number of deaths within the window
+// window: crash loop detection window (e.g., "15m0s")
+// report: crash report bead ID, if one was filed
+func CrashLoopPayload(session, agent string, deaths int, window, report string) map[string]interface{} {
+	p := map[string]interface{}{
+		"session": session,
+		"agent":   agent,
+		"deaths":  deaths,
+		"window":  window,
+	}
+	if report != "" {
+		p["report"] = report
+	}
+	return p
+}
+
+// WarrantPayload creates a payload for warrant_filed events.
+func WarrantPayload(warrantID, target, reason string) map[string]interface{} {
+	return map[string]interface{}{
+		"warrant": warrantID,
+		"target":  target,
+		"reason":  reason,
+	}
+}
+
+// DancePayload creates a payload for shutdown dance outcome events.
+// warrantID: warrant that started the dance
+// target: session that was interrogated
+// reason: why the warrant was filed
+// outcome: pardoned, executed or failed
+// attempts: number of health checks sent
+func DancePayload(warrantID, target, reason, outcome string, attempts int) map[string]interface{} {
+	return map[string]interface{}{
+		"warrant":  warrantID,
+		"target":   target,
+		"reason":   reason,
+		"outcome":  outcome,
+		"attempts": attempts,
+	}
+}
+
+// PromptPayload creates a payload for prompt_answered events.
+// session: tmux session that was blocked
+// agent: Gas Town agent identity (e.g., "gastown/polecats/Toast")
+// rule: prompt rule that matched
+// action: send_keys, escalate or restart
+// detail: keys sent, account switched to, or error
+func PromptPayload(session, agent, rule, action, detail string) map[string]interface{} {
+	p := map[string]interface{}{
+		"session": session,
+		"agent":   agent,
+		"rule":    rule,
+		"action":  action,
+	}
+	if detail != "" {
+		p["detail"] = detail
+	}
+	return p
+}
+
+// SpanPayload creates a payload for span events.
+// start/end: RFC3339Nano timestamps (equal for milestones)
+// parentSpanID, bead, status, detail: optional
+func SpanPayload(traceID, spanID, parentSpanID, name, bead, start, end, status, detail string) map[string]interface{} {
+	p := map[string]interface{}{
+		"trace_id": traceID,
+		"span_id":  spanID,
+		"name":     name,
+		"start":    start,
+		"end":      end,
+	}
+	for k, v := range map[string]string{"parent_span_id": parentSpanID, "bead": bead, "status": status, "detail": detail} {
+		if v != "" {
+			p[k] = v
+		}
+	}
+	return p
+}
+
 // SessionPayload creates a payload for session start/end events.
 // sessionID: Claude Code session UUID
 // role: Gas Town role (e.g., "gastown/crew/joe", "deacon")
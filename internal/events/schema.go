@@ -0,0 +1,496 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaVersion is the version of the event envelope and payload schemas
+// written by this build. Events written before versioning have no "v" field
+// and decode as version 0.
+const SchemaVersion = 1
+
+// Schema errors.
+var (
+	ErrInvalidPayload     = errors.New("invalid event payload")
+	ErrUnsupportedVersion = errors.New("unsupported event schema version")
+	ErrUnknownType        = errors.New("unknown event type")
+)
+
+// Town log event types. These are recorded in logs/town.log by townlog and
+// never carry a structured payload; they are registered so the two logs share
+// one taxonomy.
+const (
+	TypeWake         = "wake"
+	TypeCrash        = "crash"
+	TypeCallback     = "callback"
+	TypeConfigReload = "config_reload"
+	TypeUnquarantine = "unquarantine"
+)
+
+// Typed payloads. Field tags match the maps built by the payload helpers in
+// events.go; fields without omitempty are required.
+
+// SlingData is the payload of sling events.
+type SlingData struct {
+	Bead    string `json:"bead"`
+	Target  string `json:"target"`
+	Formula string `json:"formula,omitempty"` // Set when a formula was slung
+}
+
+// BeadData is the payload of hook and unhook events.
+type BeadData struct {
+	Bead string `json:"bead"`
+}
+
+// HandoffData is the payload of handoff events.
+type HandoffData struct {
+	ToSession bool   `json:"to_session"`
+	Subject   string `json:"subject,omitempty"`
+}
+
+// DoneData is the payload of done events.
+type DoneData struct {
+	Bead   string `json:"bead"`
+	Branch string `json:"branch"`
+}
+
+// MailData is the payload of mail events.
+type MailData struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+// SpawnData is the payload of spawn events.
+type SpawnData struct {
+	Rig     string `json:"rig"`
+	Polecat string `json:"polecat"`
+}
+
+// BootData is the payload of boot events.
+type BootData struct {
+	Rig    string   `json:"rig"`
+	Agents []string `json:"agents"`
+}
+
+// HaltData is the payload of halt events.
+type HaltData struct {
+	Services []string `json:"services"`
+}
+
+// TargetData is the payload of nudge, polecat_nudged and kill events.
+type TargetData struct {
+	Rig    string `json:"rig"`
+	Target string `json:"target"`
+	Reason string `json:"reason"`
+}
+
+// SessionData is the payload of session_start and session_end events.
+type SessionData struct {
+	SessionID string `json:"session_id"`
+	Role      string `json:"role"`
+	ActorPID  string `json:"actor_pid"`
+	Topic     string `json:"topic,omitempty"`
+	Cwd       string `json:"cwd,omitempty"`
+}
+
+// SessionDeathData is the payload of session_death events.
+type SessionDeathData struct {
+	Session string `json:"session"`
+	Agent   string `json:"agent"`
+	Reason  string `json:"reason"`
+	Caller  string `json:"caller"`
+}
+
+// MassDeathData is the payload of mass_death events.
+type MassDeathData struct {
+	Count         int      `json:"count"`
+	Window        string   `json:"window"`
+	Sessions      []string `json:"sessions"`
+	PossibleCause string   `json:"possible_cause,omitempty"`
+}
+
+// CrashLoopData is the payload of crash_loop events.
+type CrashLoopData struct {
+	Session string `json:"session"`
+	Agent   string `json:"agent"`
+	Deaths  int    `json:"deaths"`
+	Window  string `json:"window"`
+	Report  string `json:"report,omitempty"`
+}
+
+// GUPPViolationData is the payload of gupp_violation events.
+type GUPPViolationData struct {
+	Agent    string `json:"agent"`
+	HookBead string `json:"hook_bead"`
+	Stuck    string `json:"stuck"`
+}
+
+// PatrolData is the payload of patrol_started and patrol_complete events.
+type PatrolData struct {
+	Rig          string `json:"rig"`
+	PolecatCount int    `json:"polecat_count"`
+	Message      string `json:"message,omitempty"`
+}
+
+// PolecatCheckData is the payload of polecat_checked events.
+type PolecatCheckData struct {
+	Rig     string `json:"rig"`
+	Polecat string `json:"polecat"`
+	Status  string `json:"status"`
+	Issue   string `json:"issue,omitempty"`
+}
+
+// EscalationData is the payload of escalation_sent events.
+type EscalationData struct {
+	Rig      string `json:"rig"`
+	Target   string `json:"target"`
+	To       string `json:"to"`
+	Reason   string `json:"reason"`
+	Severity string `json:"severity,omitempty"`
+	Bead     string `json:"bead,omitempty"`
+}
+
+// MergeData is the payload of merge queue events. The refinery sets MR,
+// Worker and Branch; events emitted by hand (gt activity emit) may carry
+// only Rig and Message.
+type MergeData struct {
+	MR      string `json:"mr,omitempty"`
+	Worker  string `json:"worker,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Rig     string `json:"rig,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// WarrantData is the payload of warrant_filed events.
+type WarrantData struct {
+	Warrant string `json:"warrant"`
+	Target  string `json:"target"`
+	Reason  string `json:"reason"`
+}
+
+// DanceData is the payload of shutdown dance outcome events.
+type DanceData struct {
+	Warrant  string `json:"warrant"`
+	Target   string `json:"target"`
+	Reason   string `json:"reason"`
+	Outcome  string `json:"outcome"`
+	Attempts int    `json:"attempts"`
+}
+
+// PromptData is the payload of prompt_answered events.
+type PromptData struct {
+	Session string `json:"session"`
+	Agent   string `json:"agent"`
+	Rule    string `json:"rule"`
+	Action  string `json:"action"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// SpanData is the payload of span events (see internal/trace).
+type SpanData struct {
+	TraceID      string `json:"trace_id"`
+	SpanID       string `json:"span_id"`
+	ParentSpanID string `json:"parent_span_id,omitempty"`
+	Name         string `json:"name"`
+	Bead         string `json:"bead,omitempty"`
+	Start        string `json:"start"` // RFC3339Nano
+	End          string `json:"end"`   // RFC3339Nano; equals Start for milestones
+	Status       string `json:"status,omitempty"`
+	Detail       string `json:"detail,omitempty"`
+}
+
+// DashboardActionData is the payload of dashboard_action events.
+type DashboardActionData struct {
+	Action string `json:"action"`
+	Target string `json:"target"`
+	Detail string `json:"detail,omitempty"`
+	Remote string `json:"remote"`
+	Result string `json:"result"` // "ok" or "error"
+	Error  string `json:"error,omitempty"`
+}
+
+// Spec describes a registered event type.
+type Spec struct {
+	Type        string
+	Description string
+
+	// Payload is the payload struct type, or nil for town log events,
+	// which carry a free-form context string instead.
+	Payload reflect.Type
+
+	// TownLog is true if the event is recorded in logs/town.log.
+	TownLog bool
+}
+
+func spec(eventType, description string, payload interface{}, townLog bool) Spec {
+	s := Spec{Type: eventType, Description: description, TownLog: townLog}
+	if payload != nil {
+		s.Payload = reflect.TypeOf(payload)
+	}
+	return s
+}
+
+// registry holds every known event type.
+var registry = func() map[string]Spec {
+	specs := []Spec{
+		spec(TypeSling, "Work was slung to an agent's hook", SlingData{}, false),
+		spec(TypeHook, "A bead was attached to an agent's hook", BeadData{}, false),
+		spec(TypeUnhook, "A bead was removed from an agent's hook", BeadData{}, false),
+		spec(TypeHandoff, "An agent handed off to a fresh session", HandoffData{}, true),
+		spec(TypeDone, "An agent finished its work", DoneData{}, true),
+		spec(TypeMail, "Mail was sent", MailData{}, false),
+		spec(TypeSpawn, "A polecat was spawned", SpawnData{}, true),
+		spec(TypeKill, "An agent was killed intentionally", TargetData{}, true),
+		spec(TypeNudge, "A message was injected into an agent", TargetData{}, true),
+		spec(TypeBoot, "Services were started", BootData{}, false),
+		spec(TypeHalt, "Services were stopped", HaltData{}, false),
+
+		spec(TypeSessionStart, "An agent session started", SessionData{}, false),
+		spec(TypeSessionEnd, "An agent session ended", SessionData{}, false),
+		spec(TypeSessionDeath, "A session was terminated", SessionDeathData{}, true),
+		spec(TypeMassDeath, "Several sessions died within a short window", MassDeathData{}, true),
+		spec(TypeCrashLoop, "A session was quarantined after repeated deaths", CrashLoopData{}, true),
+		spec(TypeGUPPViolation, "An agent with hooked work stopped progressing", GUPPViolationData{}, false),
+
+		spec(TypePatrolStarted, "A witness patrol began", PatrolData{}, true),
+		spec(TypePolecatChecked, "A witness checked a polecat", PolecatCheckData{}, true),
+		spec(TypePolecatNudged, "A witness nudged a stuck polecat", TargetData{}, true),
+		spec(TypeEscalationSent, "An issue was escalated", EscalationData{}, true),
+		spec(TypePatrolComplete, "A witness patrol finished", PatrolData{}, true),
+
+		spec(TypeMergeStarted, "The refinery started a merge", MergeData{}, false),
+		spec(TypeMerged, "The refinery merged a branch", MergeData{}, false),
+		spec(TypeMergeFailed, "A merge failed", MergeData{}, false),
+		spec(TypeMergeSkipped, "A merge request was skipped", MergeData{}, false),
+
+		spec(TypeWarrantFiled, "A shutdown warrant was filed", WarrantData{}, false),
+		spec(TypeDancePardoned, "A shutdown dance pardoned its target", DanceData{}, false),
+		spec(TypeDanceExecuted, "A shutdown dance killed its target", DanceData{}, false),
+		spec(TypeDanceFailed, "A shutdown dance could not finish", DanceData{}, false),
+
+		spec(TypePromptAnswered, "An interactive prompt was handled", PromptData{}, false),
+
+		spec(TypeSpan, "A unit of traced work from sling to merge", SpanData{}, false),
+
+		spec(TypeDashboardAction, "An operator took an action from the web dashboard", DashboardActionData{}, false),
+
+		spec(TypeWake, "An agent was resumed", nil, true),
+		spec(TypeCrash, "An agent exited unexpectedly", nil, true),
+		spec(TypeCallback, "A callback was processed during patrol", nil, true),
+		spec(TypeConfigReload, "The daemon applied a live configuration change", nil, true),
+		spec(TypeUnquarantine, "A quarantined session was released", nil, true),
+	}
+	m := make(map[string]Spec, len(specs))
+	for _, s := range specs {
+		m[s.Type] = s
+	}
+	return m
+}()
+
+// Lookup returns the spec of a registered event type.
+func Lookup(eventType string) (Spec, bool) {
+	s, ok := registry[eventType]
+	return s, ok
+}
+
+// Specs returns all registered event types, sorted by type.
+func Specs() []Spec {
+	specs := make([]Spec, 0, len(registry))
+	for _, s := range registry {
+		specs = append(specs, s)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Type < specs[j].Type })
+	return specs
+}
+
+// payloadField is a JSON-visible field of a payload struct.
+type payloadField struct {
+	Name     string
+	Type     reflect.Type
+	Required bool
+}
+
+func fieldsOf(t reflect.Type) []payloadField {
+	var fields []payloadField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fields = append(fields, payloadField{
+			Name:     name,
+			Type:     f.Type,
+			Required: !strings.Contains(opts, "omitempty"),
+		})
+	}
+	return fields
+}
+
+// Validate checks a payload against the registered schema of its event type:
+// required fields must be present and known fields must have the right type.
+// Unknown fields are allowed so older readers tolerate newer writers.
+// Unregistered types (ad-hoc events from gt activity emit) are not checked.
+func Validate(eventType string, payload map[string]interface{}) error {
+	s, ok := registry[eventType]
+	if !ok || s.Payload == nil {
+		return nil
+	}
+	for _, f := range fieldsOf(s.Payload) {
+		v, present := payload[f.Name]
+		if !present {
+			if f.Required {
+				return fmt.Errorf("%w: %s: missing %q", ErrInvalidPayload, eventType, f.Name)
+			}
+			continue
+		}
+		if !valueMatches(v, f.Type) {
+			return fmt.Errorf("%w: %s: %q must be %s, got %T", ErrInvalidPayload, eventType, f.Name, jsonType(f.Type), v)
+		}
+	}
+	return nil
+}
+
+// valueMatches reports whether v (as built by a payload helper or decoded
+// from JSON) can be stored in a field of type t.
+func valueMatches(v interface{}, t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String:
+		_, ok := v.(string)
+		return ok
+	case reflect.Bool:
+		_, ok := v.(bool)
+		return ok
+	case reflect.Int:
+		switch n := v.(type) {
+		case int, int64:
+			return true
+		case float64:
+			return n == math.Trunc(n)
+		}
+		return false
+	case reflect.Slice:
+		switch items := v.(type) {
+		case []string:
+			return true
+		case []interface{}:
+			for _, item := range items {
+				if !valueMatches(item, t.Elem()) {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// Decode parses an events log line. Events written by a newer schema version
+// than this build understands return ErrUnsupportedVersion.
+func Decode(line []byte) (Event, error) {
+	var e Event
+	if err := json.Unmarshal(line, &e); err != nil {
+		return e, err
+	}
+	if e.Version > SchemaVersion {
+		return e, fmt.Errorf("%w: %d", ErrUnsupportedVersion, e.Version)
+	}
+	return e, nil
+}
+
+// DecodePayload decodes the event's payload into v, a pointer to one of the
+// typed payload structs.
+func (e Event) DecodePayload(v interface{}) error {
+	data, err := json.Marshal(e.Payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Data decodes the event's payload into the typed struct registered for its
+// type and returns a pointer to it (e.g. *SlingData).
+func (e Event) Data() (interface{}, error) {
+	s, ok := registry[e.Type]
+	if !ok || s.Payload == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, e.Type)
+	}
+	v := reflect.New(s.Payload).Interface()
+	if err := e.DecodePayload(v); err != nil {
+		return nil, fmt.Errorf("decoding %s payload: %w", e.Type, err)
+	}
+	return v, nil
+}
+
+// jsonType returns the JSON Schema type name for a payload field type.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int:
+		return "integer"
+	case reflect.Slice:
+		return "array"
+	default:
+		return "string"
+	}
+}
+
+// PayloadSchema returns the JSON Schema of the event type's payload.
+func (s Spec) PayloadSchema() map[string]interface{} {
+	schema := map[string]interface{}{"type": "object"}
+	if s.Payload == nil {
+		return schema
+	}
+	properties := map[string]interface{}{}
+	var required []string
+	for _, f := range fieldsOf(s.Payload) {
+		prop := map[string]interface{}{"type": jsonType(f.Type)}
+		if f.Type.Kind() == reflect.Slice {
+			prop["items"] = map[string]interface{}{"type": jsonType(f.Type.Elem())}
+		}
+		properties[f.Name] = prop
+		if f.Required {
+			required = append(required, f.Name)
+		}
+	}
+	schema["properties"] = properties
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing a complete
+// .events.jsonl line of this type.
+func (s Spec) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       s.Type,
+		"description": s.Description,
+		"type":        "object",
+		"properties": map[string]interface{}{
+			"ts":         map[string]interface{}{"type": "string", "format": "date-time"},
+			"v":          map[string]interface{}{"type": "integer", "const": SchemaVersion},
+			"source":     map[string]interface{}{"type": "string"},
+			"type":       map[string]interface{}{"const": s.Type},
+			"actor":      map[string]interface{}{"type": "string"},
+			"visibility": map[string]interface{}{"enum": []string{VisibilityAudit, VisibilityFeed, VisibilityBoth}},
+			"payload":    s.PayloadSchema(),
+			"invalid": map[string]interface{}{
+				"type":        "string",
+				"description": "Set when the payload was recorded despite failing validation",
+			},
+		},
+		"required": []string{"ts", "source", "type", "actor", "visibility"},
+	}
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate_HelpersMatchRegistry(t *testing.T) {
+	tests := []struct {
+		eventType string
+		payload   map[string]interface{}
+	}{
+		{TypeSling, SlingPayload("gt-abc", "gastown/polecats/Toast")},
+		{TypeHook, HookPayload("gt-abc")},
+		{TypeUnhook, UnhookPayload("gt-abc")},
+		{TypeHandoff, HandoffPayload("", true)},
+		{TypeDone, DonePayload("gt-abc", "polecat/Toast")},
+		{TypeMail, MailPayload("mayor/", "hi")},
+		{TypeSpawn, SpawnPayload("gastown", "Toast")},
+		{TypeKill, KillPayload("gastown", "Toast", "stuck")},
+		{TypeNudge, NudgePayload("", "deacon", "wake up")},
+		{TypeBoot, BootPayload("town", []string{"deacon"})},
+		{TypeHalt, HaltPayload([]string{"daemon"})},
+		{TypeSessionStart, SessionPayload("uuid", "deacon", "patrol", "/tmp")},
+		{TypeSessionDeath, SessionDeathPayload("gt-gastown-Toast", "gastown/polecats/Toast", "zombie", "daemon")},
+		{TypeMassDeath, MassDeathPayload(3, "5s", []string{"a", "b", "c"}, "")},
+		{TypeCrashLoop, CrashLoopPayload("s", "a", 5, "15m0s", "gt-bug")},
+		{TypeGUPPViolation, GUPPViolationPayload("gt-gastown-polecat-Toast", "gt-abc", "45m0s")},
+		{TypePatrolStarted, PatrolPayload("gastown", 3, "")},
+		{TypePolecatChecked, PolecatCheckPayload("gastown", "Toast", "working", "gt-abc")},
+		{TypeEscalationSent, EscalationPayload("gastown", "Toast", "mayor", "unresponsive")},
+		{TypeMerged, MergePayload("mr-1", "Toast", "polecat/Toast", "")},
+		{TypeWarrantFiled, WarrantPayload("w-1", "gt-gastown-Toast", "stuck")},
+		{TypeDanceExecuted, DancePayload("w-1", "gt-gastown-Toast", "stuck", "executed", 3)},
+		{TypePromptAnswered, PromptPayload("s", "a", "trust-folder", "send_keys", "Enter")},
+		{TypeDashboardAction, DashboardActionPayload("sling", "gt-abc", "gastown", "127.0.0.1:5000", nil)},
+		{TypeDashboardAction, DashboardActionPayload("mq_reject", "mr-1", "flaky", "127.0.0.1:5000", errors.New("not found"))},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.eventType, tt.payload); err != nil {
+			t.Errorf("Validate(%s): %v", tt.eventType, err)
+		}
+
+		// Payloads survive a round trip through the log as JSON.
+		data, _ := json.Marshal(tt.payload)
+		var decoded map[string]interface{}
+		_ = json.Unmarshal(data, &decoded)
+		if err := Validate(tt.eventType, decoded); err != nil {
+			t.Errorf("Validate(%s) after JSON round trip: %v", tt.eventType, err)
+		}
+	}
+}
+
+func TestValidate_Rejects(t *testing.T) {
+	if err := Validate(TypeSling, map[string]interface{}{"bead": "gt-abc"}); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("missing target: %v", err)
+	}
+	if err := Validate(TypeMassDeath, map[string]interface{}{"count": "three", "window": "5s", "sessions": []string{}}); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("string count: %v", err)
+	}
+	if err := Validate("queue_processed", map[string]interface{}{"anything": 1}); err != nil {
+		t.Errorf("unregistered type: %v", err)
+	}
+}
+
+func TestNewEvent_TagsInvalidPayload(t *testing.T) {
+	valid := newEvent(TypeSling, "mayor", SlingPayload("gt-abc", "gastown/polecats/Toast"), VisibilityFeed)
+	if valid.Invalid != "" {
+		t.Errorf("valid payload tagged invalid: %s", valid.Invalid)
+	}
+
+	e := newEvent(TypeSling, "mayor", map[string]interface{}{"bead": "gt-abc"}, VisibilityFeed)
+	if !strings.Contains(e.Invalid, `missing "target"`) {
+		t.Errorf("Invalid = %q, want the missing field", e.Invalid)
+	}
+	if e.Payload["bead"] != "gt-abc" {
+		t.Errorf("payload = %v, want it kept", e.Payload)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	e, err := Decode([]byte(`{"ts":"2026-01-01T00:00:00Z","v":1,"source":"gt","type":"mass_death","actor":"daemon","payload":{"count":3,"window":"5s","sessions":["a"]},"visibility":"feed"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := e.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := data.(*MassDeathData); !ok || p.Count != 3 || p.Window != "5s" {
+		t.Errorf("Data() = %#v", data)
+	}
+
+	if _, err := Decode([]byte(`{"v":99,"type":"sling"}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("newer version: %v", err)
+	}
+	if e, err := Decode([]byte(`{"type":"sling","payload":{"bead":"gt-abc","target":"x"}}`)); err != nil || e.Version != 0 {
+		t.Errorf("legacy event: %v, v=%d", err, e.Version)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	s, ok := Lookup(TypeCrashLoop)
+	if !ok {
+		t.Fatal("crash_loop not registered")
+	}
+	payload := s.PayloadSchema()
+	required, _ := payload["required"].([]string)
+	if len(required) != 4 {
+		t.Errorf("required = %v, want session, agent, deaths, window", required)
+	}
+	props := payload["properties"].(map[string]interface{})
+	if props["deaths"].(map[string]interface{})["type"] != "integer" {
+		t.Errorf("deaths schema = %v", props["deaths"])
+	}
+	if _, err := json.Marshal(s.JSONSchema()); err != nil {
+		t.Errorf("schema does not marshal: %v", err)
+	}
+}
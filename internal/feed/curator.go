@@ -9,18 +9,16 @@
 package feed
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/logrotate"
 )
 
 // FeedFile is the name of the curated feed file.
@@ -75,20 +73,14 @@ func NewCurator(townRoot string) *Curator {
 func (c *Curator) Start() error {
 	eventsPath := filepath.Join(c.townRoot, events.EventsFile)
 
-	// Open events file, creating if needed
-	file, err := os.OpenFile(eventsPath, os.O_RDONLY|os.O_CREATE, 0644) //nolint:gosec // G302: events file is non-sensitive operational data
+	// Follow only new events, across rotations of the events file
+	follower, err := logrotate.Follow(eventsPath)
 	if err != nil {
 		return fmt.Errorf("opening events file: %w", err)
 	}
 
-	// Seek to end to only process new events
-	if _, err := file.Seek(0, io.SeekEnd); err != nil {
-		_ = file.Close() //nolint:gosec // G104: best effort cleanup on error
-		return fmt.Errorf("seeking to end: %w", err)
-	}
-
 	c.wg.Add(1)
-	go c.run(file)
+	go c.run(follower)
 
 	return nil
 }
@@ -101,11 +93,10 @@ func (c *Curator) Stop() {
 
 // run is the main curator loop.
 // ZFC: No in-memory state to clean up - state is derived from the events file.
-func (c *Curator) run(file *os.File) {
+func (c *Curator) run(follower *logrotate.Follower) {
 	defer c.wg.Done()
-	defer file.Close()
+	defer follower.Close()
 
-	reader := bufio.NewReader(file)
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -117,8 +108,8 @@ func (c *Curator) run(file *os.File) {
 		case <-ticker.C:
 			// Read available lines
 			for {
-				line, err := reader.ReadString('\n')
-				if err != nil {
+				line, ok := follower.Next()
+				if !ok {
 					break // No more data available
 				}
 				c.processLine(line)
@@ -133,9 +124,9 @@ func (c *Curator) processLine(line string) {
 		return
 	}
 
-	var rawEvent events.Event
-	if err := json.Unmarshal([]byte(line), &rawEvent); err != nil {
-		return // Skip malformed lines
+	rawEvent, err := events.Decode([]byte(line))
+	if err != nil {
+		return // Skip malformed lines and events from a newer schema
 	}
 
 	// Filter by visibility - only process feed-visible events
@@ -178,17 +169,12 @@ func (c *Curator) shouldDedupe(event *events.Event) bool {
 func (c *Curator) readRecentFeedEvents(window time.Duration) []FeedEvent {
 	feedPath := filepath.Join(c.townRoot, FeedFile)
 
-	data, err := os.ReadFile(feedPath)
-	if err != nil {
-		return nil
-	}
-
 	now := time.Now()
 	cutoff := now.Add(-window)
 	var result []FeedEvent
 
 	// Parse lines from the end (most recent first) for efficiency
-	lines := strings.Split(string(data), "\n")
+	lines := readLinesSince(feedPath, cutoff)
 	for i := len(lines) - 1; i >= 0; i-- {
 		line := strings.TrimSpace(lines[i])
 		if line == "" {
@@ -223,18 +209,12 @@ func (c *Curator) readRecentFeedEvents(window time.Duration) []FeedEvent {
 func (c *Curator) readRecentEvents(window time.Duration) []events.Event {
 	eventsPath := filepath.Join(c.townRoot, events.EventsFile)
 
-	// Read the file (for small files, this is fine; for large files, consider tail-like reading)
-	data, err := os.ReadFile(eventsPath)
-	if err != nil {
-		return nil
-	}
-
 	now := time.Now()
 	cutoff := now.Add(-window)
 	var result []events.Event
 
 	// Parse lines from the end (most recent first) for efficiency
-	lines := strings.Split(string(data), "\n")
+	lines := readLinesSince(eventsPath, cutoff)
 	for i := len(lines) - 1; i >= 0; i-- {
 		line := strings.TrimSpace(lines[i])
 		if line == "" {
@@ -263,6 +243,23 @@ func (c *Curator) readRecentEvents(window time.Duration) []events.Event {
 	return result
 }
 
+// readLinesSince returns the lines of a log that may contain entries newer
+// than cutoff: the active file plus any segments rotated after cutoff.
+func readLinesSince(path string, cutoff time.Time) []string {
+	r, err := logrotate.Open(path, cutoff)
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+
+	var lines []string
+	scanner := logrotate.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
 // countRecentSlings counts sling events from an actor within the given window.
 // ZFC: Derives count from the events file, not in-memory cache.
 func (c *Curator) countRecentSlings(actor string, window time.Duration) int {
@@ -304,13 +301,7 @@ func (c *Curator) writeFeedEvent(event *events.Event) {
 	data = append(data, '\n')
 
 	feedPath := filepath.Join(c.townRoot, FeedFile)
-	f, err := os.OpenFile(feedPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) //nolint:gosec // G302: feed file is non-sensitive operational data
-	if err != nil {
-		return
-	}
-	defer f.Close()
-
-	_, _ = f.Write(data)
+	_ = logrotate.Append(feedPath, data, 0644)
 }
 
 // generateSummary creates a human-readable summary of an event.
@@ -377,16 +368,38 @@ func (c *Curator) generateSummary(event *events.Event) string {
 		return "Session terminated"
 
 	case events.TypeMassDeath:
-		count, _ := event.Payload["count"].(float64) // JSON numbers are float64
-		possibleCause, _ := event.Payload["possible_cause"].(string)
-		if count > 0 && possibleCause != "" {
-			return fmt.Sprintf("MASS DEATH: %d sessions died - %s", int(count), possibleCause)
+		var p events.MassDeathData
+		_ = event.DecodePayload(&p)
+		if p.Count > 0 && p.PossibleCause != "" {
+			return fmt.Sprintf("MASS DEATH: %d sessions died - %s", p.Count, p.PossibleCause)
 		}
-		if count > 0 {
-			return fmt.Sprintf("MASS DEATH: %d sessions died simultaneously", int(count))
+		if p.Count > 0 {
+			return fmt.Sprintf("MASS DEATH: %d sessions died simultaneously", p.Count)
 		}
 		return "Multiple sessions died simultaneously"
 
+	case events.TypeCrashLoop:
+		var p events.CrashLoopData
+		if err := event.DecodePayload(&p); err == nil && p.Agent != "" {
+			return fmt.Sprintf("CRASH LOOP: %s quarantined", p.Agent)
+		}
+		return "Session quarantined after crash loop"
+
+	case events.TypeGUPPViolation:
+		var p events.GUPPViolationData
+		if err := event.DecodePayload(&p); err == nil && p.Agent != "" {
+			return fmt.Sprintf("GUPP violation: %s stuck on %s for %s", p.Agent, p.HookBead, p.Stuck)
+		}
+		return "Agent with hooked work stopped progressing"
+
+	case events.TypePromptAnswered:
+		var p events.PromptData
+		_ = event.DecodePayload(&p)
+		if p.Session != "" && p.Rule != "" {
+			return fmt.Sprintf("Prompt %s in %s: %s", p.Rule, p.Session, p.Action)
+		}
+		return "Interactive prompt handled"
+
 	default:
 		return fmt.Sprintf("%s: %s", event.Actor, event.Type)
 	}
@@ -0,0 +1,341 @@
+package formula
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrFormulaNotFound is returned by a Loader for an unknown formula.
+var ErrFormulaNotFound = errors.New("formula not found")
+
+// Loader returns the content of a named formula. It resolves the names in
+// extends and include.
+type Loader func(name string) ([]byte, error)
+
+// EmbeddedLoader loads the formulas built into gt.
+func EmbeddedLoader(name string) ([]byte, error) {
+	data, err := formulasFS.ReadFile("formulas/" + name + ".formula.toml")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFormulaNotFound, name)
+	}
+	return data, nil
+}
+
+// SearchLoader loads name.formula.toml from the first directory that has it,
+// falling back to the embedded formulas.
+func SearchLoader(dirs ...string) Loader {
+	return func(name string) ([]byte, error) {
+		for _, dir := range dirs {
+			data, err := os.ReadFile(filepath.Join(dir, name+".formula.toml")) //nolint:gosec // G304: formula search paths are trusted
+			if err == nil {
+				return data, nil
+			}
+			if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("reading formula %s: %w", name, err)
+			}
+		}
+		return EmbeddedLoader(name)
+	}
+}
+
+// Resolve flattens the formula's composition. Base formulas named in extends
+// are resolved and merged in order, then the formula's own steps are applied
+// on top, then included fragments are spliced in:
+//
+//   - A step whose ID matches an inherited step overrides it in place,
+//     keeping the inherited needs unless it declares its own.
+//   - A step with before or after is inserted next to that step: after X it
+//     needs X and takes X's place in its dependents' needs; before X it takes
+//     X's needs and X needs it.
+//   - Other steps are appended.
+//
+// Included fragments are placed the same way, as a group. Vars and inputs
+// are merged, with the formula's own definitions winning. Extends and
+// include are cleared once resolved.
+func (f *Formula) Resolve(load Loader) error {
+	return f.resolve(load, []string{f.Name})
+}
+
+func (f *Formula) resolve(load Loader, stack []string) error {
+	if len(f.Extends) == 0 && len(f.Include) == 0 && !hasPlacement(f.Steps) {
+		return nil
+	}
+
+	var steps []Step
+	for _, name := range f.Extends {
+		base, err := loadFormula(load, name, stack)
+		if err != nil {
+			return fmt.Errorf("extends %s: %w", name, err)
+		}
+		f.inherit(base)
+		for _, step := range base.Steps {
+			if i := stepIndex(steps, step.ID); i >= 0 {
+				steps[i] = step
+			} else {
+				steps = append(steps, step)
+			}
+		}
+	}
+
+	for _, step := range f.Steps {
+		var err error
+		if steps, err = applyStep(steps, step); err != nil {
+			return err
+		}
+	}
+
+	for _, inc := range f.Include {
+		fragment, err := loadFragment(load, inc, stack)
+		if err != nil {
+			return fmt.Errorf("include %s from %s: %w", inc.Fragment, inc.From, err)
+		}
+		for _, step := range fragment.Steps {
+			if stepIndex(steps, step.ID) >= 0 {
+				return fmt.Errorf("include %s from %s: step %q already exists", inc.Fragment, inc.From, step.ID)
+			}
+		}
+		if steps, err = placeSteps(steps, fragment.Steps, inc.Before, inc.After); err != nil {
+			return fmt.Errorf("include %s from %s: %w", inc.Fragment, inc.From, err)
+		}
+	}
+
+	f.Steps = steps
+	f.Extends = nil
+	f.Include = nil
+	return nil
+}
+
+// hasPlacement reports whether any step is positioned with before or after.
+func hasPlacement(steps []Step) bool {
+	for _, step := range steps {
+		if step.Before != "" || step.After != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// loadFormula loads and resolves a base or library formula, refusing cycles.
+func loadFormula(load Loader, name string, stack []string) (*Formula, error) {
+	for _, seen := range stack {
+		if seen == name {
+			return nil, fmt.Errorf("composition cycle: %s -> %s", strings.Join(stack, " -> "), name)
+		}
+	}
+	if load == nil {
+		return nil, fmt.Errorf("%w: %s (no loader)", ErrFormulaNotFound, name)
+	}
+	data, err := load(name)
+	if err != nil {
+		return nil, err
+	}
+	base, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if err := base.resolve(load, append(stack, name)); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return base, nil
+}
+
+// loadFragment returns a fragment from a library formula.
+func loadFragment(load Loader, inc Include, stack []string) (*Fragment, error) {
+	if inc.Fragment == "" || inc.From == "" {
+		return nil, fmt.Errorf("include requires fragment and from")
+	}
+	lib, err := loadFormula(load, inc.From, stack)
+	if err != nil {
+		return nil, err
+	}
+	if lib.Type != TypeLibrary {
+		return nil, fmt.Errorf("%s is a %s formula, not a library", inc.From, lib.Type)
+	}
+	fragment, ok := lib.Fragments[inc.Fragment]
+	if !ok {
+		return nil, fmt.Errorf("library %s has no fragment %q", inc.From, inc.Fragment)
+	}
+	return &fragment, nil
+}
+
+// inherit copies fields the formula leaves unset from a base, and merges
+// the base's vars and inputs under the formula's own.
+func (f *Formula) inherit(base *Formula) {
+	if f.Description == "" {
+		f.Description = base.Description
+	}
+	if f.Type == "" {
+		f.Type = base.Type
+	}
+	if len(f.Legs) == 0 {
+		f.Legs = base.Legs
+	}
+	if f.Synthesis == nil {
+		f.Synthesis = base.Synthesis
+	}
+	if f.Output == nil {
+		f.Output = base.Output
+	}
+	if len(f.Template) == 0 {
+		f.Template = base.Template
+	}
+	if f.ForEach == nil {
+		f.ForEach = base.ForEach
+	}
+	if len(f.Aspects) == 0 {
+		f.Aspects = base.Aspects
+	}
+	for name, v := range base.Vars {
+		if _, ok := f.Vars[name]; !ok {
+			if f.Vars == nil {
+				f.Vars = make(map[string]Var)
+			}
+			f.Vars[name] = v
+		}
+	}
+	for name, in := range base.Inputs {
+		if _, ok := f.Inputs[name]; !ok {
+			if f.Inputs == nil {
+				f.Inputs = make(map[string]Input)
+			}
+			f.Inputs[name] = in
+		}
+	}
+	for name, p := range base.Prompts {
+		if _, ok := f.Prompts[name]; !ok {
+			if f.Prompts == nil {
+				f.Prompts = make(map[string]string)
+			}
+			f.Prompts[name] = p
+		}
+	}
+}
+
+// applyStep overrides, inserts or appends one of the formula's own steps.
+func applyStep(steps []Step, step Step) ([]Step, error) {
+	if i := stepIndex(steps, step.ID); i >= 0 {
+		if step.Before != "" || step.After != "" {
+			return nil, fmt.Errorf("step %q overrides an inherited step and cannot also set before/after", step.ID)
+		}
+		if step.Needs == nil {
+			step.Needs = steps[i].Needs
+		}
+		steps[i] = step
+		return steps, nil
+	}
+	return placeSteps(steps, []Step{step}, step.Before, step.After)
+}
+
+// placeSteps adds a group of steps, wired before or after an anchor step.
+// The group's roots (steps needing nothing else in the group) and leaves
+// (steps nothing else in the group needs) connect it to the anchor.
+func placeSteps(steps, group []Step, before, after string) ([]Step, error) {
+	if before != "" && after != "" {
+		return nil, fmt.Errorf("step %q sets both before and after", group[0].ID)
+	}
+	group = append([]Step(nil), group...)
+	for i := range group {
+		group[i].Needs = append([]string(nil), group[i].Needs...)
+		group[i].Before, group[i].After = "", ""
+	}
+	roots, leaves := groupEnds(group)
+
+	anchor := before + after
+	if anchor == "" {
+		return append(steps, group...), nil
+	}
+	at := stepIndex(steps, anchor)
+	if at < 0 {
+		return nil, fmt.Errorf("step %q is placed next to unknown step %q", group[0].ID, anchor)
+	}
+
+	if after != "" {
+		for _, i := range roots {
+			group[i].Needs = addNeed(group[i].Needs, anchor)
+		}
+		for i := range steps {
+			steps[i].Needs = replaceNeed(steps[i].Needs, anchor, leaves)
+		}
+		at++
+	} else {
+		for _, i := range roots {
+			for _, need := range steps[at].Needs {
+				group[i].Needs = addNeed(group[i].Needs, need)
+			}
+		}
+		steps[at].Needs = append([]string(nil), leaves...)
+	}
+
+	result := make([]Step, 0, len(steps)+len(group))
+	result = append(result, steps[:at]...)
+	result = append(result, group...)
+	return append(result, steps[at:]...), nil
+}
+
+// groupEnds returns the indexes of a group's roots and the IDs of its leaves.
+func groupEnds(group []Step) (roots []int, leaves []string) {
+	inGroup := make(map[string]bool)
+	for _, step := range group {
+		inGroup[step.ID] = true
+	}
+	needed := make(map[string]bool)
+	for i, step := range group {
+		root := true
+		for _, need := range step.Needs {
+			if inGroup[need] {
+				root = false
+				needed[need] = true
+			}
+		}
+		if root {
+			roots = append(roots, i)
+		}
+	}
+	for _, step := range group {
+		if !needed[step.ID] {
+			leaves = append(leaves, step.ID)
+		}
+	}
+	return roots, leaves
+}
+
+func stepIndex(steps []Step, id string) int {
+	for i := range steps {
+		if steps[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func addNeed(needs []string, id string) []string {
+	for _, n := range needs {
+		if n == id {
+			return needs
+		}
+	}
+	return append(needs, id)
+}
+
+// replaceNeed swaps id for replacements in needs, keeping needs unique.
+func replaceNeed(needs []string, id string, replacements []string) []string {
+	found := false
+	var result []string
+	for _, n := range needs {
+		if n == id {
+			found = true
+			continue
+		}
+		result = append(result, n)
+	}
+	if !found {
+		return needs
+	}
+	for _, r := range replacements {
+		result = addNeed(result, r)
+	}
+	return result
+}
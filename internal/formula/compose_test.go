@@ -0,0 +1,238 @@
+package formula
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// mapLoader serves formulas from memory.
+func mapLoader(formulas map[string]string) Loader {
+	return func(name string) ([]byte, error) {
+		data, ok := formulas[name]
+		if !ok {
+			return nil, ErrFormulaNotFound
+		}
+		return []byte(data), nil
+	}
+}
+
+const baseFormula = `
+formula = "base"
+type = "workflow"
+description = "Base workflow"
+
+[[steps]]
+id = "design"
+title = "Design"
+
+[[steps]]
+id = "implement"
+title = "Implement"
+needs = ["design"]
+
+[[steps]]
+id = "submit"
+title = "Submit"
+needs = ["implement"]
+
+[vars.feature]
+description = "The feature"
+required = true
+
+[vars.target]
+default = "main"
+`
+
+const libraryFormula = `
+formula = "checks"
+type = "library"
+
+[fragments.lint]
+description = "Lint and vet"
+
+[[fragments.lint.steps]]
+id = "lint"
+title = "Lint"
+
+[[fragments.lint.steps]]
+id = "vet"
+title = "Vet"
+
+[[fragments.lint.steps]]
+id = "lint-report"
+title = "Report"
+needs = ["lint", "vet"]
+`
+
+func needsOf(t *testing.T, f *Formula, id string) string {
+	t.Helper()
+	step := f.GetStep(id)
+	if step == nil {
+		t.Fatalf("step %q missing; steps %v", id, f.GetAllIDs())
+	}
+	return strings.Join(step.Needs, ",")
+}
+
+func TestResolve_Extends(t *testing.T) {
+	load := mapLoader(map[string]string{"base": baseFormula})
+	f, err := ParseWith([]byte(`
+formula = "child"
+extends = ["base"]
+
+[[steps]]
+id = "implement"
+title = "Implement carefully"
+
+[[steps]]
+id = "threat-model"
+title = "Threat model"
+after = "design"
+
+[[steps]]
+id = "changelog"
+title = "Changelog"
+before = "submit"
+
+[[steps]]
+id = "announce"
+title = "Announce"
+needs = ["submit"]
+
+[vars.target]
+default = "develop"
+`), load)
+	if err != nil {
+		t.Fatalf("ParseWith: %v", err)
+	}
+
+	if f.Type != TypeWorkflow || f.Description != "Base workflow" {
+		t.Errorf("type %q description %q, want inherited", f.Type, f.Description)
+	}
+	if got := strings.Join(f.GetAllIDs(), " "); got != "design threat-model implement changelog submit announce" {
+		t.Errorf("step order = %s", got)
+	}
+	if f.GetStep("implement").Title != "Implement carefully" {
+		t.Error("override did not replace the inherited step")
+	}
+	for id, want := range map[string]string{
+		"threat-model": "design",
+		"implement":    "threat-model", // Inherited needs rewired through the inserted step
+		"changelog":    "implement",
+		"submit":       "changelog",
+		"announce":     "submit",
+	} {
+		if got := needsOf(t, f, id); got != want {
+			t.Errorf("%s needs %q, want %q", id, got, want)
+		}
+	}
+	if f.Vars["target"].Default != "develop" || !f.Vars["feature"].Required {
+		t.Errorf("vars = %+v, want child target and inherited feature", f.Vars)
+	}
+	if f.Extends != nil || f.GetStep("threat-model").After != "" {
+		t.Error("composition fields not cleared after resolving")
+	}
+	if _, err := f.TopologicalSort(); err != nil {
+		t.Errorf("TopologicalSort: %v", err)
+	}
+}
+
+func TestResolve_Include(t *testing.T) {
+	load := mapLoader(map[string]string{"base": baseFormula, "checks": libraryFormula})
+	f, err := ParseWith([]byte(`
+formula = "child"
+extends = ["base"]
+
+[[include]]
+fragment = "lint"
+from = "checks"
+after = "implement"
+`), load)
+	if err != nil {
+		t.Fatalf("ParseWith: %v", err)
+	}
+
+	if got := strings.Join(f.GetAllIDs(), " "); got != "design implement lint vet lint-report submit" {
+		t.Errorf("step order = %s", got)
+	}
+	for id, want := range map[string]string{
+		"lint":        "implement",
+		"vet":         "implement",
+		"lint-report": "lint,vet",
+		"submit":      "lint-report",
+	} {
+		if got := needsOf(t, f, id); got != want {
+			t.Errorf("%s needs %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	load := mapLoader(map[string]string{
+		"base":   baseFormula,
+		"checks": libraryFormula,
+		"loop-a": "formula = \"loop-a\"\nextends = [\"loop-b\"]\n",
+		"loop-b": "formula = \"loop-b\"\nextends = [\"loop-a\"]\n",
+	})
+
+	tests := []struct {
+		name string
+		toml string
+		want string
+	}{
+		{"missing base", `formula = "x"
+extends = ["nope"]`, "formula not found"},
+		{"cycle", `formula = "x"
+extends = ["loop-a"]`, "composition cycle: x -> loop-a -> loop-b -> loop-a"},
+		{"unknown anchor", `formula = "x"
+extends = ["base"]
+[[steps]]
+id = "new"
+after = "deploy"`, `unknown step "deploy"`},
+		{"both placements", `formula = "x"
+extends = ["base"]
+[[steps]]
+id = "new"
+after = "design"
+before = "submit"`, "both before and after"},
+		{"unknown fragment", `formula = "x"
+extends = ["base"]
+[[include]]
+fragment = "fmt"
+from = "checks"`, `no fragment "fmt"`},
+		{"include non-library", `formula = "x"
+extends = ["base"]
+[[include]]
+fragment = "lint"
+from = "base"`, "not a library"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseWith([]byte(tt.toml), load)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseWith([]byte(`formula = "x"
+extends = ["nope"]`), load); !errors.Is(err, ErrFormulaNotFound) {
+		t.Errorf("err = %v, want ErrFormulaNotFound", err)
+	}
+}
+
+func TestParse_UnsupportedComposition(t *testing.T) {
+	// Aspects and expansions would otherwise be silently dropped
+	for name, want := range map[string]string{
+		"shiny-secure":     `"compose.aspects"`,
+		"shiny-enterprise": `"compose.expand"`,
+	} {
+		data, err := EmbeddedLoader(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Parse(data); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%s) err = %v, want unsupported %s", name, err, want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package formula
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Condition is a parsed when or repeat_until expression.
+//
+// Operands are quoted strings, numbers, true/false, or references to
+// runtime values:
+//
+//	feature                      a var (also vars.feature, inputs.feature)
+//	steps.<id>.outcome           done, failed or skipped ("" if not finished)
+//	steps.<id>.iterations        completed runs of a repeating step
+//	steps.<id>.outputs.<key>     an output recorded by a step
+//
+// Operators, loosest first: ||, &&, !, and the comparisons == != =~ !~
+// < <= > >=. =~ and !~ match a regular expression; the ordering operators
+// compare numbers and are false for anything else. A bare operand is true
+// unless it is empty, "false" or "0". Unknown references are empty.
+type Condition struct {
+	source string
+	root   condNode
+}
+
+// ParseCondition parses a condition expression.
+func ParseCondition(expr string) (*Condition, error) {
+	tokens, err := lexCondition(expr)
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: %w", expr, err)
+	}
+	p := &condParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: %w", expr, err)
+	}
+	return &Condition{source: expr, root: root}, nil
+}
+
+// String returns the condition's source text.
+func (c *Condition) String() string {
+	return c.source
+}
+
+// Eval evaluates the condition, resolving references with lookup.
+func (c *Condition) Eval(lookup func(ref string) string) bool {
+	return truthy(c.root.eval(lookup))
+}
+
+func truthy(s string) bool {
+	return s != "" && s != "false" && s != "0"
+}
+
+type condNode interface {
+	eval(lookup func(string) string) string
+}
+
+type literalNode string
+
+func (n literalNode) eval(func(string) string) string { return string(n) }
+
+type refNode string
+
+func (n refNode) eval(lookup func(string) string) string { return lookup(string(n)) }
+
+type notNode struct{ x condNode }
+
+func (n notNode) eval(lookup func(string) string) string {
+	return boolString(!truthy(n.x.eval(lookup)))
+}
+
+type logicNode struct {
+	op   string
+	l, r condNode
+}
+
+func (n logicNode) eval(lookup func(string) string) string {
+	l := truthy(n.l.eval(lookup))
+	if n.op == "&&" {
+		return boolString(l && truthy(n.r.eval(lookup)))
+	}
+	return boolString(l || truthy(n.r.eval(lookup)))
+}
+
+type compareNode struct {
+	op   string
+	l, r condNode
+	re   *regexp.Regexp // Precompiled when the pattern is a literal
+}
+
+func (n compareNode) eval(lookup func(string) string) string {
+	l, r := n.l.eval(lookup), n.r.eval(lookup)
+	switch n.op {
+	case "==":
+		return boolString(l == r)
+	case "!=":
+		return boolString(l != r)
+	case "=~", "!~":
+		re := n.re
+		if re == nil {
+			var err error
+			if re, err = regexp.Compile(r); err != nil {
+				return boolString(false)
+			}
+		}
+		return boolString(re.MatchString(l) == (n.op == "=~"))
+	}
+	lf, lerr := strconv.ParseFloat(l, 64)
+	rf, rerr := strconv.ParseFloat(r, 64)
+	if lerr != nil || rerr != nil {
+		return boolString(false)
+	}
+	switch n.op {
+	case "<":
+		return boolString(lf < rf)
+	case "<=":
+		return boolString(lf <= rf)
+	case ">":
+		return boolString(lf > rf)
+	default:
+		return boolString(lf >= rf)
+	}
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+type condToken struct {
+	kind byte // 's' string, 'n' number, 'r' reference, 'o' operator
+	text string
+}
+
+var condOperators = []string{"&&", "||", "==", "!=", "=~", "!~", "<=", ">=", "<", ">", "!", "(", ")"}
+
+func lexCondition(expr string) ([]condToken, error) {
+	var tokens []condToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, condToken{'s', expr[i+1 : i+1+end]})
+			i += end + 2
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(expr) && expr[i+1] >= '0' && expr[i+1] <= '9':
+			j := i + 1
+			for j < len(expr) && (expr[j] >= '0' && expr[j] <= '9' || expr[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, condToken{'n', expr[i:j]})
+			i = j
+		case isRefChar(c) && c != '.' && c != '-':
+			j := i
+			for j < len(expr) && isRefChar(expr[j]) {
+				j++
+			}
+			tokens = append(tokens, condToken{'r', expr[i:j]})
+			i = j
+		default:
+			matched := false
+			for _, op := range condOperators {
+				if strings.HasPrefix(expr[i:], op) {
+					tokens = append(tokens, condToken{'o', op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+func isRefChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+type condParser struct {
+	tokens []condToken
+	pos    int
+}
+
+func (p *condParser) peekOp(ops ...string) string {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == 'o' {
+		for _, op := range ops {
+			if p.tokens[p.pos].text == op {
+				return op
+			}
+		}
+	}
+	return ""
+}
+
+func (p *condParser) parseOr() (condNode, error) {
+	l, err := p.parseAnd()
+	for err == nil && p.peekOp("||") != "" {
+		p.pos++
+		var r condNode
+		if r, err = p.parseAnd(); err == nil {
+			l = logicNode{op: "||", l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+func (p *condParser) parseAnd() (condNode, error) {
+	l, err := p.parseNot()
+	for err == nil && p.peekOp("&&") != "" {
+		p.pos++
+		var r condNode
+		if r, err = p.parseNot(); err == nil {
+			l = logicNode{op: "&&", l: l, r: r}
+		}
+	}
+	return l, err
+}
+
+func (p *condParser) parseNot() (condNode, error) {
+	if p.peekOp("!") != "" {
+		p.pos++
+		x, err := p.parseNot()
+		return notNode{x}, err
+	}
+	return p.parseCompare()
+}
+
+func (p *condParser) parseCompare() (condNode, error) {
+	l, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peekOp("==", "!=", "=~", "!~", "<", "<=", ">", ">=")
+	if op == "" {
+		return l, nil
+	}
+	p.pos++
+	r, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	node := compareNode{op: op, l: l, r: r}
+	if lit, ok := r.(literalNode); ok && (op == "=~" || op == "!~") {
+		if node.re, err = regexp.Compile(string(lit)); err != nil {
+			return nil, fmt.Errorf("bad pattern: %w", err)
+		}
+	}
+	return node, nil
+}
+
+func (p *condParser) parseOperand() (condNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case 's', 'n':
+		return literalNode(tok.text), nil
+	case 'r':
+		if tok.text == "true" || tok.text == "false" {
+			return literalNode(tok.text), nil
+		}
+		return refNode(tok.text), nil
+	}
+	if tok.text == "(" {
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peekOp(")") == "" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", tok.text)
+}
@@ -0,0 +1,255 @@
+package formula
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultForEachAs is the placeholder name of a for_each item.
+const DefaultForEachAs = "item"
+
+// DefaultMaxItems bounds how many items a fan-out expands to unless
+// max_items says otherwise.
+const DefaultMaxItems = 50
+
+// For_each data sources.
+const (
+	ForEachGlob         = "glob"          // Files matching a pattern
+	ForEachLabel        = "label"         // Open beads with a label
+	ForEachRigs         = "rigs"          // Rigs of the town
+	ForEachFailingTests = "failing_tests" // Failing tests of a go test run
+	ForEachCommand      = "command"       // Lines printed by a shell command
+	ForEachItems        = "items"         // A literal list
+)
+
+// ForEach makes an expansion formula fan out over data gathered when it
+// is expanded: its templates are instantiated once per item. Exactly one
+// source is set. String sources may use {{vars}}, except the shell
+// commands, which get them as $GT_VAR_<NAME> instead.
+//
+// With MaxConcurrent, items run in that many lanes: each item waits for
+// the item MaxConcurrent places before it to finish.
+type ForEach struct {
+	Glob         string   `toml:"glob,omitempty"`          // Pattern relative to the work dir
+	Label        string   `toml:"label,omitempty"`         // Bead label
+	Rigs         bool     `toml:"rigs,omitempty"`          // Every rig of the town
+	FailingTests string   `toml:"failing_tests,omitempty"` // Test command, e.g. "go test ./..."
+	Command      string   `toml:"command,omitempty"`       // Shell command; one item per output line
+	Items        []string `toml:"items,omitempty"`         // Literal items
+
+	As            string `toml:"as,omitempty"`             // Placeholder name (default "item")
+	MaxConcurrent int    `toml:"max_concurrent,omitempty"` // Items in flight at once (0: no limit)
+	MaxItems      int    `toml:"max_items,omitempty"`      // Refuse to expand to more (default 50)
+	Sling         string `toml:"sling,omitempty"`          // Rig whose polecats the items are dispatched to
+}
+
+// Source returns which data source is set, or "" if none is.
+func (fe *ForEach) Source() string {
+	if sources := fe.sources(); len(sources) > 0 {
+		return sources[0]
+	}
+	return ""
+}
+
+// Name returns the placeholder name of an item.
+func (fe *ForEach) Name() string {
+	if fe.As == "" {
+		return DefaultForEachAs
+	}
+	return fe.As
+}
+
+// Limit returns the most items the fan-out may expand to.
+func (fe *ForEach) Limit() int {
+	if fe.MaxItems == 0 {
+		return DefaultMaxItems
+	}
+	return fe.MaxItems
+}
+
+func (fe *ForEach) sources() []string {
+	var sources []string
+	if fe.Glob != "" {
+		sources = append(sources, ForEachGlob)
+	}
+	if fe.Label != "" {
+		sources = append(sources, ForEachLabel)
+	}
+	if fe.Rigs {
+		sources = append(sources, ForEachRigs)
+	}
+	if fe.FailingTests != "" {
+		sources = append(sources, ForEachFailingTests)
+	}
+	if fe.Command != "" {
+		sources = append(sources, ForEachCommand)
+	}
+	if len(fe.Items) > 0 {
+		sources = append(sources, ForEachItems)
+	}
+	return sources
+}
+
+var forEachNamePattern = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// ShellVarPrefix prefixes the environment variables the formula vars are
+// passed to for_each shell commands in, so values are never parsed as
+// shell: {{base-ref}} is $GT_VAR_BASE_REF.
+const ShellVarPrefix = "GT_VAR_"
+
+var shellVarUnsafe = regexp.MustCompile(`\W`)
+
+// ShellVarEnv returns vars as environment entries for a shell command.
+func ShellVarEnv(vars map[string]string) []string {
+	env := make([]string, 0, len(vars))
+	for name, value := range vars {
+		env = append(env, ShellVarPrefix+strings.ToUpper(shellVarUnsafe.ReplaceAllString(name, "_"))+"="+value)
+	}
+	return env
+}
+
+func (fe *ForEach) validate() error {
+	switch sources := fe.sources(); len(sources) {
+	case 0:
+		return fmt.Errorf("for_each requires a source (glob, label, rigs, failing_tests, command or items)")
+	case 1:
+	default:
+		return fmt.Errorf("for_each has more than one source: %s", strings.Join(sources, ", "))
+	}
+	if fe.As != "" && !forEachNamePattern.MatchString(fe.As) {
+		return fmt.Errorf("for_each: invalid name %q for as", fe.As)
+	}
+	if fe.MaxConcurrent < 0 || fe.MaxItems < 0 {
+		return fmt.Errorf("for_each: max_concurrent and max_items must not be negative")
+	}
+	if strings.Contains(fe.Command+fe.FailingTests, "{{") {
+		return fmt.Errorf("for_each: shell commands can't use {{vars}}; use $%sNAME instead", ShellVarPrefix)
+	}
+	return nil
+}
+
+// Item is one element of for_each data. Templates refer to it as {as}
+// and to its fields as {as.field}.
+type Item struct {
+	Key    string
+	Fields map[string]string
+}
+
+// Instance is a template instantiated for an item.
+type Instance struct {
+	Item        int // Index of the item
+	Template    string
+	Title       string
+	Description string
+	Needs       []int // Instances of the same item this one needs
+	After       []int // Instances of the previous item in its lane
+}
+
+// Expand instantiates an expansion formula's templates once per item, or
+// once without placeholders if items is nil. Needs and After index the
+// returned instances. A title that does not mention the item gets its key
+// appended, so the instances of different items can be told apart.
+func (f *Formula) Expand(items []Item) ([]Instance, error) {
+	if f.Type != TypeExpansion {
+		return nil, fmt.Errorf("%s is not an expansion formula", f.Name)
+	}
+	fe := f.ForEach
+	if fe == nil {
+		fe = &ForEach{}
+		if items == nil {
+			items = []Item{{}}
+		}
+	}
+	if len(items) > fe.Limit() {
+		return nil, fmt.Errorf("%s: %d items exceed max_items (%d)", f.Name, len(items), fe.Limit())
+	}
+
+	placeholder := regexp.MustCompile(`\{\{?` + regexp.QuoteMeta(fe.Name()) + `(\.\w+)?\}\}?`)
+	var instances []Instance
+	start := make([]int, len(items)) // Index of each item's first instance
+	for i, item := range items {
+		start[i] = len(instances)
+		var missing string
+		fill := func(s string) string {
+			if f.ForEach == nil {
+				return s
+			}
+			return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+				if strings.HasPrefix(m, "{{") || strings.HasSuffix(m, "}}") {
+					return m // A {{var}}, not an item placeholder
+				}
+				field := strings.TrimSuffix(strings.TrimPrefix(m, "{"+fe.Name()), "}")
+				if field == "" {
+					return item.Key
+				}
+				value, ok := item.Fields[field[1:]]
+				if !ok {
+					missing = field[1:]
+				}
+				return value
+			})
+		}
+
+		index := make(map[string]int, len(f.Template))
+		for _, tmpl := range f.Template {
+			index[tmpl.ID] = len(instances)
+			title := tmpl.Title
+			if title == "" {
+				title = tmpl.ID
+			}
+			inst := Instance{
+				Item:        i,
+				Template:    tmpl.ID,
+				Title:       fill(title),
+				Description: fill(tmpl.Description),
+			}
+			if f.ForEach != nil && inst.Title == title && item.Key != "" {
+				inst.Title += ": " + item.Key
+			}
+			if missing != "" {
+				return nil, fmt.Errorf("template %q: %s has no field %q", tmpl.ID, fe.Name(), missing)
+			}
+			instances = append(instances, inst)
+		}
+		for j, tmpl := range f.Template {
+			for _, need := range tmpl.Needs {
+				instances[start[i]+j].Needs = append(instances[start[i]+j].Needs, index[need])
+			}
+		}
+	}
+
+	// Lanes: an item's first instances wait for the last instances of the
+	// item max_concurrent places before it
+	if n := fe.MaxConcurrent; n > 0 {
+		first, last := laneEnds(f.Template)
+		for i := n; i < len(items); i++ {
+			for _, a := range first {
+				for _, b := range last {
+					instances[start[i]+a].After = append(instances[start[i]+a].After, start[i-n]+b)
+				}
+			}
+		}
+	}
+	return instances, nil
+}
+
+// laneEnds returns the templates that need no other, and those no other
+// needs, by position.
+func laneEnds(templates []Template) (first, last []int) {
+	needed := make(map[string]bool)
+	for _, tmpl := range templates {
+		for _, need := range tmpl.Needs {
+			needed[need] = true
+		}
+	}
+	for i, tmpl := range templates {
+		if len(tmpl.Needs) == 0 {
+			first = append(first, i)
+		}
+		if !needed[tmpl.ID] {
+			last = append(last, i)
+		}
+	}
+	return first, last
+}
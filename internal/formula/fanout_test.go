@@ -0,0 +1,131 @@
+package formula
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testFanOutFormula = `
+formula = "per-package"
+type = "expansion"
+
+[for_each]
+glob = "internal/*"
+as = "pkg"
+max_concurrent = 2
+
+[[template]]
+id = "refactor"
+title = "Refactor {pkg.name}"
+description = "Refactor {pkg} for {{feature}}"
+
+[[template]]
+id = "review"
+needs = ["refactor"]
+`
+
+func TestParseForEach(t *testing.T) {
+	f, err := Parse([]byte(testFanOutFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if f.ForEach == nil || f.ForEach.Source() != ForEachGlob || f.ForEach.Name() != "pkg" || f.ForEach.Limit() != DefaultMaxItems {
+		t.Errorf("ForEach = %+v", f.ForEach)
+	}
+
+	for _, tc := range []struct {
+		forEach string
+		err     string
+	}{
+		{`as = "x"`, "requires a source"},
+		{"rigs = true\nlabel = \"gt:orphan\"", "more than one source"},
+		{"rigs = true\nas = \"bad-name\"", "invalid name"},
+		{"rigs = true\nmax_concurrent = -1", "must not be negative"},
+		{`command = "ls {{dir}}"`, "$GT_VAR_NAME"},
+	} {
+		src := "formula = \"x\"\ntype = \"expansion\"\n[for_each]\n" + tc.forEach + "\n[[template]]\nid = \"t\"\n"
+		if _, err := Parse([]byte(src)); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%q: err = %v, want %q", tc.forEach, err, tc.err)
+		}
+	}
+}
+
+func TestExpandStepCannotRepeat(t *testing.T) {
+	_, err := Parse([]byte(`
+formula = "w"
+type = "workflow"
+
+[[steps]]
+id = "fan-out"
+expand = "per-package"
+repeat_until = "true"
+`))
+	if err == nil || !strings.Contains(err.Error(), "cannot repeat") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	f, err := Parse([]byte(testFanOutFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	items := []Item{
+		{Key: "internal/a", Fields: map[string]string{"name": "a"}},
+		{Key: "internal/b", Fields: map[string]string{"name": "b"}},
+		{Key: "internal/c", Fields: map[string]string{"name": "c"}},
+	}
+	instances, err := f.Expand(items)
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if len(instances) != 6 {
+		t.Fatalf("got %d instances, want 6", len(instances))
+	}
+
+	// Placeholders are filled in; {{vars}} are left for the caller
+	if instances[0].Title != "Refactor a" || instances[0].Description != "Refactor internal/a for {{feature}}" {
+		t.Errorf("instance 0 = %+v", instances[0])
+	}
+	// A title without the placeholder gets the item's key
+	if instances[3].Title != "review: internal/b" {
+		t.Errorf("instance 3 title = %q", instances[3].Title)
+	}
+	if !reflect.DeepEqual(instances[3].Needs, []int{2}) {
+		t.Errorf("instance 3 needs = %v, want [2]", instances[3].Needs)
+	}
+	// With max_concurrent = 2, item c starts after item a is reviewed
+	if !reflect.DeepEqual(instances[4].After, []int{1}) || instances[2].After != nil {
+		t.Errorf("lanes: c after %v, b after %v", instances[4].After, instances[2].After)
+	}
+
+	if _, err := f.Expand([]Item{{Key: "x"}}); err == nil || !strings.Contains(err.Error(), `no field "name"`) {
+		t.Errorf("missing field err = %v", err)
+	}
+	f.ForEach.MaxItems = 2
+	if _, err := f.Expand(items); err == nil || !strings.Contains(err.Error(), "max_items") {
+		t.Errorf("max_items err = %v", err)
+	}
+	if none, err := f.Expand(nil); err != nil || len(none) != 0 {
+		t.Errorf("Expand(nil) = %v, %v", none, err)
+	}
+}
+
+func TestExpandWithoutForEach(t *testing.T) {
+	f, err := Parse([]byte(`
+formula = "once"
+type = "expansion"
+
+[[template]]
+id = "{target}.draft"
+title = "Draft {target}"
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	instances, err := f.Expand(nil)
+	if err != nil || len(instances) != 1 || instances[0].Title != "Draft {target}" {
+		t.Errorf("Expand = %+v, %v", instances, err)
+	}
+}
@@ -0,0 +1,217 @@
+package formula
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxIterations bounds a repeat_until step that sets no max_iterations.
+const DefaultMaxIterations = 3
+
+// WaitAllChildren is the waits_for gate that holds a step until every bead
+// bonded under the molecule at runtime (outside the formula's own steps) is
+// closed. It is the join of a dynamic fan-out.
+const WaitAllChildren = "all-children"
+
+// StepOutcome is how a finished workflow step ended.
+type StepOutcome string
+
+const (
+	// OutcomeDone is a step that completed successfully.
+	OutcomeDone StepOutcome = "done"
+	// OutcomeFailed is a step that failed.
+	OutcomeFailed StepOutcome = "failed"
+	// OutcomeSkipped is a step whose when condition was false, or a failure
+	// handler that was not needed.
+	OutcomeSkipped StepOutcome = "skipped"
+)
+
+// Progress is the runtime state of a workflow, as seen by its conditions.
+type Progress struct {
+	Outcomes   map[string]StepOutcome       // Finished steps
+	Iterations map[string]int               // Completed runs of each step
+	Vars       map[string]string            // Vars and inputs the workflow was started with
+	Outputs    map[string]map[string]string // Step ID -> output key -> value
+}
+
+// Lookup resolves a condition reference against the progress, falling back
+// to the formula's var and input defaults.
+func (f *Formula) Lookup(p *Progress, ref string) string {
+	if rest, ok := strings.CutPrefix(ref, "steps."); ok {
+		id, field, _ := strings.Cut(rest, ".")
+		switch {
+		case field == "outcome":
+			return string(p.Outcomes[id])
+		case field == "iterations":
+			return strconv.Itoa(p.Iterations[id])
+		case strings.HasPrefix(field, "outputs."):
+			return p.Outputs[id][strings.TrimPrefix(field, "outputs.")]
+		}
+		return ""
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(ref, "vars."), "inputs.")
+	if v, ok := p.Vars[name]; ok {
+		return v
+	}
+	if v, ok := f.Vars[name]; ok {
+		return v.Default
+	}
+	return f.Inputs[name].Default
+}
+
+// Plan returns the workflow steps that are ready to run and those that
+// should be skipped, given the steps finished so far.
+//
+// A step is ready when each of its needs is done or skipped, or failed and
+// recovered by its on_failure step finishing. A step whose when condition
+// is false is skipped instead, which unblocks its dependents in turn.
+// Failure handlers (steps named by on_failure) only run after a step that
+// names them fails, and are skipped once all such steps have succeeded.
+// A failed step without a handler blocks its dependents.
+//
+// For other formula types, Plan returns ReadySteps of the done steps.
+func (f *Formula) Plan(p *Progress) (ready, skip []string) {
+	if f.Type != TypeWorkflow {
+		completed := make(map[string]bool)
+		for id, outcome := range p.Outcomes {
+			completed[id] = outcome == OutcomeDone
+		}
+		return f.ReadySteps(completed), nil
+	}
+
+	outcomes := make(map[string]StepOutcome, len(p.Outcomes))
+	for id, outcome := range p.Outcomes {
+		outcomes[id] = outcome
+	}
+	planned := &Progress{Outcomes: outcomes, Iterations: p.Iterations, Vars: p.Vars, Outputs: p.Outputs}
+
+	handledBy := make(map[string][]string) // Handler ID -> steps it handles
+	for _, step := range f.Steps {
+		if step.OnFailure != "" {
+			handledBy[step.OnFailure] = append(handledBy[step.OnFailure], step.ID)
+		}
+	}
+
+	met := func(step Step, need string) bool {
+		switch outcomes[need] {
+		case OutcomeDone, OutcomeSkipped:
+			return true
+		case OutcomeFailed:
+			// A handler may need the step it handles; others wait for recovery
+			if handler := f.GetStep(need).OnFailure; handler != "" {
+				return handler == step.ID || outcomes[handler] == OutcomeDone
+			}
+		}
+		return false
+	}
+
+	for changed := true; changed; {
+		changed = false
+		ready = nil
+		for _, step := range f.Steps {
+			if outcomes[step.ID] != "" {
+				continue
+			}
+			if handled, ok := handledBy[step.ID]; ok {
+				triggered, pending := false, false
+				for _, id := range handled {
+					switch outcomes[id] {
+					case OutcomeFailed:
+						triggered = true
+					case "":
+						pending = true
+					}
+				}
+				if !triggered {
+					if !pending {
+						outcomes[step.ID] = OutcomeSkipped
+						skip = append(skip, step.ID)
+						changed = true
+					}
+					continue
+				}
+			}
+			allMet := true
+			for _, need := range step.Needs {
+				if !met(step, need) {
+					allMet = false
+					break
+				}
+			}
+			if !allMet {
+				continue
+			}
+			if step.When != "" && !f.eval(step.When, planned) {
+				outcomes[step.ID] = OutcomeSkipped
+				skip = append(skip, step.ID)
+				changed = true
+				continue
+			}
+			ready = append(ready, step.ID)
+		}
+	}
+	return ready, skip
+}
+
+// Repeat reports whether a step that just completed should run again: it
+// has a repeat_until condition that is still false, and has run fewer than
+// its max_iterations times (Iterations counts the run just completed).
+func (f *Formula) Repeat(id string, p *Progress) bool {
+	step := f.GetStep(id)
+	if step == nil || step.RepeatUntil == "" {
+		return false
+	}
+	limit := step.MaxIterations
+	if limit == 0 {
+		limit = DefaultMaxIterations
+	}
+	if p.Iterations[id] >= limit {
+		return false
+	}
+	return !f.eval(step.RepeatUntil, p)
+}
+
+// eval evaluates a condition validated at parse time.
+func (f *Formula) eval(expr string, p *Progress) bool {
+	cond, err := ParseCondition(expr)
+	if err != nil {
+		return false
+	}
+	return cond.Eval(func(ref string) string { return f.Lookup(p, ref) })
+}
+
+// validateFlow checks a step's when, repeat_until and on_failure.
+func validateFlow(step Step, ids map[string]bool) error {
+	for _, expr := range []string{step.When, step.RepeatUntil} {
+		if expr == "" {
+			continue
+		}
+		if _, err := ParseCondition(expr); err != nil {
+			return fmt.Errorf("step %q: %w", step.ID, err)
+		}
+	}
+	if step.MaxIterations < 0 {
+		return fmt.Errorf("step %q: max_iterations must not be negative", step.ID)
+	}
+	if step.MaxIterations > 0 && step.RepeatUntil == "" {
+		return fmt.Errorf("step %q: max_iterations requires repeat_until", step.ID)
+	}
+	if step.Expand != "" && step.RepeatUntil != "" {
+		return fmt.Errorf("step %q: an expand step cannot repeat", step.ID)
+	}
+	for _, gate := range step.WaitsFor {
+		if gate != WaitAllChildren {
+			return fmt.Errorf("step %q: unknown waits_for gate %q", step.ID, gate)
+		}
+	}
+	if step.OnFailure != "" {
+		if !ids[step.OnFailure] {
+			return fmt.Errorf("step %q on_failure names unknown step: %s", step.ID, step.OnFailure)
+		}
+		if step.OnFailure == step.ID {
+			return fmt.Errorf("step %q cannot be its own on_failure step", step.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package formula
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCondition(t *testing.T) {
+	values := map[string]string{
+		"feature":                    "login",
+		"steps.diff.outputs.files":   "internal/auth/token.go,README.md",
+		"steps.test.outputs.failing": "2",
+		"steps.test.outcome":         "done",
+	}
+	lookup := func(ref string) string { return values[ref] }
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`feature`, true},
+		{`missing`, false},
+		{`feature == "login"`, true},
+		{`feature != 'login'`, false},
+		{`steps.diff.outputs.files =~ "(^|,)internal/auth/"`, true},
+		{`steps.diff.outputs.files !~ "auth/"`, false},
+		{`steps.test.outputs.failing > 0`, true},
+		{`steps.test.outputs.failing <= 1`, false},
+		{`feature > 1`, false},
+		{`steps.test.outcome == "done" && !missing`, true},
+		{`missing || (feature == "x" || true)`, true},
+		{`!(feature == "login")`, false},
+	}
+	for _, tt := range tests {
+		cond, err := ParseCondition(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCondition(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := cond.Eval(lookup); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{``, `a ==`, `(a`, `a b`, `"open`, `a =~ "("`, `a # b`} {
+		if _, err := ParseCondition(bad); err == nil {
+			t.Errorf("ParseCondition(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+const flowFormula = `
+formula = "flow"
+type = "workflow"
+
+[vars.strict]
+default = "false"
+
+[[steps]]
+id = "diff"
+title = "Diff"
+
+[[steps]]
+id = "security-scan"
+title = "Security scan"
+needs = ["diff"]
+when = "steps.diff.outputs.files =~ 'auth/' || strict == 'true'"
+
+[[steps]]
+id = "fix-tests"
+title = "Fix tests"
+needs = ["security-scan"]
+repeat_until = "steps.fix-tests.outputs.status == 'green'"
+on_failure = "escalate"
+
+[[steps]]
+id = "escalate"
+title = "Escalate"
+needs = ["fix-tests"]
+
+[[steps]]
+id = "submit"
+title = "Submit"
+needs = ["fix-tests"]
+`
+
+func TestPlan(t *testing.T) {
+	f, err := Parse([]byte(flowFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	plan := func(p *Progress) string {
+		ready, skip := f.Plan(p)
+		return strings.Join(ready, ",") + " / " + strings.Join(skip, ",")
+	}
+
+	p := &Progress{Outcomes: map[string]StepOutcome{}}
+	if got := plan(p); got != "diff / " {
+		t.Errorf("start: %s", got)
+	}
+
+	// The diff doesn't touch auth/, so the scan is skipped and fix-tests runs
+	p.Outcomes["diff"] = OutcomeDone
+	p.Outputs = map[string]map[string]string{"diff": {"files": "README.md"}}
+	if got := plan(p); got != "fix-tests / security-scan" {
+		t.Errorf("after diff: %s", got)
+	}
+	if got := plan(&Progress{Outcomes: p.Outcomes, Outputs: p.Outputs, Vars: map[string]string{"strict": "true"}}); got != "security-scan / " {
+		t.Errorf("strict: %s", got)
+	}
+
+	// fix-tests repeats until green, at most three times
+	p.Outcomes["security-scan"] = OutcomeSkipped
+	p.Iterations = map[string]int{"fix-tests": 1}
+	p.Outputs["fix-tests"] = map[string]string{"status": "red"}
+	if !f.Repeat("fix-tests", p) {
+		t.Error("fix-tests should repeat while red")
+	}
+	p.Iterations["fix-tests"] = 3
+	if f.Repeat("fix-tests", p) {
+		t.Error("fix-tests repeated past max_iterations")
+	}
+	p.Iterations["fix-tests"] = 2
+	p.Outputs["fix-tests"]["status"] = "green"
+	if f.Repeat("fix-tests", p) {
+		t.Error("fix-tests repeated once green")
+	}
+
+	// Success skips the failure handler
+	p.Outcomes["fix-tests"] = OutcomeDone
+	if got := plan(p); got != "submit / escalate" {
+		t.Errorf("after success: %s", got)
+	}
+
+	// Failure runs the handler, then recovers
+	p.Outcomes["fix-tests"] = OutcomeFailed
+	if got := plan(p); got != "escalate / " {
+		t.Errorf("after failure: %s", got)
+	}
+	p.Outcomes["escalate"] = OutcomeDone
+	if got := plan(p); got != "submit / " {
+		t.Errorf("after handler: %s", got)
+	}
+
+	// ReadySteps plans with only completed steps known
+	if got := f.ReadySteps(map[string]bool{"diff": true}); strings.Join(got, ",") != "fix-tests" {
+		t.Errorf("ReadySteps = %v, want fix-tests", got)
+	}
+}
+
+func TestValidateFlow(t *testing.T) {
+	tests := []struct {
+		step string
+		want string
+	}{
+		{`when = "a =="`, "unexpected end"},
+		{`repeat_until = "done"` + "\nmax_iterations = -1", "must not be negative"},
+		{`max_iterations = 2`, "requires repeat_until"},
+		{`on_failure = "nope"`, "unknown step: nope"},
+		{`on_failure = "a"`, "its own on_failure"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte("formula = \"x\"\ntype = \"workflow\"\n[[steps]]\nid = \"a\"\n" + tt.step + "\n"))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.step, err, tt.want)
+		}
+	}
+}
+
+func TestValidateWaitsFor(t *testing.T) {
+	base := "formula = \"x\"\ntype = \"workflow\"\n[[steps]]\nid = \"join\"\n"
+	if _, err := Parse([]byte(base + "waits_for = [\"all-children\"]\n")); err != nil {
+		t.Errorf("all-children: %v", err)
+	}
+	if _, err := Parse([]byte(base + "waits_for = [\"some-children\"]\n")); err == nil || !strings.Contains(err.Error(), "unknown waits_for gate") {
+		t.Errorf("unknown gate err = %v", err)
+	}
+}
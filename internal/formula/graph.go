@@ -0,0 +1,357 @@
+package formula
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Node statuses of a live molecule graph.
+const (
+	NodeDone       = "done"
+	NodeInProgress = "in_progress"
+	NodeBlocked    = "blocked"
+	NodeReady      = "ready"
+)
+
+// Graph is a DAG of steps for rendering: a formula's steps, or the step
+// beads of a live molecule with their status.
+type Graph struct {
+	Name  string
+	Nodes []GraphNode // Dependencies before dependents
+}
+
+// GraphNode is a step of a Graph.
+type GraphNode struct {
+	ID        string
+	Label     string
+	Needs     []string // Nodes this one waits for
+	OnFailure string   // Node that runs if this one fails
+	Status    string   // One of the Node statuses; "" in a formula graph
+	Note      string   // Flow control, e.g. "when: tests.failed"
+}
+
+// Graph returns the formula's step graph: workflow steps, expansion
+// templates, convoy legs and their synthesis, or aspects.
+func (f *Formula) Graph() (*Graph, error) {
+	g := &Graph{Name: f.Name}
+	switch f.Type {
+	case TypeWorkflow:
+		for _, step := range f.Steps {
+			var notes []string
+			if step.When != "" {
+				notes = append(notes, "when: "+step.When)
+			}
+			if step.RepeatUntil != "" {
+				notes = append(notes, "repeat until: "+step.RepeatUntil)
+			}
+			if step.Expand != "" {
+				notes = append(notes, "expand: "+step.Expand)
+			}
+			for _, gate := range step.WaitsFor {
+				notes = append(notes, "waits for: "+gate)
+			}
+			g.Nodes = append(g.Nodes, GraphNode{
+				ID:        step.ID,
+				Label:     step.Title,
+				Needs:     step.Needs,
+				OnFailure: step.OnFailure,
+				Note:      strings.Join(notes, "; "),
+			})
+		}
+	case TypeExpansion:
+		for _, tmpl := range f.Template {
+			g.Nodes = append(g.Nodes, GraphNode{ID: tmpl.ID, Label: tmpl.Title, Needs: tmpl.Needs})
+		}
+		if fe := f.ForEach; fe != nil {
+			for i := range g.Nodes {
+				g.Nodes[i].Note = fmt.Sprintf("for each %s in %s", fe.Name(), fe.Source())
+			}
+		}
+	case TypeConvoy:
+		var legs []string
+		for _, leg := range f.Legs {
+			g.Nodes = append(g.Nodes, GraphNode{ID: leg.ID, Label: leg.Title})
+			legs = append(legs, leg.ID)
+		}
+		if f.Synthesis != nil {
+			needs := f.Synthesis.DependsOn
+			if len(needs) == 0 {
+				needs = legs
+			}
+			g.Nodes = append(g.Nodes, GraphNode{ID: "synthesis", Label: f.Synthesis.Title, Needs: needs})
+		}
+	case TypeAspect:
+		for _, aspect := range f.Aspects {
+			g.Nodes = append(g.Nodes, GraphNode{ID: aspect.ID, Label: aspect.Title})
+		}
+	default:
+		return nil, fmt.Errorf("%s formulas have no step graph", f.Type)
+	}
+	g.sort()
+	return g, nil
+}
+
+// LiveNode is a step bead of a live molecule.
+type LiveNode struct {
+	ID        string
+	Title     string
+	Status    string   // Bead status: open, in_progress, hooked, pinned, closed
+	DependsOn []string // Beads it is blocked by
+}
+
+// LiveGraph builds the graph of a live molecule's step beads, with each
+// node's status: done once closed, in progress once claimed, and ready or
+// blocked by whether the beads it needs are done. Dependencies on beads
+// outside the molecule are left out.
+func LiveGraph(name string, beads []LiveNode) *Graph {
+	closed := make(map[string]bool, len(beads))
+	known := make(map[string]bool, len(beads))
+	for _, b := range beads {
+		known[b.ID] = true
+		closed[b.ID] = b.Status == "closed"
+	}
+
+	g := &Graph{Name: name}
+	for _, b := range beads {
+		node := GraphNode{ID: b.ID, Label: b.Title}
+		blocked := false
+		for _, dep := range b.DependsOn {
+			if known[dep] && dep != b.ID {
+				node.Needs = append(node.Needs, dep)
+				blocked = blocked || !closed[dep]
+			}
+		}
+		switch {
+		case b.Status == "closed":
+			node.Status = NodeDone
+		case b.Status == "in_progress" || b.Status == "hooked" || b.Status == "pinned":
+			node.Status = NodeInProgress
+		case blocked:
+			node.Status = NodeBlocked
+		default:
+			node.Status = NodeReady
+		}
+		g.Nodes = append(g.Nodes, node)
+	}
+	g.sort()
+	return g
+}
+
+// HasEdges reports whether any node waits for another.
+func (g *Graph) HasEdges() bool {
+	for _, n := range g.Nodes {
+		if len(n.Needs) > 0 || n.OnFailure != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Layers groups the nodes by depth: a node sits one layer below the
+// deepest node it needs. Nodes keep their order within a layer.
+func (g *Graph) Layers() [][]GraphNode {
+	depth := make(map[string]int, len(g.Nodes))
+	var layers [][]GraphNode
+	for _, n := range g.Nodes {
+		d := 0
+		for _, need := range n.Needs {
+			if nd, ok := depth[need]; ok && nd+1 > d {
+				d = nd + 1
+			}
+		}
+		depth[n.ID] = d
+		for len(layers) <= d {
+			layers = append(layers, nil)
+		}
+		layers[d] = append(layers[d], n)
+	}
+	return layers
+}
+
+// sort orders the nodes so each comes after those it needs, keeping the
+// declared order otherwise. Nodes on a cycle keep their place at the end.
+func (g *Graph) sort() {
+	placed := make(map[string]bool, len(g.Nodes))
+	known := make(map[string]bool, len(g.Nodes))
+	for _, n := range g.Nodes {
+		known[n.ID] = true
+	}
+	sorted := make([]GraphNode, 0, len(g.Nodes))
+	for len(sorted) < len(g.Nodes) {
+		progress := false
+		for _, n := range g.Nodes {
+			if placed[n.ID] {
+				continue
+			}
+			ready := true
+			for _, need := range n.Needs {
+				if known[need] && !placed[need] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				sorted = append(sorted, n)
+				placed[n.ID] = true
+				progress = true
+			}
+		}
+		if !progress {
+			for _, n := range g.Nodes {
+				if !placed[n.ID] {
+					sorted = append(sorted, n)
+					placed[n.ID] = true
+				}
+			}
+		}
+	}
+	g.Nodes = sorted
+}
+
+// nodeColors are the fill colors of node statuses in DOT and Mermaid.
+var nodeColors = map[string]string{
+	NodeDone:       "#b7e4c7",
+	NodeInProgress: "#ffe08a",
+	NodeBlocked:    "#f4a6a6",
+	NodeReady:      "#a9d6f5",
+}
+
+// statusOrder lists node statuses for deterministic class definitions.
+var statusOrder = []string{NodeDone, NodeInProgress, NodeBlocked, NodeReady}
+
+// label returns the text shown for a node.
+func (n GraphNode) label() string {
+	label := n.ID
+	if n.Label != "" && n.Label != n.ID {
+		label += ": " + n.Label
+	}
+	return label
+}
+
+// DOT renders the graph in Graphviz DOT.
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %s {\n", dotQuote(g.Name))
+	sb.WriteString("  rankdir=TB;\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=white, fontname=Helvetica];\n")
+	for _, n := range g.Nodes {
+		label := n.label()
+		if n.Note != "" {
+			label += "\n" + n.Note
+		}
+		attrs := "label=" + dotQuote(label)
+		if color, ok := nodeColors[n.Status]; ok {
+			attrs += ", fillcolor=" + dotQuote(color)
+		}
+		fmt.Fprintf(&sb, "  %s [%s];\n", dotQuote(n.ID), attrs)
+	}
+	for _, n := range g.Nodes {
+		for _, need := range n.Needs {
+			fmt.Fprintf(&sb, "  %s -> %s;\n", dotQuote(need), dotQuote(n.ID))
+		}
+		if n.OnFailure != "" {
+			fmt.Fprintf(&sb, "  %s -> %s [style=dashed, color=red, label=\"on failure\"];\n", dotQuote(n.ID), dotQuote(n.OnFailure))
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// Mermaid renders the graph as a Mermaid flowchart.
+func (g *Graph) Mermaid() string {
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	for _, n := range g.Nodes {
+		label := n.label()
+		if n.Note != "" {
+			label += "<br/><i>" + n.Note + "</i>"
+		}
+		fmt.Fprintf(&sb, "  %s[\"%s\"]\n", ids[n.ID], mermaidEscape(label))
+	}
+	for _, n := range g.Nodes {
+		for _, need := range n.Needs {
+			fmt.Fprintf(&sb, "  %s --> %s\n", ids[need], ids[n.ID])
+		}
+		if target, ok := ids[n.OnFailure]; ok {
+			fmt.Fprintf(&sb, "  %s -. on failure .-> %s\n", ids[n.ID], target)
+		}
+	}
+	byStatus := make(map[string][]string)
+	for _, n := range g.Nodes {
+		if n.Status != "" {
+			byStatus[n.Status] = append(byStatus[n.Status], ids[n.ID])
+		}
+	}
+	for _, status := range statusOrder {
+		if len(byStatus[status]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "  classDef %s fill:%s\n", status, nodeColors[status])
+		fmt.Fprintf(&sb, "  class %s %s\n", strings.Join(byStatus[status], ","), status)
+	}
+	return sb.String()
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+// asciiMarks mark node statuses in the ASCII layout.
+var asciiMarks = map[string]string{
+	NodeDone:       "[x]",
+	NodeInProgress: "[>]",
+	NodeBlocked:    "[#]",
+	NodeReady:      "[ ]",
+}
+
+// ASCII renders the graph as stages: each stage's steps can run once the
+// stages above them are done.
+func (g *Graph) ASCII() string {
+	var sb strings.Builder
+	sb.WriteString(g.Name + "\n")
+	layers := g.Layers()
+	width := 0
+	for _, n := range g.Nodes {
+		if len(n.ID) > width {
+			width = len(n.ID)
+		}
+	}
+	for i, layer := range layers {
+		fmt.Fprintf(&sb, "|\n+- stage %d\n", i+1)
+		for _, n := range layer {
+			mark, ok := asciiMarks[n.Status]
+			if !ok {
+				mark = " * "
+			}
+			line := fmt.Sprintf("|  %s %-*s", mark, width, n.ID)
+			if n.Label != "" && n.Label != n.ID {
+				line += "  " + n.Label
+			}
+			if len(n.Needs) > 0 {
+				needs := append([]string(nil), n.Needs...)
+				sort.Strings(needs)
+				line += "  <- " + strings.Join(needs, ", ")
+			}
+			if n.OnFailure != "" {
+				line += "  (on failure: " + n.OnFailure + ")"
+			}
+			if n.Note != "" {
+				line += "  [" + n.Note + "]"
+			}
+			sb.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	if len(g.Nodes) > 0 && g.Nodes[0].Status != "" {
+		sb.WriteString("\n[x] done  [>] in progress  [#] blocked  [ ] ready\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,121 @@
+package formula
+
+import (
+	"strings"
+	"testing"
+)
+
+const testGraphFormula = `
+formula = "ship"
+type = "workflow"
+
+[[steps]]
+id = "design"
+title = "Design it"
+
+[[steps]]
+id = "test"
+needs = ["implement"]
+on_failure = "fix"
+
+[[steps]]
+id = "implement"
+needs = ["design"]
+
+[[steps]]
+id = "fix"
+when = "false"
+`
+
+func TestFormulaGraph(t *testing.T) {
+	f, err := Parse([]byte(testGraphFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	g, err := f.Graph()
+	if err != nil {
+		t.Fatalf("Graph: %v", err)
+	}
+	var order []string
+	for _, n := range g.Nodes {
+		order = append(order, n.ID)
+	}
+	// implement moves ahead of test, which needs it
+	if got := strings.Join(order, " "); got != "design implement fix test" {
+		t.Errorf("order = %q", got)
+	}
+	layers := g.Layers()
+	if len(layers) != 3 || len(layers[0]) != 2 || layers[2][0].ID != "test" {
+		t.Errorf("layers = %+v", layers)
+	}
+
+	dot := g.DOT()
+	for _, want := range []string{`digraph "ship"`, `"design" [label="design: Design it"]`, `"implement" -> "test";`, `"test" -> "fix" [style=dashed`, `when: false`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT missing %q:\n%s", want, dot)
+		}
+	}
+	mermaid := g.Mermaid()
+	for _, want := range []string{"flowchart TD", `n0["design: Design it"]`, "n1 --> n3", "n3 -. on failure .-> n2"} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid missing %q:\n%s", want, mermaid)
+		}
+	}
+	ascii := g.ASCII()
+	for _, want := range []string{"+- stage 3", " *  test       <- implement  (on failure: fix)", "[when: false]"} {
+		if !strings.Contains(ascii, want) {
+			t.Errorf("ASCII missing %q:\n%s", want, ascii)
+		}
+	}
+	if strings.Contains(ascii, "[x] done") {
+		t.Errorf("formula graph has a status legend:\n%s", ascii)
+	}
+}
+
+func TestConvoyGraph(t *testing.T) {
+	f := &Formula{Name: "review", Type: TypeConvoy,
+		Legs:      []Leg{{ID: "a"}, {ID: "b"}},
+		Synthesis: &Synthesis{Title: "Combine"},
+	}
+	g, err := f.Graph()
+	if err != nil {
+		t.Fatalf("Graph: %v", err)
+	}
+	if n := g.Nodes[2]; n.ID != "synthesis" || strings.Join(n.Needs, ",") != "a,b" {
+		t.Errorf("synthesis = %+v", n)
+	}
+	if _, err := (&Formula{Type: TypeLibrary}).Graph(); err == nil {
+		t.Error("library formula has a graph")
+	}
+}
+
+func TestLiveGraph(t *testing.T) {
+	g := LiveGraph("gt-mol: Ship", []LiveNode{
+		{ID: "gt-3", Title: "Review", Status: "open", DependsOn: []string{"gt-2", "gt-outside"}},
+		{ID: "gt-1", Title: "Design", Status: "closed"},
+		{ID: "gt-2", Title: "Build", Status: "hooked", DependsOn: []string{"gt-1"}},
+		{ID: "gt-4", Title: "Docs", Status: "open", DependsOn: []string{"gt-1"}},
+	})
+	status := make(map[string]string)
+	for _, n := range g.Nodes {
+		status[n.ID] = n.Status
+	}
+	want := map[string]string{"gt-1": NodeDone, "gt-2": NodeInProgress, "gt-3": NodeBlocked, "gt-4": NodeReady}
+	for id, s := range want {
+		if status[id] != s {
+			t.Errorf("%s status = %q, want %q", id, status[id], s)
+		}
+	}
+	if g.Nodes[len(g.Nodes)-1].ID != "gt-3" || len(g.Nodes[len(g.Nodes)-1].Needs) != 1 {
+		t.Errorf("nodes = %+v", g.Nodes)
+	}
+	if !g.HasEdges() {
+		t.Error("HasEdges = false")
+	}
+	if out := g.Mermaid(); !strings.Contains(out, "classDef blocked fill:") || !strings.Contains(out, "class n0 done") {
+		t.Errorf("Mermaid:\n%s", out)
+	}
+	if out := g.ASCII(); !strings.Contains(out, "[#] gt-3  Review  <- gt-2") || !strings.Contains(out, "[x] done") {
+		t.Errorf("ASCII:\n%s", out)
+	}
+}
@@ -34,11 +34,11 @@ func TestParseRealFormulas(t *testing.T) {
 	}
 
 	// Known files that use advanced features not yet supported:
-	// - Composition (extends, compose): shiny-enterprise, shiny-secure
+	// - Composition beyond extends/include (compose aspects, expand): shiny-enterprise, shiny-secure
 	// - Aspect-oriented (advice, pointcuts): security-audit
 	skipAdvanced := map[string]string{
-		"shiny-enterprise.formula.toml": "uses formula composition (extends)",
-		"shiny-secure.formula.toml":     "uses formula composition (extends)",
+		"shiny-enterprise.formula.toml": "uses formula composition (compose.expand)",
+		"shiny-secure.formula.toml":     "uses formula composition (compose.aspects)",
 		"security-audit.formula.toml":   "uses aspect-oriented features (advice/pointcuts)",
 	}
 
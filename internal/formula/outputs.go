@@ -0,0 +1,138 @@
+package formula
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// outputKeyPattern matches step output keys.
+var outputKeyPattern = regexp.MustCompile(`^\w+$`)
+
+// outputRefPattern matches {{steps.<id>.outputs.<key>}} references.
+var outputRefPattern = regexp.MustCompile(`\{\{steps\.([\w-]+)\.outputs\.(\w+)\}\}`)
+
+// OutputVar returns the template variable name of a step output, as used
+// in {{steps.<id>.outputs.<key>}} and by beads.ExpandTemplateVars.
+func OutputVar(stepID, key string) string {
+	return "steps." + stepID + ".outputs." + key
+}
+
+// OutputContext returns the recorded outputs of a workflow as template
+// variables, for beads.ExpandTemplateVars.
+func OutputContext(outputs map[string]map[string]string) map[string]string {
+	ctx := make(map[string]string)
+	for id, values := range outputs {
+		for key, value := range values {
+			ctx[OutputVar(id, key)] = value
+		}
+	}
+	return ctx
+}
+
+// CheckOutputKey reports whether key is a valid output key.
+func CheckOutputKey(key string) error {
+	if !outputKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid output key %q (letters, digits and _ only)", key)
+	}
+	return nil
+}
+
+// CheckOutputs validates the outputs a step records against those it
+// declares. A step that declares no outputs may record any.
+func (f *Formula) CheckOutputs(stepID string, outputs map[string]string) error {
+	for key := range outputs {
+		if err := CheckOutputKey(key); err != nil {
+			return err
+		}
+	}
+	step := f.GetStep(stepID)
+	if step == nil || len(step.Outputs) == 0 {
+		return nil
+	}
+	for key := range outputs {
+		if _, ok := step.Outputs[key]; !ok {
+			return fmt.Errorf("step %s has no output %q (declares %s)", stepID, key, strings.Join(sortedKeys(step.Outputs), ", "))
+		}
+	}
+	return nil
+}
+
+// MissingOutputs returns the required outputs of a step that are not in
+// outputs, sorted.
+func (f *Formula) MissingOutputs(stepID string, outputs map[string]string) []string {
+	step := f.GetStep(stepID)
+	if step == nil {
+		return nil
+	}
+	var missing []string
+	for key, decl := range step.Outputs {
+		if _, ok := outputs[key]; decl.Required && !ok {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// validateOutputs checks declared output keys, and that each
+// {{steps.<id>.outputs.<key>}} in a step refers to a declared output of a
+// step it (transitively) needs.
+func (f *Formula) validateOutputs() error {
+	for _, step := range f.Steps {
+		for key := range step.Outputs {
+			if err := CheckOutputKey(key); err != nil {
+				return fmt.Errorf("step %q: %w", step.ID, err)
+			}
+		}
+	}
+
+	for _, step := range f.Steps {
+		upstream := f.upstream(step.ID)
+		for _, m := range outputRefPattern.FindAllStringSubmatch(step.Title+"\n"+step.Description, -1) {
+			id, key := m[1], m[2]
+			from := f.GetStep(id)
+			switch {
+			case from == nil:
+				return fmt.Errorf("step %q uses output of unknown step %q", step.ID, id)
+			case !upstream[id]:
+				return fmt.Errorf("step %q uses output of %q but does not need it", step.ID, id)
+			case len(from.Outputs) > 0:
+				if _, ok := from.Outputs[key]; !ok {
+					return fmt.Errorf("step %q uses undeclared output %s.%s", step.ID, id, key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// upstream returns the steps a step transitively needs.
+func (f *Formula) upstream(id string) map[string]bool {
+	seen := make(map[string]bool)
+	var visit func(id string)
+	visit = func(id string) {
+		step := f.GetStep(id)
+		if step == nil {
+			return
+		}
+		for _, need := range step.Needs {
+			if !seen[need] {
+				seen[need] = true
+				visit(need)
+			}
+		}
+	}
+	visit(id)
+	return seen
+}
+
+func sortedKeys(m map[string]StepOutput) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
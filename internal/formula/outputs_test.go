@@ -0,0 +1,99 @@
+package formula
+
+import (
+	"strings"
+	"testing"
+)
+
+const outputsFormula = `
+formula = "release"
+type = "workflow"
+
+[[steps]]
+id = "build"
+title = "Build"
+
+[steps.outputs.artifact]
+description = "Path of the built tarball"
+artifact = true
+required = true
+
+[steps.outputs.version]
+
+[[steps]]
+id = "notes"
+title = "Write notes"
+needs = ["build"]
+
+[[steps]]
+id = "publish"
+title = "Publish {{steps.build.outputs.version}}"
+description = "Upload {{steps.build.outputs.artifact}} with {{steps.notes.outputs.file}}."
+needs = ["notes"]
+`
+
+func TestCheckOutputs(t *testing.T) {
+	f, err := Parse([]byte(outputsFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if err := f.CheckOutputs("build", map[string]string{"artifact": "out/x.tgz", "version": "1.2"}); err != nil {
+		t.Errorf("CheckOutputs: %v", err)
+	}
+	if err := f.CheckOutputs("build", map[string]string{"colour": "red"}); err == nil || !strings.Contains(err.Error(), "declares artifact, version") {
+		t.Errorf("undeclared err = %v", err)
+	}
+	if err := f.CheckOutputs("notes", map[string]string{"anything": "goes"}); err != nil {
+		t.Errorf("undeclared outputs of notes: %v", err)
+	}
+	if err := f.CheckOutputs("notes", map[string]string{"bad-key": "x"}); err == nil {
+		t.Error("invalid key accepted")
+	}
+
+	if missing := f.MissingOutputs("build", map[string]string{"version": "1.2"}); strings.Join(missing, ",") != "artifact" {
+		t.Errorf("MissingOutputs = %v, want [artifact]", missing)
+	}
+	if missing := f.MissingOutputs("notes", nil); len(missing) != 0 {
+		t.Errorf("MissingOutputs(notes) = %v", missing)
+	}
+}
+
+func TestValidateOutputs(t *testing.T) {
+	tests := []struct {
+		steps string
+		want  string
+	}{
+		{`[[steps]]
+id = "a"
+[steps.outputs.bad-key]`, `invalid output key "bad-key"`},
+		{`[[steps]]
+id = "a"
+description = "{{steps.b.outputs.x}}"`, `unknown step "b"`},
+		{`[[steps]]
+id = "a"
+[[steps]]
+id = "b"
+description = "{{steps.a.outputs.x}}"`, `does not need it`},
+		{`[[steps]]
+id = "a"
+[steps.outputs.y]
+[[steps]]
+id = "b"
+needs = ["a"]
+description = "{{steps.a.outputs.x}}"`, "undeclared output a.x"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte("formula = \"x\"\ntype = \"workflow\"\n" + tt.steps + "\n"))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("err = %v, want %q", err, tt.want)
+		}
+	}
+}
+
+func TestOutputContext(t *testing.T) {
+	ctx := OutputContext(map[string]map[string]string{"build": {"version": "1.2"}})
+	if len(ctx) != 1 || ctx["steps.build.outputs.version"] != "1.2" {
+		t.Errorf("OutputContext = %v", ctx)
+	}
+}
@@ -0,0 +1,276 @@
+package formula
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Param types for vars and inputs. An empty type is a string.
+const (
+	ParamString   = "string"
+	ParamNumber   = "number"
+	ParamBool     = "bool"
+	ParamEnum     = "enum"     // One of Values
+	ParamBeadID   = "bead-id"  // A bead ID such as gt-abc12 or gt-abc12.3
+	ParamRigName  = "rig-name" // A rig in the town
+	ParamGlob     = "glob"     // A file pattern
+	ParamDuration = "duration" // A Go duration such as 30m or 1h30m
+)
+
+// beadIDPattern matches bead IDs: a prefix, a dash, and an ID with
+// optional dotted child suffixes.
+var beadIDPattern = regexp.MustCompile(`^[a-z][a-z0-9]*-[a-z0-9][a-z0-9-]*(\.[a-z0-9]+)*$`)
+
+// Param is a var or input of a formula, with its type and constraints.
+type Param struct {
+	Name           string
+	Description    string
+	Type           string
+	Required       bool
+	RequiredUnless []string // Required unless one of these is set
+	Default        string
+	Values         []string // Allowed values of an enum
+}
+
+// Params returns the formula's vars and inputs sorted by name. A name
+// declared as both uses the var.
+func (f *Formula) Params() []Param {
+	byName := make(map[string]Param)
+	for name, in := range f.Inputs {
+		byName[name] = Param{
+			Name:           name,
+			Description:    in.Description,
+			Type:           in.Type,
+			Required:       in.Required,
+			RequiredUnless: in.RequiredUnless,
+			Default:        in.Default,
+			Values:         in.Values,
+		}
+	}
+	for name, v := range f.Vars {
+		byName[name] = Param{
+			Name:        name,
+			Description: v.Description,
+			Type:        v.Type,
+			Required:    v.Required,
+			Default:     v.Default,
+			Values:      v.Values,
+		}
+	}
+	params := make([]Param, 0, len(byName))
+	for _, p := range byName {
+		params = append(params, p)
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+	return params
+}
+
+// Check validates a value against the param's type and returns it in
+// canonical form (bools as true/false). rigs lists the town's rigs for
+// rig-name params; when nil, any well-formed name is accepted.
+func (p Param) Check(value string, rigs []string) (string, error) {
+	switch p.Type {
+	case "", ParamString:
+		return value, nil
+	case ParamNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", fmt.Errorf("%s: %q is not a number", p.Name, value)
+		}
+	case ParamBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			switch strings.ToLower(value) {
+			case "yes", "y", "on":
+				b = true
+			case "no", "n", "off":
+				b = false
+			default:
+				return "", fmt.Errorf("%s: %q is not a bool", p.Name, value)
+			}
+		}
+		return strconv.FormatBool(b), nil
+	case ParamEnum:
+		for _, v := range p.Values {
+			if v == value {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("%s: %q is not one of %s", p.Name, value, strings.Join(p.Values, ", "))
+	case ParamBeadID:
+		if !beadIDPattern.MatchString(value) {
+			return "", fmt.Errorf("%s: %q is not a bead ID", p.Name, value)
+		}
+	case ParamRigName:
+		if value == "" || strings.ContainsAny(value, "/ \t") {
+			return "", fmt.Errorf("%s: %q is not a rig name", p.Name, value)
+		}
+		if rigs != nil {
+			for _, rig := range rigs {
+				if rig == value {
+					return value, nil
+				}
+			}
+			return "", fmt.Errorf("%s: no rig named %q", p.Name, value)
+		}
+	case ParamGlob:
+		if _, err := path.Match(value, ""); err != nil {
+			return "", fmt.Errorf("%s: %q is not a valid glob: %w", p.Name, value, err)
+		}
+	case ParamDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return "", fmt.Errorf("%s: %q is not a duration", p.Name, value)
+		}
+	default:
+		return "", fmt.Errorf("%s: unknown type %q", p.Name, p.Type)
+	}
+	return value, nil
+}
+
+// BindOptions configures BindParams.
+type BindOptions struct {
+	// Rigs lists the town's rigs, for rig-name params.
+	Rigs []string
+
+	// Prompt asks for a required param that has no value. When nil, missing
+	// params are an error.
+	Prompt func(p Param) (string, error)
+}
+
+// BindParams validates the values given for a formula's params and fills
+// in defaults, prompting for missing required params if opts.Prompt is set.
+// Values for undeclared params are an error, unless the formula declares
+// none (its vars are then unchecked).
+func (f *Formula) BindParams(given map[string]string, opts BindOptions) (map[string]string, error) {
+	params := f.Params()
+	bound := make(map[string]string, len(given))
+	if len(params) == 0 {
+		for k, v := range given {
+			bound[k] = v
+		}
+		return bound, nil
+	}
+
+	declared := make(map[string]bool, len(params))
+	var names []string
+	for _, p := range params {
+		declared[p.Name] = true
+		names = append(names, p.Name)
+	}
+	for name := range given {
+		if !declared[name] {
+			return nil, fmt.Errorf("formula %s has no var %q (takes %s)", f.Name, name, strings.Join(names, ", "))
+		}
+	}
+
+	set := make(map[string]string, len(given)) // Given or prompted
+	for k, v := range given {
+		set[k] = v
+	}
+	var missing []string
+	reported := make(map[string]bool)
+	for _, p := range params {
+		value, ok := given[p.Name]
+		if !ok && p.Default != "" {
+			value, ok = p.Default, true
+		}
+		if !ok && p.required(set) {
+			if opts.Prompt == nil {
+				switch {
+				case p.Required || len(p.RequiredUnless) == 0:
+					missing = append(missing, p.Name)
+				case !anyOf(reported, p.RequiredUnless):
+					// One entry for the group of alternatives
+					missing = append(missing, p.Name+" (or "+strings.Join(p.RequiredUnless, " or ")+")")
+				}
+				reported[p.Name] = true
+				continue
+			}
+			var err error
+			if value, err = opts.Prompt(p); err != nil {
+				return nil, err
+			}
+			ok = true
+			set[p.Name] = value
+		}
+		if !ok {
+			continue
+		}
+		checked, err := p.Check(value, opts.Rigs)
+		if err != nil {
+			return nil, err
+		}
+		bound[p.Name] = checked
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("formula %s requires %s (set with --var)", f.Name, strings.Join(missing, ", "))
+	}
+	return bound, nil
+}
+
+// required reports whether the param needs a value given the others set.
+func (p Param) required(set map[string]string) bool {
+	if p.Required {
+		return true
+	}
+	if len(p.RequiredUnless) == 0 {
+		return false
+	}
+	for _, other := range p.RequiredUnless {
+		if _, ok := set[other]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+func anyOf(set map[string]bool, names []string) bool {
+	for _, name := range names {
+		if set[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// validateParams checks param types, enum values and defaults.
+func (f *Formula) validateParams() error {
+	declared := make(map[string]bool)
+	for _, p := range f.Params() {
+		declared[p.Name] = true
+	}
+	for _, p := range f.Params() {
+		if !validParamType(p.Type) {
+			return fmt.Errorf("var %q: unknown type %q", p.Name, p.Type)
+		}
+		if p.Type == ParamEnum && len(p.Values) == 0 {
+			return fmt.Errorf("var %q: enum requires values", p.Name)
+		}
+		if p.Type != ParamEnum && len(p.Values) > 0 {
+			return fmt.Errorf("var %q: values are only allowed for enum", p.Name)
+		}
+		for _, other := range p.RequiredUnless {
+			if !declared[other] {
+				return fmt.Errorf("var %q: required_unless names unknown var %q", p.Name, other)
+			}
+		}
+		if p.Default != "" {
+			if _, err := p.Check(p.Default, nil); err != nil {
+				return fmt.Errorf("default for %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func validParamType(t string) bool {
+	switch t {
+	case "", ParamString, ParamNumber, ParamBool, ParamEnum, ParamBeadID, ParamRigName, ParamGlob, ParamDuration:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package formula
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParamCheck(t *testing.T) {
+	tests := []struct {
+		typ   string
+		value string
+		want  string // "" means an error is expected
+	}{
+		{"", "anything", "anything"},
+		{ParamNumber, "42", "42"},
+		{ParamNumber, "4.5", "4.5"},
+		{ParamNumber, "lots", ""},
+		{ParamBool, "yes", "true"},
+		{ParamBool, "0", "false"},
+		{ParamBool, "maybe", ""},
+		{ParamEnum, "medium", "medium"},
+		{ParamEnum, "huge", ""},
+		{ParamBeadID, "gt-abc12", "gt-abc12"},
+		{ParamBeadID, "hq-cv-x9.3", "hq-cv-x9.3"},
+		{ParamBeadID, "abc12", ""},
+		{ParamRigName, "gastown", "gastown"},
+		{ParamRigName, "nowhere", ""},
+		{ParamGlob, "internal/**/*.go", "internal/**/*.go"},
+		{ParamGlob, "[", ""},
+		{ParamDuration, "1h30m", "1h30m"},
+		{ParamDuration, "soon", ""},
+	}
+	for _, tt := range tests {
+		p := Param{Name: "v", Type: tt.typ, Values: []string{"small", "medium"}}
+		got, err := p.Check(tt.value, []string{"gastown", "beads"})
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s %q: accepted as %q, want error", tt.typ, tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s %q = %q, %v, want %q", tt.typ, tt.value, got, err, tt.want)
+		}
+	}
+}
+
+const paramsFormula = `
+formula = "params"
+type = "workflow"
+
+[[steps]]
+id = "work"
+
+[vars.issue]
+type = "bead-id"
+required = true
+
+[vars.rig]
+type = "rig-name"
+default = "gastown"
+
+[vars.dry_run]
+type = "bool"
+default = "no"
+
+[inputs.pr]
+type = "number"
+required_unless = ["files"]
+
+[inputs.files]
+type = "glob"
+required_unless = ["pr"]
+`
+
+func TestBindParams(t *testing.T) {
+	f, err := Parse([]byte(paramsFormula))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	opts := BindOptions{Rigs: []string{"gastown", "beads"}}
+
+	bound, err := f.BindParams(map[string]string{"issue": "gt-abc", "pr": "12", "rig": "beads"}, opts)
+	if err != nil {
+		t.Fatalf("BindParams: %v", err)
+	}
+	if bound["issue"] != "gt-abc" || bound["rig"] != "beads" || bound["dry_run"] != "false" || bound["pr"] != "12" {
+		t.Errorf("bound = %v", bound)
+	}
+	if _, ok := bound["files"]; ok {
+		t.Errorf("files bound without a value: %v", bound)
+	}
+
+	_, err = f.BindParams(map[string]string{}, opts)
+	if err == nil || !strings.Contains(err.Error(), "requires files (or pr), issue") {
+		t.Errorf("missing err = %v", err)
+	}
+	if _, err := f.BindParams(map[string]string{"issue": "gt-abc", "pr": "x"}, opts); err == nil {
+		t.Error("non-numeric pr accepted")
+	}
+	if _, err := f.BindParams(map[string]string{"issue": "gt-abc", "pr": "1", "colour": "red"}, opts); err == nil ||
+		!strings.Contains(err.Error(), `no var "colour"`) {
+		t.Errorf("unknown var err = %v", err)
+	}
+
+	// Prompting fills in required params
+	var asked []string
+	opts.Prompt = func(p Param) (string, error) {
+		asked = append(asked, p.Name)
+		if p.Name == "issue" {
+			return "gt-xyz", nil
+		}
+		return "*.go", nil
+	}
+	bound, err = f.BindParams(map[string]string{}, opts)
+	if err != nil || bound["issue"] != "gt-xyz" || strings.Join(asked, ",") != "files,issue" {
+		t.Errorf("prompted bound = %v, %v, asked %v", bound, err, asked)
+	}
+
+	stop := errors.New("interrupted")
+	opts.Prompt = func(Param) (string, error) { return "", stop }
+	if _, err := f.BindParams(map[string]string{}, opts); !errors.Is(err, stop) {
+		t.Errorf("prompt err = %v, want %v", err, stop)
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	tests := []struct {
+		vars string
+		want string
+	}{
+		{"[vars.a]\ntype = \"colour\"", `unknown type "colour"`},
+		{"[vars.a]\ntype = \"enum\"", "enum requires values"},
+		{"[vars.a]\nvalues = [\"x\"]", "only allowed for enum"},
+		{"[vars.a]\ntype = \"duration\"\ndefault = \"soon\"", "not a duration"},
+		{"[inputs.a]\nrequired_unless = [\"b\"]", `unknown var "b"`},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte("formula = \"x\"\ntype = \"workflow\"\n[[steps]]\nid = \"s\"\n" + tt.vars + "\n"))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: err = %v, want %q", tt.vars, err, tt.want)
+		}
+	}
+}
@@ -3,24 +3,37 @@ package formula
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/BurntSushi/toml"
 )
 
-// ParseFile reads and parses a formula.toml file.
+// ParseFile reads and parses a formula.toml file. Formulas it extends or
+// includes are looked up next to it, then among the embedded formulas.
 func ParseFile(path string) (*Formula, error) {
 	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from trusted formula directory
 	if err != nil {
 		return nil, fmt.Errorf("reading formula file: %w", err)
 	}
-	return Parse(data)
+	return ParseWith(data, SearchLoader(filepath.Dir(path)))
 }
 
-// Parse parses formula.toml content from bytes.
+// Parse parses formula.toml content from bytes. Formulas it extends or
+// includes are looked up among the embedded formulas.
 func Parse(data []byte) (*Formula, error) {
-	var f Formula
-	if _, err := toml.Decode(string(data), &f); err != nil {
-		return nil, fmt.Errorf("parsing TOML: %w", err)
+	return ParseWith(data, EmbeddedLoader)
+}
+
+// ParseWith parses formula.toml content, resolving extends and include
+// with load before validating.
+func ParseWith(data []byte, load Loader) (*Formula, error) {
+	f, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.Resolve(load); err != nil {
+		return nil, err
 	}
 
 	// Infer type from content if not explicitly set
@@ -30,6 +43,23 @@ func Parse(data []byte) (*Formula, error) {
 		return nil, err
 	}
 
+	return f, nil
+}
+
+// decode parses TOML without resolving or validating.
+func decode(data []byte) (*Formula, error) {
+	var f Formula
+	md, err := toml.Decode(string(data), &f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing TOML: %w", err)
+	}
+	// Composition beyond extends and include (aspects, expansions) isn't
+	// implemented. Refuse it rather than run the formula without it.
+	for _, key := range md.Undecoded() {
+		if len(key) == 2 && key[0] == "compose" {
+			return nil, fmt.Errorf("unsupported composition %q (only extends and include are implemented)", key.String())
+		}
+	}
 	return &f, nil
 }
 
@@ -48,6 +78,8 @@ func (f *Formula) inferType() {
 		f.Type = TypeExpansion
 	} else if len(f.Aspects) > 0 {
 		f.Type = TypeAspect
+	} else if len(f.Fragments) > 0 {
+		f.Type = TypeLibrary
 	}
 }
 
@@ -59,7 +91,11 @@ func (f *Formula) Validate() error {
 	}
 
 	if !f.Type.IsValid() {
-		return fmt.Errorf("invalid formula type %q (must be convoy, workflow, expansion, or aspect)", f.Type)
+		return fmt.Errorf("invalid formula type %q (must be convoy, workflow, expansion, aspect, or library)", f.Type)
+	}
+
+	if err := f.validateParams(); err != nil {
+		return err
 	}
 
 	// Type-specific validation
@@ -72,6 +108,8 @@ func (f *Formula) Validate() error {
 		return f.validateExpansion()
 	case TypeAspect:
 		return f.validateAspect()
+	case TypeLibrary:
+		return f.validateLibrary()
 	}
 
 	return nil
@@ -132,12 +170,19 @@ func (f *Formula) validateWorkflow() error {
 		}
 	}
 
+	// Validate control flow
+	for _, step := range f.Steps {
+		if err := validateFlow(step, seen); err != nil {
+			return err
+		}
+	}
+
 	// Check for cycles
 	if err := f.checkCycles(); err != nil {
 		return err
 	}
 
-	return nil
+	return f.validateOutputs()
 }
 
 func (f *Formula) validateExpansion() error {
@@ -166,6 +211,9 @@ func (f *Formula) validateExpansion() error {
 		}
 	}
 
+	if f.ForEach != nil {
+		return f.ForEach.validate()
+	}
 	return nil
 }
 
@@ -189,6 +237,37 @@ func (f *Formula) validateAspect() error {
 	return nil
 }
 
+func (f *Formula) validateLibrary() error {
+	if len(f.Fragments) == 0 {
+		return fmt.Errorf("library formula requires at least one fragment")
+	}
+
+	for name, fragment := range f.Fragments {
+		if len(fragment.Steps) == 0 {
+			return fmt.Errorf("fragment %q requires at least one step", name)
+		}
+		seen := make(map[string]bool)
+		for _, step := range fragment.Steps {
+			if step.ID == "" {
+				return fmt.Errorf("fragment %q: step missing required id field", name)
+			}
+			if seen[step.ID] {
+				return fmt.Errorf("fragment %q: duplicate step id: %s", name, step.ID)
+			}
+			seen[step.ID] = true
+		}
+		for _, step := range fragment.Steps {
+			for _, need := range step.Needs {
+				if !seen[need] {
+					return fmt.Errorf("fragment %q: step %q needs unknown step: %s", name, step.ID, need)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 // checkCycles detects circular dependencies in steps.
 func (f *Formula) checkCycles() error {
 	// Build adjacency list
@@ -324,26 +403,19 @@ func (f *Formula) TopologicalSort() ([]string, error) {
 
 // ReadySteps returns steps that have no unmet dependencies.
 // completed is a set of step IDs that have been completed.
+// Workflow steps are planned as by Plan, with no vars or outputs set.
 func (f *Formula) ReadySteps(completed map[string]bool) []string {
 	var ready []string
 
 	switch f.Type {
 	case TypeWorkflow:
-		for _, step := range f.Steps {
-			if completed[step.ID] {
-				continue
-			}
-			allMet := true
-			for _, need := range step.Needs {
-				if !completed[need] {
-					allMet = false
-					break
-				}
-			}
-			if allMet {
-				ready = append(ready, step.ID)
+		p := &Progress{Outcomes: make(map[string]StepOutcome)}
+		for id, done := range completed {
+			if done {
+				p.Outcomes[id] = OutcomeDone
 			}
 		}
+		ready, _ = f.Plan(p)
 	case TypeExpansion:
 		for _, tmpl := range f.Template {
 			if completed[tmpl.ID] {
@@ -0,0 +1,396 @@
+package formula
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Formula sources, in order of precedence.
+const (
+	SourceRig       = "rig"       // .beads/formulas/ of the current rig or project
+	SourceTown      = "town"      // .beads/formulas/ of the town
+	SourceInstalled = "installed" // Packages installed with gt formula install
+	SourceUser      = "user"      // ~/.beads/formulas/
+	SourceEmbedded  = "embedded"  // Built into gt
+)
+
+// LockFileName is the lockfile of installed formula packages, kept in the
+// town's .beads/formulas/.
+const LockFileName = "formulas.lock.json"
+
+// InstalledDirName is the subdirectory of the town's .beads/formulas/ that
+// packages are installed into, one directory per package. It is searched
+// after the town's own formulas, so a town formula of the same name
+// overrides an installed one without touching the package.
+const InstalledDirName = "installed"
+
+// ChecksumFileName is the optional checksum file of a formula package, in
+// sha256sum format. It guards against corrupted formulas, not tampered ones:
+// it comes from the same repository as the formulas it lists.
+const ChecksumFileName = "SHA256SUMS"
+
+// formulaExts are the file extensions of formulas.
+var formulaExts = []string{".formula.toml", ".formula.json"}
+
+// Lock pins the installed formula packages of a town.
+type Lock struct {
+	Packages []LockedPackage `json:"packages"`
+}
+
+// LockedPackage is an installed formula package.
+type LockedPackage struct {
+	Name        string            `json:"name"`
+	URL         string            `json:"url"`
+	Ref         string            `json:"ref,omitempty"`      // Requested ref; empty follows the default branch
+	Commit      string            `json:"commit"`             // Commit the formulas were installed from
+	Verified    bool              `json:"verified,omitempty"` // Signature checked on install and update
+	Insecure    bool              `json:"insecure,omitempty"` // Installed without a signature check
+	Formulas    map[string]string `json:"formulas"`           // Filename -> sha256
+	InstalledAt string            `json:"installed_at"`
+}
+
+// PackageDir returns the directory a package is installed into, given the
+// town's .beads/formulas/.
+func PackageDir(dir, name string) string {
+	return filepath.Join(dir, InstalledDirName, name)
+}
+
+// checkPackageName rejects package names that aren't a plain directory name.
+func checkPackageName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid formula package name %q", name)
+	}
+	return nil
+}
+
+// LoadLock reads the lockfile in dir. A missing lockfile is an empty lock.
+func LoadLock(dir string) (*Lock, error) {
+	data, err := os.ReadFile(filepath.Join(dir, LockFileName))
+	if os.IsNotExist(err) {
+		return &Lock{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading formula lock: %w", err)
+	}
+	var l Lock
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("parsing formula lock: %w", err)
+	}
+	return &l, nil
+}
+
+// Save writes the lockfile to dir.
+func (l *Lock) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating formulas directory: %w", err)
+	}
+	sort.Slice(l.Packages, func(i, j int) bool { return l.Packages[i].Name < l.Packages[j].Name })
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding formula lock: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, LockFileName), append(data, '\n'), 0644)
+}
+
+// Get returns the locked package with the given name, or nil.
+func (l *Lock) Get(name string) *LockedPackage {
+	for i := range l.Packages {
+		if l.Packages[i].Name == name {
+			return &l.Packages[i]
+		}
+	}
+	return nil
+}
+
+// Owner returns the package that installed a formula file, or "".
+func (l *Lock) Owner(file string) string {
+	for _, pkg := range l.Packages {
+		if _, ok := pkg.Formulas[file]; ok {
+			return pkg.Name
+		}
+	}
+	return ""
+}
+
+// Modified returns the files of a package that were changed since it was
+// installed under dir, sorted. Missing files are not modified: they are
+// simply installed again.
+func (l *Lock) Modified(dir, name string) []string {
+	pkg := l.Get(name)
+	if pkg == nil {
+		return nil
+	}
+	var modified []string
+	for file, hash := range pkg.Formulas {
+		current, err := computeFileHash(filepath.Join(PackageDir(dir, name), file))
+		if err == nil && current != hash {
+			modified = append(modified, file)
+		}
+	}
+	sort.Strings(modified)
+	return modified
+}
+
+// Install writes a package's formulas into its own directory under dir,
+// a town's .beads/formulas/, and records them in the lock, replacing the
+// files of any previously installed version. A file installed by another
+// package is a conflict, and files modified since the previous install are
+// kept unless force is set. The caller saves the lock.
+func (l *Lock) Install(dir string, pkg LockedPackage, files map[string][]byte, force bool) error {
+	if err := checkPackageName(pkg.Name); err != nil {
+		return err
+	}
+	for file := range files {
+		if owner := l.Owner(file); owner != "" && owner != pkg.Name {
+			return fmt.Errorf("%s is already installed by package %s", file, owner)
+		}
+	}
+	if modified := l.Modified(dir, pkg.Name); len(modified) > 0 && !force {
+		return fmt.Errorf("installed formulas of %s were modified: %s (use --force to overwrite)",
+			pkg.Name, strings.Join(modified, ", "))
+	}
+	pkgDir := PackageDir(dir, pkg.Name)
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		return fmt.Errorf("creating package directory: %w", err)
+	}
+	old := l.Get(pkg.Name)
+	if old != nil {
+		for file := range old.Formulas {
+			if _, kept := files[file]; !kept {
+				if err := os.Remove(filepath.Join(pkgDir, file)); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("removing %s: %w", file, err)
+				}
+			}
+		}
+	}
+
+	pkg.Formulas = make(map[string]string, len(files))
+	for _, file := range sortedFiles(files) {
+		data := files[file]
+		if err := os.WriteFile(filepath.Join(pkgDir, file), data, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", file, err)
+		}
+		pkg.Formulas[file] = computeHash(data)
+	}
+	if old != nil {
+		*old = pkg
+	} else {
+		l.Packages = append(l.Packages, pkg)
+	}
+	return nil
+}
+
+// Uninstall removes a package's directory under dir and its lock entry.
+func (l *Lock) Uninstall(dir, name string) error {
+	if l.Get(name) == nil {
+		return fmt.Errorf("formula package %s is not installed", name)
+	}
+	if err := os.RemoveAll(PackageDir(dir, name)); err != nil {
+		return fmt.Errorf("removing %s: %w", name, err)
+	}
+	for i := range l.Packages {
+		if l.Packages[i].Name == name {
+			l.Packages = append(l.Packages[:i], l.Packages[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Sources returns the directories of the installed packages under dir, to
+// be searched after the town's own formulas.
+func (l *Lock) Sources(dir string) []Source {
+	if l == nil {
+		return nil
+	}
+	sources := make([]Source, 0, len(l.Packages))
+	for _, pkg := range l.Packages {
+		sources = append(sources, Source{Name: SourceInstalled, Dir: PackageDir(dir, pkg.Name)})
+	}
+	return sources
+}
+
+// CheckLocked verifies formulas fetched for a locked package against the
+// checksums in the lock.
+func (pkg *LockedPackage) CheckLocked(files map[string][]byte) error {
+	for file, hash := range pkg.Formulas {
+		data, ok := files[file]
+		if !ok {
+			return fmt.Errorf("%s: %s is missing at %s", pkg.Name, file, shortCommit(pkg.Commit))
+		}
+		if computeHash(data) != hash {
+			return fmt.Errorf("%s: checksum mismatch for %s at %s", pkg.Name, file, shortCommit(pkg.Commit))
+		}
+	}
+	for file := range files {
+		if _, ok := pkg.Formulas[file]; !ok {
+			return fmt.Errorf("%s: %s is not in the lock at %s", pkg.Name, file, shortCommit(pkg.Commit))
+		}
+	}
+	return nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+// PackageFormulas reads the formulas of a checked-out package: formula
+// files at its root, in formulas/ and in .beads/formulas/. It returns the
+// content by filename, and each file's path relative to root.
+func PackageFormulas(root string) (files map[string][]byte, paths map[string]string, err error) {
+	files = make(map[string][]byte)
+	paths = make(map[string]string)
+	for _, sub := range []string{".", "formulas", filepath.Join(".beads", "formulas")} {
+		entries, err := os.ReadDir(filepath.Join(root, sub))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading package: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !isFormulaFile(entry.Name()) {
+				continue
+			}
+			rel := filepath.ToSlash(filepath.Join(sub, entry.Name()))
+			if prev, dup := paths[entry.Name()]; dup {
+				return nil, nil, fmt.Errorf("package has %s twice (%s and %s)", entry.Name(), prev, rel)
+			}
+			data, err := os.ReadFile(filepath.Join(root, rel)) //nolint:gosec // G304: path is within the package checkout
+			if err != nil {
+				return nil, nil, fmt.Errorf("reading %s: %w", rel, err)
+			}
+			files[entry.Name()] = data
+			paths[entry.Name()] = rel
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("no formulas found (looked for *.formula.toml at the root, in formulas/ and .beads/formulas/)")
+	}
+	return files, paths, nil
+}
+
+// VerifyChecksums checks a package's formulas against the SHA256SUMS file
+// at its root. Every formula must be listed. It reports whether the
+// package has a checksum file.
+func VerifyChecksums(root string, files map[string][]byte, paths map[string]string) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(root, ChecksumFileName))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("reading %s: %w", ChecksumFileName, err)
+	}
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		sums[strings.TrimPrefix(strings.TrimPrefix(fields[1], "*"), "./")] = strings.ToLower(fields[0])
+	}
+
+	for file, content := range files {
+		want, ok := sums[paths[file]]
+		if !ok {
+			return true, fmt.Errorf("%s is not listed in %s", paths[file], ChecksumFileName)
+		}
+		if computeHash(content) != want {
+			return true, fmt.Errorf("checksum mismatch for %s", paths[file])
+		}
+	}
+	return true, nil
+}
+
+// Source is a directory of formulas at a level of the search path.
+type Source struct {
+	Name string // One of the Source* constants
+	Dir  string
+}
+
+// ListedFormula is a formula found on the search path.
+type ListedFormula struct {
+	Name    string   `json:"name"`
+	Source  string   `json:"source"`
+	Path    string   `json:"path,omitempty"`    // Empty for embedded formulas
+	Shadows []string `json:"shadows,omitempty"` // Lower-precedence sources that also have it
+}
+
+// ListSources lists the formulas in sources, in order of precedence, and
+// the embedded formulas. A formula is taken from the first source that
+// has it. The result is sorted by name.
+func ListSources(sources []Source) ([]ListedFormula, error) {
+	byName := make(map[string]*ListedFormula)
+	add := func(name, source, path string) {
+		if listed, ok := byName[name]; ok {
+			listed.Shadows = append(listed.Shadows, source)
+			return
+		}
+		byName[name] = &ListedFormula{Name: name, Source: source, Path: path}
+	}
+
+	for _, src := range sources {
+		entries, err := os.ReadDir(src.Dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s formulas: %w", src.Name, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !isFormulaFile(entry.Name()) {
+				continue
+			}
+			add(formulaName(entry.Name()), src.Name, filepath.Join(src.Dir, entry.Name()))
+		}
+	}
+
+	embedded, err := getEmbeddedFormulas()
+	if err != nil {
+		return nil, err
+	}
+	for file := range embedded {
+		add(formulaName(file), SourceEmbedded, "")
+	}
+
+	listed := make([]ListedFormula, 0, len(byName))
+	for _, f := range byName {
+		listed = append(listed, *f)
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Name < listed[j].Name })
+	return listed, nil
+}
+
+func sortedFiles(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isFormulaFile(name string) bool {
+	return formulaName(name) != name
+}
+
+// formulaName strips the formula extension from a filename.
+func formulaName(file string) string {
+	for _, ext := range formulaExts {
+		if strings.HasSuffix(file, ext) {
+			return strings.TrimSuffix(file, ext)
+		}
+	}
+	return file
+}
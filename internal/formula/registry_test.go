@@ -0,0 +1,192 @@
+package formula
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLockInstall tests installing, updating and uninstalling a package.
+func TestLockInstall(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".beads", "formulas")
+	writeTestFile(t, filepath.Join(dir, "mine.formula.toml"), "town's own")
+	pkgDir := PackageDir(dir, "acme")
+
+	lock := &Lock{}
+	files := map[string][]byte{
+		"acme-a.formula.toml": []byte("a v1"),
+		"acme-b.formula.toml": []byte("b v1"),
+		"mine.formula.toml":   []byte("package's"),
+	}
+	if err := lock.Install(dir, LockedPackage{Name: "acme", Commit: "c1"}, files, false); err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	pkg := lock.Get("acme")
+	if pkg == nil || len(pkg.Formulas) != 3 {
+		t.Fatalf("package = %+v, want 3 formulas", pkg)
+	}
+	if data, _ := os.ReadFile(filepath.Join(pkgDir, "mine.formula.toml")); string(data) != "package's" {
+		t.Errorf("package formula not installed in its directory: %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "mine.formula.toml")); string(data) != "town's own" {
+		t.Errorf("town formula overwritten: %q", data)
+	}
+	if lock.Owner("acme-a.formula.toml") != "acme" {
+		t.Error("wrong owner")
+	}
+
+	// Round trip through the lockfile
+	if err := lock.Save(dir); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	lock, err := LoadLock(dir)
+	if err != nil || lock.Get("acme") == nil || lock.Get("acme").Commit != "c1" {
+		t.Fatalf("LoadLock() = %+v, %v", lock, err)
+	}
+
+	// Another package may not take over a file
+	err = lock.Install(dir, LockedPackage{Name: "other"}, map[string][]byte{"acme-a.formula.toml": []byte("x")}, false)
+	if err == nil || !strings.Contains(err.Error(), "already installed by package acme") {
+		t.Errorf("conflict err = %v", err)
+	}
+	if err := lock.Install(dir, LockedPackage{Name: ".."}, files, false); err == nil {
+		t.Error("package name outside the installed directory accepted")
+	}
+
+	// A town override is not an edit of the package; only edits in its
+	// directory are, and those are kept unless forced
+	writeTestFile(t, filepath.Join(dir, "acme-a.formula.toml"), "town override")
+	update := map[string][]byte{"acme-a.formula.toml": []byte("a v2")}
+	if err := lock.Install(dir, LockedPackage{Name: "acme", Commit: "c2"}, update, false); err != nil {
+		t.Fatalf("update with a town override: %v", err)
+	}
+	writeTestFile(t, filepath.Join(pkgDir, "acme-a.formula.toml"), "edited")
+	update = map[string][]byte{"acme-a.formula.toml": []byte("a v3")}
+	if err := lock.Install(dir, LockedPackage{Name: "acme", Commit: "c3"}, update, false); err == nil {
+		t.Error("modified formula overwritten without force")
+	}
+	if err := lock.Install(dir, LockedPackage{Name: "acme", Commit: "c3"}, update, true); err != nil {
+		t.Fatalf("Install(force) error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(pkgDir, "acme-b.formula.toml")); !os.IsNotExist(err) {
+		t.Error("file dropped by the new version was not removed")
+	}
+	if len(lock.Packages) != 1 || lock.Get("acme").Commit != "c3" {
+		t.Errorf("packages = %+v", lock.Packages)
+	}
+
+	if err := lock.Uninstall(dir, "acme"); err != nil {
+		t.Fatalf("Uninstall() error: %v", err)
+	}
+	if _, err := os.Stat(pkgDir); !os.IsNotExist(err) {
+		t.Error("uninstalled package directory still present")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mine.formula.toml")); err != nil {
+		t.Error("uninstall removed the town's own formula")
+	}
+	if err := lock.Uninstall(dir, "acme"); err == nil {
+		t.Error("uninstalling a missing package succeeded")
+	}
+}
+
+// TestCheckLocked tests verifying fetched formulas against the lock.
+func TestCheckLocked(t *testing.T) {
+	pkg := &LockedPackage{
+		Name:     "acme",
+		Formulas: map[string]string{"a.formula.toml": computeHash([]byte("a"))},
+	}
+	if err := pkg.CheckLocked(map[string][]byte{"a.formula.toml": []byte("a")}); err != nil {
+		t.Errorf("CheckLocked() error: %v", err)
+	}
+	for _, files := range []map[string][]byte{
+		{"a.formula.toml": []byte("changed")},
+		{},
+		{"a.formula.toml": []byte("a"), "new.formula.toml": []byte("n")},
+	} {
+		if err := pkg.CheckLocked(files); err == nil {
+			t.Errorf("CheckLocked(%v) accepted", files)
+		}
+	}
+}
+
+// TestPackageFormulas tests reading a package and its checksum file.
+func TestPackageFormulas(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "a.formula.toml"), "a")
+	writeTestFile(t, filepath.Join(root, "formulas", "b.formula.json"), "b")
+	writeTestFile(t, filepath.Join(root, "README.md"), "not a formula")
+
+	files, paths, err := PackageFormulas(root)
+	if err != nil {
+		t.Fatalf("PackageFormulas() error: %v", err)
+	}
+	if len(files) != 2 || paths["b.formula.json"] != "formulas/b.formula.json" {
+		t.Errorf("files = %v, paths = %v", files, paths)
+	}
+
+	if has, err := VerifyChecksums(root, files, paths); has || err != nil {
+		t.Errorf("VerifyChecksums() without SHA256SUMS = %v, %v", has, err)
+	}
+	sums := computeHash([]byte("a")) + "  a.formula.toml\n" + computeHash([]byte("b")) + " *./formulas/b.formula.json\n"
+	writeTestFile(t, filepath.Join(root, ChecksumFileName), sums)
+	if has, err := VerifyChecksums(root, files, paths); !has || err != nil {
+		t.Errorf("VerifyChecksums() = %v, %v", has, err)
+	}
+	files["a.formula.toml"] = []byte("tampered")
+	if _, err := VerifyChecksums(root, files, paths); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("tampered err = %v", err)
+	}
+
+	writeTestFile(t, filepath.Join(root, ".beads", "formulas", "a.formula.toml"), "again")
+	if _, _, err := PackageFormulas(root); err == nil {
+		t.Error("duplicate formula accepted")
+	}
+	if _, _, err := PackageFormulas(t.TempDir()); err == nil {
+		t.Error("empty package accepted")
+	}
+}
+
+// TestListSources tests precedence and shadowing across sources.
+func TestListSources(t *testing.T) {
+	rig, town := t.TempDir(), t.TempDir()
+	writeTestFile(t, filepath.Join(rig, "code-review.formula.toml"), "rig")
+	writeTestFile(t, filepath.Join(town, "code-review.formula.toml"), "town")
+	writeTestFile(t, filepath.Join(town, "mine.formula.toml"), "town")
+	writeTestFile(t, filepath.Join(PackageDir(town, "acme"), "acme.formula.toml"), "installed")
+	writeTestFile(t, filepath.Join(PackageDir(town, "acme"), "mine.formula.toml"), "installed")
+	lock := &Lock{Packages: []LockedPackage{{Name: "acme"}}}
+
+	sources := append([]Source{{SourceRig, rig}, {SourceTown, town}}, lock.Sources(town)...)
+	listed, err := ListSources(sources)
+	if err != nil {
+		t.Fatalf("ListSources() error: %v", err)
+	}
+	byName := make(map[string]ListedFormula)
+	for _, f := range listed {
+		byName[f.Name] = f
+	}
+	want := map[string]string{
+		"code-review": "rig town embedded",
+		"acme":        "installed",
+		"mine":        "town installed",
+		"design":      "embedded",
+	}
+	for name, sources := range want {
+		f := byName[name]
+		got := strings.Join(append([]string{f.Source}, f.Shadows...), " ")
+		if got != sources {
+			t.Errorf("%s: sources = %q, want %q", name, got, sources)
+		}
+	}
+}
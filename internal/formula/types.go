@@ -6,6 +6,9 @@
 //   - workflow: Sequential steps with dependencies
 //   - expansion: Template-based step generation
 //   - aspect: Multi-aspect parallel analysis (like convoy but for analysis)
+//
+// Library formulas hold named step fragments that workflows include.
+// Formulas may extend other formulas; see Resolve.
 package formula
 
 // FormulaType represents the type of formula.
@@ -20,100 +23,161 @@ const (
 	TypeExpansion FormulaType = "expansion"
 	// TypeAspect is an aspect-based formula for multi-aspect parallel analysis.
 	TypeAspect FormulaType = "aspect"
+	// TypeLibrary is a library of reusable step fragments.
+	TypeLibrary FormulaType = "library"
 )
 
 // Formula represents a parsed formula.toml file.
 type Formula struct {
 	// Common fields
 	Name        string      `toml:"formula"`
-	Description string      `toml:"description"`
-	Type        FormulaType `toml:"type"`
-	Version     int         `toml:"version"`
+	Description string      `toml:"description,omitempty"`
+	Type        FormulaType `toml:"type,omitempty"`
+	Version     int         `toml:"version,omitempty"`
+
+	// Composition, applied by Resolve
+	Extends []string  `toml:"extends,omitempty"` // Base formulas, in order
+	Include []Include `toml:"include,omitempty"` // Step fragments from libraries
 
 	// Convoy-specific
-	Inputs    map[string]Input `toml:"inputs"`
-	Prompts   map[string]string `toml:"prompts"`
-	Output    *Output           `toml:"output"`
-	Legs      []Leg             `toml:"legs"`
-	Synthesis *Synthesis        `toml:"synthesis"`
+	Inputs    map[string]Input  `toml:"inputs,omitempty"`
+	Prompts   map[string]string `toml:"prompts,omitempty"`
+	Output    *Output           `toml:"output,omitempty"`
+	Legs      []Leg             `toml:"legs,omitempty"`
+	Synthesis *Synthesis        `toml:"synthesis,omitempty"`
 
 	// Workflow-specific
-	Steps []Step           `toml:"steps"`
-	Vars  map[string]Var   `toml:"vars"`
+	Steps []Step         `toml:"steps,omitempty"`
+	Vars  map[string]Var `toml:"vars,omitempty"`
 
 	// Expansion-specific
-	Template []Template `toml:"template"`
+	Template []Template `toml:"template,omitempty"`
+	ForEach  *ForEach   `toml:"for_each,omitempty"` // Instantiate the templates per item of runtime data
 
 	// Aspect-specific (similar to convoy but for analysis)
-	Aspects []Aspect `toml:"aspects"`
+	Aspects []Aspect `toml:"aspects,omitempty"`
+
+	// Library-specific
+	Fragments map[string]Fragment `toml:"fragments,omitempty"`
+}
+
+// Include pulls a named step fragment from a library formula into a
+// workflow. Without Before or After the fragment's steps are appended.
+type Include struct {
+	Fragment string `toml:"fragment"`
+	From     string `toml:"from"`             // Library formula name
+	Before   string `toml:"before,omitempty"` // Step the fragment runs before
+	After    string `toml:"after,omitempty"`  // Step the fragment runs after
+}
+
+// Fragment is a reusable group of steps in a library formula.
+type Fragment struct {
+	Description string `toml:"description,omitempty"`
+	Steps       []Step `toml:"steps"`
 }
 
 // Aspect represents a parallel analysis aspect in an aspect formula.
 type Aspect struct {
 	ID          string `toml:"id"`
-	Title       string `toml:"title"`
-	Focus       string `toml:"focus"`
-	Description string `toml:"description"`
+	Title       string `toml:"title,omitempty"`
+	Focus       string `toml:"focus,omitempty"`
+	Description string `toml:"description,omitempty"`
 }
 
 // Input represents an input parameter for a formula.
+// Type is one of the Param types; see Param.Check.
 type Input struct {
-	Description    string   `toml:"description"`
-	Type           string   `toml:"type"`
-	Required       bool     `toml:"required"`
-	RequiredUnless []string `toml:"required_unless"`
-	Default        string   `toml:"default"`
+	Description    string   `toml:"description,omitempty"`
+	Type           string   `toml:"type,omitempty"`
+	Required       bool     `toml:"required,omitempty"`
+	RequiredUnless []string `toml:"required_unless,omitempty"`
+	Default        string   `toml:"default,omitempty"`
+	Values         []string `toml:"values,omitempty"` // Allowed values of an enum
 }
 
 // Output configures where formula outputs are written.
 type Output struct {
-	Directory  string `toml:"directory"`
-	LegPattern string `toml:"leg_pattern"`
-	Synthesis  string `toml:"synthesis"`
+	Directory  string `toml:"directory,omitempty"`
+	LegPattern string `toml:"leg_pattern,omitempty"`
+	Synthesis  string `toml:"synthesis,omitempty"`
 }
 
 // Leg represents a parallel execution unit in a convoy formula.
 type Leg struct {
 	ID          string `toml:"id"`
-	Title       string `toml:"title"`
-	Focus       string `toml:"focus"`
-	Description string `toml:"description"`
+	Title       string `toml:"title,omitempty"`
+	Focus       string `toml:"focus,omitempty"`
+	Description string `toml:"description,omitempty"`
 }
 
 // Synthesis represents the synthesis step that combines leg outputs.
 type Synthesis struct {
-	Title       string   `toml:"title"`
-	Description string   `toml:"description"`
-	DependsOn   []string `toml:"depends_on"`
+	Title       string   `toml:"title,omitempty"`
+	Description string   `toml:"description,omitempty"`
+	DependsOn   []string `toml:"depends_on,omitempty"`
 }
 
 // Step represents a sequential step in a workflow formula.
+//
+// In a formula that extends another, a step whose ID matches an inherited
+// step overrides it, and Before or After insert a new step next to an
+// inherited one. Both are cleared once the formula is resolved.
+//
+// When, RepeatUntil and OnFailure control flow at runtime; see Plan.
 type Step struct {
 	ID          string   `toml:"id"`
-	Title       string   `toml:"title"`
-	Description string   `toml:"description"`
-	Needs       []string `toml:"needs"`
+	Title       string   `toml:"title,omitempty"`
+	Description string   `toml:"description,omitempty"`
+	Needs       []string `toml:"needs,omitempty"`
+	Before      string   `toml:"before,omitempty"`
+	After       string   `toml:"after,omitempty"`
+
+	When          string `toml:"when,omitempty"`           // Condition; the step is skipped when false
+	RepeatUntil   string `toml:"repeat_until,omitempty"`   // Condition; the step reruns until true
+	MaxIterations int    `toml:"max_iterations,omitempty"` // Bound on repeat_until runs (default 3)
+	OnFailure     string `toml:"on_failure,omitempty"`     // Step to run if this step fails
+
+	// Gates the step waits on besides its needs; see WaitAllChildren
+	WaitsFor []string `toml:"waits_for,omitempty"`
+
+	// Expansion formula gt instantiates under the molecule when the step is
+	// reached, instead of an agent working it; see ForEach
+	Expand string `toml:"expand,omitempty"`
+
+	// Outputs the step records when it closes, for later steps to use as
+	// {{steps.<id>.outputs.<key>}}
+	Outputs map[string]StepOutput `toml:"outputs,omitempty"`
+}
+
+// StepOutput declares a value a workflow step hands to later steps.
+type StepOutput struct {
+	Description string `toml:"description,omitempty"`
+	Artifact    bool   `toml:"artifact,omitempty"` // The value is a path to a file the step wrote
+	Required    bool   `toml:"required,omitempty"` // The step cannot close without it
 }
 
 // Template represents a template step in an expansion formula.
 type Template struct {
 	ID          string   `toml:"id"`
-	Title       string   `toml:"title"`
-	Description string   `toml:"description"`
-	Needs       []string `toml:"needs"`
+	Title       string   `toml:"title,omitempty"`
+	Description string   `toml:"description,omitempty"`
+	Needs       []string `toml:"needs,omitempty"`
 }
 
 // Var represents a variable definition for formulas.
+// Type is one of the Param types; see Param.Check.
 type Var struct {
-	Description string `toml:"description"`
-	Required    bool   `toml:"required"`
-	Default     string `toml:"default"`
+	Description string   `toml:"description,omitempty"`
+	Type        string   `toml:"type,omitempty"`
+	Required    bool     `toml:"required,omitempty"`
+	Default     string   `toml:"default,omitempty"`
+	Values      []string `toml:"values,omitempty"` // Allowed values of an enum
 }
 
 // IsValid returns true if the formula type is recognized.
 func (t FormulaType) IsValid() bool {
 	switch t {
-	case TypeConvoy, TypeWorkflow, TypeExpansion, TypeAspect:
+	case TypeConvoy, TypeWorkflow, TypeExpansion, TypeAspect, TypeLibrary:
 		return true
 	default:
 		return false
@@ -577,6 +577,17 @@ func (g *Git) Rev(ref string) (string, error) {
 	return g.run("rev-parse", ref)
 }
 
+// VerifySignature checks the GPG or SSH signature of a ref: the tag itself
+// if ref names a tag, otherwise the commit.
+func (g *Git) VerifySignature(ref string) error {
+	if _, err := g.run("rev-parse", "--verify", "--quiet", "refs/tags/"+ref); err == nil {
+		_, err := g.run("verify-tag", ref)
+		return err
+	}
+	_, err := g.run("verify-commit", ref)
+	return err
+}
+
 // IsAncestor checks if ancestor is an ancestor of descendant.
 func (g *Git) IsAncestor(ancestor, descendant string) (bool, error) {
 	_, err := g.run("merge-base", "--is-ancestor", ancestor, descendant)
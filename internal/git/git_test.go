@@ -395,3 +395,20 @@ func TestCheckConflicts_WithConflict(t *testing.T) {
 		t.Error("expected clean working directory after CheckConflicts")
 	}
 }
+
+func TestVerifySignatureUnsigned(t *testing.T) {
+	dir := initTestRepo(t)
+	g := NewGit(dir)
+
+	if err := g.VerifySignature("HEAD"); err == nil {
+		t.Error("unsigned commit verified")
+	}
+	cmd := exec.Command("git", "tag", "-a", "v1", "-m", "v1")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("git tag: %v", err)
+	}
+	if err := g.VerifySignature("v1"); err == nil {
+		t.Error("unsigned tag verified")
+	}
+}
@@ -0,0 +1,311 @@
+// Package logrotate rotates Gas Town's append-only logs (.events.jsonl,
+// .feed.jsonl, logs/town.log) into gzip'd segments and reads across them.
+//
+// A log at path P is the active file P plus zero or more segments named
+// P.<rotation time>.gz, where the rotation time is UTC in a sortable form.
+// Writers append through Append, which holds an advisory lock on P.lock so a
+// rotation never renames the file out from under a write in another process.
+package logrotate
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// segmentTimeFormat names segments; lexical order is chronological order.
+const segmentTimeFormat = "20060102T150405.000Z"
+
+// segmentSuffix is the extension of compressed segments.
+const segmentSuffix = ".gz"
+
+// Policy controls when a log rotates and how long segments are kept.
+// Zero values disable the corresponding limit.
+type Policy struct {
+	// MaxSize rotates the active file once it reaches this many bytes.
+	MaxSize int64
+
+	// MaxAge rotates the active file once its oldest line is this old.
+	MaxAge time.Duration
+
+	// Retain deletes segments rotated longer ago than this.
+	Retain time.Duration
+
+	// MaxSegments keeps at most this many segments, deleting the oldest.
+	MaxSegments int
+}
+
+// Log is an append-only log file subject to rotation.
+type Log struct {
+	// Path is the active file.
+	Path string
+
+	// Timestamp extracts the time a line was written. Used to measure the age
+	// of the active file; nil disables age-based rotation.
+	Timestamp func(line []byte) (time.Time, bool)
+}
+
+// Segment is a rotated, compressed part of a log.
+type Segment struct {
+	Path      string
+	RotatedAt time.Time // Newest content in the segment is no later than this
+}
+
+func lockPath(path string) string {
+	return path + ".lock"
+}
+
+// withLock runs fn while holding the log's rotation lock.
+func withLock(path string, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating log directory: %w", err)
+	}
+	lock := flock.New(lockPath(path))
+	if err := lock.Lock(); err != nil {
+		return fmt.Errorf("locking %s: %w", filepath.Base(path), err)
+	}
+	defer func() { _ = lock.Unlock() }()
+	return fn()
+}
+
+// Append appends data to the active file of the log at path, creating it with
+// perm if needed. Safe to call concurrently with rotation in another process.
+func Append(path string, data []byte, perm os.FileMode) error {
+	return withLock(path, func() error {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, perm) //nolint:gosec // G302/G304: log paths are constructed internally
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
+
+// Segments returns the rotated segments of the log at path, oldest first.
+func Segments(path string) ([]Segment, error) {
+	matches, err := filepath.Glob(globEscape(path) + ".*" + segmentSuffix)
+	if err != nil {
+		return nil, err
+	}
+	prefix := path + "."
+	var segments []Segment
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, prefix), segmentSuffix)
+		t, err := time.Parse(segmentTimeFormat, stamp)
+		if err != nil {
+			continue // Not one of ours
+		}
+		segments = append(segments, Segment{Path: m, RotatedAt: t})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Path < segments[j].Path })
+	return segments, nil
+}
+
+// globEscape escapes glob metacharacters in a literal path.
+func globEscape(path string) string {
+	r := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
+	return r.Replace(path)
+}
+
+// ShouldRotate reports whether the active file has outgrown the policy.
+func (l Log) ShouldRotate(p Policy, now time.Time) (bool, error) {
+	info, err := os.Stat(l.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if info.Size() == 0 {
+		return false, nil
+	}
+	if p.MaxSize > 0 && info.Size() >= p.MaxSize {
+		return true, nil
+	}
+	if p.MaxAge > 0 && l.Timestamp != nil {
+		if oldest, ok := l.oldest(); ok && now.Sub(oldest) >= p.MaxAge {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// oldest returns the timestamp of the first parseable line of the active file.
+func (l Log) oldest() (time.Time, bool) {
+	f, err := os.Open(l.Path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for i := 0; i < 10 && scanner.Scan(); i++ {
+		if t, ok := l.Timestamp(scanner.Bytes()); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Rotate moves the active file into a new compressed segment if the policy
+// calls for it, then applies retention. Returns whether a rotation happened.
+func (l Log) Rotate(p Policy, now time.Time) (bool, error) {
+	if err := l.recoverStaged(); err != nil {
+		return false, err
+	}
+	rotate, err := l.ShouldRotate(p, now)
+	if err != nil || !rotate {
+		if err == nil {
+			_, err = l.Prune(p, now)
+		}
+		return false, err
+	}
+	if err := l.ForceRotate(now); err != nil {
+		return false, err
+	}
+	_, err = l.Prune(p, now)
+	return true, err
+}
+
+// ForceRotate moves the active file into a new compressed segment.
+// Writers start a fresh active file on their next append.
+func (l Log) ForceRotate(now time.Time) error {
+	if err := l.recoverStaged(); err != nil {
+		return err
+	}
+	stamp := now.UTC()
+	var staged string
+	err := withLock(l.Path, func() error {
+		for {
+			staged = fmt.Sprintf("%s.%s", l.Path, stamp.Format(segmentTimeFormat))
+			if _, err := os.Stat(staged + segmentSuffix); os.IsNotExist(err) {
+				break
+			}
+			stamp = stamp.Add(time.Millisecond)
+		}
+		return os.Rename(l.Path, staged)
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("rotating %s: %w", filepath.Base(l.Path), err)
+	}
+
+	// Compress outside the lock; no writer can reach the staged file.
+	return compressStaged(staged)
+}
+
+// compressStaged turns a staged file into its segment and removes it.
+func compressStaged(staged string) error {
+	if err := compress(staged, staged+segmentSuffix); err != nil {
+		if os.IsNotExist(err) {
+			return nil // Another rotation finished it
+		}
+		return fmt.Errorf("compressing %s: %w", filepath.Base(staged), err)
+	}
+	if err := os.Remove(staged); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// recoverStaged compresses staged files left by a rotation that was
+// interrupted between renaming the active file and compressing it, which
+// readers would otherwise never see.
+func (l Log) recoverStaged() error {
+	matches, err := filepath.Glob(globEscape(l.Path) + ".*")
+	if err != nil {
+		return err
+	}
+	prefix := l.Path + "."
+	for _, m := range matches {
+		if _, err := time.Parse(segmentTimeFormat, strings.TrimPrefix(m, prefix)); err != nil {
+			continue // Segment, lock, temp file, or not one of ours
+		}
+		if err := compressStaged(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compress gzips src into dst atomically, keeping the mode of src.
+func compress(src, dst string) error {
+	in, err := os.Open(src) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	// A temp file of our own: a concurrent rotation may be recovering the same
+	// staged file.
+	out, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := out.Name()
+	if err := out.Chmod(info.Mode().Perm()); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// Prune deletes segments beyond the policy's retention. Returns how many
+// segments were removed.
+func (l Log) Prune(p Policy, now time.Time) (int, error) {
+	if p.Retain <= 0 && p.MaxSegments <= 0 {
+		return 0, nil
+	}
+	segments, err := Segments(l.Path)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for i, s := range segments {
+		expired := p.Retain > 0 && now.Sub(s.RotatedAt) > p.Retain
+		excess := p.MaxSegments > 0 && len(segments)-i > p.MaxSegments
+		if !expired && !excess {
+			continue
+		}
+		if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,195 @@
+package logrotate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if err := Append(path, []byte(line+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readAll(t *testing.T, path string, since time.Time) string {
+	t.Helper()
+	r, err := Open(path, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotate_BySizeAndReadAcrossSegments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".events.jsonl")
+	log := Log{Path: path}
+	policy := Policy{MaxSize: 10}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	appendLines(t, path, "one", "two", "three")
+	rotated, err := log.Rotate(policy, now)
+	if err != nil || !rotated {
+		t.Fatalf("Rotate = %v, %v; want rotation", rotated, err)
+	}
+	appendLines(t, path, "four")
+
+	segments, err := Segments(path)
+	if err != nil || len(segments) != 1 {
+		t.Fatalf("Segments = %v, %v; want 1", segments, err)
+	}
+	if !strings.HasSuffix(segments[0].Path, ".gz") {
+		t.Errorf("segment %s is not compressed", segments[0].Path)
+	}
+
+	if got := readAll(t, path, time.Time{}); got != "one\ntwo\nthree\nfour\n" {
+		t.Errorf("Open read %q", got)
+	}
+	if got := readAll(t, path, now.Add(time.Hour)); got != "four\n" {
+		t.Errorf("Open since after rotation read %q, want only active file", got)
+	}
+
+	tail, err := TailLines(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(tail, ",") != "three,four" {
+		t.Errorf("TailLines = %v", tail)
+	}
+}
+
+func TestRotate_ByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "town.log")
+	log := Log{Path: path, Timestamp: func(line []byte) (time.Time, bool) {
+		ts, err := time.Parse("2006-01-02", string(line[:10]))
+		return ts, err == nil
+	}}
+	appendLines(t, path, "2026-01-01 spawn", "2026-01-02 done")
+
+	policy := Policy{MaxAge: 48 * time.Hour}
+	if ok, _ := log.ShouldRotate(policy, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)); ok {
+		t.Error("rotated a log younger than MaxAge")
+	}
+	if ok, _ := log.ShouldRotate(policy, time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)); !ok {
+		t.Error("did not rotate a log older than MaxAge")
+	}
+}
+
+func TestPrune_RetentionAndMaxSegments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".feed.jsonl")
+	log := Log{Path: path}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		appendLines(t, path, fmt.Sprintf("line %d", i))
+		if err := log.ForceRotate(start.Add(time.Duration(i) * 24 * time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := log.Prune(Policy{Retain: 40 * time.Hour}, start.Add(3*24*time.Hour))
+	if err != nil || removed != 2 {
+		t.Fatalf("Prune by age removed %d, %v; want 2", removed, err)
+	}
+	removed, err = log.Prune(Policy{MaxSegments: 1}, start.Add(3*24*time.Hour))
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune by count removed %d, %v; want 1", removed, err)
+	}
+	if got := readAll(t, path, time.Time{}); got != "line 3\n" {
+		t.Errorf("remaining log = %q", got)
+	}
+}
+
+func TestFollower_SurvivesRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".events.jsonl")
+	appendLines(t, path, "before follow")
+
+	f, err := Follow(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	appendLines(t, path, "a")
+	if err := (Log{Path: path}).ForceRotate(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, path, "b")
+
+	var got []string
+	for {
+		line, ok := f.Next()
+		if !ok {
+			break
+		}
+		got = append(got, line)
+	}
+	if strings.Join(got, ",") != "a,b" {
+		t.Errorf("followed %v, want [a b]", got)
+	}
+}
+
+func TestOpen_MissingLogIsEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.log")
+	if got := readAll(t, path, time.Time{}); got != "" {
+		t.Errorf("read %q from missing log", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Open created the log")
+	}
+}
+
+func TestRotate_RecoversInterruptedRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "town.log")
+	log := Log{Path: path}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// A rotation that crashed after renaming the active file aside
+	staged := path + "." + now.Format(segmentTimeFormat)
+	if err := os.WriteFile(staged, []byte("lost\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, path, "kept")
+
+	if _, err := log.Rotate(Policy{}, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if _, err := os.Stat(staged); !os.IsNotExist(err) {
+		t.Errorf("staged file still present: %v", err)
+	}
+	if got := readAll(t, path, time.Time{}); got != "lost\nkept\n" {
+		t.Errorf("Open read %q, want the staged lines recovered", got)
+	}
+}
+
+func TestForceRotate_KeepsFileMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "town.log")
+	if err := Append(path, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Log{Path: path}).ForceRotate(time.Now()); err != nil {
+		t.Fatalf("ForceRotate: %v", err)
+	}
+	segments, err := Segments(path)
+	if err != nil || len(segments) != 1 {
+		t.Fatalf("Segments = %v, %v; want 1", segments, err)
+	}
+	info, err := os.Stat(segments[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("segment mode = %o, want 600", perm)
+	}
+}
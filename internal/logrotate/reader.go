@@ -0,0 +1,227 @@
+package logrotate
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Files returns the files making up the log at path, oldest first: rotated
+// segments followed by the active file (if it exists). Segments rotated
+// before since hold only older content and are skipped; a zero since keeps all.
+func Files(path string, since time.Time) ([]string, error) {
+	segments, err := Segments(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, s := range segments {
+		if !since.IsZero() && s.RotatedAt.Before(since) {
+			continue
+		}
+		files = append(files, s.Path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// ReadFile returns the contents of a log file, decompressing segments.
+func ReadFile(name string) ([]byte, error) {
+	f, err := openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// openFile opens a log file, decompressing segments transparently.
+func openFile(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name) //nolint:gosec // G304: log paths are constructed internally
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(name, segmentSuffix) {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &gzipFile{Reader: zr, file: f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	_ = g.Reader.Close()
+	return g.file.Close()
+}
+
+// Open returns a reader over the whole log at path (segments, then the active
+// file), as if it had never been rotated. See Files for since.
+// A log that doesn't exist reads as empty.
+func Open(path string, since time.Time) (io.ReadCloser, error) {
+	files, err := Files(path, since)
+	if err != nil {
+		return nil, err
+	}
+	return &multiFile{files: files}, nil
+}
+
+// multiFile reads files in sequence, opening each lazily.
+type multiFile struct {
+	files   []string
+	current io.ReadCloser
+}
+
+func (m *multiFile) Read(p []byte) (int, error) {
+	for {
+		if m.current == nil {
+			if len(m.files) == 0 {
+				return 0, io.EOF
+			}
+			f, err := openFile(m.files[0])
+			m.files = m.files[1:]
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue // Pruned or rotated since listing
+				}
+				return 0, err
+			}
+			m.current = f
+		}
+		n, err := m.current.Read(p)
+		if err == io.EOF {
+			_ = m.current.Close()
+			m.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (m *multiFile) Close() error {
+	if m.current != nil {
+		return m.current.Close()
+	}
+	return nil
+}
+
+// NewScanner returns a line scanner sized for large JSONL lines.
+func NewScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return scanner
+}
+
+// TailLines returns up to n last lines of the log at path, oldest first,
+// reading segments newest-first only as far back as needed.
+func TailLines(path string, n int) ([]string, error) {
+	files, err := Files(path, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	var tail []string
+	for i := len(files) - 1; i >= 0 && len(tail) < n; i-- {
+		data, err := ReadFile(files[i])
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		var lines []string
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				lines = append(lines, string(line))
+			}
+		}
+		tail = append(lines, tail...)
+	}
+	if len(tail) > n {
+		tail = tail[len(tail)-n:]
+	}
+	return tail, nil
+}
+
+// Follower tails the active file of a log, reopening it after rotation.
+type Follower struct {
+	path    string
+	file    *os.File
+	reader  *bufio.Reader
+	partial string
+}
+
+// Follow starts tailing the log at path from its current end.
+// The active file is created if it doesn't exist yet.
+func Follow(path string) (*Follower, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0644) //nolint:gosec // G302/G304: log paths are constructed internally
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &Follower{path: path, file: f, reader: bufio.NewReader(f)}, nil
+}
+
+// Next returns the next complete line (without newline), or false if none is
+// available yet. After the active file is rotated away, the remainder of the
+// old file is drained before switching to the new one.
+func (f *Follower) Next() (string, bool) {
+	for {
+		chunk, err := f.reader.ReadString('\n')
+		if err == nil {
+			line := f.partial + strings.TrimSuffix(chunk, "\n")
+			f.partial = ""
+			return line, true
+		}
+		f.partial += chunk
+
+		if !f.rotated() {
+			return "", false
+		}
+		next, err := os.Open(f.path)
+		if err != nil {
+			return "", false
+		}
+		_ = f.file.Close()
+		f.file = next
+		f.reader = bufio.NewReader(next)
+		f.partial = ""
+	}
+}
+
+// rotated reports whether the path now names a different file than the one open.
+func (f *Follower) rotated() bool {
+	current, err := f.file.Stat()
+	if err != nil {
+		return false
+	}
+	latest, err := os.Stat(f.path)
+	if err != nil {
+		return false
+	}
+	return !os.SameFile(current, latest)
+}
+
+// Close stops following.
+func (f *Follower) Close() error {
+	return f.file.Close()
+}
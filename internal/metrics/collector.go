@@ -0,0 +1,299 @@
+package metrics
+
+import (
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/logrotate"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/session"
+)
+
+// ContentType is the media type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Sources provides the raw data metrics are computed from. A nil source is
+// skipped and its metrics are left out.
+type Sources struct {
+	Sessions    func() ([]string, error)                // tmux session names
+	Rigs        func() ([]string, error)                // registered rig names
+	MergeQueue  func(rig string) ([]*mrqueue.MR, error) // pending MRs
+	MergeEvents func(rig string) ([]mrqueue.Event, error)
+	UnreadMail  func() (map[string]int, error)        // unread messages by recipient
+	Costs       func(since time.Time) ([]Cost, error) // session costs recorded since
+}
+
+// Cost is the recorded cost of one ended session.
+type Cost struct {
+	Role    string
+	Rig     string
+	USD     float64
+	EndedAt time.Time
+}
+
+// countedEvents are the town events log types exposed as counters.
+var countedEvents = []struct {
+	Type string
+	Name string
+	Help string
+}{
+	{events.TypeSessionDeath, "gastown_session_deaths_total", "Sessions terminated."},
+	{events.TypeMassDeath, "gastown_mass_deaths_total", "Times several sessions died within a short window."},
+	{events.TypeCrashLoop, "gastown_crash_loops_total", "Sessions quarantined after repeated deaths."},
+	{events.TypeGUPPViolation, "gastown_gupp_violations_total", "Agents found with hooked work not progressing."},
+}
+
+// Collector computes town metrics on demand.
+type Collector struct {
+	townRoot string
+	sources  Sources
+	now      func() time.Time
+
+	mu       sync.Mutex
+	follower *logrotate.Follower // Tails the events log between scrapes
+	counts   map[string]float64  // Events seen, by type
+}
+
+// NewCollector creates a collector for the town.
+func NewCollector(townRoot string, sources Sources) *Collector {
+	return &Collector{
+		townRoot: townRoot,
+		sources:  sources,
+		now:      time.Now,
+		counts:   make(map[string]float64),
+	}
+}
+
+// Close stops tailing the events log.
+func (c *Collector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.follower == nil {
+		return nil
+	}
+	err := c.follower.Close()
+	c.follower = nil
+	return err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_ = Write(w, c.Collect())
+}
+
+// Collect computes every metric. Sources that fail are reported through
+// gastown_source_up rather than failing the scrape.
+func (c *Collector) Collect() []*Family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	up := NewFamily("gastown_source_up", Gauge, "Whether a metrics source could be read (1) or not (0).")
+	var families []*Family
+	report := func(source string, err error) bool { return sourceUp(up, source, err) }
+
+	if c.sources.Sessions != nil {
+		sessions, err := c.sources.Sessions()
+		if report("tmux", err) {
+			families = append(families, sessionFamily(sessions))
+		}
+	}
+
+	if c.sources.Rigs != nil {
+		rigs, err := c.sources.Rigs()
+		if report("rigs", err) {
+			sort.Strings(rigs)
+			families = append(families, c.mergeFamilies(rigs, up)...)
+		}
+	}
+
+	counts, err := c.countEvents()
+	if report("events", err) {
+		for _, e := range countedEvents {
+			f := NewFamily(e.Name, Counter, e.Help)
+			f.Add(counts[e.Type])
+			families = append(families, f)
+		}
+	}
+
+	if c.sources.UnreadMail != nil {
+		unread, err := c.sources.UnreadMail()
+		if report("mail", err) {
+			f := NewFamily("gastown_mail_unread", Gauge, "Unread messages by recipient.")
+			for to, n := range unread {
+				f.Add(float64(n), "recipient", to)
+			}
+			families = append(families, f)
+		}
+	}
+
+	if c.sources.Costs != nil {
+		since := c.now().Add(-time.Hour)
+		costs, err := c.sources.Costs(since)
+		if report("costs", err) {
+			families = append(families, costFamily(costs, since))
+		}
+	}
+
+	return append(families, up)
+}
+
+// sessionFamily counts Gas Town sessions by role and rig. Other tmux
+// sessions are ignored.
+func sessionFamily(sessions []string) *Family {
+	type key struct{ role, rig string }
+	counts := map[key]int{}
+	for _, name := range sessions {
+		id, err := session.ParseSessionName(name)
+		if err != nil {
+			continue
+		}
+		counts[key{string(id.Role), id.Rig}]++
+	}
+
+	f := NewFamily("gastown_sessions", Gauge, "Running agent sessions by role and rig.")
+	for k, n := range counts {
+		f.Add(float64(n), "role", k.role, "rig", k.rig)
+	}
+	return f
+}
+
+// mergeFamilies reports each rig's queue depth, oldest pending MR and merge
+// results.
+func (c *Collector) mergeFamilies(rigs []string, up *Family) []*Family {
+	depth := NewFamily("gastown_merge_queue_depth", Gauge, "Merge requests waiting in the queue.")
+	oldest := NewFamily("gastown_merge_queue_oldest_seconds", Gauge, "Age of the oldest waiting merge request.")
+	merges := NewFamily("gastown_merges_total", Counter, "Merge attempts by result.")
+
+	now := c.now()
+	var queueErr, eventsErr error
+	for _, rig := range rigs {
+		if c.sources.MergeQueue != nil {
+			if mrs, err := c.sources.MergeQueue(rig); err != nil {
+				queueErr = err
+			} else {
+				depth.Add(float64(len(mrs)), "rig", rig)
+				oldest.Add(oldestAge(mrs, now).Seconds(), "rig", rig)
+			}
+		}
+
+		if c.sources.MergeEvents != nil {
+			evs, err := c.sources.MergeEvents(rig)
+			if err != nil {
+				eventsErr = err
+				continue
+			}
+			results := map[string]int{"merged": 0, "failed": 0, "skipped": 0}
+			for _, e := range evs {
+				switch e.Type {
+				case mrqueue.EventMerged:
+					results["merged"]++
+				case mrqueue.EventMergeFailed:
+					results["failed"]++
+				case mrqueue.EventMergeSkipped:
+					results["skipped"]++
+				}
+			}
+			for result, n := range results {
+				merges.Add(float64(n), "rig", rig, "result", result)
+			}
+		}
+	}
+
+	var families []*Family
+	if c.sources.MergeQueue != nil {
+		sourceUp(up, "merge_queue", queueErr)
+		families = append(families, depth, oldest)
+	}
+	if c.sources.MergeEvents != nil {
+		sourceUp(up, "merge_events", eventsErr)
+		families = append(families, merges)
+	}
+	return families
+}
+
+// oldestAge returns how long the oldest MR has been waiting.
+func oldestAge(mrs []*mrqueue.MR, now time.Time) time.Duration {
+	var age time.Duration
+	for _, mr := range mrs {
+		if a := now.Sub(mr.CreatedAt); a > age {
+			age = a
+		}
+	}
+	return age
+}
+
+// costFamily sums session costs recorded in the last hour by role.
+func costFamily(costs []Cost, since time.Time) *Family {
+	byRole := map[string]float64{}
+	for _, c := range costs {
+		if c.EndedAt.Before(since) {
+			continue
+		}
+		byRole[c.Role] += c.USD
+	}
+
+	f := NewFamily("gastown_cost_usd_per_hour", Gauge, "Session cost recorded in the last hour, by role.")
+	for role, usd := range byRole {
+		f.Add(usd, "role", role)
+	}
+	return f
+}
+
+// countEvents returns counts of the town events of interest. The first call
+// reads the whole log (including rotated segments); later calls only read
+// what was appended since.
+func (c *Collector) countEvents() (map[string]float64, error) {
+	path := filepath.Join(c.townRoot, events.EventsFile)
+	if c.follower == nil {
+		r, err := logrotate.Open(path, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		scanner := logrotate.NewScanner(r)
+		for scanner.Scan() {
+			c.count(scanner.Bytes())
+		}
+		r.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		f, err := logrotate.Follow(path)
+		if err != nil {
+			return nil, err
+		}
+		c.follower = f
+	}
+
+	for {
+		line, ok := c.follower.Next()
+		if !ok {
+			break
+		}
+		c.count([]byte(line))
+	}
+	return c.counts, nil
+}
+
+func (c *Collector) count(line []byte) {
+	e, err := events.Decode(line)
+	if err != nil {
+		return
+	}
+	c.counts[e.Type]++
+}
+
+// sourceUp records whether a source could be read and reports success.
+func sourceUp(up *Family, source string, err error) bool {
+	if err != nil {
+		up.Add(0, "source", source)
+		return false
+	}
+	up.Add(1, "source", source)
+	return true
+}
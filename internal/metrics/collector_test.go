@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+func appendEvent(t *testing.T, townRoot, eventType string) {
+	t.Helper()
+	f, err := os.OpenFile(filepath.Join(townRoot, events.EventsFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	line := `{"ts":"2026-01-01T00:00:00Z","source":"gt","type":"` + eventType + `","actor":"daemon","visibility":"feed","v":1}` + "\n"
+	if _, err := f.WriteString(line); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sample(t *testing.T, families []*Family, name string, labels ...string) float64 {
+	t.Helper()
+	for _, f := range families {
+		if f.Name != name {
+			continue
+		}
+	samples:
+		for _, s := range f.Samples {
+			for i := 0; i+1 < len(labels); i += 2 {
+				if s.Labels[labels[i]] != labels[i+1] {
+					continue samples
+				}
+			}
+			return s.Value
+		}
+	}
+	t.Fatalf("no sample %s%v", name, labels)
+	return 0
+}
+
+func testSources(now time.Time) Sources {
+	return Sources{
+		Sessions: func() ([]string, error) {
+			return []string{"hq-mayor", "gt-gastown-witness", "gt-gastown-Toast", "gt-gastown-Nux", "gt-beads-crew-joe", "scratch"}, nil
+		},
+		Rigs: func() ([]string, error) { return []string{"gastown", "beads"}, nil },
+		MergeQueue: func(rig string) ([]*mrqueue.MR, error) {
+			if rig == "beads" {
+				return nil, nil
+			}
+			return []*mrqueue.MR{
+				{ID: "mr-1", CreatedAt: now.Add(-10 * time.Minute)},
+				{ID: "mr-2", CreatedAt: now.Add(-time.Minute)},
+			}, nil
+		},
+		MergeEvents: func(rig string) ([]mrqueue.Event, error) {
+			if rig == "beads" {
+				return nil, errors.New("unreadable")
+			}
+			return []mrqueue.Event{{Type: mrqueue.EventMergeStarted}, {Type: mrqueue.EventMerged}, {Type: mrqueue.EventMerged}, {Type: mrqueue.EventMergeFailed}}, nil
+		},
+		UnreadMail: func() (map[string]int, error) { return map[string]int{"mayor/": 3}, nil },
+		Costs: func(since time.Time) ([]Cost, error) {
+			return []Cost{
+				{Role: "polecat", USD: 1.5, EndedAt: now.Add(-time.Minute)},
+				{Role: "polecat", USD: 2, EndedAt: now.Add(-30 * time.Minute)},
+				{Role: "polecat", USD: 9, EndedAt: now.Add(-2 * time.Hour)},
+			}, nil
+		},
+	}
+}
+
+func TestCollect(t *testing.T) {
+	townRoot := t.TempDir()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	appendEvent(t, townRoot, events.TypeSessionDeath)
+	appendEvent(t, townRoot, events.TypeGUPPViolation)
+	appendEvent(t, townRoot, events.TypeSling)
+
+	c := NewCollector(townRoot, testSources(now))
+	c.now = func() time.Time { return now }
+	defer c.Close()
+
+	got := c.Collect()
+	checks := []struct {
+		name   string
+		labels []string
+		want   float64
+	}{
+		{"gastown_sessions", []string{"role", "polecat", "rig", "gastown"}, 2},
+		{"gastown_sessions", []string{"role", "mayor"}, 1},
+		{"gastown_sessions", []string{"role", "crew", "rig", "beads"}, 1},
+		{"gastown_merge_queue_depth", []string{"rig", "gastown"}, 2},
+		{"gastown_merge_queue_depth", []string{"rig", "beads"}, 0},
+		{"gastown_merge_queue_oldest_seconds", []string{"rig", "gastown"}, 600},
+		{"gastown_merges_total", []string{"rig", "gastown", "result", "merged"}, 2},
+		{"gastown_merges_total", []string{"rig", "gastown", "result", "failed"}, 1},
+		{"gastown_session_deaths_total", nil, 1},
+		{"gastown_gupp_violations_total", nil, 1},
+		{"gastown_crash_loops_total", nil, 0},
+		{"gastown_mail_unread", []string{"recipient", "mayor/"}, 3},
+		{"gastown_cost_usd_per_hour", []string{"role", "polecat"}, 3.5},
+		{"gastown_source_up", []string{"source", "tmux"}, 1},
+		{"gastown_source_up", []string{"source", "merge_events"}, 0},
+	}
+	for _, tc := range checks {
+		if v := sample(t, got, tc.name, tc.labels...); v != tc.want {
+			t.Errorf("%s%v = %v, want %v", tc.name, tc.labels, v, tc.want)
+		}
+	}
+
+	// Later scrapes count only what was appended since.
+	appendEvent(t, townRoot, events.TypeSessionDeath)
+	got = c.Collect()
+	if v := sample(t, got, "gastown_session_deaths_total"); v != 2 {
+		t.Errorf("session deaths after append = %v, want 2", v)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	c := NewCollector(t.TempDir(), Sources{
+		Sessions: func() ([]string, error) { return nil, errors.New("no tmux") },
+	})
+	defer c.Close()
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `gastown_source_up{source="tmux"} 0`) {
+		t.Errorf("missing tmux down sample:\n%s", body)
+	}
+	if strings.Contains(body, "gastown_sessions") {
+		t.Errorf("sessions reported despite failed source:\n%s", body)
+	}
+	if !strings.Contains(body, "gastown_session_deaths_total 0") {
+		t.Errorf("missing zero counter:\n%s", body)
+	}
+}
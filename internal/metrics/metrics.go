@@ -0,0 +1,117 @@
+// Package metrics exposes town health in the Prometheus text exposition
+// format.
+//
+// Metrics are computed on each scrape from the sources Gas Town already keeps:
+// the tmux session list, each rig's merge queue and merge event log, the town
+// events log, open mail beads and session cost wisps. Nothing is stored.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metric types.
+const (
+	Gauge   = "gauge"
+	Counter = "counter"
+)
+
+// Family is a named metric with its samples.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Sample is one value of a family, identified by its labels.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// NewFamily creates an empty metric family.
+func NewFamily(name, typ, help string) *Family {
+	return &Family{Name: name, Type: typ, Help: help}
+}
+
+// Add appends a sample. labels are key/value pairs.
+func (f *Family) Add(value float64, labels ...string) {
+	s := Sample{Value: value}
+	if len(labels) > 0 {
+		s.Labels = make(map[string]string, len(labels)/2)
+		for i := 0; i+1 < len(labels); i += 2 {
+			s.Labels[labels[i]] = labels[i+1]
+		}
+	}
+	f.Samples = append(f.Samples, s)
+}
+
+// Write renders families in the Prometheus text format (version 0.0.4).
+// Samples are sorted by their labels so output is stable between scrapes.
+func Write(w io.Writer, families []*Family) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.Name, f.Type)
+
+		lines := make([]string, 0, len(f.Samples))
+		for _, s := range f.Samples {
+			lines = append(lines, f.Name+formatLabels(s.Labels)+" "+formatValue(s.Value))
+		}
+		sort.Strings(lines)
+		for _, l := range lines {
+			bw.WriteString(l)
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", k, escapeLabel(labels[k]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
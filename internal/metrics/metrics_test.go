@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	sessions := NewFamily("gastown_sessions", Gauge, "Running sessions.")
+	sessions.Add(2, "role", "polecat", "rig", "gastown")
+	sessions.Add(1, "role", "mayor", "rig", "")
+	deaths := NewFamily("gastown_session_deaths_total", Counter, "Line one\nline two.")
+	deaths.Add(3)
+	odd := NewFamily("gastown_odd", Gauge, "Escaping.")
+	odd.Add(math.Inf(1), "name", `a "quoted" \ value`)
+	odd.Add(0.25, "name", "x")
+
+	var b strings.Builder
+	if err := Write(&b, []*Family{sessions, deaths, odd}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP gastown_sessions Running sessions.
+# TYPE gastown_sessions gauge
+gastown_sessions{rig="",role="mayor"} 1
+gastown_sessions{rig="gastown",role="polecat"} 2
+# HELP gastown_session_deaths_total Line one\nline two.
+# TYPE gastown_session_deaths_total counter
+gastown_session_deaths_total 3
+# HELP gastown_odd Escaping.
+# TYPE gastown_odd gauge
+gastown_odd{name="a \"quoted\" \\ value"} +Inf
+gastown_odd{name="x"} 0.25
+`
+	if b.String() != want {
+		t.Errorf("Write output:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// LiveSources reads the town's tmux server, rigs, merge queues and mail.
+// Costs are left to the caller, since cost wisps are queried through gt.
+func LiveSources(townRoot string) Sources {
+	t := tmux.NewTmux()
+	return Sources{
+		Sessions: t.ListSessions,
+		Rigs: func() ([]string, error) {
+			return config.TownRigNames(townRoot)
+		},
+		MergeQueue: func(rig string) ([]*mrqueue.MR, error) {
+			return mrqueue.New(filepath.Join(townRoot, rig)).List()
+		},
+		MergeEvents: func(rig string) ([]mrqueue.Event, error) {
+			return mrqueue.NewEventLoggerFromRig(filepath.Join(townRoot, rig)).ReadEvents()
+		},
+		UnreadMail: func() (map[string]int, error) {
+			return unreadMail(townRoot)
+		},
+	}
+}
+
+// unreadMail counts open message beads by recipient.
+func unreadMail(townRoot string) (map[string]int, error) {
+	cmd := exec.Command("bd", "list", "--type=message", "--status=open", "--json", "--limit=0") //nolint:gosec // G204: bd is a trusted internal tool
+	cmd.Dir = townRoot
+	cmd.Env = append(os.Environ(), "BEADS_DIR="+filepath.Join(townRoot, ".beads"))
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("listing messages: %w", err)
+	}
+
+	var msgs []struct {
+		Assignee string `json:"assignee"`
+	}
+	if out := bytes.TrimSpace(stdout.Bytes()); len(out) > 0 && string(out) != "null" {
+		if err := json.Unmarshal(out, &msgs); err != nil {
+			return nil, fmt.Errorf("parsing messages: %w", err)
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, m := range msgs {
+		counts[m.Assignee]++
+	}
+	return counts, nil
+}
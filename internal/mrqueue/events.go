@@ -2,6 +2,7 @@
 package mrqueue
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -146,6 +147,37 @@ func (l *EventLogger) LogMergeSkipped(mr *MR, reason string) error {
 	})
 }
 
+// ReadEvents returns the events in the log, oldest first. A missing log reads
+// as empty; malformed lines are skipped.
+func (l *EventLogger) ReadEvents() ([]Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("opening event log: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading event log: %w", err)
+	}
+	return events, nil
+}
+
 // LogPath returns the path to the event log file.
 func (l *EventLogger) LogPath() string {
 	return l.logPath
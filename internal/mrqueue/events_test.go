@@ -112,3 +112,34 @@ func splitLines(s string) []string {
 	}
 	return lines
 }
+
+func TestReadEvents(t *testing.T) {
+	logger := NewEventLogger(filepath.Join(t.TempDir(), ".beads"))
+
+	events, err := logger.ReadEvents()
+	if err != nil || len(events) != 0 {
+		t.Fatalf("ReadEvents on missing log = %v, %v; want empty", events, err)
+	}
+
+	mr := &MR{ID: "mr-1", Branch: "polecat/nux", Target: "main", Rig: "gastown"}
+	if err := logger.LogMerged(mr, "abc123"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(logger.LogPath(), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("not json\n")
+	f.Close()
+	if err := logger.LogMergeFailed(mr, "conflict"); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err = logger.ReadEvents()
+	if err != nil {
+		t.Fatalf("ReadEvents: %v", err)
+	}
+	if len(events) != 2 || events[0].Type != EventMerged || events[1].Type != EventMergeFailed {
+		t.Errorf("ReadEvents = %+v, want merged then merge_failed", events)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/steveyegge/gastown/internal/runtime"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/trace"
 )
 
 // debugSession logs non-fatal errors during session startup when GT_DEBUG_SESSION=1.
@@ -63,6 +64,11 @@ type SessionStartOptions struct {
 	// RuntimeConfigDir is resolved config directory for the runtime account.
 	// If set, this is injected as an environment variable.
 	RuntimeConfigDir string
+
+	// TraceParent is the W3C traceparent of the span that dispatched the work
+	// (usually gt sling). If set, it is injected as GT_TRACEPARENT so spans
+	// recorded inside the session join the same trace.
+	TraceParent string
 }
 
 // SessionInfo contains information about a running polecat session.
@@ -178,6 +184,9 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 	if runtimeConfig.Session != nil && runtimeConfig.Session.ConfigDirEnv != "" && opts.RuntimeConfigDir != "" {
 		command = config.PrependEnv(command, map[string]string{runtimeConfig.Session.ConfigDirEnv: opts.RuntimeConfigDir})
 	}
+	if opts.TraceParent != "" {
+		command = config.PrependEnv(command, map[string]string{trace.EnvTraceParent: opts.TraceParent})
+	}
 
 	// Create session with command directly to avoid send-keys race condition.
 	// See: https://github.com/anthropics/gastown/issues/280
@@ -197,6 +206,9 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 		RuntimeConfigDir: opts.RuntimeConfigDir,
 		BeadsNoDaemon:    true,
 	})
+	if opts.TraceParent != "" {
+		envVars[trace.EnvTraceParent] = opts.TraceParent
+	}
 	for k, v := range envVars {
 		debugSession("SetEnvironment "+k, m.tmux.SetEnvironment(sessionID, k, v))
 	}
@@ -0,0 +1,261 @@
+// Package prompts detects interactive prompts that block agent sessions and
+// answers them using the prompt rules of the session's agent preset.
+//
+// Health checks (daemon heartbeat, gt deacon health-check) call Answerer.Check
+// with the rules for the session's runtime. The first rule matching the last
+// lines of the pane decides the action: send keys, escalate to a human, or
+// restart the session (optionally under another account). Every action waits
+// until the pane has stopped changing between two checks, so text printed by
+// an agent that is still working doesn't trigger it. Every action is logged
+// as a prompt_answered event.
+package prompts
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+const (
+	// CaptureLines is how much pane history is captured when looking for prompts.
+	CaptureLines = 40
+
+	// tailLines is how many trailing non-blank lines are matched: a blocking
+	// prompt sits at the bottom of the pane. Prompts answered earlier scroll
+	// out of this window and don't re-trigger.
+	tailLines = 8
+
+	// DefaultCooldown suppresses repeated escalations and restarts for the same
+	// session and rule while a human (or the restarted session) catches up.
+	DefaultCooldown = 15 * time.Minute
+
+	// DefaultKeyCooldown suppresses answering the same prompt again before
+	// the dialog has had time to go away.
+	DefaultKeyCooldown = 2 * time.Minute
+
+	// defaultKeyDelay separates keys so dialogs can redraw between them.
+	defaultKeyDelay = 200 * time.Millisecond
+)
+
+// Terminal is the subset of tmux used to inspect and answer sessions.
+type Terminal interface {
+	CapturePane(session string, lines int) (string, error)
+	SendKeysRaw(session, keys string) error
+}
+
+// Rule is a compiled prompt rule.
+type Rule struct {
+	config.PromptRule
+	re *regexp.Regexp
+}
+
+// Compile validates and compiles prompt rules, preserving order.
+func Compile(rules []config.PromptRule) ([]Rule, error) {
+	compiled := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, Rule{PromptRule: r, re: regexp.MustCompile(r.Pattern)})
+	}
+	return compiled, nil
+}
+
+// RulesFor returns the compiled prompt rules for an agent preset.
+func RulesFor(agentName string) ([]Rule, error) {
+	return Compile(config.GetPromptRules(agentName))
+}
+
+// Match returns the first rule matching the tail of the pane and the matched
+// text, or nil if no rule matches.
+func Match(rules []Rule, pane string) (*Rule, string) {
+	return matchTail(rules, lastLines(pane, tailLines))
+}
+
+func matchTail(rules []Rule, tail string) (*Rule, string) {
+	for i := range rules {
+		if m := rules[i].re.FindString(tail); m != "" {
+			return &rules[i], m
+		}
+	}
+	return nil, ""
+}
+
+// Detection describes a prompt found in a session and what was done about it.
+type Detection struct {
+	Session string
+	Agent   string // Gas Town identity (e.g., "gastown/polecats/Toast")
+	Rule    string
+	Action  config.PromptAction
+	Keys    []string
+	Account string // Account requested by a restart rule ("next", a handle, or empty)
+	Matched string // Text that matched the rule's pattern
+}
+
+// Answerer runs prompt rules against sessions.
+type Answerer struct {
+	term  Terminal
+	actor string
+
+	// Escalate reports a prompt that needs a human. If nil, escalations are
+	// only logged.
+	Escalate func(Detection) error
+
+	// Restart restarts the session. If nil, restart rules escalate instead.
+	Restart func(Detection) error
+
+	// Cooldown suppresses repeated escalate/restart actions per session and rule.
+	Cooldown time.Duration
+
+	// KeyCooldown suppresses repeated send_keys actions per session and rule.
+	KeyCooldown time.Duration
+
+	// KeyDelay is the pause between keys of a send_keys rule.
+	KeyDelay time.Duration
+
+	// LogFeed records prompt_answered events. Defaults to events.LogFeed.
+	LogFeed func(eventType, actor string, payload map[string]interface{}) error
+
+	mu       sync.Mutex
+	handled  map[string]time.Time // session + rule -> last action
+	lastTail map[string]string    // session -> pane tail at the previous check
+}
+
+// NewAnswerer creates an Answerer. actor is recorded on events (e.g., "daemon").
+func NewAnswerer(term Terminal, actor string) *Answerer {
+	return &Answerer{
+		term:        term,
+		actor:       actor,
+		Cooldown:    DefaultCooldown,
+		KeyCooldown: DefaultKeyCooldown,
+		KeyDelay:    defaultKeyDelay,
+		LogFeed:     events.LogFeed,
+		handled:     make(map[string]time.Time),
+		lastTail:    make(map[string]string),
+	}
+}
+
+// Check captures the session's pane and handles the first matching rule.
+// Returns nil if no prompt was found, the match is still in cooldown, or the
+// match has not yet been seen on an unchanged pane.
+// The returned error reports a failed action; the detection is still returned.
+func (a *Answerer) Check(session, agent string, rules []Rule) (*Detection, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	pane, err := a.term.CapturePane(session, CaptureLines)
+	if err != nil {
+		return nil, fmt.Errorf("capturing pane: %w", err)
+	}
+
+	tail := lastLines(pane, tailLines)
+	settled := a.settle(session, tail)
+
+	rule, matched := matchTail(rules, tail)
+	if rule == nil {
+		return nil, nil
+	}
+
+	det := &Detection{
+		Session: session,
+		Agent:   agent,
+		Rule:    rule.Name,
+		Action:  rule.Action,
+		Keys:    rule.Keys,
+		Account: rule.Account,
+		Matched: strings.TrimSpace(matched),
+	}
+
+	if det.Action == config.PromptRestart && a.Restart == nil {
+		det.Action = config.PromptEscalate
+	}
+	// A pane still changing belongs to an agent that is still working
+	if !settled {
+		return nil, nil
+	}
+	cooldown := a.Cooldown
+	if det.Action == config.PromptSendKeys {
+		cooldown = a.KeyCooldown
+	}
+	if !a.claim(session, rule.Name, cooldown) {
+		return nil, nil
+	}
+
+	var detail string
+	switch det.Action {
+	case config.PromptSendKeys:
+		err = a.sendKeys(session, rule.Keys)
+		detail = strings.Join(rule.Keys, " ")
+	case config.PromptRestart:
+		err = a.Restart(*det)
+		detail = det.Account
+	case config.PromptEscalate:
+		if a.Escalate != nil {
+			err = a.Escalate(*det)
+		}
+		detail = det.Matched
+	}
+	if err != nil {
+		detail = fmt.Sprintf("failed: %v", err)
+	}
+
+	_ = a.LogFeed(events.TypePromptAnswered, a.actor,
+		events.PromptPayload(session, agent, rule.Name, string(det.Action), detail))
+
+	return det, err
+}
+
+// claim records an action for session and rule, returning false if one
+// already happened within cooldown.
+func (a *Answerer) claim(session, rule string, cooldown time.Duration) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := session + "\x00" + rule
+	now := time.Now()
+	if last, ok := a.handled[key]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+	a.handled[key] = now
+	return true
+}
+
+// settle records the pane tail of a session and reports whether it is the
+// same as at the previous check.
+func (a *Answerer) settle(session, tail string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	prev, ok := a.lastTail[session]
+	a.lastTail[session] = tail
+	return ok && prev == tail
+}
+
+func (a *Answerer) sendKeys(session string, keys []string) error {
+	for i, key := range keys {
+		if i > 0 && a.KeyDelay > 0 {
+			time.Sleep(a.KeyDelay)
+		}
+		if err := a.term.SendKeysRaw(session, key); err != nil {
+			return fmt.Errorf("sending %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// lastLines returns the last n non-blank lines of s.
+func lastLines(s string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
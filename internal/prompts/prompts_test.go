@@ -0,0 +1,238 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// fakeTerminal records keys sent to sessions.
+type fakeTerminal struct {
+	panes map[string]string
+	sent  []string
+}
+
+func (f *fakeTerminal) CapturePane(session string, lines int) (string, error) {
+	pane, ok := f.panes[session]
+	if !ok {
+		return "", errors.New("no session")
+	}
+	return pane, nil
+}
+
+func (f *fakeTerminal) SendKeysRaw(session, keys string) error {
+	f.sent = append(f.sent, keys)
+	return nil
+}
+
+// newTestAnswerer returns an Answerer that doesn't pause between keys or
+// write events to the town the tests run under.
+func newTestAnswerer(term Terminal) *Answerer {
+	a := NewAnswerer(term, "daemon")
+	a.KeyDelay = 0
+	a.LogFeed = func(string, string, map[string]interface{}) error { return nil }
+	return a
+}
+
+func claudeRules(t *testing.T) []Rule {
+	t.Helper()
+	rules, err := RulesFor("claude")
+	if err != nil {
+		t.Fatalf("RulesFor(claude): %v", err)
+	}
+	return rules
+}
+
+func TestMatch_BuiltinClaudeRules(t *testing.T) {
+	rules := claudeRules(t)
+	tests := []struct {
+		name string
+		pane string
+		want string
+	}{
+		{"idle", "> \n", ""},
+		{"bypass", "WARNING: Claude Code running in Bypass Permissions mode\n❯ 1. No, exit\n  2. Yes, I accept\n", "bypass-permissions"},
+		{"trust", "Do you trust the files in this folder?\n❯ 1. Yes, proceed\n", "trust-folder"},
+		{"rate limit", "Claude usage limit reached. Your limit will reset at 5pm.\n", "rate-limit"},
+		{"login", "OAuth token has expired. Please run /login\n", "login-expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, _ := Match(rules, tt.pane)
+			got := ""
+			if rule != nil {
+				got = rule.Name
+			}
+			if got != tt.want {
+				t.Errorf("Match = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch_IgnoresScrolledOffPrompt(t *testing.T) {
+	pane := "Do you trust the files in this folder?\n"
+	for i := 0; i < tailLines; i++ {
+		pane += "working...\n"
+	}
+	if rule, _ := Match(claudeRules(t), pane); rule != nil {
+		t.Errorf("matched %q in scrolled-off output", rule.Name)
+	}
+}
+
+func TestCompile_RejectsInvalidRules(t *testing.T) {
+	tests := []config.PromptRule{
+		{Name: "", Pattern: "x", Action: config.PromptEscalate},
+		{Name: "bad-re", Pattern: "(", Action: config.PromptEscalate},
+		{Name: "no-keys", Pattern: "x", Action: config.PromptSendKeys},
+		{Name: "bad-action", Pattern: "x", Action: "shrug"},
+	}
+	for _, r := range tests {
+		if _, err := Compile([]config.PromptRule{r}); err == nil {
+			t.Errorf("Compile(%+v) succeeded, want error", r)
+		}
+	}
+}
+
+func TestAnswerer_SendsKeys(t *testing.T) {
+	term := &fakeTerminal{panes: map[string]string{
+		"gt-gastown-Toast": "Bypass Permissions mode\n  2. Yes, I accept\n",
+	}}
+	a := newTestAnswerer(term)
+
+	// The first sighting only records the pane; the second, unchanged, acts.
+	if det, _ := a.Check("gt-gastown-Toast", "gastown/polecats/Toast", claudeRules(t)); det != nil {
+		t.Fatalf("first check acted (%+v), want it to wait for a settled pane", det)
+	}
+	det, err := a.Check("gt-gastown-Toast", "gastown/polecats/Toast", claudeRules(t))
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if det == nil || det.Rule != "bypass-permissions" {
+		t.Fatalf("detection = %+v, want bypass-permissions", det)
+	}
+	if want := []string{"Down", "Enter"}; !reflect.DeepEqual(term.sent, want) {
+		t.Errorf("sent %v, want %v", term.sent, want)
+	}
+}
+
+func TestAnswerer_DoesNotAnswerTwice(t *testing.T) {
+	// The dialog is still on screen at the next heartbeat, e.g. while it
+	// redraws after the keys were sent.
+	term := &fakeTerminal{panes: map[string]string{
+		"gt-gastown-Toast": "Do you trust the files in this folder?\n❯ 1. Yes, proceed\n",
+	}}
+	a := newTestAnswerer(term)
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.Check("gt-gastown-Toast", "gastown/polecats/Toast", claudeRules(t)); err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+	}
+	if want := []string{"Enter"}; !reflect.DeepEqual(term.sent, want) {
+		t.Errorf("sent %v, want %v once", term.sent, want)
+	}
+}
+
+func TestAnswerer_IgnoresPromptTextAboveTail(t *testing.T) {
+	pane := "Do you trust the files in this folder?\n❯ 1. Yes, proceed\n"
+	for i := 0; i < tailLines; i++ {
+		pane += "● Editing main.go\n"
+	}
+	term := &fakeTerminal{panes: map[string]string{"s": pane}}
+	a := newTestAnswerer(term)
+
+	if det, _ := a.Check("s", "gastown/polecats/Toast", claudeRules(t)); det != nil {
+		t.Errorf("detection = %+v, want none for text above the tail", det)
+	}
+	if len(term.sent) != 0 {
+		t.Errorf("sent %v, want nothing", term.sent)
+	}
+}
+
+func TestAnswerer_WorkingAgentIsNotRestarted(t *testing.T) {
+	// The agent printed a rate limit message but keeps producing output.
+	term := &fakeTerminal{panes: map[string]string{}}
+	a := newTestAnswerer(term)
+	restarts := 0
+	a.Restart = func(Detection) error {
+		restarts++
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		term.panes["s"] = fmt.Sprintf("grep found: usage limit reached in docs/limits.md\n✻ Working… (%ds)\n", i)
+		if _, err := a.Check("s", "gastown/polecats/Toast", claudeRules(t)); err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+	}
+	if restarts != 0 {
+		t.Errorf("restarted %d times, want 0 while the pane keeps changing", restarts)
+	}
+}
+
+func TestAnswerer_ChangingPaneIsNotSentKeys(t *testing.T) {
+	// Prompt text passes through the tail of an agent that is still printing.
+	term := &fakeTerminal{panes: map[string]string{}}
+	a := newTestAnswerer(term)
+
+	for i := 0; i < 3; i++ {
+		term.panes["s"] = fmt.Sprintf("Do you trust the files in this folder?\n❯ 1. Yes, proceed\n● Reading file %d\n", i)
+		if det, _ := a.Check("s", "gastown/polecats/Toast", claudeRules(t)); det != nil {
+			t.Fatalf("check %d acted (%+v), want nothing while the pane changes", i, det)
+		}
+	}
+	if len(term.sent) != 0 {
+		t.Errorf("sent %v, want nothing", term.sent)
+	}
+}
+
+func TestAnswerer_RestartFallsBackToEscalate(t *testing.T) {
+	term := &fakeTerminal{panes: map[string]string{"s": "usage limit reached\n"}}
+	a := newTestAnswerer(term)
+	var escalated []Detection
+	a.Escalate = func(d Detection) error {
+		escalated = append(escalated, d)
+		return nil
+	}
+
+	// The first sighting only records the pane; the second, unchanged, acts.
+	if det, _ := a.Check("s", "gastown/witness", claudeRules(t)); det != nil {
+		t.Fatalf("first check acted (%+v), want it to wait for a settled pane", det)
+	}
+	det, err := a.Check("s", "gastown/witness", claudeRules(t))
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if det == nil || det.Action != config.PromptEscalate {
+		t.Fatalf("detection = %+v, want escalate", det)
+	}
+	if len(escalated) != 1 {
+		t.Errorf("escalated %d times, want 1", len(escalated))
+	}
+}
+
+func TestAnswerer_CooldownSuppressesRepeatRestart(t *testing.T) {
+	term := &fakeTerminal{panes: map[string]string{"s": "usage limit reached\n"}}
+	a := newTestAnswerer(term)
+	restarts := 0
+	a.Restart = func(d Detection) error {
+		if d.Account != "next" {
+			t.Errorf("Account = %q, want next", d.Account)
+		}
+		restarts++
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.Check("s", "gastown/polecats/Toast", claudeRules(t)); err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+	}
+	if restarts != 1 {
+		t.Errorf("restarted %d times, want 1", restarts)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -19,6 +20,7 @@ import (
 	"github.com/steveyegge/gastown/internal/mrqueue"
 	"github.com/steveyegge/gastown/internal/protocol"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/trace"
 )
 
 // MergeQueueConfig holds configuration for the merge queue processor.
@@ -235,7 +237,33 @@ func (e *Engineer) ProcessMR(ctx context.Context, mr *beads.Issue) ProcessResult
 	_, _ = fmt.Fprintf(e.output, "  Target: %s\n", mrFields.Target)
 	_, _ = fmt.Fprintf(e.output, "  Worker: %s\n", mrFields.Worker)
 
-	return e.doMerge(ctx, mrFields.Branch, mrFields.Target, mrFields.SourceIssue)
+	span := e.startMergeSpan(mrFields.TraceParent, mrFields.SourceIssue, mr.ID)
+	result := e.doMerge(ctx, mrFields.Branch, mrFields.Target, mrFields.SourceIssue)
+	finishMergeSpan(span, result)
+	return result
+}
+
+// startMergeSpan starts the "merge" span of an MR's trace. The parent is the
+// MR's trace_parent field if set, otherwise the latest span of the source issue.
+func (e *Engineer) startMergeSpan(traceParent, sourceIssue, mrID string) *trace.Span {
+	parent, err := trace.ParseTraceParent(traceParent)
+	if err != nil {
+		parent = trace.Resume(filepath.Dir(e.rig.Path), sourceIssue)
+	}
+	span := trace.Start("merge", parent)
+	span.Bead = mrID
+	span.Actor = e.rig.Name + "/refinery"
+	return span
+}
+
+// finishMergeSpan records the outcome of a merge on its span.
+func finishMergeSpan(span *trace.Span, result ProcessResult) {
+	if result.Success {
+		span.Detail = result.MergeCommit
+		span.Finish(nil)
+		return
+	}
+	span.Finish(errors.New(result.Error))
 }
 
 // doMerge performs the actual git merge operation.
@@ -486,7 +514,10 @@ func (e *Engineer) ProcessMRFromQueue(ctx context.Context, mr *mrqueue.MR) Proce
 	}
 
 	// Use the shared merge logic
-	return e.doMerge(ctx, mr.Branch, mr.Target, mr.SourceIssue)
+	span := e.startMergeSpan("", mr.SourceIssue, mr.ID)
+	result := e.doMerge(ctx, mr.Branch, mr.Target, mr.SourceIssue)
+	finishMergeSpan(span, result)
+	return result
 }
 
 // handleSuccessFromQueue handles a successful merge from wisp queue.
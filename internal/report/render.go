@@ -0,0 +1,105 @@
+package report
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+//go:embed templates/report.html
+var templateFS embed.FS
+
+var htmlTemplate = template.Must(template.New("report.html").Funcs(template.FuncMap{
+	"duration": FormatDuration,
+	"percent":  FormatPercent,
+	"usd":      FormatUSD,
+	"rigName":  rigName,
+	"htmlRow":  newHTMLRow,
+}).ParseFS(templateFS, "templates/report.html"))
+
+// htmlRow is a table row with its label and CSS class.
+type htmlRow struct {
+	Class string
+	Rig   string
+	Row   *Row
+}
+
+func newHTMLRow(class, rig string, row *Row) htmlRow {
+	return htmlRow{Class: class, Rig: rig, Row: row}
+}
+
+// WriteHTML renders the report as a standalone HTML page.
+func WriteHTML(w io.Writer, r *Report) error {
+	return htmlTemplate.Execute(w, r)
+}
+
+// WriteText renders the report as plain text tables, newest period first.
+func WriteText(w io.Writer, r *Report) error {
+	for i := len(r.Buckets) - 1; i >= 0; i-- {
+		b := r.Buckets[i]
+		fmt.Fprintf(w, "%s  (%s – %s)\n", b.Label, b.Start.Format("2006-01-02"), b.End.AddDate(0, 0, -1).Format("2006-01-02"))
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  RIG\tROLE\tCLOSED\tSLING→MERGE\tMERGE FAIL\tCONFLICT\tCRASH\tHANDOFF/BEAD\tCOST\tCOST/BEAD")
+		writeTextRow(tw, "all", "", b.Total)
+		for _, row := range b.Rigs {
+			writeTextRow(tw, row.Rig, "", row)
+		}
+		for _, row := range b.Roles {
+			writeTextRow(tw, rigName(row.Rig), row.Role, row)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+	return nil
+}
+
+func writeTextRow(w io.Writer, rig, role string, row *Row) {
+	if role == "" {
+		role = "*"
+	}
+	fmt.Fprintf(w, "  %s\t%s\t%d\t%s\t%s\t%s\t%s\t%.1f\t%s\t%s\n",
+		rig, role, row.BeadsClosed, FormatDuration(row.MedianSlingToMerge),
+		FormatPercent(row.MergeFailureRate), FormatPercent(row.ConflictRate), FormatPercent(row.CrashRate),
+		row.HandoffsPerBead, FormatUSD(row.CostUSD), FormatUSD(row.CostPerBead))
+}
+
+// FormatDuration renders a duration at a useful precision, or "-" for zero.
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "-"
+	case d < time.Hour:
+		return d.Round(time.Minute).String()
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%.1fh", d.Hours())
+	default:
+		return fmt.Sprintf("%.1fd", d.Hours()/24)
+	}
+}
+
+// FormatPercent renders a rate as a percentage.
+func FormatPercent(rate float64) string {
+	return fmt.Sprintf("%.0f%%", rate*100)
+}
+
+// FormatUSD renders a cost in dollars.
+func FormatUSD(usd float64) string {
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+// rigName labels town-level agents, which have no rig.
+func rigName(rig string) string {
+	if rig == "" {
+		return "town"
+	}
+	return rig
+}
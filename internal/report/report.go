@@ -0,0 +1,434 @@
+// Package report computes historical analytics for a town: throughput,
+// cycle time, merge and crash rates and cost, bucketed by week or month and
+// broken down by rig and role.
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/townlog"
+)
+
+// Report periods.
+const (
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// slingLookback is how far before the first bucket slings are read, so MRs
+// merged early in the report can be matched to the sling that started them.
+const slingLookback = 30 * 24 * time.Hour
+
+// Sources provides the raw data reports are computed from. A nil source is
+// skipped and the metrics it feeds are left at zero.
+type Sources struct {
+	Events      func(since time.Time) ([]events.Event, error)  // town events log
+	TownLog     func(since time.Time) ([]townlog.Event, error) // crash records
+	Rigs        func() ([]string, error)                       // registered rig names
+	MergeEvents func(rig string) ([]mrqueue.Event, error)
+	ClosedBeads func(since time.Time) ([]Bead, error) // may be partial along with an error
+	Costs       func(since time.Time) ([]Cost, error) // session costs recorded since
+}
+
+// Bead is a closed bead.
+type Bead struct {
+	ID       string
+	Assignee string // Agent address, e.g. gastown/polecats/nux
+	ClosedAt time.Time
+}
+
+// Cost is the recorded cost of one ended session.
+type Cost struct {
+	Role    string
+	Rig     string
+	USD     float64
+	EndedAt time.Time
+}
+
+// Options selects the report's buckets.
+type Options struct {
+	Period  string    // PeriodWeek or PeriodMonth
+	Periods int       // Number of buckets, ending with the current one
+	Rig     string    // Only report this rig (optional)
+	Now     time.Time // End of the report
+}
+
+// Report is the analytics for consecutive periods, oldest first.
+type Report struct {
+	Period    string    `json:"period"`
+	Generated time.Time `json:"generated"`
+	Rig       string    `json:"rig,omitempty"`
+	Buckets   []*Bucket `json:"buckets"`
+	Warnings  []string  `json:"warnings,omitempty"` // Sources that could not be read
+}
+
+// Bucket is one period of the report.
+type Bucket struct {
+	Label string    `json:"label"` // 2026-W42 or 2026-10
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"` // Exclusive
+	Total *Row      `json:"total"`
+	Rigs  []*Row    `json:"rigs"`  // One per rig, all roles
+	Roles []*Row    `json:"roles"` // One per rig and role
+}
+
+// Row holds the counts and rates for a rig, a role within a rig, or the
+// whole town. Rates are zero when their denominator is.
+type Row struct {
+	Rig  string `json:"rig,omitempty"`  // Empty for town-level agents and totals
+	Role string `json:"role,omitempty"` // Empty for rig rollups and totals
+
+	BeadsClosed   int     `json:"beads_closed"`
+	Merges        int     `json:"merges"`
+	MergeFailures int     `json:"merge_failures"`
+	Conflicts     int     `json:"conflicts"` // Failures caused by merge conflicts
+	Spawns        int     `json:"spawns"`
+	Crashes       int     `json:"crashes"`
+	Handoffs      int     `json:"handoffs"`
+	CostUSD       float64 `json:"cost_usd"`
+
+	MedianSlingToMerge time.Duration `json:"median_sling_to_merge_ns"`
+	MergeFailureRate   float64       `json:"merge_failure_rate"`
+	ConflictRate       float64       `json:"conflict_rate"`
+	CrashRate          float64       `json:"crash_rate"` // Crashes per spawned polecat
+	HandoffsPerBead    float64       `json:"handoffs_per_bead"`
+	CostPerBead        float64       `json:"cost_per_bead"`
+
+	slingToMerge []time.Duration
+}
+
+// Build computes a report from the sources.
+func Build(src Sources, opts Options) *Report {
+	if opts.Period != PeriodMonth {
+		opts.Period = PeriodWeek
+	}
+	if opts.Periods < 1 {
+		opts.Periods = 1
+	}
+
+	r := &Report{Period: opts.Period, Generated: opts.Now, Rig: opts.Rig}
+	start := periodStart(opts.Now, opts.Period)
+	for i := 1; i < opts.Periods; i++ {
+		start = addPeriod(start, opts.Period, -1)
+	}
+	for s := start; !s.After(opts.Now); s = addPeriod(s, opts.Period, 1) {
+		r.Buckets = append(r.Buckets, newBucket(s, addPeriod(s, opts.Period, 1), opts.Period))
+	}
+
+	b := &builder{report: r, rig: opts.Rig, slings: make(map[string][]time.Time)}
+	b.addEvents(src.Events, start.Add(-slingLookback))
+	b.addCrashes(src.TownLog, start)
+	b.addMerges(src.Rigs, src.MergeEvents)
+	b.addBeads(src.ClosedBeads, start)
+	b.addCosts(src.Costs, start)
+
+	for _, bucket := range r.Buckets {
+		bucket.finish()
+	}
+	return r
+}
+
+func newBucket(start, end time.Time, period string) *Bucket {
+	label := start.Format("2006-01")
+	if period == PeriodWeek {
+		year, week := start.ISOWeek()
+		label = fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return &Bucket{Label: label, Start: start, End: end, Total: &Row{}}
+}
+
+// periodStart returns the start of the week (Monday) or month containing t.
+func periodStart(t time.Time, period string) time.Time {
+	y, m, d := t.Date()
+	if period == PeriodMonth {
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	}
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+}
+
+func addPeriod(t time.Time, period string, n int) time.Time {
+	if period == PeriodMonth {
+		return t.AddDate(0, n, 0)
+	}
+	return t.AddDate(0, 0, 7*n)
+}
+
+// bucket returns the bucket containing t, or nil.
+func (r *Report) bucket(t time.Time) *Bucket {
+	for _, b := range r.Buckets {
+		if !t.Before(b.Start) && t.Before(b.End) {
+			return b
+		}
+	}
+	return nil
+}
+
+// row returns the row for a role within a rig, creating it.
+func (b *Bucket) row(rig, role string) *Row {
+	for _, row := range b.Roles {
+		if row.Rig == rig && row.Role == role {
+			return row
+		}
+	}
+	row := &Row{Rig: rig, Role: role}
+	b.Roles = append(b.Roles, row)
+	return row
+}
+
+// rigRow returns the rollup row for a rig, creating it.
+func (b *Bucket) rigRow(rig string) *Row {
+	for _, row := range b.Rigs {
+		if row.Rig == rig {
+			return row
+		}
+	}
+	row := &Row{Rig: rig}
+	b.Rigs = append(b.Rigs, row)
+	return row
+}
+
+// add applies fn to the role row, the rig rollup (for rig-level agents) and
+// the total.
+func (b *Bucket) add(rig, role string, fn func(*Row)) {
+	fn(b.row(rig, role))
+	if rig != "" {
+		fn(b.rigRow(rig))
+	}
+	fn(b.Total)
+}
+
+// finish sorts the rows and computes medians and rates.
+func (b *Bucket) finish() {
+	sort.Slice(b.Rigs, func(i, j int) bool { return b.Rigs[i].Rig < b.Rigs[j].Rig })
+	sort.Slice(b.Roles, func(i, j int) bool {
+		if b.Roles[i].Rig != b.Roles[j].Rig {
+			return b.Roles[i].Rig < b.Roles[j].Rig
+		}
+		return b.Roles[i].Role < b.Roles[j].Role
+	})
+	b.Total.finish()
+	for _, row := range b.Rigs {
+		row.finish()
+	}
+	for _, row := range b.Roles {
+		row.finish()
+	}
+}
+
+func (r *Row) finish() {
+	r.MedianSlingToMerge = median(r.slingToMerge)
+	r.MergeFailureRate = ratio(r.MergeFailures, r.Merges+r.MergeFailures)
+	r.ConflictRate = ratio(r.Conflicts, r.Merges+r.MergeFailures)
+	r.CrashRate = ratio(r.Crashes, r.Spawns)
+	r.HandoffsPerBead = ratio(r.Handoffs, r.BeadsClosed)
+	if r.BeadsClosed > 0 {
+		r.CostPerBead = r.CostUSD / float64(r.BeadsClosed)
+	}
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+func median(ds []time.Duration) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// builder accumulates source data into a report's buckets.
+type builder struct {
+	report *Report
+	rig    string
+	slings map[string][]time.Time // Sling times by bead, in log order
+}
+
+func (b *builder) warn(source string, err error) {
+	b.report.Warnings = append(b.report.Warnings, fmt.Sprintf("%s: %v", source, err))
+}
+
+// add records a data point at t for the agent's rig and role, unless it falls
+// outside the report or belongs to another rig.
+func (b *builder) add(t time.Time, rig, role string, fn func(*Row)) {
+	if b.rig != "" && rig != b.rig {
+		return
+	}
+	if bucket := b.report.bucket(t); bucket != nil {
+		bucket.add(rig, role, fn)
+	}
+}
+
+func (b *builder) addEvents(source func(time.Time) ([]events.Event, error), since time.Time) {
+	if source == nil {
+		return
+	}
+	evs, err := source(since)
+	if err != nil {
+		b.warn("events", err)
+		return
+	}
+	for _, e := range evs {
+		t, err := time.Parse(time.RFC3339, e.Timestamp)
+		if err != nil {
+			continue
+		}
+		switch e.Type {
+		case events.TypeSling:
+			if bead, _ := e.Payload["bead"].(string); bead != "" {
+				b.slings[bead] = append(b.slings[bead], t)
+			}
+		case events.TypeSpawn:
+			rig, _ := e.Payload["rig"].(string)
+			b.add(t, rig, string(session.RolePolecat), func(r *Row) { r.Spawns++ })
+		case events.TypeHandoff:
+			rig, role := ParseAgent(e.Actor)
+			b.add(t, rig, role, func(r *Row) { r.Handoffs++ })
+		}
+	}
+}
+
+func (b *builder) addCrashes(source func(time.Time) ([]townlog.Event, error), since time.Time) {
+	if source == nil {
+		return
+	}
+	evs, err := source(since)
+	if err != nil {
+		b.warn("town log", err)
+		return
+	}
+	for _, e := range evs {
+		if e.Type != townlog.EventCrash {
+			continue
+		}
+		rig, role := ParseAgent(e.Agent)
+		b.add(e.Timestamp, rig, role, func(r *Row) { r.Crashes++ })
+	}
+}
+
+func (b *builder) addMerges(rigs func() ([]string, error), source func(string) ([]mrqueue.Event, error)) {
+	if rigs == nil || source == nil {
+		return
+	}
+	names, err := rigs()
+	if err != nil {
+		b.warn("rigs", err)
+		return
+	}
+	for _, rig := range names {
+		if b.rig != "" && rig != b.rig {
+			continue
+		}
+		evs, err := source(rig)
+		if err != nil {
+			b.warn("merge queue "+rig, err)
+			continue
+		}
+		for _, e := range evs {
+			switch e.Type {
+			case mrqueue.EventMerged:
+				cycle, ok := b.slingToMerge(e.SourceIssue, e.Timestamp)
+				b.add(e.Timestamp, rig, string(session.RolePolecat), func(r *Row) {
+					r.Merges++
+					if ok {
+						r.slingToMerge = append(r.slingToMerge, cycle)
+					}
+				})
+			case mrqueue.EventMergeFailed:
+				conflict := strings.Contains(strings.ToLower(e.Reason), "conflict")
+				b.add(e.Timestamp, rig, string(session.RolePolecat), func(r *Row) {
+					r.MergeFailures++
+					if conflict {
+						r.Conflicts++
+					}
+				})
+			}
+		}
+	}
+}
+
+// slingToMerge returns the time from the bead's last sling before the merge
+// to the merge.
+func (b *builder) slingToMerge(bead string, merged time.Time) (time.Duration, bool) {
+	var last time.Time
+	for _, t := range b.slings[bead] {
+		if !t.After(merged) && t.After(last) {
+			last = t
+		}
+	}
+	if last.IsZero() {
+		return 0, false
+	}
+	return merged.Sub(last), true
+}
+
+func (b *builder) addBeads(source func(time.Time) ([]Bead, error), since time.Time) {
+	if source == nil {
+		return
+	}
+	beads, err := source(since)
+	if err != nil {
+		b.warn("beads", err) // May still have the beads of the databases that could be read
+	}
+	for _, bead := range beads {
+		rig, role := ParseAgent(bead.Assignee)
+		b.add(bead.ClosedAt, rig, role, func(r *Row) { r.BeadsClosed++ })
+	}
+}
+
+func (b *builder) addCosts(source func(time.Time) ([]Cost, error), since time.Time) {
+	if source == nil {
+		return
+	}
+	costs, err := source(since)
+	if err != nil {
+		b.warn("costs", err)
+		return
+	}
+	for _, c := range costs {
+		usd := c.USD
+		b.add(c.EndedAt, c.Rig, c.Role, func(r *Row) { r.CostUSD += usd })
+	}
+}
+
+// ParseAgent returns the rig and role of an agent address (gastown/polecats/nux,
+// mayor) or session name (gt-gastown-nux). Unrecognized agents have no rig
+// and role "other".
+func ParseAgent(agent string) (rig, role string) {
+	parts := strings.Split(strings.TrimSuffix(agent, "/"), "/")
+	switch {
+	case agent == "":
+		return "", "other"
+	case len(parts) == 1 && (agent == string(session.RoleMayor) || agent == string(session.RoleDeacon)):
+		return "", agent
+	case len(parts) == 2 && (parts[1] == string(session.RoleWitness) || parts[1] == string(session.RoleRefinery)):
+		return parts[0], parts[1]
+	case len(parts) == 3 && parts[1] == "polecats":
+		return parts[0], string(session.RolePolecat)
+	case len(parts) == 3 && parts[1] == string(session.RoleCrew):
+		return parts[0], string(session.RoleCrew)
+	case len(parts) == 2:
+		// rig/name is shorthand for a polecat
+		return parts[0], string(session.RolePolecat)
+	}
+	if id, err := session.ParseSessionName(agent); err == nil {
+		return id.Rig, string(id.Role)
+	}
+	return "", "other"
+}
@@ -0,0 +1,198 @@
+package report
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/townlog"
+)
+
+// Wednesday of ISO week 42.
+var testNow = time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+func ts(t time.Time) string { return t.Format(time.RFC3339) }
+
+func testSources() Sources {
+	thisWeek := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC) // Monday
+	lastWeek := thisWeek.AddDate(0, 0, -7)
+	return Sources{
+		Events: func(since time.Time) ([]events.Event, error) {
+			return []events.Event{
+				{Timestamp: ts(lastWeek.Add(-time.Hour)), Type: events.TypeSling, Payload: map[string]interface{}{"bead": "gt-1"}},
+				{Timestamp: ts(thisWeek), Type: events.TypeSling, Payload: map[string]interface{}{"bead": "gt-2"}},
+				{Timestamp: ts(thisWeek), Type: events.TypeSling, Payload: map[string]interface{}{"bead": "gt-3"}},
+				{Timestamp: ts(thisWeek), Type: events.TypeSpawn, Payload: map[string]interface{}{"rig": "gastown", "polecat": "nux"}},
+				{Timestamp: ts(thisWeek), Type: events.TypeSpawn, Payload: map[string]interface{}{"rig": "gastown", "polecat": "toast"}},
+				{Timestamp: ts(thisWeek.Add(time.Hour)), Type: events.TypeHandoff, Actor: "gastown/polecats/nux"},
+				{Timestamp: ts(thisWeek.Add(time.Hour)), Type: events.TypeHandoff, Actor: "mayor"},
+			}, nil
+		},
+		TownLog: func(since time.Time) ([]townlog.Event, error) {
+			return []townlog.Event{
+				{Timestamp: thisWeek.Add(2 * time.Hour), Type: townlog.EventCrash, Agent: "gastown/polecats/toast"},
+				{Timestamp: thisWeek.Add(2 * time.Hour), Type: townlog.EventDone, Agent: "gastown/polecats/nux"},
+			}, nil
+		},
+		Rigs: func() ([]string, error) { return []string{"beads", "gastown"}, nil },
+		MergeEvents: func(rig string) ([]mrqueue.Event, error) {
+			if rig == "beads" {
+				return nil, errors.New("no queue")
+			}
+			return []mrqueue.Event{
+				{Timestamp: lastWeek, Type: mrqueue.EventMerged, SourceIssue: "gt-1"},
+				{Timestamp: thisWeek.Add(2 * time.Hour), Type: mrqueue.EventMerged, SourceIssue: "gt-2"},
+				{Timestamp: thisWeek.Add(4 * time.Hour), Type: mrqueue.EventMerged, SourceIssue: "gt-3"},
+				{Timestamp: thisWeek.Add(5 * time.Hour), Type: mrqueue.EventMergeFailed, Reason: "Merge conflict in main.go"},
+				{Timestamp: thisWeek.Add(5 * time.Hour), Type: mrqueue.EventMergeSkipped},
+			}, nil
+		},
+		ClosedBeads: func(since time.Time) ([]Bead, error) {
+			return []Bead{
+				{ID: "gt-1", Assignee: "gastown/polecats/nux", ClosedAt: lastWeek},
+				{ID: "gt-2", Assignee: "gastown/polecats/nux", ClosedAt: thisWeek.Add(3 * time.Hour)},
+				{ID: "gt-3", Assignee: "gastown/polecats/toast", ClosedAt: thisWeek.Add(5 * time.Hour)},
+				{ID: "hq-1", Assignee: "mayor", ClosedAt: thisWeek},
+			}, nil
+		},
+		Costs: func(since time.Time) ([]Cost, error) {
+			return []Cost{
+				{Role: "polecat", Rig: "gastown", USD: 3, EndedAt: thisWeek},
+				{Role: "witness", Rig: "gastown", USD: 1, EndedAt: thisWeek},
+				{Role: "mayor", USD: 2, EndedAt: thisWeek},
+			}, nil
+		},
+	}
+}
+
+func findRow(rows []*Row, rig, role string) *Row {
+	for _, r := range rows {
+		if r.Rig == rig && r.Role == role {
+			return r
+		}
+	}
+	return nil
+}
+
+func TestBuild(t *testing.T) {
+	r := Build(testSources(), Options{Period: PeriodWeek, Periods: 2, Now: testNow})
+
+	if len(r.Buckets) != 2 || r.Buckets[0].Label != "2026-W41" || r.Buckets[1].Label != "2026-W42" {
+		t.Fatalf("buckets = %+v, want W41 and W42", r.Buckets)
+	}
+	if len(r.Warnings) != 1 || !strings.Contains(r.Warnings[0], "merge queue beads") {
+		t.Errorf("warnings = %q, want the beads merge queue", r.Warnings)
+	}
+
+	last := r.Buckets[0].Total
+	if last.BeadsClosed != 1 || last.Merges != 1 || last.MedianSlingToMerge != time.Hour {
+		t.Errorf("last week total = %+v", last)
+	}
+
+	total := r.Buckets[1].Total
+	if total.BeadsClosed != 3 || total.Merges != 2 || total.MergeFailures != 1 || total.Conflicts != 1 {
+		t.Errorf("this week counts = %+v", total)
+	}
+	if total.MedianSlingToMerge != 3*time.Hour {
+		t.Errorf("median sling to merge = %v, want 3h", total.MedianSlingToMerge)
+	}
+	if total.MergeFailureRate != 1.0/3 || total.CostUSD != 6 || total.CostPerBead != 2 {
+		t.Errorf("this week rates = %+v", total)
+	}
+
+	gastown := findRow(r.Buckets[1].Rigs, "gastown", "")
+	if gastown == nil || gastown.BeadsClosed != 2 || gastown.CostUSD != 4 || gastown.CostPerBead != 2 {
+		t.Errorf("gastown rollup = %+v", gastown)
+	}
+
+	polecats := findRow(r.Buckets[1].Roles, "gastown", "polecat")
+	if polecats == nil || polecats.Spawns != 2 || polecats.Crashes != 1 || polecats.CrashRate != 0.5 ||
+		polecats.Handoffs != 1 || polecats.HandoffsPerBead != 0.5 {
+		t.Errorf("gastown polecats = %+v", polecats)
+	}
+	if mayor := findRow(r.Buckets[1].Roles, "", "mayor"); mayor == nil || mayor.BeadsClosed != 1 || mayor.Handoffs != 1 {
+		t.Errorf("mayor = %+v", mayor)
+	}
+}
+
+func TestBuild_RigFilterAndMonths(t *testing.T) {
+	r := Build(testSources(), Options{Period: PeriodMonth, Periods: 1, Rig: "gastown", Now: testNow})
+
+	if len(r.Buckets) != 1 || r.Buckets[0].Label != "2026-10" {
+		t.Fatalf("buckets = %+v, want 2026-10", r.Buckets)
+	}
+	total := r.Buckets[0].Total
+	if total.BeadsClosed != 3 || total.CostUSD != 4 {
+		t.Errorf("gastown total = %+v, want the mayor left out", total)
+	}
+	if findRow(r.Buckets[0].Roles, "", "mayor") != nil {
+		t.Error("rig filter kept a town-level row")
+	}
+}
+
+func TestBuild_PartialClosedBeads(t *testing.T) {
+	src := testSources()
+	full := src.ClosedBeads
+	src.ClosedBeads = func(since time.Time) ([]Bead, error) {
+		beads, _ := full(since)
+		return beads, errors.New("listing closed beads in /town/beads: exit status 1")
+	}
+	r := Build(src, Options{Period: PeriodWeek, Periods: 2, Now: testNow})
+
+	if len(r.Warnings) != 2 || !strings.Contains(r.Warnings[1], "/town/beads") {
+		t.Errorf("warnings = %q, want the unreadable rig", r.Warnings)
+	}
+	if got := r.Buckets[1].Total.BeadsClosed; got != 3 {
+		t.Errorf("beads closed = %d, want the beads that could be read (3)", got)
+	}
+}
+
+func TestParseAgent(t *testing.T) {
+	tests := []struct {
+		agent, rig, role string
+	}{
+		{"gastown/polecats/nux", "gastown", "polecat"},
+		{"gastown/crew/max", "gastown", "crew"},
+		{"gastown/witness", "gastown", "witness"},
+		{"gastown/refinery", "gastown", "refinery"},
+		{"gastown/nux", "gastown", "polecat"},
+		{"mayor", "", "mayor"},
+		{"deacon", "", "deacon"},
+		{"gt-gastown-toast", "gastown", "polecat"},
+		{"", "", "other"},
+		{"daemon", "", "other"},
+	}
+	for _, tt := range tests {
+		rig, role := ParseAgent(tt.agent)
+		if rig != tt.rig || role != tt.role {
+			t.Errorf("ParseAgent(%q) = %q, %q, want %q, %q", tt.agent, rig, role, tt.rig, tt.role)
+		}
+	}
+}
+
+func TestWriteTextAndHTML(t *testing.T) {
+	r := Build(testSources(), Options{Period: PeriodWeek, Periods: 1, Now: testNow})
+
+	var text strings.Builder
+	if err := WriteText(&text, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"2026-W42", "SLING→MERGE", "gastown", "polecat", "3.0h", "33%", "$2.00", "warning: merge queue beads"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var html strings.Builder
+	if err := WriteHTML(&html, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Weekly Report", "2026-W42", `<tr class="total">`, "gastown", "3.0h", "$6.00"} {
+		if !strings.Contains(html.String(), want) {
+			t.Errorf("html missing %q", want)
+		}
+	}
+}
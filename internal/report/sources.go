@@ -0,0 +1,115 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/logrotate"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/townlog"
+)
+
+// LiveSources reads the town's events logs, merge queue event logs and
+// closed beads. Costs are left to the caller, since cost digests are queried
+// through gt.
+func LiveSources(townRoot string) Sources {
+	return Sources{
+		Events: func(since time.Time) ([]events.Event, error) {
+			return readEvents(filepath.Join(townRoot, events.EventsFile), since)
+		},
+		TownLog: func(since time.Time) ([]townlog.Event, error) {
+			return townlog.ReadEventsSince(townRoot, since)
+		},
+		Rigs: func() ([]string, error) {
+			return config.TownRigNames(townRoot)
+		},
+		MergeEvents: func(rig string) ([]mrqueue.Event, error) {
+			return mrqueue.NewEventLoggerFromRig(filepath.Join(townRoot, rig)).ReadEvents()
+		},
+		ClosedBeads: func(since time.Time) ([]Bead, error) {
+			return closedBeads(townRoot, since)
+		},
+	}
+}
+
+// readEvents decodes the events log, skipping malformed lines.
+func readEvents(path string, since time.Time) ([]events.Event, error) {
+	r, err := logrotate.Open(path, since)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var evs []events.Event
+	scanner := logrotate.NewScanner(r)
+	for scanner.Scan() {
+		e, err := events.Decode(scanner.Bytes())
+		if err != nil {
+			continue
+		}
+		evs = append(evs, e)
+	}
+	return evs, scanner.Err()
+}
+
+// workTypes are the bead types counted as closed work. Messages, convoys,
+// merge requests, agent beads and molecule steps are bookkeeping.
+var workTypes = map[string]bool{"task": true, "bug": true, "feature": true, "chore": true}
+
+// closedBeads lists work beads closed since the given time in the town and
+// rig databases. Beads routed into several databases are counted once. A
+// database that can't be listed is skipped: the beads of the others are
+// returned along with an error naming it.
+func closedBeads(townRoot string, since time.Time) ([]Bead, error) {
+	var errs []error
+	dirs := []string{townRoot}
+	rigs, err := config.TownRigNames(townRoot)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listing rigs: %w", err))
+	}
+	for _, rig := range rigs {
+		dirs = append(dirs, filepath.Join(townRoot, rig))
+	}
+
+	seen := make(map[string]bool)
+	var result []Bead
+	for _, dir := range dirs {
+		issues, err := listClosed(dir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, issue := range issues {
+			closedAt, err := time.Parse(time.RFC3339, issue.ClosedAt)
+			if err != nil || closedAt.Before(since) || !workTypes[issue.Type] || seen[issue.ID] {
+				continue
+			}
+			seen[issue.ID] = true
+			result = append(result, Bead{ID: issue.ID, Assignee: issue.Assignee, ClosedAt: closedAt})
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// listClosed lists the closed beads of one database.
+func listClosed(dir string) ([]beads.Issue, error) {
+	bd := beads.NewWithBeadsDir(dir, beads.ResolveBeadsDir(dir))
+	out, err := bd.Run("list", "--status=closed", "--json", "--limit=0")
+	if err != nil {
+		return nil, fmt.Errorf("listing closed beads in %s: %w", dir, err)
+	}
+	var issues []beads.Issue
+	if out = bytes.TrimSpace(out); len(out) > 0 && string(out) != "null" {
+		if err := json.Unmarshal(out, &issues); err != nil {
+			return nil, fmt.Errorf("parsing closed beads in %s: %w", dir, err)
+		}
+	}
+	return issues, nil
+}
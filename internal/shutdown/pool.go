@@ -0,0 +1,536 @@
+package shutdown
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// Pool sizing (GT_DOG_POOL_SIZE overrides the default).
+const (
+	DefaultPoolSize = 5
+	MaxPoolSize     = 20
+	PoolSizeEnv     = "GT_DOG_POOL_SIZE"
+)
+
+const (
+	// dispatchInterval is how often the pool checks for new warrants.
+	dispatchInterval = 5 * time.Second
+
+	// healthCheckPrefix marks the interrogation message in the pane.
+	healthCheckPrefix = "[DOG] HEALTH CHECK"
+
+	// responseKeyword starts the response the target must print to be
+	// pardoned: ALIVE-<token>, with the dance's one-time token.
+	responseKeyword = "ALIVE"
+
+	// tokenAlphabet and tokenLength shape dance tokens.
+	tokenAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	tokenLength   = 5
+
+	// captureLines is how much pane history to inspect when evaluating.
+	captureLines = 50
+)
+
+// Sessions is the subset of tmux operations a dance needs.
+// *tmux.Tmux satisfies it; tests substitute a fake.
+type Sessions interface {
+	HasSession(name string) (bool, error)
+	NudgeSession(session, message string) error
+	CapturePane(session string, lines int) (string, error)
+	KillSession(name string) error
+}
+
+// PoolSizeFromEnv returns the pool size from GT_DOG_POOL_SIZE,
+// clamped to [1, MaxPoolSize], or DefaultPoolSize if unset or invalid.
+func PoolSizeFromEnv() int {
+	n, err := strconv.Atoi(os.Getenv(PoolSizeEnv))
+	if err != nil || n < 1 {
+		return DefaultPoolSize
+	}
+	if n > MaxPoolSize {
+		return MaxPoolSize
+	}
+	return n
+}
+
+// Pool runs up to size shutdown dances concurrently, each with its own timeouts.
+// Warrants beyond capacity stay queued in the registry until a dog frees up.
+type Pool struct {
+	registry *Registry
+	sessions Sessions
+	size     int
+
+	// Timeouts are the per-attempt response windows. Defaults to DefaultTimeouts.
+	Timeouts []time.Duration
+
+	// Logf receives progress messages (e.g., the daemon log). Optional.
+	Logf func(format string, args ...interface{})
+
+	// OnResult is called after a dance finishes and its record is archived. Optional.
+	OnResult func(Result)
+
+	// LogFeed records feed events for kills and outcomes. Defaults to events.LogFeed.
+	LogFeed func(eventType, actor string, payload map[string]interface{}) error
+
+	mu      sync.Mutex
+	running map[string]bool // dog IDs with a live goroutine
+	seq     int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPool creates a pool backed by registry. A size < 1 uses DefaultPoolSize.
+func NewPool(registry *Registry, sessions Sessions, size int) *Pool {
+	if size < 1 {
+		size = DefaultPoolSize
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Pool{
+		registry: registry,
+		sessions: sessions,
+		size:     size,
+		Timeouts: DefaultTimeouts,
+		LogFeed:  events.LogFeed,
+		running:  make(map[string]bool),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start resumes dances left active by a previous daemon and begins
+// dispatching pending warrants.
+func (p *Pool) Start() error {
+	if _, err := p.RecoverOrphans(); err != nil {
+		return err
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(dispatchInterval)
+		defer ticker.Stop()
+
+		p.Dispatch()
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				p.Dispatch()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop halts dispatching and waits for running dances to park.
+// In-flight dances keep their active records and resume on the next Start.
+func (p *Pool) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+// RecoverOrphans resumes every dance in active/ that has no running goroutine.
+// Returns the number of dances resumed.
+func (p *Pool) RecoverOrphans() (int, error) {
+	dances, err := p.registry.Active()
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for _, d := range dances {
+		msg := fmt.Sprintf("Dog %s resuming dance for %s (%s, attempt %d)", d.ID, targetOf(d), d.State, d.Attempt)
+		if p.launch(d) {
+			p.logf("%s", msg)
+			resumed++
+		}
+	}
+	return resumed, nil
+}
+
+// Dispatch starts dances for pending warrants while dogs are free.
+// Returns the number of dances started.
+func (p *Pool) Dispatch() int {
+	if p.ctx.Err() != nil {
+		return 0
+	}
+
+	pending, err := p.registry.Pending()
+	if err != nil {
+		p.logf("Error reading warrants: %v", err)
+		return 0
+	}
+
+	started := 0
+	for _, w := range pending {
+		if p.Busy() >= p.size {
+			p.logf("Dog pool exhausted (%d/%d), %d warrant(s) queued", p.size, p.size, len(pending)-started)
+			break
+		}
+
+		d, err := p.registry.Claim(w, p.nextDogID(), time.Now())
+		if err != nil {
+			p.logf("Error claiming warrant %s: %v", w.ID, err)
+			continue
+		}
+		dogID := d.ID
+		if p.launch(d) {
+			p.logf("Dog %s interrogating %s (warrant %s: %s)", dogID, w.Target, w.ID, w.Reason)
+			started++
+		}
+	}
+	return started
+}
+
+// Busy returns the number of dogs currently running a dance.
+func (p *Pool) Busy() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.running)
+}
+
+// Size returns the pool capacity.
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// launch starts a goroutine for d unless one is already running.
+func (p *Pool) launch(d *Dance) bool {
+	p.mu.Lock()
+	if p.running[d.ID] {
+		p.mu.Unlock()
+		return false
+	}
+	p.running[d.ID] = true
+	p.mu.Unlock()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer p.release(d.ID)
+		p.run(d)
+	}()
+	return true
+}
+
+func (p *Pool) release(dogID string) {
+	p.mu.Lock()
+	delete(p.running, dogID)
+	p.mu.Unlock()
+}
+
+func (p *Pool) nextDogID() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seq++
+	return fmt.Sprintf("dog-%d-%d", time.Now().UnixMilli(), p.seq)
+}
+
+// run drives a dance from its current state to a terminal state.
+// If the pool is stopped mid-dance, run returns without finishing so the
+// persisted record can be resumed later.
+func (p *Pool) run(d *Dance) {
+	for {
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		switch d.State {
+		case StateInterrogating:
+			if err := p.interrogate(d); err != nil {
+				if p.ctx.Err() != nil {
+					return
+				}
+				p.finish(d, OutcomeFailed, err.Error())
+				return
+			}
+			if d.State.IsTerminal() {
+				return
+			}
+
+		case StateEvaluating:
+			alive, err := p.evaluate(d)
+			if err != nil {
+				p.finish(d, OutcomeFailed, err.Error())
+				return
+			}
+			if alive {
+				p.finish(d, OutcomePardoned, fmt.Sprintf("responded on attempt %d", d.Attempt))
+				return
+			}
+			if d.Attempt >= MaxAttempts {
+				d.State = StateExecuting
+			} else {
+				d.Attempt++
+				d.State = StateInterrogating
+				d.LastMessageAt = time.Time{}
+				d.NextTimeout = time.Time{}
+			}
+			p.save(d)
+
+		case StateExecuting:
+			p.execute(d)
+			return
+
+		default:
+			// Terminal record left in active/ by a crash after finishing: archive it.
+			if err := p.registry.Finish(d); err != nil {
+				p.logf("Error archiving dance %s: %v", d.ID, err)
+			}
+			return
+		}
+	}
+}
+
+// interrogate sends the health check (unless one is already outstanding from
+// before a restart) and waits out the attempt's timeout.
+func (p *Pool) interrogate(d *Dance) error {
+	target := targetOf(d)
+
+	if d.NextTimeout.IsZero() {
+		alive, err := p.sessions.HasSession(target)
+		if err != nil {
+			return fmt.Errorf("checking session %s: %w", target, err)
+		}
+		if !alive {
+			// Nothing left to interrogate; the warrant is moot.
+			p.finish(d, OutcomeExecuted, "session already gone")
+			return nil
+		}
+
+		if d.Token == "" {
+			token, err := newDanceToken()
+			if err != nil {
+				return err
+			}
+			d.Token = token
+		}
+		timeout := p.timeoutFor(d.Attempt)
+		if err := p.sessions.NudgeSession(target, healthCheckMessage(d, timeout)); err != nil {
+			return fmt.Errorf("sending health check to %s: %w", target, err)
+		}
+		now := time.Now()
+		d.LastMessageAt = now
+		d.NextTimeout = now.Add(timeout)
+		p.save(d)
+	}
+
+	wait := time.Until(d.NextTimeout)
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-p.ctx.Done():
+			return p.ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	d.State = StateEvaluating
+	p.save(d)
+	return nil
+}
+
+// evaluate checks the target's pane for a response to the health check.
+func (p *Pool) evaluate(d *Dance) (bool, error) {
+	target := targetOf(d)
+	alive, err := p.sessions.HasSession(target)
+	if err != nil {
+		return false, fmt.Errorf("checking session %s: %w", target, err)
+	}
+	if !alive {
+		return false, nil
+	}
+	output, err := p.sessions.CapturePane(target, captureLines)
+	if err != nil {
+		return false, fmt.Errorf("capturing pane for %s: %w", target, err)
+	}
+	return respondedToHealthCheck(output, d.Token), nil
+}
+
+// execute kills the target session and records the outcome.
+func (p *Pool) execute(d *Dance) {
+	target := targetOf(d)
+	d.State = StateExecuting
+	p.save(d)
+
+	alive, err := p.sessions.HasSession(target)
+	if err != nil {
+		p.finish(d, OutcomeFailed, fmt.Sprintf("checking session: %v", err))
+		return
+	}
+	if alive {
+		if err := p.sessions.KillSession(target); err != nil {
+			p.finish(d, OutcomeFailed, fmt.Sprintf("killing session: %v", err))
+			return
+		}
+		_ = p.LogFeed(events.TypeSessionDeath, "daemon",
+			events.SessionDeathPayload(target, "", "shutdown dance: "+reasonOf(d), "dog"))
+	}
+	p.finish(d, OutcomeExecuted, fmt.Sprintf("no response after %d attempts", d.Attempt))
+}
+
+// finish records the outcome, archives the dance and reports it.
+func (p *Pool) finish(d *Dance, outcome Outcome, details string) {
+	now := time.Now()
+	d.Outcome = outcome
+	d.Details = details
+	d.CompletedAt = now
+	switch outcome {
+	case OutcomePardoned:
+		d.State = StatePardoned
+	case OutcomeExecuted:
+		d.State = StateComplete
+	default:
+		d.State = StateFailed
+	}
+
+	if err := p.registry.Finish(d); err != nil {
+		p.logf("Error archiving dance %s: %v", d.ID, err)
+	}
+
+	p.logf("Dog %s %s %s: %s", d.ID, outcome, targetOf(d), details)
+	_ = p.LogFeed(outcomeEventType(outcome), "daemon",
+		events.DancePayload(warrantIDOf(d), targetOf(d), reasonOf(d), string(outcome), d.Attempt))
+
+	if p.OnResult != nil {
+		p.OnResult(Result{
+			DogID:    d.ID,
+			Warrant:  d.Warrant,
+			Outcome:  outcome,
+			Attempts: d.Attempt,
+			Duration: now.Sub(d.StartedAt),
+			Details:  details,
+		})
+	}
+}
+
+func (p *Pool) save(d *Dance) {
+	if err := p.registry.Save(d); err != nil {
+		p.logf("Error saving dance %s: %v", d.ID, err)
+	}
+}
+
+func (p *Pool) timeoutFor(attempt int) time.Duration {
+	timeouts := p.Timeouts
+	if len(timeouts) == 0 {
+		timeouts = DefaultTimeouts
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt > len(timeouts) {
+		return timeouts[len(timeouts)-1]
+	}
+	return timeouts[attempt-1]
+}
+
+func (p *Pool) logf(format string, args ...interface{}) {
+	if p.Logf != nil {
+		p.Logf(format, args...)
+	}
+}
+
+// newDanceToken returns a random one-time token for a dance's responses.
+func newDanceToken() (string, error) {
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating dance token: %w", err)
+	}
+	for i := range b {
+		b[i] = tokenAlphabet[int(b[i])%len(tokenAlphabet)]
+	}
+	return string(b), nil
+}
+
+// spelledToken writes a token with dashes between its characters, so the
+// response it asks for never appears in the health check itself, however
+// the pane wraps it.
+func spelledToken(token string) string {
+	return strings.Join(strings.Split(token, ""), "-")
+}
+
+// healthCheckMessage builds the interrogation nudge. It asks for
+// ALIVE-<token> but spells the token out with dashes.
+func healthCheckMessage(d *Dance, timeout time.Duration) string {
+	return fmt.Sprintf("%s: Session %s, respond %s-<code> within %ds, where <code> is %s without the dashes, or face termination. Warrant reason: %s. Filed by: %s. Attempt: %d/%d",
+		healthCheckPrefix, targetOf(d), responseKeyword, int(timeout.Seconds()), spelledToken(d.Token),
+		reasonOf(d), requesterOf(d), d.Attempt, MaxAttempts)
+}
+
+// respondedToHealthCheck reports whether the pane shows ALIVE-<token> after
+// the most recent health check, on a line that isn't part of the check.
+// Without a token, or with the health check scrolled out of the captured
+// window, nothing counts as a response.
+func respondedToHealthCheck(output, token string) bool {
+	if token == "" {
+		return false
+	}
+	lines := strings.Split(output, "\n")
+	last := -1
+	for i, line := range lines {
+		if strings.Contains(line, healthCheckPrefix) {
+			last = i
+		}
+	}
+	if last < 0 {
+		return false
+	}
+	response := responseKeyword + "-" + token
+	for _, line := range lines[last+1:] {
+		if strings.Contains(line, response) && !strings.Contains(line, spelledToken(token)) {
+			return true
+		}
+	}
+	return false
+}
+
+func outcomeEventType(o Outcome) string {
+	switch o {
+	case OutcomePardoned:
+		return events.TypeDancePardoned
+	case OutcomeExecuted:
+		return events.TypeDanceExecuted
+	default:
+		return events.TypeDanceFailed
+	}
+}
+
+func targetOf(d *Dance) string {
+	if d.Warrant == nil {
+		return ""
+	}
+	return d.Warrant.Target
+}
+
+func reasonOf(d *Dance) string {
+	if d.Warrant == nil {
+		return ""
+	}
+	return d.Warrant.Reason
+}
+
+func requesterOf(d *Dance) string {
+	if d.Warrant == nil {
+		return ""
+	}
+	return d.Warrant.Requester
+}
+
+func warrantIDOf(d *Dance) string {
+	if d.Warrant == nil {
+		return ""
+	}
+	return d.Warrant.ID
+}
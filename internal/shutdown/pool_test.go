@@ -0,0 +1,324 @@
+package shutdown
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// fakeSessions is an in-memory Sessions implementation.
+type fakeSessions struct {
+	mu      sync.Mutex
+	alive   map[string]bool
+	panes   map[string]string
+	respond map[string]bool // append ALIVE-<token> after each health check
+	nudges  map[string]int
+	killed  []string
+}
+
+// spelledCodePattern finds the spelled-out token of a health check.
+var spelledCodePattern = regexp.MustCompile(`<code> is (\S+) without`)
+
+func newFakeSessions(targets ...string) *fakeSessions {
+	f := &fakeSessions{
+		alive:   make(map[string]bool),
+		panes:   make(map[string]string),
+		respond: make(map[string]bool),
+		nudges:  make(map[string]int),
+	}
+	for _, t := range targets {
+		f.alive[t] = true
+	}
+	return f
+}
+
+func (f *fakeSessions) HasSession(name string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.alive[name], nil
+}
+
+func (f *fakeSessions) NudgeSession(session, message string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.alive[session] {
+		return errors.New("no session")
+	}
+	f.nudges[session]++
+	f.panes[session] += message + "\n"
+	if m := spelledCodePattern.FindStringSubmatch(message); m != nil && f.respond[session] {
+		f.panes[session] += "> " + responseKeyword + "-" + strings.ReplaceAll(m[1], "-", "") + "\n"
+	}
+	return nil
+}
+
+func (f *fakeSessions) CapturePane(session string, lines int) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.panes[session], nil
+}
+
+func (f *fakeSessions) KillSession(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.alive[name] = false
+	f.killed = append(f.killed, name)
+	return nil
+}
+
+// feedRecorder collects the feed events a pool logs, instead of writing
+// them to the events file of whatever town the test runs under.
+type feedRecorder struct {
+	mu    sync.Mutex
+	types []string
+}
+
+func (r *feedRecorder) log(eventType, actor string, payload map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types = append(r.types, eventType)
+	return nil
+}
+
+func (r *feedRecorder) logged() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.types...)
+}
+
+func newTestPool(t *testing.T, sessions Sessions, size int) (*Pool, *Registry, chan Result) {
+	t.Helper()
+	reg := NewRegistry(t.TempDir())
+	pool := NewPool(reg, sessions, size)
+	pool.LogFeed = (&feedRecorder{}).log
+	pool.Timeouts = []time.Duration{5 * time.Millisecond, 5 * time.Millisecond, 5 * time.Millisecond}
+	results := make(chan Result, 10)
+	pool.OnResult = func(r Result) { results <- r }
+	return pool, reg, results
+}
+
+func waitResult(t *testing.T, results chan Result) Result {
+	t.Helper()
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for dance result")
+		return Result{}
+	}
+}
+
+func TestPool_ExecutesUnresponsiveSession(t *testing.T) {
+	sessions := newFakeSessions("gt-gastown-Toast")
+	pool, reg, results := newTestPool(t, sessions, 2)
+	defer pool.Stop()
+	feed := &feedRecorder{}
+	pool.LogFeed = feed.log
+
+	if err := reg.File(&Warrant{Target: "gt-gastown-Toast", Reason: "stuck", Requester: "deacon"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := pool.Dispatch(); n != 1 {
+		t.Fatalf("Dispatch started %d dances, want 1", n)
+	}
+
+	r := waitResult(t, results)
+	if got := feed.logged(); len(got) != 2 || got[0] != events.TypeSessionDeath || got[1] != events.TypeDanceExecuted {
+		t.Errorf("feed events = %v, want session death then dance executed", got)
+	}
+	if r.Outcome != OutcomeExecuted {
+		t.Errorf("Outcome = %s, want executed (%s)", r.Outcome, r.Details)
+	}
+	if r.Attempts != MaxAttempts {
+		t.Errorf("Attempts = %d, want %d", r.Attempts, MaxAttempts)
+	}
+	if sessions.nudges["gt-gastown-Toast"] != MaxAttempts {
+		t.Errorf("sent %d health checks, want %d", sessions.nudges["gt-gastown-Toast"], MaxAttempts)
+	}
+	if len(sessions.killed) != 1 {
+		t.Errorf("killed = %v, want Toast", sessions.killed)
+	}
+
+	pool.Stop()
+	if active, _ := reg.Active(); len(active) != 0 {
+		t.Errorf("active dances = %d, want 0", len(active))
+	}
+	completed, _ := reg.Completed(0)
+	if len(completed) != 1 || completed[0].State != StateComplete {
+		t.Errorf("completed = %+v, want one complete dance", completed)
+	}
+}
+
+func TestPool_PardonsResponsiveSession(t *testing.T) {
+	sessions := newFakeSessions("gt-gastown-Shadow")
+	sessions.respond["gt-gastown-Shadow"] = true
+	pool, reg, results := newTestPool(t, sessions, 2)
+	defer pool.Stop()
+
+	if err := reg.File(&Warrant{Target: "gt-gastown-Shadow", Reason: "stuck"}); err != nil {
+		t.Fatal(err)
+	}
+	pool.Dispatch()
+
+	r := waitResult(t, results)
+	if r.Outcome != OutcomePardoned {
+		t.Errorf("Outcome = %s, want pardoned", r.Outcome)
+	}
+	if len(sessions.killed) != 0 {
+		t.Errorf("pardoned session was killed: %v", sessions.killed)
+	}
+}
+
+func TestPool_QueuesWhenExhausted(t *testing.T) {
+	sessions := newFakeSessions("a", "b", "c")
+	pool, reg, results := newTestPool(t, sessions, 2)
+	pool.Timeouts = []time.Duration{time.Hour}
+	defer pool.Stop()
+
+	for _, target := range []string{"a", "b", "c"} {
+		if err := reg.File(&Warrant{Target: target, Reason: "stuck"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := pool.Dispatch(); n != 2 {
+		t.Fatalf("Dispatch started %d dances, want 2", n)
+	}
+	pending, _ := reg.Pending()
+	if len(pending) != 1 {
+		t.Errorf("pending warrants = %d, want 1", len(pending))
+	}
+	select {
+	case r := <-results:
+		t.Fatalf("unexpected result %+v", r)
+	default:
+	}
+}
+
+func TestPool_ResumesAfterRestart(t *testing.T) {
+	sessions := newFakeSessions("gt-gastown-Toast")
+	pool, reg, _ := newTestPool(t, sessions, 2)
+	pool.Timeouts = []time.Duration{time.Hour}
+
+	if err := reg.File(&Warrant{Target: "gt-gastown-Toast", Reason: "stuck"}); err != nil {
+		t.Fatal(err)
+	}
+	pool.Dispatch()
+
+	// Wait for the health check, then simulate a daemon shutdown mid-dance.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sessions.mu.Lock()
+		n := sessions.nudges["gt-gastown-Toast"]
+		sessions.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("health check never sent")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	pool.Stop()
+
+	active, _ := reg.Active()
+	if len(active) != 1 || active[0].State != StateInterrogating {
+		t.Fatalf("active after stop = %+v, want one interrogating dance", active)
+	}
+
+	// A new pool picks it up and honors the outstanding deadline.
+	sessions.respond["gt-gastown-Toast"] = true
+	sessions.panes["gt-gastown-Toast"] += responseKeyword + "-" + active[0].Token + "\n"
+	active[0].NextTimeout = time.Now()
+	if err := reg.Save(active[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	pool2 := NewPool(reg, sessions, 2)
+	pool2.LogFeed = (&feedRecorder{}).log
+	results := make(chan Result, 1)
+	pool2.OnResult = func(r Result) { results <- r }
+	defer pool2.Stop()
+	if n, err := pool2.RecoverOrphans(); err != nil || n != 1 {
+		t.Fatalf("RecoverOrphans = %d, %v; want 1", n, err)
+	}
+
+	r := waitResult(t, results)
+	if r.Outcome != OutcomePardoned {
+		t.Errorf("Outcome = %s, want pardoned", r.Outcome)
+	}
+	if sessions.nudges["gt-gastown-Toast"] != 1 {
+		t.Errorf("resumed dance re-sent health check (%d nudges)", sessions.nudges["gt-gastown-Toast"])
+	}
+}
+
+func TestRespondedToHealthCheck(t *testing.T) {
+	d := &Dance{Warrant: &Warrant{Target: "gt-gastown-Toast", Reason: "stuck"}, Attempt: 1, Token: "K7Q2M"}
+	check := healthCheckMessage(d, time.Minute)
+	if strings.Contains(check, "ALIVE-K7Q2M") {
+		t.Fatalf("health check contains its own response: %q", check)
+	}
+	// The pane wraps the check into 40-column lines
+	var wrapped string
+	for rest := check; rest != ""; {
+		n := min(40, len(rest))
+		wrapped += rest[:n] + "\n"
+		rest = rest[n:]
+	}
+	wrapped = strings.TrimSuffix(wrapped, "\n")
+
+	tests := []struct {
+		name   string
+		output string
+		want   bool
+	}{
+		{"no output", "", false},
+		{"check only", check + "\n", false},
+		{"check wrapped", wrapped + "\n", false},
+		{"bare keyword", check + "\n> ALIVE\n", false},
+		{"response after check", check + "\n> ALIVE-K7Q2M\n", true},
+		{"response after wrapped check", wrapped + "\n> ALIVE-K7Q2M\n", true},
+		{"response to another dance", check + "\n> ALIVE-ABCDE\n", false},
+		{"response before latest check", "ALIVE-K7Q2M\n" + check + "\n", false},
+		{"check scrolled off", "running tests...\nALIVE-K7Q2M\n", false},
+		{"stuck prompt", "> half-typed command", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := respondedToHealthCheck(tt.output, "K7Q2M"); got != tt.want {
+				t.Errorf("respondedToHealthCheck(%q) = %v, want %v", tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_RejectsDuplicateTarget(t *testing.T) {
+	reg := NewRegistry(t.TempDir())
+	if err := reg.File(&Warrant{Target: "x", Reason: "stuck"}); err != nil {
+		t.Fatal(err)
+	}
+	err := reg.File(&Warrant{Target: "x", Reason: "again"})
+	if !errors.Is(err, ErrDuplicateWarrant) {
+		t.Errorf("File duplicate = %v, want ErrDuplicateWarrant", err)
+	}
+}
+
+func TestPoolSizeFromEnv(t *testing.T) {
+	t.Setenv(PoolSizeEnv, "")
+	if got := PoolSizeFromEnv(); got != DefaultPoolSize {
+		t.Errorf("unset = %d, want %d", got, DefaultPoolSize)
+	}
+	t.Setenv(PoolSizeEnv, "8")
+	if got := PoolSizeFromEnv(); got != 8 {
+		t.Errorf("8 = %d", got)
+	}
+	t.Setenv(PoolSizeEnv, "100")
+	if got := PoolSizeFromEnv(); got != MaxPoolSize {
+		t.Errorf("100 = %d, want %d", got, MaxPoolSize)
+	}
+}
@@ -0,0 +1,214 @@
+package shutdown
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Registry errors.
+var (
+	ErrDuplicateWarrant = errors.New("warrant already pending or in progress for target")
+	ErrWarrantNotFound  = errors.New("warrant not found")
+)
+
+// Registry stores warrants and dance records on disk:
+//
+//	deacon/dogs/warrants/<warrant-id>.json   pending warrants
+//	deacon/dogs/active/<dog-id>.json         dances in progress
+//	deacon/dogs/completed/<dog-id>.json      finished dances (audit)
+//
+// Files are the source of truth so the CLI can read pool status without
+// talking to the daemon, and so dances survive daemon restarts.
+type Registry struct {
+	root string
+}
+
+// NewRegistry creates a registry for the given town.
+func NewRegistry(townRoot string) *Registry {
+	return &Registry{root: filepath.Join(townRoot, "deacon", "dogs")}
+}
+
+func (r *Registry) warrantsDir() string  { return filepath.Join(r.root, "warrants") }
+func (r *Registry) activeDir() string    { return filepath.Join(r.root, "active") }
+func (r *Registry) completedDir() string { return filepath.Join(r.root, "completed") }
+
+// File records a new pending warrant. ID and FiledAt are filled in if empty.
+// Returns ErrDuplicateWarrant if the target already has a pending or active warrant.
+func (r *Registry) File(w *Warrant) error {
+	if w.Target == "" {
+		return fmt.Errorf("warrant target is required")
+	}
+
+	pending, err := r.Pending()
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		if p.Target == w.Target {
+			return fmt.Errorf("%w: %s (%s)", ErrDuplicateWarrant, w.Target, p.ID)
+		}
+	}
+	active, err := r.Active()
+	if err != nil {
+		return err
+	}
+	for _, d := range active {
+		if d.Warrant != nil && d.Warrant.Target == w.Target {
+			return fmt.Errorf("%w: %s (%s)", ErrDuplicateWarrant, w.Target, d.ID)
+		}
+	}
+
+	if w.FiledAt.IsZero() {
+		w.FiledAt = time.Now()
+	}
+	if w.ID == "" {
+		w.ID = fmt.Sprintf("warrant-%d", w.FiledAt.UnixNano())
+	}
+
+	if err := os.MkdirAll(r.warrantsDir(), 0755); err != nil {
+		return fmt.Errorf("creating warrants directory: %w", err)
+	}
+	return util.AtomicWriteJSON(filepath.Join(r.warrantsDir(), w.ID+".json"), w)
+}
+
+// Pending returns pending warrants, oldest first.
+func (r *Registry) Pending() ([]*Warrant, error) {
+	var warrants []*Warrant
+	err := readJSONDir(r.warrantsDir(), func(data []byte) error {
+		var w Warrant
+		if err := json.Unmarshal(data, &w); err != nil {
+			return err
+		}
+		warrants = append(warrants, &w)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(warrants, func(i, j int) bool {
+		return warrants[i].FiledAt.Before(warrants[j].FiledAt)
+	})
+	return warrants, nil
+}
+
+// Cancel removes a pending warrant before a dog picks it up.
+func (r *Registry) Cancel(warrantID string) error {
+	err := os.Remove(filepath.Join(r.warrantsDir(), warrantID+".json"))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrWarrantNotFound, warrantID)
+	}
+	return err
+}
+
+// Claim starts a dance for a pending warrant: the dance record is written to
+// active/ before the warrant file is removed, so a crash in between leaves a
+// recoverable dance rather than a lost warrant.
+func (r *Registry) Claim(w *Warrant, dogID string, now time.Time) (*Dance, error) {
+	d := &Dance{
+		ID:        dogID,
+		Warrant:   w,
+		State:     StateInterrogating,
+		Attempt:   1,
+		StartedAt: now,
+	}
+	if err := r.Save(d); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(filepath.Join(r.warrantsDir(), w.ID+".json")); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("removing claimed warrant: %w", err)
+	}
+	return d, nil
+}
+
+// Save persists an active dance.
+func (r *Registry) Save(d *Dance) error {
+	if err := os.MkdirAll(r.activeDir(), 0755); err != nil {
+		return fmt.Errorf("creating active directory: %w", err)
+	}
+	return util.AtomicWriteJSON(filepath.Join(r.activeDir(), d.ID+".json"), d)
+}
+
+// Active returns dances in progress, oldest first.
+func (r *Registry) Active() ([]*Dance, error) {
+	return r.readDances(r.activeDir())
+}
+
+// Finish moves a dance from active/ to completed/.
+func (r *Registry) Finish(d *Dance) error {
+	if err := os.MkdirAll(r.completedDir(), 0755); err != nil {
+		return fmt.Errorf("creating completed directory: %w", err)
+	}
+	if err := util.AtomicWriteJSON(filepath.Join(r.completedDir(), d.ID+".json"), d); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(r.activeDir(), d.ID+".json")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing active dance: %w", err)
+	}
+	return nil
+}
+
+// Completed returns up to limit finished dances, most recent first.
+// A limit <= 0 returns all of them.
+func (r *Registry) Completed(limit int) ([]*Dance, error) {
+	dances, err := r.readDances(r.completedDir())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(dances, func(i, j int) bool {
+		return dances[i].CompletedAt.After(dances[j].CompletedAt)
+	})
+	if limit > 0 && len(dances) > limit {
+		dances = dances[:limit]
+	}
+	return dances, nil
+}
+
+func (r *Registry) readDances(dir string) ([]*Dance, error) {
+	var dances []*Dance
+	err := readJSONDir(dir, func(data []byte) error {
+		var d Dance
+		if err := json.Unmarshal(data, &d); err != nil {
+			return err
+		}
+		dances = append(dances, &d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(dances, func(i, j int) bool {
+		return dances[i].StartedAt.Before(dances[j].StartedAt)
+	})
+	return dances, nil
+}
+
+// readJSONDir calls fn with the contents of each .json file in dir.
+// A missing directory is treated as empty; unparseable files are skipped.
+func readJSONDir(dir string, fn func([]byte) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name())) //nolint:gosec // G304: path is constructed internally
+		if err != nil {
+			continue
+		}
+		_ = fn(data) // skip corrupt records rather than wedging the pool
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Package shutdown runs shutdown dances: the deterministic
+// WARRANT -> INTERROGATE -> EVALUATE -> PARDON|EXECUTE state machine used to
+// decide whether a stuck session should be killed.
+//
+// Dances run as goroutines in a fixed-size pool inside the daemon, not as
+// Claude sessions. Each dance persists its state under ~/gt/deacon/dogs/ so a
+// daemon restart resumes in-flight dances instead of forgetting them.
+// See dog-pool-architecture.md for the design.
+//
+// These "dance dogs" are distinct from the Deacon's helper dogs in internal/dog.
+package shutdown
+
+import (
+	"time"
+)
+
+// Warrant is a request to interrogate (and possibly kill) a session.
+type Warrant struct {
+	ID        string    `json:"id"`        // Unique warrant ID (e.g., "warrant-1704567890123")
+	Target    string    `json:"target"`    // Session to interrogate (e.g., "gt-gastown-Toast")
+	Reason    string    `json:"reason"`    // Why the warrant was filed
+	Requester string    `json:"requester"` // Who filed the warrant (e.g., "deacon", "boot")
+	FiledAt   time.Time `json:"filed_at"`
+}
+
+// DanceState is the state of a shutdown dance.
+type DanceState string
+
+const (
+	// StateInterrogating means a health check was sent and the dog is waiting.
+	StateInterrogating DanceState = "interrogating"
+	// StateEvaluating means the dog is checking the pane for a response.
+	StateEvaluating DanceState = "evaluating"
+	// StatePardoned means the session responded and the warrant was cancelled.
+	StatePardoned DanceState = "pardoned"
+	// StateExecuting means the dog is killing the session.
+	StateExecuting DanceState = "executing"
+	// StateComplete means the warrant was executed.
+	StateComplete DanceState = "complete"
+	// StateFailed means the dance errored out.
+	StateFailed DanceState = "failed"
+)
+
+// IsTerminal reports whether the dance has finished.
+func (s DanceState) IsTerminal() bool {
+	return s == StatePardoned || s == StateComplete || s == StateFailed
+}
+
+// Outcome is the final result of a dance.
+type Outcome string
+
+const (
+	// OutcomePardoned means the session responded in time.
+	OutcomePardoned Outcome = "pardoned"
+	// OutcomeExecuted means the session was killed.
+	OutcomeExecuted Outcome = "executed"
+	// OutcomeFailed means the dance could not complete.
+	OutcomeFailed Outcome = "failed"
+)
+
+// MaxAttempts is the number of interrogations before a warrant is executed.
+const MaxAttempts = 3
+
+// DefaultTimeouts are the per-attempt response windows (60s, 120s, 240s),
+// for a cumulative wait of 7 minutes before execution.
+var DefaultTimeouts = []time.Duration{60 * time.Second, 120 * time.Second, 240 * time.Second}
+
+// Dance is the persistent record of one shutdown dance.
+// Active dances live in deacon/dogs/active/<id>.json; finished ones are
+// moved to deacon/dogs/completed/ for audit.
+type Dance struct {
+	ID            string     `json:"id"` // Dog ID running the dance (e.g., "dog-1704567890123")
+	Warrant       *Warrant   `json:"warrant"`
+	State         DanceState `json:"state"`
+	Attempt       int        `json:"attempt"`
+	StartedAt     time.Time  `json:"started_at"`
+	LastMessageAt time.Time  `json:"last_message_at,omitempty"`
+	NextTimeout   time.Time  `json:"next_timeout,omitempty"`
+	Token         string     `json:"token,omitempty"` // One-time code the target answers with
+	Outcome       Outcome    `json:"outcome,omitempty"`
+	Details       string     `json:"details,omitempty"`
+	CompletedAt   time.Time  `json:"completed_at,omitempty"`
+}
+
+// Result summarizes a finished dance.
+type Result struct {
+	DogID    string
+	Warrant  *Warrant
+	Outcome  Outcome
+	Attempts int
+	Duration time.Duration
+	Details  string
+}
@@ -5,41 +5,52 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/logrotate"
 )
 
 // EventType represents the type of agent lifecycle event.
+// Values are event types from the events registry, so the town log and
+// .events.jsonl share one taxonomy.
 type EventType string
 
 const (
 	// EventSpawn indicates a new agent was created.
-	EventSpawn EventType = "spawn"
+	EventSpawn EventType = events.TypeSpawn
 	// EventWake indicates an agent was resumed.
-	EventWake EventType = "wake"
+	EventWake EventType = events.TypeWake
 	// EventNudge indicates a message was injected into an agent.
-	EventNudge EventType = "nudge"
+	EventNudge EventType = events.TypeNudge
 	// EventHandoff indicates an agent handed off to a fresh session.
-	EventHandoff EventType = "handoff"
+	EventHandoff EventType = events.TypeHandoff
 	// EventDone indicates an agent finished its work.
-	EventDone EventType = "done"
+	EventDone EventType = events.TypeDone
 	// EventCrash indicates an agent exited unexpectedly.
-	EventCrash EventType = "crash"
+	EventCrash EventType = events.TypeCrash
 	// EventKill indicates an agent was killed intentionally.
-	EventKill EventType = "kill"
+	EventKill EventType = events.TypeKill
 	// EventCallback indicates a callback was processed during patrol.
-	EventCallback EventType = "callback"
+	EventCallback EventType = events.TypeCallback
 
 	// Witness patrol events
-	EventPatrolStarted  EventType = "patrol_started"
-	EventPolecatChecked EventType = "polecat_checked"
-	EventPolecatNudged  EventType = "polecat_nudged"
-	EventEscalationSent EventType = "escalation_sent"
-	EventPatrolComplete EventType = "patrol_complete"
+	EventPatrolStarted  EventType = events.TypePatrolStarted
+	EventPolecatChecked EventType = events.TypePolecatChecked
+	EventPolecatNudged  EventType = events.TypePolecatNudged
+	EventEscalationSent EventType = events.TypeEscalationSent
+	EventPatrolComplete EventType = events.TypePatrolComplete
 
 	// Session death events (for crash investigation)
-	EventSessionDeath EventType = "session_death" // Session terminated (with reason)
-	EventMassDeath    EventType = "mass_death"    // Multiple sessions died in short window
+	EventSessionDeath EventType = events.TypeSessionDeath // Session terminated (with reason)
+	EventMassDeath    EventType = events.TypeMassDeath    // Multiple sessions died in short window
+
+	// Daemon events
+	EventConfigReload EventType = events.TypeConfigReload // Daemon applied a live configuration change
+	EventCrashLoop    EventType = events.TypeCrashLoop    // Daemon quarantined a session after repeated deaths
+	EventUnquarantine EventType = events.TypeUnquarantine  // Quarantined session released for restarts
 )
 
 // Event represents a single agent lifecycle event.
@@ -66,6 +77,12 @@ func logPath(townRoot string) string {
 	return filepath.Join(logDir(townRoot), "town.log")
 }
 
+// Path returns the path to the active town log file.
+// Older entries live in rotated segments alongside it (see logrotate).
+func Path(townRoot string) string {
+	return logPath(townRoot)
+}
+
 // NewLogger creates a new Logger for the given town root.
 func NewLogger(townRoot string) *Logger {
 	return &Logger{
@@ -83,16 +100,9 @@ func (l *Logger) LogEvent(event Event) error {
 		return fmt.Errorf("creating log directory: %w", err)
 	}
 
-	// Open file for appending
-	f, err := os.OpenFile(l.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return fmt.Errorf("opening log file: %w", err)
-	}
-	defer f.Close()
-
-	// Write human-readable log line
+	// Write human-readable log line (under the rotation lock)
 	line := formatLogLine(event)
-	if _, err := f.WriteString(line + "\n"); err != nil {
+	if err := logrotate.Append(l.logPath, []byte(line+"\n"), 0600); err != nil {
 		return fmt.Errorf("writing log line: %w", err)
 	}
 
@@ -204,6 +214,20 @@ func formatLogLine(e Event) string {
 		} else {
 			detail = "MASS SESSION DEATH"
 		}
+	case EventConfigReload:
+		if e.Context != "" {
+			detail = fmt.Sprintf("reloaded config (%s)", e.Context)
+		} else {
+			detail = "reloaded config"
+		}
+	case EventCrashLoop:
+		if e.Context != "" {
+			detail = fmt.Sprintf("quarantined after crash loop (%s)", e.Context)
+		} else {
+			detail = "quarantined after crash loop"
+		}
+	case EventUnquarantine:
+		detail = "released from quarantine"
 	default:
 		detail = string(e.Type)
 		if e.Context != "" {
@@ -222,20 +246,45 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
-// ReadEvents reads all events from the log file.
+// ReadEvents reads all events from the log, including rotated segments.
 // Useful for filtering and analysis.
 func ReadEvents(townRoot string) ([]Event, error) {
-	path := logPath(townRoot)
+	return ReadEventsSince(townRoot, time.Time{})
+}
 
-	content, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed from trusted townRoot
+// ReadEventsSince reads events from the log, skipping rotated segments that
+// hold only events older than since. Events in the remaining files are all
+// returned; apply a Filter for an exact cutoff. A zero since reads everything.
+func ReadEventsSince(townRoot string, since time.Time) ([]Event, error) {
+	r, err := logrotate.Open(logPath(townRoot), since)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil // No log file yet
+		return nil, fmt.Errorf("reading log file: %w", err)
+	}
+	defer r.Close()
+
+	var events []Event
+	scanner := logrotate.NewScanner(r)
+	for scanner.Scan() {
+		event, err := parseLogLine(scanner.Text())
+		if err != nil {
+			continue // Skip malformed lines
 		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("reading log file: %w", err)
 	}
+	return events, nil
+}
 
-	return ParseLogLines(string(content))
+// LineTime returns the timestamp of a town log line.
+// Used by log rotation to measure the age of the active file.
+func LineTime(line []byte) (time.Time, bool) {
+	if len(line) < 19 {
+		return time.Time{}, false
+	}
+	ts, err := time.ParseInLocation("2006-01-02 15:04:05", string(line[:19]), time.Local)
+	return ts, err == nil
 }
 
 // ParseLogLines parses log lines back into Events.
@@ -332,9 +381,14 @@ func splitLines(s string) []string {
 	return lines
 }
 
-// TailEvents returns the last n events from the log.
+// TailEvents returns the last n events from the log, reading rotated
+// segments only as far back as needed.
 func TailEvents(townRoot string, n int) ([]Event, error) {
-	events, err := ReadEvents(townRoot)
+	lines, err := logrotate.TailLines(logPath(townRoot), n)
+	if err != nil {
+		return nil, fmt.Errorf("reading log file: %w", err)
+	}
+	events, err := ParseLogLines(strings.Join(lines, "\n"))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,106 @@
+package trace
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// OTLP/JSON encoding of traces (opentelemetry-proto, ExportTraceServiceRequest),
+// for loading into any OTLP-compatible backend without a live collector.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"` // 1 = OK, 2 = ERROR
+	Message string `json:"message,omitempty"`
+}
+
+const otlpSpanKindInternal = 1
+
+func attr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: value}}
+}
+
+// OTLP converts spans into an OTLP/JSON export request.
+func OTLP(spans []*Span) interface{} {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		o := otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentID,
+			Name:              s.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Status:            otlpStatus{Code: 1},
+		}
+		if s.Status == StatusError {
+			o.Status = otlpStatus{Code: 2, Message: s.Detail}
+		}
+		for _, a := range []otlpAttribute{attr("gt.bead", s.Bead), attr("gt.actor", s.Actor), attr("gt.detail", s.Detail)} {
+			if a.Value.StringValue != "" {
+				o.Attributes = append(o.Attributes, a)
+			}
+		}
+		out = append(out, o)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttribute{attr("service.name", "gastown")}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "github.com/steveyegge/gastown/internal/trace"}, Spans: out}},
+	}}}
+}
+
+// WriteOTLP writes spans to path as an OTLP/JSON file ("-" for stdout).
+func WriteOTLP(path string, spans []*Span) error {
+	if path == "-" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(OTLP(spans))
+	}
+	return util.AtomicWriteJSON(path, OTLP(spans))
+}
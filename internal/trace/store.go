@@ -0,0 +1,236 @@
+package trace
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/logrotate"
+)
+
+// ResumeWindow bounds how far back Resume looks for a bead's latest span.
+const ResumeWindow = 7 * 24 * time.Hour
+
+// Load reads the spans recorded in the town's events log since the given
+// time (zero for all), across rotated segments, in log order.
+func Load(townRoot string, since time.Time) ([]*Span, error) {
+	r, err := logrotate.Open(filepath.Join(townRoot, events.EventsFile), since)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readSpans(r, since)
+}
+
+// readSpans decodes the spans in an events log stream ending after since.
+func readSpans(r io.Reader, since time.Time) ([]*Span, error) {
+	var spans []*Span
+	scanner := logrotate.NewScanner(r)
+	for scanner.Scan() {
+		e, err := events.Decode(scanner.Bytes())
+		if err != nil || e.Type != events.TypeSpan {
+			continue
+		}
+		if s, ok := spanFromEvent(e); ok && (since.IsZero() || !s.End.Before(since)) {
+			spans = append(spans, s)
+		}
+	}
+	return spans, scanner.Err()
+}
+
+func spanFromEvent(e events.Event) (*Span, bool) {
+	var d events.SpanData
+	if err := e.DecodePayload(&d); err != nil || d.TraceID == "" || d.SpanID == "" {
+		return nil, false
+	}
+	start, err := time.Parse(time.RFC3339Nano, d.Start)
+	if err != nil {
+		return nil, false
+	}
+	end, err := time.Parse(time.RFC3339Nano, d.End)
+	if err != nil {
+		end = start
+	}
+	return &Span{
+		TraceID:  d.TraceID,
+		SpanID:   d.SpanID,
+		ParentID: d.ParentSpanID,
+		Name:     d.Name,
+		Bead:     d.Bead,
+		Actor:    e.Actor,
+		Detail:   d.Detail,
+		Start:    start,
+		End:      end,
+		Status:   d.Status,
+	}, true
+}
+
+// Resume returns the context to continue a trace from: the latest span
+// recorded for any of the given beads within ResumeWindow, otherwise
+// GT_TRACEPARENT if set. The bead's own trace comes first because a
+// long-lived session keeps GT_TRACEPARENT from whatever was slung to it
+// earlier. Returns an invalid context (start a new trace) if neither is found.
+func Resume(townRoot string, beads ...string) SpanContext {
+	if townRoot != "" && len(beads) > 0 {
+		if c := latestRecorded(townRoot, time.Now().Add(-ResumeWindow), beads...); c.IsValid() {
+			return c
+		}
+	}
+	c, _ := FromEnv()
+	return c
+}
+
+// latestRecorded returns the latest span recorded for the beads since the
+// given time. The log's files are read newest first, stopping at the first
+// that has one, so the rotated history is only decompressed as far as needed.
+func latestRecorded(townRoot string, since time.Time, beads ...string) SpanContext {
+	files, err := logrotate.Files(filepath.Join(townRoot, events.EventsFile), since)
+	if err != nil {
+		return SpanContext{}
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		data, err := logrotate.ReadFile(files[i])
+		if err != nil {
+			continue
+		}
+		spans, _ := readSpans(bytes.NewReader(data), since)
+		if c := latestFor(spans, beads...); c.IsValid() {
+			return c
+		}
+	}
+	return SpanContext{}
+}
+
+func latestFor(spans []*Span, beads ...string) SpanContext {
+	want := make(map[string]bool, len(beads))
+	for _, b := range beads {
+		if b != "" {
+			want[b] = true
+		}
+	}
+	var latest *Span
+	for _, s := range spans {
+		if want[s.Bead] && (latest == nil || !s.End.Before(latest.End)) {
+			latest = s
+		}
+	}
+	if latest == nil {
+		return SpanContext{}
+	}
+	return latest.Context()
+}
+
+// Trace is the set of spans sharing a trace ID, ordered by start time.
+type Trace struct {
+	ID    string  `json:"trace_id"`
+	Spans []*Span `json:"spans"`
+}
+
+// Group splits spans into traces, oldest trace first.
+func Group(spans []*Span) []*Trace {
+	byID := map[string]*Trace{}
+	var traces []*Trace
+	for _, s := range spans {
+		t, ok := byID[s.TraceID]
+		if !ok {
+			t = &Trace{ID: s.TraceID}
+			byID[s.TraceID] = t
+			traces = append(traces, t)
+		}
+		t.Spans = append(t.Spans, s)
+	}
+	for _, t := range traces {
+		sort.SliceStable(t.Spans, func(i, j int) bool { return t.Spans[i].Start.Before(t.Spans[j].Start) })
+	}
+	sort.SliceStable(traces, func(i, j int) bool { return traces[i].Start().Before(traces[j].Start()) })
+	return traces
+}
+
+// ForBead returns the traces containing a span about the bead, oldest first.
+func ForBead(spans []*Span, bead string) []*Trace {
+	var matched []*Trace
+	for _, t := range Group(spans) {
+		for _, s := range t.Spans {
+			if s.Bead == bead {
+				matched = append(matched, t)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// Start returns when the first span started.
+func (t *Trace) Start() time.Time {
+	if len(t.Spans) == 0 {
+		return time.Time{}
+	}
+	return t.Spans[0].Start
+}
+
+// End returns when the last span ended.
+func (t *Trace) End() time.Time {
+	var end time.Time
+	for _, s := range t.Spans {
+		if s.End.After(end) {
+			end = s.End
+		}
+	}
+	return end
+}
+
+// Step is a span on the critical path and the idle time before it started.
+type Step struct {
+	Span *Span
+	Wait time.Duration
+}
+
+// CriticalPath returns the chain of spans that determined the trace's
+// end-to-end time, oldest first. Starting from the span that ended last, each
+// step's predecessor is the span (other than its own children) that finished
+// most recently before it started; spans nested inside their parent fall back to the parent. Wait is
+// the gap between a predecessor ending and the step starting.
+func (t *Trace) CriticalPath() []Step {
+	if len(t.Spans) == 0 {
+		return nil
+	}
+	byID := make(map[string]*Span, len(t.Spans))
+	last := t.Spans[0]
+	for _, s := range t.Spans {
+		byID[s.SpanID] = s
+		if !s.End.Before(last.End) {
+			last = s
+		}
+	}
+
+	var path []Step
+	seen := map[*Span]bool{}
+	for cur := last; cur != nil && !seen[cur]; {
+		seen[cur] = true
+		var pred *Span
+		for _, s := range t.Spans {
+			if seen[s] || s.ParentID == cur.SpanID || s.End.After(cur.Start) {
+				continue
+			}
+			if pred == nil || s.End.After(pred.End) {
+				pred = s
+			}
+		}
+		step := Step{Span: cur}
+		if pred != nil {
+			step.Wait = cur.Start.Sub(pred.End)
+		} else if parent := byID[cur.ParentID]; parent != nil && !seen[parent] {
+			pred = parent // Nested inside its parent: no wait
+		}
+		path = append(path, step)
+		cur = pred
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
@@ -0,0 +1,177 @@
+// Package trace records OpenTelemetry-style spans for work moving through
+// Gas Town, from gt sling to the refinery merge.
+//
+// Spans are written to the events log as "span" events (audit visibility), so
+// no collector is needed; `gt trace` reads them back and can export OTLP JSON.
+// The trace context crosses process boundaries in three ways:
+//   - a lookup of the latest span recorded for a bead (Resume),
+//   - the trace_parent field of merge-request beads,
+//   - GT_TRACEPARENT in a polecat's environment (W3C traceparent format), or
+//     in the tmux session environment of an agent already running when work
+//     was slung to it, used when the bead has no recorded trace.
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// EnvTraceParent carries the parent span context into agent sessions.
+const EnvTraceParent = "GT_TRACEPARENT"
+
+// Span statuses.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID string // 32 hex chars
+	SpanID  string // 16 hex chars
+}
+
+// IsValid reports whether the context identifies a span.
+func (c SpanContext) IsValid() bool {
+	return len(c.TraceID) == 32 && len(c.SpanID) == 16
+}
+
+// TraceParent formats the context as a W3C traceparent header value.
+func (c SpanContext) TraceParent() string {
+	if !c.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", c.TraceID, c.SpanID)
+}
+
+// ParseTraceParent parses a W3C traceparent value.
+func ParseTraceParent(s string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", s)
+	}
+	c := SpanContext{TraceID: parts[1], SpanID: parts[2]}
+	if !c.IsValid() || !isHex(c.TraceID) || !isHex(c.SpanID) {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", s)
+	}
+	return c, nil
+}
+
+// FromEnv returns the span context in GT_TRACEPARENT, if any. Inside tmux, an
+// unset variable is looked up in the session environment, where gt sling
+// sets it for sessions that were already running. The variable outlives the
+// work it was set for, so callers that know their bead should use Resume.
+func FromEnv() (SpanContext, bool) {
+	v := os.Getenv(EnvTraceParent)
+	if v == "" && os.Getenv("TMUX") != "" {
+		v = sessionEnv(EnvTraceParent)
+	}
+	c, err := ParseTraceParent(v)
+	return c, err == nil
+}
+
+// sessionEnv returns a variable of the current tmux session's environment.
+func sessionEnv(key string) string {
+	out, err := exec.Command("tmux", "show-environment", key).Output()
+	if err != nil {
+		return ""
+	}
+	_, value, ok := strings.Cut(strings.TrimSpace(string(out)), "=")
+	if !ok {
+		return "" // "-KEY": removed from the session
+	}
+	return value
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Span is a unit of work in a trace. Milestones (e.g. "done") are spans whose
+// start and end coincide.
+type Span struct {
+	TraceID  string    `json:"trace_id"`
+	SpanID   string    `json:"span_id"`
+	ParentID string    `json:"parent_span_id,omitempty"`
+	Name     string    `json:"name"`
+	Bead     string    `json:"bead,omitempty"` // Bead the span is about, used to find the trace
+	Actor    string    `json:"actor,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Status   string    `json:"status,omitempty"`
+}
+
+// Start begins a span as a child of parent, or as the root of a new trace if
+// parent is not valid.
+func Start(name string, parent SpanContext) *Span {
+	s := &Span{
+		SpanID: randomHex(8),
+		Name:   name,
+		Start:  time.Now().UTC(),
+	}
+	if parent.IsValid() {
+		s.TraceID = parent.TraceID
+		s.ParentID = parent.SpanID
+	} else {
+		s.TraceID = randomHex(16)
+	}
+	return s
+}
+
+// Context returns the span's context, for propagation to child spans.
+func (s *Span) Context() SpanContext {
+	return SpanContext{TraceID: s.TraceID, SpanID: s.SpanID}
+}
+
+// Duration returns how long the span took.
+func (s *Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Finish ends the span and records it in the events log. A non-nil err marks
+// the span as failed. Recording is best-effort, like all events.
+func (s *Span) Finish(err error) {
+	s.End = time.Now().UTC()
+	s.Status = StatusOK
+	if err != nil {
+		s.Status = StatusError
+		if s.Detail == "" {
+			s.Detail = err.Error()
+		}
+	}
+	s.record()
+}
+
+// Mark records a milestone span (start == end) as a child of parent and
+// returns its context.
+func Mark(name string, parent SpanContext, bead, actor, detail string) SpanContext {
+	s := Start(name, parent)
+	s.Bead = bead
+	s.Actor = actor
+	s.Detail = detail
+	s.End = s.Start
+	s.Status = StatusOK
+	s.record()
+	return s.Context()
+}
+
+func (s *Span) record() {
+	_ = events.LogAudit(events.TypeSpan, s.Actor, events.SpanPayload(
+		s.TraceID, s.SpanID, s.ParentID, s.Name, s.Bead,
+		s.Start.Format(time.RFC3339Nano), s.End.Format(time.RFC3339Nano), s.Status, s.Detail))
+}
@@ -0,0 +1,277 @@
+package trace
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/logrotate"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	c := SpanContext{TraceID: "0123456789abcdef0123456789abcdef", SpanID: "0123456789abcdef"}
+	got, err := ParseTraceParent(c.TraceParent())
+	if err != nil {
+		t.Fatalf("ParseTraceParent(%q): %v", c.TraceParent(), err)
+	}
+	if got != c {
+		t.Errorf("round trip = %+v, want %+v", got, c)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-abc-def-01",
+		"01-0123456789abcdef0123456789abcdef-0123456789abcdef-01",
+		"00-zz23456789abcdef0123456789abcdef-0123456789abcdef-01",
+		"00-0123456789abcdef0123456789abcdef-0123456789abcdef",
+	} {
+		if _, err := ParseTraceParent(bad); err == nil {
+			t.Errorf("ParseTraceParent(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	root := Start("sling", SpanContext{})
+	if !root.Context().IsValid() || root.ParentID != "" {
+		t.Fatalf("root span = %+v, want new trace without parent", root)
+	}
+	child := Start("spawn", root.Context())
+	if child.TraceID != root.TraceID || child.ParentID != root.SpanID {
+		t.Errorf("child span = %+v, want child of %+v", child, root.Context())
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	c := Start("sling", SpanContext{}).Context()
+	t.Setenv(EnvTraceParent, c.TraceParent())
+	got, ok := FromEnv()
+	if !ok || got != c {
+		t.Errorf("FromEnv() = %+v, %v; want %+v", got, ok, c)
+	}
+
+	t.Setenv(EnvTraceParent, "garbage")
+	if _, ok := FromEnv(); ok {
+		t.Error("FromEnv() accepted an invalid traceparent")
+	}
+}
+
+func TestSpanFromEvent(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+	end := start.Add(90 * time.Second)
+	payload := events.SpanPayload("0123456789abcdef0123456789abcdef", "0123456789abcdef", "fedcba9876543210",
+		"done", "gt-abc", start.Format(time.RFC3339Nano), end.Format(time.RFC3339Nano), StatusError, "tests failed")
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := events.Event{Type: events.TypeSpan, Actor: "gastown/polecats/Toast", Payload: payload}
+	if err := events.Validate(e.Type, payload); err != nil {
+		t.Fatalf("span payload %s failed validation: %v", raw, err)
+	}
+
+	s, ok := spanFromEvent(e)
+	if !ok {
+		t.Fatal("spanFromEvent rejected a valid span")
+	}
+	if s.Name != "done" || s.Bead != "gt-abc" || s.Actor != e.Actor || s.ParentID != "fedcba9876543210" {
+		t.Errorf("span = %+v", s)
+	}
+	if !s.Start.Equal(start) || s.Duration() != 90*time.Second || s.Status != StatusError {
+		t.Errorf("span timing/status = %v %v %q", s.Start, s.Duration(), s.Status)
+	}
+
+	if _, ok := spanFromEvent(events.Event{Type: events.TypeSpan, Payload: map[string]interface{}{"name": "x"}}); ok {
+		t.Error("spanFromEvent accepted a span without IDs")
+	}
+}
+
+// pipeline builds sling → spawn → step → done → merge with gaps between them.
+func pipeline(t0 time.Time) []*Span {
+	at := func(min int) time.Time { return t0.Add(time.Duration(min) * time.Minute) }
+	sling := &Span{TraceID: "t1", SpanID: "sling", Name: "sling", Bead: "gt-abc", Start: at(0), End: at(1)}
+	spawn := &Span{TraceID: "t1", SpanID: "spawn", ParentID: "sling", Name: "spawn", Start: at(0), End: at(0)}
+	step := &Span{TraceID: "t1", SpanID: "step", ParentID: "sling", Name: "step", Bead: "gt-mol", Start: at(3), End: at(10)}
+	done := &Span{TraceID: "t1", SpanID: "done", ParentID: "step", Name: "done", Bead: "gt-abc", Start: at(10), End: at(11)}
+	merge := &Span{TraceID: "t1", SpanID: "merge", ParentID: "done", Name: "merge", Bead: "gt-mr", Start: at(31), End: at(33)}
+	return []*Span{merge, sling, step, spawn, done}
+}
+
+func TestCriticalPath(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	traces := Group(pipeline(t0))
+	if len(traces) != 1 {
+		t.Fatalf("Group returned %d traces, want 1", len(traces))
+	}
+	tr := traces[0]
+	if !tr.Start().Equal(t0) || tr.End().Sub(t0) != 33*time.Minute {
+		t.Errorf("trace bounds = %v..%v", tr.Start(), tr.End())
+	}
+
+	path := tr.CriticalPath()
+	want := []struct {
+		name string
+		wait time.Duration
+	}{
+		{"sling", 0},
+		{"step", 2 * time.Minute},
+		{"done", 0},
+		{"merge", 20 * time.Minute},
+	}
+	if len(path) != len(want) {
+		var names []string
+		for _, s := range path {
+			names = append(names, s.Span.Name)
+		}
+		t.Fatalf("critical path = %v, want %d steps", names, len(want))
+	}
+	for i, w := range want {
+		if path[i].Span.Name != w.name || path[i].Wait != w.wait {
+			t.Errorf("step %d = %s (wait %v), want %s (wait %v)", i, path[i].Span.Name, path[i].Wait, w.name, w.wait)
+		}
+	}
+}
+
+func TestCriticalPathNested(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	parent := &Span{TraceID: "t1", SpanID: "p", Name: "merge", Start: t0, End: t0.Add(10 * time.Minute)}
+	child := &Span{TraceID: "t1", SpanID: "c", ParentID: "p", Name: "merged", Start: t0.Add(5 * time.Minute), End: t0.Add(10 * time.Minute)}
+
+	path := Group([]*Span{parent, child})[0].CriticalPath()
+	if len(path) != 2 || path[0].Span != parent || path[1].Span != child || path[1].Wait != 0 {
+		t.Errorf("nested critical path = %+v", path)
+	}
+}
+
+func TestForBeadAndLatest(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	spans := pipeline(t0)
+	retry := &Span{TraceID: "t2", SpanID: "retry", Name: "sling", Bead: "gt-abc", Start: t0.Add(time.Hour), End: t0.Add(time.Hour)}
+	other := &Span{TraceID: "t3", SpanID: "other", Name: "sling", Bead: "gt-xyz", Start: t0, End: t0}
+	spans = append(spans, retry, other)
+
+	traces := ForBead(spans, "gt-abc")
+	if len(traces) != 2 || traces[0].ID != "t1" || traces[1].ID != "t2" {
+		t.Fatalf("ForBead(gt-abc) = %d traces", len(traces))
+	}
+	if got := latestFor(spans, "gt-abc"); got.SpanID != "retry" {
+		t.Errorf("latestFor(gt-abc) = %+v, want retry", got)
+	}
+	if got := latestFor(spans, "gt-mr", ""); got.SpanID != "merge" {
+		t.Errorf("latestFor(gt-mr) = %+v, want merge", got)
+	}
+	if got := latestFor(spans, "gt-none"); got.IsValid() {
+		t.Errorf("latestFor(gt-none) = %+v, want invalid", got)
+	}
+}
+
+func TestLatestRecorded(t *testing.T) {
+	townRoot := t.TempDir()
+	path := filepath.Join(townRoot, events.EventsFile)
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	record := func(spanID, bead string, at time.Time) {
+		t.Helper()
+		payload := events.SpanPayload("0123456789abcdef0123456789abcdef", spanID, "", "step", bead,
+			at.Format(time.RFC3339Nano), at.Format(time.RFC3339Nano), StatusOK, "")
+		line, err := json.Marshal(events.Event{Type: events.TypeSpan, Payload: payload})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := logrotate.Append(path, append(line, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	record("000000000000000a", "gt-old", t0)
+	record("000000000000000b", "gt-abc", t0)
+	if err := (logrotate.Log{Path: path}).ForceRotate(t0.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	record("000000000000000c", "gt-abc", t0.Add(2*time.Hour))
+
+	if got := latestRecorded(townRoot, time.Time{}, "gt-abc"); got.SpanID != "000000000000000c" {
+		t.Errorf("latestRecorded(gt-abc) = %+v, want the span in the active file", got)
+	}
+	if got := latestRecorded(townRoot, time.Time{}, "gt-old"); got.SpanID != "000000000000000a" {
+		t.Errorf("latestRecorded(gt-old) = %+v, want the span in the segment", got)
+	}
+	if got := latestRecorded(townRoot, t0.Add(90*time.Minute), "gt-old"); got.IsValid() {
+		t.Errorf("latestRecorded(gt-old) outside the window = %+v, want invalid", got)
+	}
+}
+
+func TestResume_PrefersBeadTrace(t *testing.T) {
+	townRoot := t.TempDir()
+	now := time.Now()
+	payload := events.SpanPayload("0123456789abcdef0123456789abcdef", "000000000000000a", "", "sling", "gt-abc",
+		now.Format(time.RFC3339Nano), now.Format(time.RFC3339Nano), StatusOK, "")
+	line, err := json.Marshal(events.Event{Type: events.TypeSpan, Payload: payload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := logrotate.Append(filepath.Join(townRoot, events.EventsFile), append(line, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A long-lived session still carries the trace of earlier work.
+	stale := Start("sling", SpanContext{}).Context()
+	t.Setenv(EnvTraceParent, stale.TraceParent())
+	t.Setenv("TMUX", "")
+
+	if got := Resume(townRoot, "gt-abc"); got.SpanID != "000000000000000a" {
+		t.Errorf("Resume(gt-abc) = %+v, want the bead's recorded span", got)
+	}
+	if got := Resume(townRoot, "gt-new"); got != stale {
+		t.Errorf("Resume(gt-new) = %+v, want GT_TRACEPARENT as fallback", got)
+	}
+}
+
+func TestOTLP(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	spans := []*Span{{
+		TraceID: "t1", SpanID: "s1", ParentID: "p1", Name: "merge", Bead: "gt-mr",
+		Start: t0, End: t0.Add(time.Second), Status: StatusError, Detail: "conflict",
+	}}
+	data, err := json.Marshal(OTLP(spans))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID           string `json:"traceId"`
+					ParentSpanID      string `json:"parentSpanId"`
+					StartTimeUnixNano string `json:"startTimeUnixNano"`
+					EndTimeUnixNano   string `json:"endTimeUnixNano"`
+					Attributes        []struct {
+						Key string `json:"key"`
+					} `json:"attributes"`
+					Status struct {
+						Code    int    `json:"code"`
+						Message string `json:"message"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatal(err)
+	}
+	got := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if got.TraceID != "t1" || got.ParentSpanID != "p1" {
+		t.Errorf("ids = %s/%s", got.TraceID, got.ParentSpanID)
+	}
+	if got.StartTimeUnixNano != "1767225600000000000" || got.EndTimeUnixNano != "1767225601000000000" {
+		t.Errorf("times = %s..%s", got.StartTimeUnixNano, got.EndTimeUnixNano)
+	}
+	if got.Status.Code != 2 || got.Status.Message != "conflict" {
+		t.Errorf("status = %+v, want error with message", got.Status)
+	}
+	if len(got.Attributes) != 2 {
+		t.Errorf("attributes = %+v, want gt.bead and gt.detail only", got.Attributes)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/logrotate"
 )
 
 // EventSource represents a source of events
@@ -214,9 +215,9 @@ func parseBeadContext(beadID string) (actor, rig, role string) {
 
 // GtEventsSource reads events from ~/gt/.events.jsonl (gt activity log)
 type GtEventsSource struct {
-	file   *os.File
-	events chan Event
-	cancel context.CancelFunc
+	follower *logrotate.Follower
+	events   chan Event
+	cancel   context.CancelFunc
 }
 
 // GtEvent is the structure of events in .events.jsonl
@@ -232,7 +233,10 @@ type GtEvent struct {
 // NewGtEventsSource creates a source that tails ~/gt/.events.jsonl
 func NewGtEventsSource(townRoot string) (*GtEventsSource, error) {
 	eventsPath := filepath.Join(townRoot, ".events.jsonl")
-	file, err := os.Open(eventsPath)
+	if _, err := os.Stat(eventsPath); err != nil {
+		return nil, err
+	}
+	follower, err := logrotate.Follow(eventsPath)
 	if err != nil {
 		return nil, err
 	}
@@ -240,9 +244,9 @@ func NewGtEventsSource(townRoot string) (*GtEventsSource, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	source := &GtEventsSource{
-		file:   file,
-		events: make(chan Event, 100),
-		cancel: cancel,
+		follower: follower,
+		events:   make(chan Event, 100),
+		cancel:   cancel,
 	}
 
 	go source.tail(ctx)
@@ -254,10 +258,7 @@ func NewGtEventsSource(townRoot string) (*GtEventsSource, error) {
 func (s *GtEventsSource) tail(ctx context.Context) {
 	defer close(s.events)
 
-	// Seek to end for live tailing
-	_, _ = s.file.Seek(0, 2)
-
-	scanner := bufio.NewScanner(s.file)
+	// Live tailing from the end, following the daemon's log rotation
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -266,8 +267,11 @@ func (s *GtEventsSource) tail(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			for scanner.Scan() {
-				line := scanner.Text()
+			for {
+				line, ok := s.follower.Next()
+				if !ok {
+					break
+				}
 				if event := parseGtEventLine(line); event != nil {
 					select {
 					case s.events <- *event:
@@ -287,7 +291,7 @@ func (s *GtEventsSource) Events() <-chan Event {
 // Close stops the source
 func (s *GtEventsSource) Close() error {
 	s.cancel()
-	return s.file.Close()
+	return s.follower.Close()
 }
 
 // parseGtEventLine parses a line from .events.jsonl
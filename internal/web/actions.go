@@ -0,0 +1,174 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// ActionsPrefix is the path prefix of the dashboard's write actions.
+const ActionsPrefix = APIPrefix + "actions/"
+
+// Actions performs operator actions on the town. The implementation runs the
+// same code paths as the corresponding gt commands; actor identifies the
+// operator for event attribution.
+type Actions interface {
+	// Sling spawns a polecat in rig and hooks a ready bead to it, returning
+	// the polecat's name.
+	Sling(actor, bead, rig string) (string, error)
+	// Nudge injects a message into a polecat's session.
+	Nudge(actor, rig, polecat, message string) error
+	// RetryMR requeues a failed merge request.
+	RetryMR(actor, rig, id string) error
+	// RejectMR removes a merge request from the queue and notifies its worker.
+	RejectMR(actor, rig, id, reason string) error
+	// PauseDeacon stops the Deacon's patrol actions until resumed.
+	PauseDeacon(actor, reason string) error
+	// CloseConvoy closes an open convoy.
+	CloseConvoy(actor, id, reason string) error
+}
+
+// ErrActionInvalid marks an action that was refused because of its
+// arguments or the current state (e.g. slinging a bead that isn't ready).
+var ErrActionInvalid = errors.New("invalid action")
+
+// ActionResult is the response to a successful action.
+type ActionResult struct {
+	Action  string `json:"action"`
+	Target  string `json:"target"`
+	Message string `json:"message"`
+}
+
+// ActionHandler serves the authenticated write actions:
+//
+//	POST /api/v1/actions/sling          bead, rig
+//	POST /api/v1/actions/nudge          rig, polecat, message
+//	POST /api/v1/actions/mq/retry       rig, id
+//	POST /api/v1/actions/mq/reject      rig, id, reason
+//	POST /api/v1/actions/deacon/pause   reason (optional)
+//	POST /api/v1/actions/convoys/close  id, reason (optional)
+//
+// Parameters are read from a JSON object or a form body. Every attempt,
+// successful or not, is recorded in the audit log as a dashboard_action
+// event attributed to the operator.
+type ActionHandler struct {
+	actions  Actions
+	actor    string
+	snapshot *Snapshot // Invalidated after a successful action; may be nil
+	audit    func(eventType, actor string, payload map[string]interface{}) error
+	mux      *http.ServeMux
+}
+
+// NewActionHandler creates the action endpoints. actor is recorded as the
+// operator taking every action.
+func NewActionHandler(actions Actions, actor string, snapshot *Snapshot) *ActionHandler {
+	h := &ActionHandler{
+		actions:  actions,
+		actor:    actor,
+		snapshot: snapshot,
+		audit:    events.LogAudit,
+		mux:      http.NewServeMux(),
+	}
+	h.handle("sling", "sling", []string{"bead", "rig"}, func(p map[string]string) (string, string, string, error) {
+		polecat, err := h.actions.Sling(h.actor, p["bead"], p["rig"])
+		return p["bead"], p["rig"], fmt.Sprintf("Slung %s to %s/%s", p["bead"], p["rig"], polecat), err
+	})
+	h.handle("nudge", "nudge", []string{"rig", "polecat", "message"}, func(p map[string]string) (string, string, string, error) {
+		target := p["rig"] + "/" + p["polecat"]
+		return target, p["message"], "Nudged " + target, h.actions.Nudge(h.actor, p["rig"], p["polecat"], p["message"])
+	})
+	h.handle("mq/retry", "mq_retry", []string{"rig", "id"}, func(p map[string]string) (string, string, string, error) {
+		return p["id"], p["rig"], "Queued " + p["id"] + " for retry", h.actions.RetryMR(h.actor, p["rig"], p["id"])
+	})
+	h.handle("mq/reject", "mq_reject", []string{"rig", "id", "reason"}, func(p map[string]string) (string, string, string, error) {
+		return p["id"], p["reason"], "Rejected " + p["id"], h.actions.RejectMR(h.actor, p["rig"], p["id"], p["reason"])
+	})
+	h.handle("deacon/pause", "deacon_pause", nil, func(p map[string]string) (string, string, string, error) {
+		return "deacon", p["reason"], "Deacon paused", h.actions.PauseDeacon(h.actor, p["reason"])
+	})
+	h.handle("convoys/close", "convoy_close", []string{"id"}, func(p map[string]string) (string, string, string, error) {
+		return p["id"], p["reason"], "Closed convoy " + p["id"], h.actions.CloseConvoy(h.actor, p["id"], p["reason"])
+	})
+	h.mux.HandleFunc(ActionsPrefix, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeError(w, http.StatusNotFound, "unknown action")
+	})
+	return h
+}
+
+// ServeHTTP routes action requests.
+func (h *ActionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// handle registers an action. run returns the audit target and detail, the
+// success message and the action's error.
+func (h *ActionHandler) handle(path, action string, required []string, run func(map[string]string) (target, detail, message string, err error)) {
+	h.mux.HandleFunc("POST "+ActionsPrefix+path, func(w http.ResponseWriter, r *http.Request) {
+		params, err := actionParams(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for _, name := range required {
+			if params[name] == "" {
+				writeError(w, http.StatusBadRequest, "missing "+name)
+				return
+			}
+		}
+
+		target, detail, message, err := run(params)
+		_ = h.audit(events.TypeDashboardAction, h.actor, events.DashboardActionPayload(action, target, detail, r.RemoteAddr, err))
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrActionInvalid) {
+				status = http.StatusConflict
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+		if h.snapshot != nil {
+			h.snapshot.Invalidate(action)
+		}
+		writeJSON(w, http.StatusOK, ActionResult{Action: action, Target: target, Message: message})
+	})
+}
+
+// maxActionBody bounds the size of an action request body.
+const maxActionBody = 64 << 10
+
+// actionParams reads string parameters from a JSON object or form body.
+func actionParams(r *http.Request) (map[string]string, error) {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxActionBody)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	params := map[string]string{}
+	if mediaType == "application/json" {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("invalid JSON body: %w", err)
+		}
+		for k, v := range body {
+			s, ok := v.(string)
+			if !ok {
+				return nil, &paramError{k, fmt.Sprint(v)}
+			}
+			params[k] = strings.TrimSpace(s)
+		}
+		return params, nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("invalid form body: %w", err)
+	}
+	for k := range r.PostForm {
+		params[k] = strings.TrimSpace(r.PostForm.Get(k))
+	}
+	return params, nil
+}
@@ -0,0 +1,303 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// fakeActions records calls and fails with err when set.
+type fakeActions struct {
+	calls []string
+	err   error
+}
+
+func (f *fakeActions) record(format string, args ...interface{}) error {
+	f.calls = append(f.calls, fmt.Sprintf(format, args...))
+	return f.err
+}
+
+func (f *fakeActions) Sling(actor, bead, rig string) (string, error) {
+	return "nux", f.record("sling %s %s %s", actor, bead, rig)
+}
+
+func (f *fakeActions) Nudge(actor, rig, polecat, message string) error {
+	return f.record("nudge %s %s/%s %s", actor, rig, polecat, message)
+}
+
+func (f *fakeActions) RetryMR(actor, rig, id string) error {
+	return f.record("retry %s %s %s", actor, rig, id)
+}
+
+func (f *fakeActions) RejectMR(actor, rig, id, reason string) error {
+	return f.record("reject %s %s %s %s", actor, rig, id, reason)
+}
+
+func (f *fakeActions) PauseDeacon(actor, reason string) error {
+	return f.record("pause %s %s", actor, reason)
+}
+
+func (f *fakeActions) CloseConvoy(actor, id, reason string) error {
+	return f.record("close %s %s %s", actor, id, reason)
+}
+
+type auditEntry struct {
+	eventType, actor string
+	payload          map[string]interface{}
+}
+
+func newTestActionHandler(actions Actions) (*ActionHandler, *[]auditEntry) {
+	h := NewActionHandler(actions, "overseer/steve", nil)
+	var audit []auditEntry
+	h.audit = func(eventType, actor string, payload map[string]interface{}) error {
+		audit = append(audit, auditEntry{eventType, actor, payload})
+		return events.Validate(eventType, payload)
+	}
+	return h, &audit
+}
+
+func postJSON(t *testing.T, h http.Handler, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestActionHandler_Actions(t *testing.T) {
+	tests := []struct {
+		path string
+		body string
+		call string
+	}{
+		{"sling", `{"bead":"gt-abc","rig":"gastown"}`, "sling overseer/steve gt-abc gastown"},
+		{"nudge", `{"rig":"gastown","polecat":"nux","message":"status?"}`, "nudge overseer/steve gastown/nux status?"},
+		{"mq/retry", `{"rig":"gastown","id":"mr-1"}`, "retry overseer/steve gastown mr-1"},
+		{"mq/reject", `{"rig":"gastown","id":"mr-1","reason":"breaks build"}`, "reject overseer/steve gastown mr-1 breaks build"},
+		{"deacon/pause", `{"reason":"maintenance"}`, "pause overseer/steve maintenance"},
+		{"convoys/close", `{"id":"hq-cv-1"}`, "close overseer/steve hq-cv-1 "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			actions := &fakeActions{}
+			h, audit := newTestActionHandler(actions)
+
+			w := postJSON(t, h, ActionsPrefix+tt.path, tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+			}
+			if len(actions.calls) != 1 || actions.calls[0] != tt.call {
+				t.Errorf("calls = %q, want %q", actions.calls, tt.call)
+			}
+			if len(*audit) != 1 {
+				t.Fatalf("audit entries = %d, want 1", len(*audit))
+			}
+			a := (*audit)[0]
+			if a.eventType != events.TypeDashboardAction || a.actor != "overseer/steve" || a.payload["result"] != "ok" {
+				t.Errorf("audit = %+v", a)
+			}
+		})
+	}
+}
+
+func TestActionHandler_FormBody(t *testing.T) {
+	actions := &fakeActions{}
+	h, _ := newTestActionHandler(actions)
+
+	form := url.Values{"bead": {"gt-abc"}, "rig": {" gastown "}}
+	req := httptest.NewRequest(http.MethodPost, ActionsPrefix+"sling", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var result ActionResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Message != "Slung gt-abc to gastown/nux" {
+		t.Errorf("message = %q", result.Message)
+	}
+}
+
+func TestActionHandler_Errors(t *testing.T) {
+	actions := &fakeActions{}
+	h, audit := newTestActionHandler(actions)
+
+	if w := postJSON(t, h, ActionsPrefix+"sling", `{"bead":"gt-abc"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("missing rig: status = %d, want 400", w.Code)
+	}
+	if w := postJSON(t, h, ActionsPrefix+"sling", `{"bead":1}`); w.Code != http.StatusBadRequest {
+		t.Errorf("non-string param: status = %d, want 400", w.Code)
+	}
+	if w := postJSON(t, h, ActionsPrefix+"explode", `{}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown action: status = %d, want 404", w.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, ActionsPrefix+"sling", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want 405", w.Code)
+	}
+	if len(actions.calls) != 0 || len(*audit) != 0 {
+		t.Errorf("rejected requests reached actions: calls %q, audit %d", actions.calls, len(*audit))
+	}
+
+	// Refusals are 409 and failures 500; both are audited
+	actions.err = fmt.Errorf("%w: bead gt-abc is not ready", ErrActionInvalid)
+	if w := postJSON(t, h, ActionsPrefix+"sling", `{"bead":"gt-abc","rig":"gastown"}`); w.Code != http.StatusConflict {
+		t.Errorf("refused: status = %d, want 409", w.Code)
+	}
+	actions.err = fmt.Errorf("tmux exploded")
+	if w := postJSON(t, h, ActionsPrefix+"nudge", `{"rig":"gastown","polecat":"nux","message":"hi"}`); w.Code != http.StatusInternalServerError {
+		t.Errorf("failed: status = %d, want 500", w.Code)
+	}
+	if len(*audit) != 2 {
+		t.Fatalf("audit entries = %d, want 2", len(*audit))
+	}
+	for _, a := range *audit {
+		if a.payload["result"] != "error" || a.payload["error"] == "" {
+			t.Errorf("audit payload = %v, want error result", a.payload)
+		}
+	}
+}
+
+func TestActionHandler_InvalidatesSnapshot(t *testing.T) {
+	snapshot := NewSnapshot(&MockConvoyFetcher{}, 0)
+	h := NewActionHandler(&fakeActions{}, "overseer", snapshot)
+	h.audit = func(string, string, map[string]interface{}) error { return nil }
+
+	postJSON(t, h, ActionsPrefix+"deacon/pause", `{}`)
+	if u, _ := snapshot.Latest(); u.Version != 1 || u.Reason != "deacon_pause" {
+		t.Errorf("update = %+v, want version 1 deacon_pause", u)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	auth := NewAuth("s3cret")
+	protected := auth.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		cookie string
+		want   int
+	}{
+		{"no credentials", nil, "", http.StatusUnauthorized},
+		{"bearer", map[string]string{"Authorization": "Bearer s3cret"}, "", http.StatusNoContent},
+		{"wrong bearer", map[string]string{"Authorization": "Bearer nope"}, "", http.StatusUnauthorized},
+		{"cookie", nil, "s3cret", http.StatusNoContent},
+		{"cookie same origin", map[string]string{"Origin": "http://example.com"}, "s3cret", http.StatusNoContent},
+		{"cookie cross origin", map[string]string{"Origin": "http://evil.test"}, "s3cret", http.StatusUnauthorized},
+		{"wrong cookie", nil, "nope", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/api/v1/actions/sling", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: AuthCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			protected.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireLocalHost(t *testing.T) {
+	h := RequireLocalHost(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		host string
+		want int
+	}{
+		{"localhost:8080", http.StatusNoContent},
+		{"LOCALHOST", http.StatusNoContent},
+		{"127.0.0.1:8080", http.StatusNoContent},
+		{"[::1]:8080", http.StatusNoContent},
+		{"[::1]", http.StatusNoContent},
+		{"evil.test:8080", http.StatusForbidden},
+		{"localhost.evil.test", http.StatusForbidden},
+		{"192.168.1.5:8080", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/polecats/gastown/Toast", nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("Host %q: status = %d, want %d", tt.host, w.Code, tt.want)
+		}
+	}
+}
+
+func TestAuth_Login(t *testing.T) {
+	auth := NewAuth("s3cret")
+
+	w := httptest.NewRecorder()
+	auth.ServeLogin(w, httptest.NewRequest(http.MethodGet, "/login?token=nope", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("bad token: status = %d, want 401", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	auth.ServeLogin(w, httptest.NewRequest(http.MethodGet, "/login?token=s3cret", nil))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+		t.Fatalf("status = %d, location %q", w.Code, w.Header().Get("Location"))
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != "s3cret" || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteStrictMode {
+		t.Errorf("cookies = %+v", cookies)
+	}
+
+	if NewAuth("").Authenticated(httptest.NewRequest(http.MethodGet, "/", nil)) {
+		t.Error("empty token authenticated a request")
+	}
+}
+
+func TestLoadOrCreateToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".runtime", "dashboard-token")
+
+	token, err := LoadOrCreateToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 64 {
+		t.Errorf("token length = %d, want 64", len(token))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	again, err := LoadOrCreateToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != token {
+		t.Error("token changed between loads")
+	}
+}
@@ -0,0 +1,351 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/logrotate"
+)
+
+// APIPrefix is the path prefix of the JSON API.
+const APIPrefix = "/api/v1/"
+
+// Pagination limits.
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// defaultEventsWindow is how far back /api/v1/events looks without ?since.
+const defaultEventsWindow = 24 * time.Hour
+
+// CostRow is the recorded cost of one ended session.
+type CostRow struct {
+	SessionID string    `json:"session_id"`
+	Role      string    `json:"role"`
+	Rig       string    `json:"rig,omitempty"`
+	Worker    string    `json:"worker,omitempty"`
+	CostUSD   float64   `json:"cost_usd"`
+	EndedAt   time.Time `json:"ended_at"`
+	WorkItem  string    `json:"work_item,omitempty"`
+}
+
+// CostFetcher returns session costs recorded since the given time.
+type CostFetcher func(since time.Time) ([]CostRow, error)
+
+// ListResponse is the envelope of every list endpoint.
+type ListResponse struct {
+	Items  interface{} `json:"items"`
+	Total  int         `json:"total"` // Matching items before pagination
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// APIHandler serves the read-only JSON API under /api/v1/:
+//
+//	GET /api/v1/convoys   ?status= &work_status= &q=
+//	GET /api/v1/polecats  ?rig=
+//	GET /api/v1/mq        ?rig= &source=local|github
+//	GET /api/v1/tasks     ?status= &assignee= &priority=
+//	GET /api/v1/events    ?type= &actor= &visibility= &since=
+//	GET /api/v1/costs     ?since= &role= &rig=
+//
+// Every list takes ?limit= (default 50, max 500) and ?offset=. Convoys,
+// polecats, tasks and GitHub PRs come from the dashboard's cached Snapshot.
+type APIHandler struct {
+	snapshot *Snapshot
+	townRoot string
+	costs    CostFetcher
+	now      func() time.Time
+	mux      *http.ServeMux
+}
+
+// NewAPIHandler creates the API over the dashboard snapshot. costs may be nil,
+// in which case /api/v1/costs reports 503.
+func NewAPIHandler(snapshot *Snapshot, townRoot string, costs CostFetcher) *APIHandler {
+	h := &APIHandler{
+		snapshot: snapshot,
+		townRoot: townRoot,
+		costs:    costs,
+		now:      time.Now,
+		mux:      http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET "+APIPrefix+"convoys", h.listConvoys)
+	h.mux.HandleFunc("GET "+APIPrefix+"polecats", h.listPolecats)
+	h.mux.HandleFunc("GET "+APIPrefix+"mq", h.listMergeQueue)
+	h.mux.HandleFunc("GET "+APIPrefix+"tasks", h.listTasks)
+	h.mux.HandleFunc("GET "+APIPrefix+"events", h.listEvents)
+	h.mux.HandleFunc("GET "+APIPrefix+"costs", h.listCosts)
+	h.mux.HandleFunc(APIPrefix, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+	})
+	return h
+}
+
+// ServeHTTP routes API requests.
+func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *APIHandler) listConvoys(w http.ResponseWriter, r *http.Request) {
+	data, ok := h.data(w)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	text := strings.ToLower(q.Get("q"))
+	items := filter(data.Convoys, func(c ConvoyRow) bool {
+		return matches(q.Get("status"), c.Status) &&
+			matches(q.Get("work_status"), c.WorkStatus) &&
+			(text == "" || strings.Contains(strings.ToLower(c.ID+" "+c.Title), text))
+	})
+	writeList(w, r, items)
+}
+
+func (h *APIHandler) listPolecats(w http.ResponseWriter, r *http.Request) {
+	data, ok := h.data(w)
+	if !ok {
+		return
+	}
+	rig := r.URL.Query().Get("rig")
+	writeList(w, r, filter(data.Polecats, func(p PolecatRow) bool { return matches(rig, p.Rig) }))
+}
+
+func (h *APIHandler) listMergeQueue(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	rig := q.Get("rig")
+	switch q.Get("source") {
+	case "", "local":
+		rigs, err := config.TownRigNames(h.townRoot)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "reading rigs: "+err.Error())
+			return
+		}
+		rows := FetchLocalMergeQueue(h.townRoot, rigs, h.now())
+		writeList(w, r, filter(rows, func(m LocalMRRow) bool { return matches(rig, m.Rig) }))
+	case "github":
+		data, ok := h.data(w)
+		if !ok {
+			return
+		}
+		writeList(w, r, filter(data.MergeQueue, func(m MergeQueueRow) bool { return matches(rig, m.Repo) }))
+	default:
+		writeError(w, http.StatusBadRequest, "source must be local or github")
+	}
+}
+
+func (h *APIHandler) listTasks(w http.ResponseWriter, r *http.Request) {
+	data, ok := h.data(w)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	priority := -1
+	if v := q.Get("priority"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid priority")
+			return
+		}
+		priority = n
+	}
+	writeList(w, r, filter(data.TaskQueue, func(t TaskRow) bool {
+		return matches(q.Get("status"), t.Status) &&
+			matches(q.Get("assignee"), t.Assignee) &&
+			(priority < 0 || t.Priority == priority)
+	}))
+}
+
+// listEvents returns events from the town's events log, newest first.
+func (h *APIHandler) listEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	since, err := h.parseSince(q.Get("since"), defaultEventsWindow)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rd, err := logrotate.Open(filepath.Join(h.townRoot, events.EventsFile), since)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "reading events: "+err.Error())
+		return
+	}
+	defer rd.Close()
+
+	var items []events.Event
+	scanner := logrotate.NewScanner(rd)
+	for scanner.Scan() {
+		e, err := events.Decode(scanner.Bytes())
+		if err != nil {
+			continue
+		}
+		if ts, err := time.Parse(time.RFC3339, e.Timestamp); err == nil && ts.Before(since) {
+			continue
+		}
+		if matches(q.Get("type"), e.Type) && matches(q.Get("actor"), e.Actor) && matches(q.Get("visibility"), e.Visibility) {
+			items = append(items, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "reading events: "+err.Error())
+		return
+	}
+
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	writeList(w, r, items)
+}
+
+// listCosts returns session costs, most recent first.
+func (h *APIHandler) listCosts(w http.ResponseWriter, r *http.Request) {
+	if h.costs == nil {
+		writeError(w, http.StatusServiceUnavailable, "cost data unavailable")
+		return
+	}
+	q := r.URL.Query()
+	since, err := h.parseSince(q.Get("since"), defaultEventsWindow)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	costs, err := h.costs(since)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "querying costs: "+err.Error())
+		return
+	}
+	items := filter(costs, func(c CostRow) bool {
+		return !c.EndedAt.Before(since) && matches(q.Get("role"), c.Role) && matches(q.Get("rig"), c.Rig)
+	})
+	sort.SliceStable(items, func(i, j int) bool { return items[i].EndedAt.After(items[j].EndedAt) })
+	writeList(w, r, items)
+}
+
+// data returns the cached dashboard data, writing an error if it can't be
+// fetched.
+func (h *APIHandler) data(w http.ResponseWriter) (ConvoyData, bool) {
+	data, err := h.snapshot.Data()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "fetching dashboard data: "+err.Error())
+		return ConvoyData{}, false
+	}
+	return data, true
+}
+
+// parseSince parses ?since= as a duration ago ("2h", "7d") or an RFC 3339
+// time. Empty uses def; "0" means all time.
+func (h *APIHandler) parseSince(v string, def time.Duration) (time.Time, error) {
+	switch v {
+	case "":
+		return h.now().Add(-def), nil
+	case "0":
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	d, err := parseAgo(v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return h.now().Add(-d), nil
+}
+
+// parseAgo parses a Go duration, also accepting a "d" (day) suffix.
+func parseAgo(v string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, &paramError{"since", v}
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, &paramError{"since", v}
+	}
+	return d, nil
+}
+
+type paramError struct{ name, value string }
+
+func (e *paramError) Error() string { return "invalid " + e.name + ": " + strconv.Quote(e.value) }
+
+// matches reports whether value passes a filter: empty matches everything,
+// otherwise any of the comma-separated alternatives (case-insensitive).
+func matches(filter, value string) bool {
+	if filter == "" {
+		return true
+	}
+	for _, f := range strings.Split(filter, ",") {
+		if strings.EqualFold(strings.TrimSpace(f), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func filter[T any](items []T, keep func(T) bool) []T {
+	out := make([]T, 0, len(items))
+	for _, it := range items {
+		if keep(it) {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// writeList paginates items per ?limit= and ?offset= and writes the envelope.
+func writeList[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	total := len(items)
+	page := items[min(offset, total):min(offset+limit, total)]
+	if page == nil {
+		page = []T{}
+	}
+	writeJSON(w, http.StatusOK, ListResponse{Items: page, Total: total, Limit: limit, Offset: offset})
+}
+
+func pageParams(r *http.Request) (limit, offset int, err error) {
+	limit = defaultPageLimit
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			return 0, 0, &paramError{"limit", v}
+		}
+		limit = min(limit, maxPageLimit)
+	}
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, &paramError{"offset", v}
+		}
+	}
+	return limit, offset, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
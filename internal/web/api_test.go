@@ -0,0 +1,308 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+var apiNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// setupAPITown creates a town with two rigs, queued MRs and an events log.
+func setupAPITown(t *testing.T) string {
+	t.Helper()
+	townRoot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(townRoot, "mayor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rigs := `{"version":1,"rigs":{"gastown":{"git_url":"x"},"beads":{"git_url":"y"}}}`
+	if err := os.WriteFile(filepath.Join(townRoot, "mayor", "rigs.json"), []byte(rigs), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mr := range []struct {
+		rig string
+		mr  mrqueue.MR
+	}{
+		{"gastown", mrqueue.MR{ID: "mr-old", Branch: "polecat/nux", Priority: 2, CreatedAt: apiNow.Add(-3 * time.Hour), ClaimedBy: "refinery-1"}},
+		{"gastown", mrqueue.MR{ID: "mr-new", Branch: "polecat/toast", Priority: 2, CreatedAt: apiNow.Add(-time.Minute), BlockedBy: "gt-fix"}},
+		{"beads", mrqueue.MR{ID: "mr-beads", Branch: "polecat/joe", Priority: 0, CreatedAt: apiNow.Add(-time.Hour)}},
+	} {
+		dir := filepath.Join(townRoot, mr.rig, ".beads", "mq")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(mr.mr)
+		if err := os.WriteFile(filepath.Join(dir, mr.mr.ID+".json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var log strings.Builder
+	for _, e := range []struct {
+		ago   time.Duration
+		typ   string
+		actor string
+	}{
+		{48 * time.Hour, events.TypeSling, "mayor"},
+		{2 * time.Hour, events.TypeSling, "mayor"},
+		{90 * time.Minute, events.TypeDone, "gastown/polecats/nux"},
+		{time.Hour, events.TypeSessionDeath, "daemon"},
+		{time.Minute, events.TypeDone, "gastown/polecats/toast"},
+	} {
+		line, _ := json.Marshal(events.Event{
+			Timestamp: apiNow.Add(-e.ago).Format(time.RFC3339), Source: "gt", Type: e.typ, Actor: e.actor, Visibility: events.VisibilityFeed,
+		})
+		log.Write(line)
+		log.WriteByte('\n')
+	}
+	if err := os.WriteFile(filepath.Join(townRoot, events.EventsFile), []byte(log.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return townRoot
+}
+
+func newTestAPI(t *testing.T, costs CostFetcher) *APIHandler {
+	t.Helper()
+	fetcher := &MockConvoyFetcher{
+		Convoys: []ConvoyRow{
+			{ID: "hq-cv-1", Title: "Auth rewrite", Status: "open", WorkStatus: "active"},
+			{ID: "hq-cv-2", Title: "Docs", Status: "open", WorkStatus: "stuck"},
+			{ID: "hq-cv-3", Title: "Old", Status: "closed", WorkStatus: "complete"},
+		},
+		Polecats: []PolecatRow{
+			{Name: "nux", Rig: "gastown"},
+			{Name: "joe", Rig: "beads"},
+		},
+		MergeQueue: []MergeQueueRow{{Number: 7, Repo: "gastown"}},
+		TaskQueue: []TaskRow{
+			{ID: "gt-1", Status: "open", Priority: 1},
+			{ID: "gt-2", Status: "in_progress", Priority: 2, Assignee: "gastown/polecats/nux"},
+		},
+	}
+	h := NewAPIHandler(NewSnapshot(fetcher, time.Hour), setupAPITown(t), costs)
+	h.now = func() time.Time { return apiNow }
+	return h
+}
+
+// getList performs a GET and decodes the list envelope, with items into v.
+func getList(t *testing.T, h http.Handler, url string, v interface{}) ListResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", url, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d: %s", url, w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("GET %s Content-Type = %q", url, ct)
+	}
+	var resp struct {
+		ListResponse
+		Items json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	if err := json.Unmarshal(resp.Items, v); err != nil {
+		t.Fatalf("GET %s items: %v", url, err)
+	}
+	return resp.ListResponse
+}
+
+func TestAPI_Convoys(t *testing.T) {
+	h := newTestAPI(t, nil)
+
+	var convoys []ConvoyRow
+	resp := getList(t, h, "/api/v1/convoys?status=open", &convoys)
+	if resp.Total != 2 || len(convoys) != 2 {
+		t.Errorf("status=open: total %d, %d items", resp.Total, len(convoys))
+	}
+
+	getList(t, h, "/api/v1/convoys?work_status=stuck,complete", &convoys)
+	if len(convoys) != 2 {
+		t.Errorf("work_status=stuck,complete: %d items", len(convoys))
+	}
+
+	getList(t, h, "/api/v1/convoys?q=auth", &convoys)
+	if len(convoys) != 1 || convoys[0].ID != "hq-cv-1" {
+		t.Errorf("q=auth: %+v", convoys)
+	}
+
+	resp = getList(t, h, "/api/v1/convoys?limit=2&offset=2", &convoys)
+	if resp.Total != 3 || resp.Limit != 2 || resp.Offset != 2 || len(convoys) != 1 || convoys[0].ID != "hq-cv-3" {
+		t.Errorf("page 2: %+v %+v", resp, convoys)
+	}
+
+	resp = getList(t, h, "/api/v1/convoys?offset=10", &convoys)
+	if resp.Total != 3 || len(convoys) != 0 {
+		t.Errorf("past the end: %+v %+v", resp, convoys)
+	}
+}
+
+func TestAPI_PolecatsAndTasks(t *testing.T) {
+	h := newTestAPI(t, nil)
+
+	var polecats []PolecatRow
+	getList(t, h, "/api/v1/polecats?rig=beads", &polecats)
+	if len(polecats) != 1 || polecats[0].Name != "joe" {
+		t.Errorf("polecats rig=beads: %+v", polecats)
+	}
+
+	var tasks []TaskRow
+	getList(t, h, "/api/v1/tasks?priority=2", &tasks)
+	if len(tasks) != 1 || tasks[0].ID != "gt-2" {
+		t.Errorf("tasks priority=2: %+v", tasks)
+	}
+	getList(t, h, "/api/v1/tasks?assignee=gastown/polecats/nux&status=in_progress", &tasks)
+	if len(tasks) != 1 {
+		t.Errorf("tasks assignee: %+v", tasks)
+	}
+}
+
+func TestAPI_MergeQueue(t *testing.T) {
+	h := newTestAPI(t, nil)
+
+	var mrs []LocalMRRow
+	resp := getList(t, h, "/api/v1/mq", &mrs)
+	if resp.Total != 3 {
+		t.Fatalf("local mq total = %d, want 3", resp.Total)
+	}
+	if mrs[0].ID != "mr-beads" {
+		t.Errorf("highest score first: got %s, want mr-beads (P0)", mrs[0].ID)
+	}
+
+	getList(t, h, "/api/v1/mq?rig=gastown", &mrs)
+	if len(mrs) != 2 || mrs[0].ID != "mr-old" || mrs[0].ClaimedBy != "refinery-1" || mrs[1].BlockedBy != "gt-fix" {
+		t.Errorf("gastown mq: %+v", mrs)
+	}
+
+	var prs []MergeQueueRow
+	getList(t, h, "/api/v1/mq?source=github", &prs)
+	if len(prs) != 1 || prs[0].Number != 7 {
+		t.Errorf("github mq: %+v", prs)
+	}
+}
+
+func TestAPI_Events(t *testing.T) {
+	h := newTestAPI(t, nil)
+
+	var evs []events.Event
+	resp := getList(t, h, "/api/v1/events", &evs)
+	if resp.Total != 4 {
+		t.Fatalf("default window total = %d, want 4 (last 24h)", resp.Total)
+	}
+	if evs[0].Actor != "gastown/polecats/toast" {
+		t.Errorf("newest first: got %s", evs[0].Actor)
+	}
+
+	getList(t, h, "/api/v1/events?type=done&since=80m", &evs)
+	if len(evs) != 1 || evs[0].Actor != "gastown/polecats/toast" {
+		t.Errorf("type=done since=80m: %+v", evs)
+	}
+
+	getList(t, h, "/api/v1/events?since=0&type=sling", &evs)
+	if len(evs) != 2 {
+		t.Errorf("since=0 type=sling: %d events, want 2", len(evs))
+	}
+
+	getList(t, h, "/api/v1/events?since=7d&actor=daemon", &evs)
+	if len(evs) != 1 || evs[0].Type != events.TypeSessionDeath {
+		t.Errorf("actor=daemon: %+v", evs)
+	}
+}
+
+func TestAPI_Costs(t *testing.T) {
+	var gotSince time.Time
+	h := newTestAPI(t, func(since time.Time) ([]CostRow, error) {
+		gotSince = since
+		return []CostRow{
+			{SessionID: "a", Role: "polecat", Rig: "gastown", CostUSD: 1, EndedAt: apiNow.Add(-2 * time.Hour)},
+			{SessionID: "b", Role: "witness", Rig: "gastown", CostUSD: 2, EndedAt: apiNow.Add(-time.Hour)},
+			{SessionID: "c", Role: "polecat", Rig: "beads", CostUSD: 3, EndedAt: apiNow.Add(-30 * time.Hour)},
+		}, nil
+	})
+
+	var costs []CostRow
+	getList(t, h, "/api/v1/costs?role=polecat", &costs)
+	if !gotSince.Equal(apiNow.Add(-24 * time.Hour)) {
+		t.Errorf("default since = %v", gotSince)
+	}
+	if len(costs) != 1 || costs[0].SessionID != "a" {
+		t.Errorf("role=polecat in last day: %+v", costs)
+	}
+
+	getList(t, h, "/api/v1/costs?since=2d", &costs)
+	if len(costs) != 3 || costs[0].SessionID != "b" {
+		t.Errorf("since=2d, newest first: %+v", costs)
+	}
+}
+
+func TestAPI_Errors(t *testing.T) {
+	h := newTestAPI(t, nil)
+	tests := []struct {
+		method, url string
+		status      int
+	}{
+		{"GET", "/api/v1/costs", http.StatusServiceUnavailable},
+		{"GET", "/api/v1/convoys?limit=0", http.StatusBadRequest},
+		{"GET", "/api/v1/convoys?offset=-1", http.StatusBadRequest},
+		{"GET", "/api/v1/events?since=yesterday", http.StatusBadRequest},
+		{"GET", "/api/v1/tasks?priority=high", http.StatusBadRequest},
+		{"GET", "/api/v1/mq?source=gitlab", http.StatusBadRequest},
+		{"GET", "/api/v1/nope", http.StatusNotFound},
+		{"POST", "/api/v1/convoys", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.url, w.Code, tt.status)
+		}
+	}
+
+	failing := NewAPIHandler(NewSnapshot(&MockConvoyFetcher{Error: errors.New("bd down")}, 0), t.TempDir(), nil)
+	w := httptest.NewRecorder()
+	failing.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/convoys", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "bd down") {
+		t.Errorf("fetch failure = %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestFetchRigMergeQueues(t *testing.T) {
+	townRoot := setupAPITown(t)
+	logger := mrqueue.NewEventLoggerFromRig(filepath.Join(townRoot, "gastown"))
+	if err := logger.LogMergeFailed(&mrqueue.MR{ID: "mr-0", Branch: "polecat/max"}, "tests failed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.LogMerged(&mrqueue.MR{ID: "mr-1", Branch: "polecat/slit"}, "0123456789abcdef"); err != nil {
+		t.Fatal(err)
+	}
+
+	queues := FetchRigMergeQueues(townRoot, []string{"beads", "gastown", "missing"}, apiNow)
+	if len(queues) != 3 {
+		t.Fatalf("queues = %d, want 3", len(queues))
+	}
+
+	gastown := queues[1]
+	if gastown.Rig != "gastown" || len(gastown.MRs) != 2 || gastown.MRs[0].ID != "mr-old" {
+		t.Errorf("gastown queue = %+v, want mr-old first", gastown.MRs)
+	}
+	if len(gastown.Events) != 2 || gastown.Events[0].MRID != "mr-1" || gastown.Events[0].Detail != "01234567" ||
+		gastown.Events[1].Detail != "tests failed" {
+		t.Errorf("gastown events = %+v, want newest first with details", gastown.Events)
+	}
+
+	missing := queues[2]
+	if missing.MRs == nil || missing.Events == nil || len(missing.MRs)+len(missing.Events) != 0 {
+		t.Errorf("missing rig = %+v, want empty non-nil slices", missing)
+	}
+}
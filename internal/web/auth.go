@@ -0,0 +1,150 @@
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AuthCookie is the cookie set by /login that authenticates browser sessions.
+const AuthCookie = "gt_dashboard"
+
+// LoadOrCreateToken returns the dashboard token stored at path, generating
+// and saving a new one (mode 0600) if the file doesn't exist. The token
+// survives restarts so scripts and open browser sessions keep working.
+func LoadOrCreateToken(path string) (string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed internally
+	if err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("reading token: %w", err)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("creating token directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("writing token: %w", err)
+	}
+	return token, nil
+}
+
+// Auth checks requests against the dashboard token. Scripts send it as
+// "Authorization: Bearer <token>"; browsers visit /login?token=<token> once
+// and are then authenticated by an HttpOnly, SameSite=Strict cookie.
+type Auth struct {
+	token string
+}
+
+// NewAuth creates an authenticator for the token.
+func NewAuth(token string) *Auth {
+	return &Auth{token: token}
+}
+
+// Authenticated reports whether the request carries the token. Cookie
+// authentication is refused for cross-origin requests, so another site
+// can't drive the dashboard through the operator's browser.
+func (a *Auth) Authenticated(r *http.Request) bool {
+	if a.token == "" {
+		return false
+	}
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return a.valid(bearer)
+	}
+	c, err := r.Cookie(AuthCookie)
+	if err != nil || !a.valid(c.Value) {
+		return false
+	}
+	return sameOrigin(r)
+}
+
+func (a *Auth) valid(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+// Require wraps next so unauthenticated requests get 401.
+func (a *Auth) Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.Authenticated(r) {
+			writeError(w, http.StatusUnauthorized, "authentication required: open the login URL printed by gt dashboard, or send Authorization: Bearer <token>")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireLocalHost wraps next so requests whose Host header doesn't name the
+// loopback interface get 403. Reads on a loopback dashboard need no token, so
+// without this a site that rebinds its DNS name to 127.0.0.1 could read agent
+// panes through the operator's browser.
+func RequireLocalHost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLocalHost(r.Host) {
+			writeError(w, http.StatusForbidden, "host not allowed: open the dashboard via localhost, 127.0.0.1 or [::1]")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLocalHost reports whether a Host header (with or without port) names
+// the loopback interface.
+func isLocalHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ServeLogin handles GET /login?token=<token>: it sets the session cookie and
+// redirects to the dashboard.
+func (a *Auth) ServeLogin(w http.ResponseWriter, r *http.Request) {
+	if !a.valid(r.URL.Query().Get("token")) {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     AuthCookie,
+		Value:    a.token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	// Keep the token out of the address bar and browser history
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// sameOrigin reports whether a browser request came from the dashboard's own
+// pages. Requests without an Origin header (non-browser clients, same-origin
+// GETs) are allowed.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
@@ -10,12 +10,19 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/activity"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/formula"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 // LiveConvoyFetcher fetches convoy data from beads.
 type LiveConvoyFetcher struct {
+	townRoot  string
 	townBeads string
+
+	// GitHubRepos lists "owner/name" repos whose open PRs are shown
+	// alongside the local merge queue. Empty turns the GitHub view off.
+	GitHubRepos []string
 }
 
 // NewLiveConvoyFetcher creates a fetcher for the current workspace.
@@ -26,6 +33,7 @@ func NewLiveConvoyFetcher() (*LiveConvoyFetcher, error) {
 	}
 
 	return &LiveConvoyFetcher{
+		townRoot:  townRoot,
 		townBeads: filepath.Join(townRoot, ".beads"),
 	}, nil
 }
@@ -125,7 +133,9 @@ func (f *LiveConvoyFetcher) FetchConvoys() ([]ConvoyRow, error) {
 
 		// Get tracked issues for expandable view
 		row.TrackedIssues = make([]TrackedIssue, len(tracked))
+		graphNodes := make([]formula.LiveNode, len(tracked))
 		for i, t := range tracked {
+			graphNodes[i] = formula.LiveNode{ID: t.ID, Title: t.Title, Status: t.Status, DependsOn: t.DependsOn}
 			row.TrackedIssues[i] = TrackedIssue{
 				ID:          t.ID,
 				Title:       t.Title,
@@ -138,6 +148,10 @@ func (f *LiveConvoyFetcher) FetchConvoys() ([]ConvoyRow, error) {
 				UpdatedAt:   t.UpdatedAt.Format("2006-01-02 15:04"),
 			}
 		}
+		// Fan-outs track beads that wait on each other; show how
+		if graph := formula.LiveGraph(c.Title, graphNodes); graph.HasEdges() {
+			row.Graph = graph
+		}
 
 		rows = append(rows, row)
 	}
@@ -157,6 +171,7 @@ type trackedIssueInfo struct {
 	CreatedAt    string
 	LastActivity time.Time
 	UpdatedAt    time.Time // Fallback for activity when no assignee
+	DependsOn    []string  // Issues it is blocked by
 }
 
 // getTrackedIssues fetches tracked issues for a convoy.
@@ -216,6 +231,7 @@ func (f *LiveConvoyFetcher) getTrackedIssues(convoyID string) []trackedIssueInfo
 			info.Type = d.Type
 			info.CreatedAt = d.CreatedAt
 			info.UpdatedAt = d.UpdatedAt
+			info.DependsOn = d.DependsOn
 		} else {
 			info.Title = "(external)"
 			info.Status = "unknown"
@@ -242,6 +258,7 @@ type issueDetail struct {
 	Type        string
 	CreatedAt   string
 	UpdatedAt   time.Time
+	DependsOn   []string // Issues it is blocked by
 }
 
 // getIssueDetailsBatch fetches details for multiple issues.
@@ -273,6 +290,11 @@ func (f *LiveConvoyFetcher) getIssueDetailsBatch(issueIDs []string) map[string]*
 		Type        string `json:"issue_type"`
 		CreatedAt   string `json:"created_at"`
 		UpdatedAt   string `json:"updated_at"`
+
+		Dependencies []struct {
+			ID             string `json:"id"`
+			DependencyType string `json:"dependency_type"`
+		} `json:"dependencies"`
 	}
 	if err := json.Unmarshal(stdout.Bytes(), &issues); err != nil {
 		return result
@@ -289,6 +311,11 @@ func (f *LiveConvoyFetcher) getIssueDetailsBatch(issueIDs []string) map[string]*
 			Type:        issue.Type,
 			CreatedAt:   issue.CreatedAt,
 		}
+		for _, dep := range issue.Dependencies {
+			if dep.DependencyType == "blocks" {
+				detail.DependsOn = append(detail.DependsOn, dep.ID)
+			}
+		}
 		// Parse updated_at timestamp
 		if issue.UpdatedAt != "" {
 			if t, err := time.Parse(time.RFC3339, issue.UpdatedAt); err == nil {
@@ -464,21 +491,26 @@ func calculateWorkStatus(completed, total int, activityColor string) string {
 	}
 }
 
-// FetchMergeQueue fetches open PRs from configured repos.
+// FetchLocalMergeQueue fetches each rig's queued MRs and recent merge events.
+func (f *LiveConvoyFetcher) FetchLocalMergeQueue() ([]RigMergeQueue, error) {
+	rigs, err := config.TownRigNames(f.townRoot)
+	if err != nil {
+		return nil, err
+	}
+	return FetchRigMergeQueues(f.townRoot, rigs, time.Now()), nil
+}
+
+// FetchMergeQueue fetches open PRs from the configured GitHub repos.
 func (f *LiveConvoyFetcher) FetchMergeQueue() ([]MergeQueueRow, error) {
-	// Repos to query for PRs
-	repos := []struct {
-		Full  string // Full repo path for gh CLI
-		Short string // Short name for display
-	}{
-		{"michaellady/roxas", "roxas"},
-		{"michaellady/gastown", "gastown"},
+	if len(f.GitHubRepos) == 0 {
+		return nil, nil
 	}
 
-	var result []MergeQueueRow
+	result := []MergeQueueRow{}
 
-	for _, repo := range repos {
-		prs, err := f.fetchPRsForRepo(repo.Full, repo.Short)
+	for _, repo := range f.GitHubRepos {
+		short := repo[strings.LastIndex(repo, "/")+1:]
+		prs, err := f.fetchPRsForRepo(repo, short)
 		if err != nil {
 			// Non-fatal: continue with other repos
 			continue
@@ -628,8 +660,8 @@ func (f *LiveConvoyFetcher) FetchPolecats() ([]PolecatRow, error) {
 		return nil, nil
 	}
 
-	// Pre-fetch merge queue count to determine refinery idle status
-	mergeQueueCount := f.getMergeQueueCount()
+	// Pre-fetch merge queue depths to determine refinery idle status
+	queueDepths := f.getMergeQueueDepths()
 
 	var polecats []PolecatRow
 	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
@@ -675,7 +707,7 @@ func (f *LiveConvoyFetcher) FetchPolecats() ([]PolecatRow, error) {
 		// Get status hint - special handling for refinery
 		var statusHint string
 		if polecat == "refinery" {
-			statusHint = f.getRefineryStatusHint(mergeQueueCount)
+			statusHint = f.getRefineryStatusHint(queueDepths[rig])
 		} else {
 			statusHint = f.getPolecatStatusHint(sessionName)
 		}
@@ -716,13 +748,17 @@ func (f *LiveConvoyFetcher) getPolecatStatusHint(sessionName string) string {
 	return ""
 }
 
-// getMergeQueueCount returns the total number of open PRs across all repos.
-func (f *LiveConvoyFetcher) getMergeQueueCount() int {
-	mergeQueue, err := f.FetchMergeQueue()
+// getMergeQueueDepths returns the number of queued MRs in each rig.
+func (f *LiveConvoyFetcher) getMergeQueueDepths() map[string]int {
+	depths := make(map[string]int)
+	rigs, err := config.TownRigNames(f.townRoot)
 	if err != nil {
-		return 0
+		return depths
+	}
+	for _, mr := range FetchLocalMergeQueue(f.townRoot, rigs, time.Now()) {
+		depths[mr.Rig]++
 	}
-	return len(mergeQueue)
+	return depths
 }
 
 // getRefineryStatusHint returns appropriate status for refinery based on merge queue.
@@ -8,6 +8,9 @@ import (
 // ConvoyFetcher defines the interface for fetching convoy data.
 type ConvoyFetcher interface {
 	FetchConvoys() ([]ConvoyRow, error)
+	FetchLocalMergeQueue() ([]RigMergeQueue, error)
+	// FetchMergeQueue returns open GitHub PRs, or nil if the GitHub view
+	// is turned off.
 	FetchMergeQueue() ([]MergeQueueRow, error)
 	FetchPolecats() ([]PolecatRow, error)
 	FetchTaskQueue() ([]TaskRow, error)
@@ -15,11 +18,12 @@ type ConvoyFetcher interface {
 
 // ConvoyHandler handles HTTP requests for the convoy dashboard.
 type ConvoyHandler struct {
-	fetcher  ConvoyFetcher
+	snapshot *Snapshot
 	template *template.Template
 }
 
 // NewConvoyHandler creates a new convoy handler with the given fetcher.
+// Fetched data is cached in a Snapshot; see Snapshot for live updates.
 func NewConvoyHandler(fetcher ConvoyFetcher) (*ConvoyHandler, error) {
 	tmpl, err := LoadTemplates()
 	if err != nil {
@@ -27,48 +31,68 @@ func NewConvoyHandler(fetcher ConvoyFetcher) (*ConvoyHandler, error) {
 	}
 
 	return &ConvoyHandler{
-		fetcher:  fetcher,
+		snapshot: NewSnapshot(fetcher, DefaultSnapshotMaxAge),
 		template: tmpl,
 	}, nil
 }
 
+// Snapshot returns the handler's data cache.
+func (h *ConvoyHandler) Snapshot() *Snapshot {
+	return h.snapshot
+}
+
 // ServeHTTP handles GET / requests and renders the convoy dashboard.
 func (h *ConvoyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	convoys, err := h.fetcher.FetchConvoys()
+	data, err := h.snapshot.Data()
 	if err != nil {
 		http.Error(w, "Failed to fetch convoys", http.StatusInternalServerError)
 		return
 	}
 
-	mergeQueue, err := h.fetcher.FetchMergeQueue()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if err := h.template.ExecuteTemplate(w, "convoy.html", data); err != nil {
+		http.Error(w, "Failed to render template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// fetchConvoyData runs every fetcher method to build the dashboard data.
+func fetchConvoyData(f ConvoyFetcher) (ConvoyData, error) {
+	convoys, err := f.FetchConvoys()
+	if err != nil {
+		return ConvoyData{}, err
+	}
+
+	localMergeQueue, err := f.FetchLocalMergeQueue()
+	if err != nil {
+		// Non-fatal: show convoys even if the local merge queue fails
+		localMergeQueue = nil
+	}
+
+	mergeQueue, err := f.FetchMergeQueue()
 	if err != nil {
 		// Non-fatal: show convoys even if merge queue fails
 		mergeQueue = nil
 	}
 
-	polecats, err := h.fetcher.FetchPolecats()
+	polecats, err := f.FetchPolecats()
 	if err != nil {
 		// Non-fatal: show convoys even if polecats fail
 		polecats = nil
 	}
 
-	taskQueue, err := h.fetcher.FetchTaskQueue()
+	taskQueue, err := f.FetchTaskQueue()
 	if err != nil {
 		// Non-fatal: show convoys even if task queue fails
 		taskQueue = nil
 	}
 
-	data := ConvoyData{
-		Convoys:    convoys,
-		MergeQueue: mergeQueue,
-		Polecats:   polecats,
-		TaskQueue:  taskQueue,
-	}
-
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-
-	if err := h.template.ExecuteTemplate(w, "convoy.html", data); err != nil {
-		http.Error(w, "Failed to render template", http.StatusInternalServerError)
-		return
-	}
+	return ConvoyData{
+		Convoys:         convoys,
+		LocalMergeQueue: localMergeQueue,
+		MergeQueue:      mergeQueue,
+		Polecats:        polecats,
+		TaskQueue:       taskQueue,
+	}, nil
 }
@@ -17,16 +17,22 @@ var errFetchFailed = errors.New("fetch failed")
 
 // MockConvoyFetcher is a mock implementation for testing.
 type MockConvoyFetcher struct {
-	Convoys    []ConvoyRow
-	MergeQueue []MergeQueueRow
-	Polecats   []PolecatRow
-	Error      error
+	Convoys         []ConvoyRow
+	LocalMergeQueue []RigMergeQueue
+	MergeQueue      []MergeQueueRow
+	Polecats        []PolecatRow
+	TaskQueue       []TaskRow
+	Error           error
 }
 
 func (m *MockConvoyFetcher) FetchConvoys() ([]ConvoyRow, error) {
 	return m.Convoys, m.Error
 }
 
+func (m *MockConvoyFetcher) FetchLocalMergeQueue() ([]RigMergeQueue, error) {
+	return m.LocalMergeQueue, nil
+}
+
 func (m *MockConvoyFetcher) FetchMergeQueue() ([]MergeQueueRow, error) {
 	return m.MergeQueue, nil
 }
@@ -35,6 +41,10 @@ func (m *MockConvoyFetcher) FetchPolecats() ([]PolecatRow, error) {
 	return m.Polecats, nil
 }
 
+func (m *MockConvoyFetcher) FetchTaskQueue() ([]TaskRow, error) {
+	return m.TaskQueue, nil
+}
+
 func TestConvoyHandler_RendersTemplate(t *testing.T) {
 	mock := &MockConvoyFetcher{
 		Convoys: []ConvoyRow{
@@ -316,6 +326,57 @@ func TestConvoyHandler_EmptyMergeQueue(t *testing.T) {
 	}
 }
 
+func TestConvoyHandler_LocalMergeQueueRendering(t *testing.T) {
+	mock := &MockConvoyFetcher{
+		Convoys: []ConvoyRow{},
+		LocalMergeQueue: []RigMergeQueue{
+			{
+				Rig: "gastown",
+				MRs: []LocalMRRow{
+					{ID: "mr-1", Rig: "gastown", Branch: "polecat/nux", Target: "main", Worker: "nux", Score: 1010.5, ClaimedBy: "refinery-1"},
+					{ID: "mr-2", Rig: "gastown", Branch: "polecat/toast", Target: "main", Worker: "toast", Score: 900, BlockedBy: "gt-fix", RetryCount: 2},
+				},
+				Events: []MergeEventRow{
+					{Time: time.Now(), Type: "merge_failed", MRID: "mr-0", Branch: "polecat/max", Detail: "tests failed"},
+				},
+			},
+			{Rig: "beads", MRs: []LocalMRRow{}, Events: []MergeEventRow{}},
+		},
+	}
+
+	handler, err := NewConvoyHandler(mock)
+	if err != nil {
+		t.Fatalf("NewConvoyHandler() error = %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	body := w.Body.String()
+
+	for _, want := range []string{
+		"gastown",
+		"mr-1",
+		"1010.5",
+		"refinery-1",
+		"gt-fix",
+		"retryMR(",
+		"rejectMR(",
+		"mq-event-merge_failed",
+		"tests failed",
+		"No merge requests queued", // beads has an empty queue
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Response missing %q", want)
+		}
+	}
+
+	// The GitHub view is off when MergeQueue is nil
+	if strings.Contains(body, "GitHub Pull Requests") {
+		t.Error("GitHub PR section should be hidden when the GitHub view is off")
+	}
+}
+
 // Integration tests for polecat workers rendering
 
 func TestConvoyHandler_PolecatWorkersRendering(t *testing.T) {
@@ -517,8 +578,11 @@ func TestConvoyHandler_HTMXAutoRefresh(t *testing.T) {
 	if !strings.Contains(body, "hx-trigger") {
 		t.Error("Response should contain hx-trigger attribute for HTMX")
 	}
-	if !strings.Contains(body, "every 10s") {
-		t.Error("Response should contain 'every 10s' trigger interval")
+	if !strings.Contains(body, "gt:update") {
+		t.Error("Response should refresh on live update events")
+	}
+	if !strings.Contains(body, "every 60s") {
+		t.Error("Response should contain 'every 60s' fallback trigger interval")
 	}
 }
 
@@ -686,7 +750,8 @@ func TestE2E_Server_FullDashboard(t *testing.T) {
 		{"Polecat section", "Polecat Workers"},
 		{"Polecat name", "furiosa"},
 		{"Polecat status", "Running E2E tests"},
-		{"HTMX auto-refresh", `hx-trigger="every 10s"`},
+		{"HTMX live refresh", `hx-trigger="gt:update, every 60s"`},
+		{"Live update stream", `new EventSource('/events')`},
 	}
 
 	for _, check := range checks {
@@ -956,6 +1021,10 @@ func (m *MockConvoyFetcherWithErrors) FetchConvoys() ([]ConvoyRow, error) {
 	return m.Convoys, nil
 }
 
+func (m *MockConvoyFetcherWithErrors) FetchLocalMergeQueue() ([]RigMergeQueue, error) {
+	return nil, m.MergeQueueError
+}
+
 func (m *MockConvoyFetcherWithErrors) FetchMergeQueue() ([]MergeQueueRow, error) {
 	return nil, m.MergeQueueError
 }
@@ -964,6 +1033,10 @@ func (m *MockConvoyFetcherWithErrors) FetchPolecats() ([]PolecatRow, error) {
 	return nil, m.PolecatsError
 }
 
+func (m *MockConvoyFetcherWithErrors) FetchTaskQueue() ([]TaskRow, error) {
+	return nil, nil
+}
+
 func TestConvoyHandler_NonFatalErrors(t *testing.T) {
 	mock := &MockConvoyFetcherWithErrors{
 		Convoys: []ConvoyRow{
@@ -0,0 +1,263 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/logrotate"
+)
+
+// DefaultSnapshotMaxAge bounds how stale the cached dashboard data can get
+// when no event arrives to invalidate it (e.g. beads changed directly via bd).
+const DefaultSnapshotMaxAge = 30 * time.Second
+
+// Long-poll and SSE timing.
+const (
+	defaultPollTimeout = 25 * time.Second
+	maxPollTimeout     = 50 * time.Second // Below the dashboard's write timeout
+	sseKeepalive       = 20 * time.Second
+	sseRetry           = 5 * time.Second
+)
+
+// Update announces that the dashboard data changed.
+type Update struct {
+	Version uint64    `json:"version"`
+	Reason  string    `json:"reason"` // Event type that caused the change
+	At      time.Time `json:"at"`
+}
+
+// Snapshot caches the dashboard data so page loads don't re-run every
+// ConvoyFetcher method (which shell out to bd, tmux and gh). The cache is
+// invalidated by events from the town's events log; clients learn about
+// changes via server-sent events (/events) or long-polling (/poll).
+type Snapshot struct {
+	fetcher ConvoyFetcher
+	maxAge  time.Duration
+	now     func() time.Time
+
+	fetchMu sync.Mutex // Serializes fetches so concurrent loads share one
+
+	mu         sync.Mutex
+	data       *ConvoyData
+	fetchedAt  time.Time
+	fetchedFor uint64        // update.Version the cached data reflects
+	update     Update        // Latest change
+	changed    chan struct{} // Closed and replaced on every change
+}
+
+// NewSnapshot creates a snapshot cache over the fetcher. maxAge <= 0 uses
+// DefaultSnapshotMaxAge.
+func NewSnapshot(fetcher ConvoyFetcher, maxAge time.Duration) *Snapshot {
+	if maxAge <= 0 {
+		maxAge = DefaultSnapshotMaxAge
+	}
+	return &Snapshot{
+		fetcher: fetcher,
+		maxAge:  maxAge,
+		now:     time.Now,
+		changed: make(chan struct{}),
+	}
+}
+
+// Data returns the dashboard data, fetching it if the cache is empty, stale
+// or invalidated. Convoy fetch errors are returned; the other sections are
+// non-fatal and left empty on error.
+func (s *Snapshot) Data() (ConvoyData, error) {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	s.mu.Lock()
+	version := s.update.Version
+	if s.data != nil && s.fetchedFor == version && s.now().Sub(s.fetchedAt) < s.maxAge {
+		data := *s.data
+		s.mu.Unlock()
+		return data, nil
+	}
+	s.mu.Unlock()
+
+	data, err := fetchConvoyData(s.fetcher)
+	if err != nil {
+		return ConvoyData{}, err
+	}
+
+	s.mu.Lock()
+	s.data = &data
+	s.fetchedAt = s.now()
+	s.fetchedFor = version // A change during the fetch leaves the cache stale
+	s.mu.Unlock()
+	return data, nil
+}
+
+// Invalidate drops the cached data and notifies waiting clients.
+func (s *Snapshot) Invalidate(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.update = Update{Version: s.update.Version + 1, Reason: reason, At: s.now()}
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Latest returns the most recent change and a channel closed on the next one.
+func (s *Snapshot) Latest() (Update, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.update, s.changed
+}
+
+// Wait blocks until the version passes since, the context ends or the
+// timeout elapses. ok is false if nothing changed.
+func (s *Snapshot) Wait(ctx context.Context, since uint64, timeout time.Duration) (Update, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		u, changed := s.Latest()
+		if u.Version > since {
+			return u, true
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return u, false
+		case <-ctx.Done():
+			return u, false
+		}
+	}
+}
+
+// refreshReason reports whether an events log line changes what the
+// dashboard shows: convoy progress, merges and polecat lifecycle.
+func refreshReason(line []byte) (string, bool) {
+	e, err := events.Decode(line)
+	if err != nil {
+		return "", false
+	}
+	switch e.Type {
+	case events.TypeSling, events.TypeHook, events.TypeUnhook, events.TypeDone,
+		events.TypeHandoff, events.TypeSpawn, events.TypeKill,
+		events.TypeSessionDeath, events.TypeMassDeath, events.TypeCrashLoop,
+		events.TypeMerged, events.TypeMergeFailed, events.TypeMergeSkipped:
+		return e.Type, true
+	case events.TypeSpan:
+		// The refinery records merges as trace spans
+		if name, _ := e.Payload["name"].(string); name == "merge" || name == "merged" {
+			return events.TypeMerged, true
+		}
+	}
+	return "", false
+}
+
+// WatchEvents tails the events log at path and invalidates the snapshot when
+// a relevant event is appended, checking every interval until ctx ends.
+// Events read in the same check are coalesced into one change.
+func (s *Snapshot) WatchEvents(ctx context.Context, path string, interval time.Duration) error {
+	f, err := logrotate.Follow(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		var reason string
+		for {
+			line, ok := f.Next()
+			if !ok {
+				break
+			}
+			if r, ok := refreshReason([]byte(line)); ok {
+				reason = r
+			}
+		}
+		if reason != "" {
+			s.Invalidate(reason)
+		}
+	}
+}
+
+// ServeSSE streams an "update" event whenever the snapshot changes.
+// The first event carries the current version so clients can tell a
+// reconnect from a change.
+func (s *Snapshot) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	// The stream outlives the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	u, changed := s.Latest()
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	writeSSE(w, "hello", u)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(sseKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case <-changed:
+			u, changed = s.Latest()
+			writeSSE(w, "update", u)
+		}
+		flusher.Flush()
+	}
+}
+
+func writeSSE(w http.ResponseWriter, event string, u Update) {
+	data, _ := json.Marshal(u)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", u.Version, event, data)
+}
+
+// ServePoll answers a long-poll: GET /poll?since=<version>[&timeout=25s].
+// Responds with the latest Update as soon as the version passes since, or
+// 204 No Content if nothing changed before the timeout.
+func (s *Snapshot) ServePoll(w http.ResponseWriter, r *http.Request) {
+	var since uint64
+	if v := r.URL.Query().Get("since"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+		since = n
+	}
+	timeout := defaultPollTimeout
+	if v := r.URL.Query().Get("timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid timeout", http.StatusBadRequest)
+			return
+		}
+		timeout = min(d, maxPollTimeout)
+	}
+
+	u, ok := s.Wait(r.Context(), since, timeout)
+	w.Header().Set("Cache-Control", "no-cache")
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(u)
+}
@@ -0,0 +1,221 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// countingFetcher counts how often the convoy list is fetched.
+type countingFetcher struct {
+	MockConvoyFetcher
+	calls atomic.Int32
+}
+
+func (c *countingFetcher) FetchConvoys() ([]ConvoyRow, error) {
+	c.calls.Add(1)
+	return c.MockConvoyFetcher.FetchConvoys()
+}
+
+func TestSnapshot_CachesUntilInvalidated(t *testing.T) {
+	f := &countingFetcher{MockConvoyFetcher: MockConvoyFetcher{Convoys: []ConvoyRow{{ID: "hq-cv-1"}}}}
+	s := NewSnapshot(f, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		data, err := s.Data()
+		if err != nil || len(data.Convoys) != 1 {
+			t.Fatalf("Data() = %+v, %v", data, err)
+		}
+	}
+	if n := f.calls.Load(); n != 1 {
+		t.Errorf("fetched %d times, want 1 (cached)", n)
+	}
+
+	s.Invalidate(events.TypeDone)
+	if _, err := s.Data(); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.calls.Load(); n != 2 {
+		t.Errorf("fetched %d times after invalidate, want 2", n)
+	}
+}
+
+func TestSnapshot_MaxAge(t *testing.T) {
+	f := &countingFetcher{}
+	s := NewSnapshot(f, time.Minute)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	_, _ = s.Data()
+	now = now.Add(30 * time.Second)
+	_, _ = s.Data()
+	now = now.Add(time.Minute)
+	_, _ = s.Data()
+	if n := f.calls.Load(); n != 2 {
+		t.Errorf("fetched %d times, want 2 (refetch after max age)", n)
+	}
+}
+
+func TestSnapshot_ErrorsAreNotCached(t *testing.T) {
+	f := &countingFetcher{MockConvoyFetcher: MockConvoyFetcher{Error: errFetchFailed}}
+	s := NewSnapshot(f, time.Hour)
+	if _, err := s.Data(); err == nil {
+		t.Fatal("Data() succeeded despite fetch error")
+	}
+	f.Error = nil
+	if _, err := s.Data(); err != nil {
+		t.Fatalf("Data() after recovery: %v", err)
+	}
+}
+
+func TestSnapshot_Wait(t *testing.T) {
+	s := NewSnapshot(&MockConvoyFetcher{}, 0)
+
+	if _, ok := s.Wait(context.Background(), 0, 10*time.Millisecond); ok {
+		t.Error("Wait reported a change with none made")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.Invalidate(events.TypeSessionDeath)
+	}()
+	u, ok := s.Wait(context.Background(), 0, 5*time.Second)
+	if !ok || u.Version != 1 || u.Reason != events.TypeSessionDeath {
+		t.Errorf("Wait = %+v, %v; want version 1 session_death", u, ok)
+	}
+
+	// A client that is behind returns immediately
+	if u, ok := s.Wait(context.Background(), 0, time.Nanosecond); !ok || u.Version != 1 {
+		t.Errorf("Wait behind = %+v, %v", u, ok)
+	}
+}
+
+func TestRefreshReason(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{`{"type":"done","actor":"gastown/polecats/nux"}`, "done", true},
+		{`{"type":"session_death","payload":{"session":"gt-gastown-nux"}}`, "session_death", true},
+		{`{"type":"span","payload":{"name":"merge"}}`, "merged", true},
+		{`{"type":"span","payload":{"name":"step"}}`, "", false},
+		{`{"type":"mail"}`, "", false},
+		{`{"type":"done","v":99}`, "", false},
+		{`not json`, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := refreshReason([]byte(tt.line))
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("refreshReason(%s) = %q, %v; want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSnapshot_WatchEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), events.EventsFile)
+	s := NewSnapshot(&MockConvoyFetcher{}, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- s.WatchEvents(ctx, path, 5*time.Millisecond) }()
+
+	// Wait for the follower to create the log, then append to it
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"type":"mail"}` + "\n" + `{"type":"sling"}` + "\n")
+	f.Close()
+
+	u, ok := s.Wait(ctx, 0, 5*time.Second)
+	if !ok || u.Reason != events.TypeSling {
+		t.Errorf("after append: %+v, %v; want sling update", u, ok)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("WatchEvents: %v", err)
+	}
+}
+
+func TestSnapshot_ServePoll(t *testing.T) {
+	s := NewSnapshot(&MockConvoyFetcher{}, 0)
+
+	w := httptest.NewRecorder()
+	s.ServePoll(w, httptest.NewRequest("GET", "/poll?since=0&timeout=10ms", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("idle poll status = %d, want 204", w.Code)
+	}
+
+	s.Invalidate(events.TypeMerged)
+	w = httptest.NewRecorder()
+	s.ServePoll(w, httptest.NewRequest("GET", "/poll?since=0", nil))
+	var u Update
+	if err := json.NewDecoder(w.Body).Decode(&u); err != nil || u.Version != 1 || u.Reason != events.TypeMerged {
+		t.Errorf("poll = %d %+v %v", w.Code, u, err)
+	}
+
+	w = httptest.NewRecorder()
+	s.ServePoll(w, httptest.NewRequest("GET", "/poll?since=abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad since status = %d, want 400", w.Code)
+	}
+}
+
+func TestSnapshot_ServeSSE(t *testing.T) {
+	s := NewSnapshot(&MockConvoyFetcher{}, 0)
+	srv := httptest.NewServer(http.HandlerFunc(s.ServeSSE))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func(prefix string) string {
+		t.Helper()
+		for lines.Scan() {
+			if strings.HasPrefix(lines.Text(), prefix) {
+				return strings.TrimPrefix(lines.Text(), prefix)
+			}
+		}
+		t.Fatalf("stream ended before %q", prefix)
+		return ""
+	}
+
+	if ev := next("event: "); ev != "hello" {
+		t.Fatalf("first event = %q, want hello", ev)
+	}
+	s.Invalidate(events.TypeDone)
+	if ev := next("event: "); ev != "update" {
+		t.Fatalf("second event = %q, want update", ev)
+	}
+	var u Update
+	if err := json.Unmarshal([]byte(next("data: ")), &u); err != nil || u.Version != 1 || u.Reason != events.TypeDone {
+		t.Errorf("update data = %+v, %v", u, err)
+	}
+}
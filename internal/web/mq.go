@@ -0,0 +1,128 @@
+package web
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// LocalMRRow is a merge request waiting in a rig's local queue (.beads/mq).
+type LocalMRRow struct {
+	ID          string     `json:"id"`
+	Rig         string     `json:"rig"`
+	Branch      string     `json:"branch"`
+	Target      string     `json:"target"`
+	SourceIssue string     `json:"source_issue"`
+	Worker      string     `json:"worker"`
+	Title       string     `json:"title"`
+	Priority    int        `json:"priority"`
+	Score       float64    `json:"score"` // Higher merges first
+	RetryCount  int        `json:"retry_count"`
+	ConvoyID    string     `json:"convoy_id,omitempty"`
+	ClaimedBy   string     `json:"claimed_by,omitempty"`
+	ClaimedAt   *time.Time `json:"claimed_at,omitempty"`
+	BlockedBy   string     `json:"blocked_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// RigMergeQueue is one rig's local merge queue and its recent activity.
+type RigMergeQueue struct {
+	Rig    string          `json:"rig"`
+	MRs    []LocalMRRow    `json:"mrs"`    // Highest score first
+	Events []MergeEventRow `json:"events"` // Newest first
+}
+
+// MergeEventRow is an entry from a rig's merge queue event log
+// (.beads/mq_events.jsonl).
+type MergeEventRow struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"` // merge_started, merged, merge_failed, merge_skipped
+	MRID   string    `json:"mr_id"`
+	Branch string    `json:"branch"`
+	Worker string    `json:"worker,omitempty"`
+	Detail string    `json:"detail,omitempty"` // Merge commit or failure reason
+}
+
+// mergeEventsPerRig is how many recent merge queue events the dashboard shows
+// for each rig.
+const mergeEventsPerRig = 10
+
+// FetchLocalMergeQueue lists the MRs queued in each rig, highest score first.
+// Rigs whose queue can't be read are skipped.
+func FetchLocalMergeQueue(townRoot string, rigs []string, now time.Time) []LocalMRRow {
+	var rows []LocalMRRow
+	for _, rig := range rigs {
+		mrs, err := mrqueue.New(filepath.Join(townRoot, rig)).List()
+		if err != nil {
+			continue
+		}
+		for _, mr := range mrs {
+			rows = append(rows, localMRRow(rig, mr, now))
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Score > rows[j].Score })
+	return rows
+}
+
+// FetchRigMergeQueues returns each rig's queued MRs and most recent merge
+// queue events. Unreadable queues or event logs leave that part empty.
+func FetchRigMergeQueues(townRoot string, rigs []string, now time.Time) []RigMergeQueue {
+	queues := make([]RigMergeQueue, 0, len(rigs))
+	for _, rig := range rigs {
+		rigPath := filepath.Join(townRoot, rig)
+		q := RigMergeQueue{Rig: rig, MRs: []LocalMRRow{}, Events: []MergeEventRow{}}
+
+		if mrs, err := mrqueue.New(rigPath).List(); err == nil {
+			for _, mr := range mrs {
+				q.MRs = append(q.MRs, localMRRow(rig, mr, now))
+			}
+			sort.SliceStable(q.MRs, func(i, j int) bool { return q.MRs[i].Score > q.MRs[j].Score })
+		}
+
+		if evs, err := mrqueue.NewEventLoggerFromRig(rigPath).ReadEvents(); err == nil {
+			for i := len(evs) - 1; i >= 0 && len(q.Events) < mergeEventsPerRig; i-- {
+				q.Events = append(q.Events, mergeEventRow(evs[i]))
+			}
+		}
+
+		queues = append(queues, q)
+	}
+	return queues
+}
+
+func mergeEventRow(e mrqueue.Event) MergeEventRow {
+	detail := e.Reason
+	if e.MergeCommit != "" {
+		detail = shortCommit(e.MergeCommit)
+	}
+	return MergeEventRow{
+		Time:   e.Timestamp,
+		Type:   string(e.Type),
+		MRID:   e.MRID,
+		Branch: e.Branch,
+		Worker: e.Worker,
+		Detail: detail,
+	}
+}
+
+func localMRRow(rig string, mr *mrqueue.MR, now time.Time) LocalMRRow {
+	return LocalMRRow{
+		ID:          mr.ID,
+		Rig:         rig,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		SourceIssue: mr.SourceIssue,
+		Worker:      mr.Worker,
+		Title:       mr.Title,
+		Priority:    mr.Priority,
+		Score:       mr.ScoreAt(now),
+		RetryCount:  mr.RetryCount,
+		ConvoyID:    mr.ConvoyID,
+		ClaimedBy:   mr.ClaimedBy,
+		ClaimedAt:   mr.ClaimedAt,
+		BlockedBy:   mr.BlockedBy,
+		CreatedAt:   mr.CreatedAt,
+	}
+}
@@ -0,0 +1,426 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/checkpoint"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/logrotate"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// Polecat page defaults.
+const (
+	defaultPaneLines    = 40
+	maxPaneLines        = 500
+	polecatEventsLimit  = 25
+	polecatEventsWindow = 7 * 24 * time.Hour
+)
+
+// ErrPolecatNotFound is returned when the rig or polecat doesn't exist.
+var ErrPolecatNotFound = errors.New("polecat not found")
+
+// PolecatDetail is everything the dashboard shows about one polecat.
+type PolecatDetail struct {
+	Rig       string
+	Name      string
+	SessionID string
+	Running   bool
+	State     string
+	Branch    string
+	Target    string // Branch the work merges into
+
+	HookBead   *BeadRef
+	Molecule   *MoleculeProgress
+	Checkpoint *checkpoint.Checkpoint
+	Convoy     *BeadRef // Convoy tracking the hooked bead
+
+	Diffstat string      // git diff --stat of the branch against Target
+	MR       *LocalMRRow // Pending MR for the branch, if queued
+	MRStatus string
+
+	Events []events.Event // Newest first
+	Pane   string         // Last lines of the session's pane
+
+	// Warnings lists sections that couldn't be loaded.
+	Warnings []string
+}
+
+// BeadRef identifies a bead.
+type BeadRef struct {
+	ID     string
+	Title  string
+	Status string
+}
+
+// MoleculeProgress summarizes the steps of the molecule attached to a bead.
+type MoleculeProgress struct {
+	RootID string
+	Title  string
+	Steps  []BeadRef
+	Done   int
+}
+
+// Total returns the number of steps.
+func (m *MoleculeProgress) Total() int { return len(m.Steps) }
+
+// PolecatFetcher loads a polecat's detail page data.
+type PolecatFetcher interface {
+	FetchPolecat(rig, name string, paneLines int) (*PolecatDetail, error)
+}
+
+// PolecatHandler serves GET /polecats/{rig}/{name}[?lines=N].
+type PolecatHandler struct {
+	fetcher  PolecatFetcher
+	template *template.Template
+}
+
+// NewPolecatHandler creates the polecat detail page handler.
+func NewPolecatHandler(fetcher PolecatFetcher) (*PolecatHandler, error) {
+	tmpl, err := LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	return &PolecatHandler{fetcher: fetcher, template: tmpl}, nil
+}
+
+// ServeHTTP renders a polecat's detail page.
+func (h *PolecatHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lines := defaultPaneLines
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid lines", http.StatusBadRequest)
+			return
+		}
+		lines = min(n, maxPaneLines)
+	}
+
+	detail, err := h.fetcher.FetchPolecat(r.PathValue("rig"), r.PathValue("name"), lines)
+	if errors.Is(err, ErrPolecatNotFound) {
+		http.Error(w, "Polecat not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch polecat", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.template.ExecuteTemplate(w, "polecat.html", detail); err != nil {
+		http.Error(w, "Failed to render template", http.StatusInternalServerError)
+		return
+	}
+}
+
+// isPolecatName reports whether a name from the URL can name a polecat.
+// Path separators and dots would let the lookup reach outside the rig's
+// polecats directory, so only a plain directory name is accepted.
+func isPolecatName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, `/\.`)
+}
+
+// LivePolecatFetcher loads polecat details from the town: the polecat's
+// clone, beads, checkpoint, the rig's merge queue and the tmux session.
+type LivePolecatFetcher struct {
+	townRoot string
+}
+
+// NewLivePolecatFetcher creates a fetcher for the town.
+func NewLivePolecatFetcher(townRoot string) *LivePolecatFetcher {
+	return &LivePolecatFetcher{townRoot: townRoot}
+}
+
+// FetchPolecat loads a polecat's details. Only a missing rig or polecat is an
+// error; sections that can't be loaded are left empty and noted in Warnings.
+func (f *LivePolecatFetcher) FetchPolecat(rigName, name string, paneLines int) (*PolecatDetail, error) {
+	if !isPolecatName(name) {
+		return nil, ErrPolecatNotFound
+	}
+	rigsConfig, err := config.LoadRigsConfig(filepath.Join(f.townRoot, "mayor", "rigs.json"))
+	if err != nil {
+		return nil, fmt.Errorf("loading rigs: %w", err)
+	}
+	r, err := rig.NewManager(f.townRoot, rigsConfig, git.NewGit(f.townRoot)).GetRig(rigName)
+	if errors.Is(err, rig.ErrRigNotFound) {
+		return nil, ErrPolecatNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	p, err := polecat.NewManager(r, git.NewGit(r.Path)).Get(name)
+	if errors.Is(err, polecat.ErrPolecatNotFound) {
+		return nil, ErrPolecatNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := polecat.NewSessionManager(tmux.NewTmux(), r)
+	d := &PolecatDetail{
+		Rig:       rigName,
+		Name:      name,
+		SessionID: sessions.SessionName(name),
+		State:     string(p.State),
+		Branch:    p.Branch,
+		Target:    r.DefaultBranch(),
+	}
+	warn := func(section string, err error) {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("%s: %v", section, err))
+	}
+
+	if d.Running, err = sessions.IsRunning(name); err != nil {
+		warn("session", err)
+	}
+	if d.Running {
+		if d.Pane, err = sessions.Capture(name, paneLines); err != nil {
+			warn("pane", err)
+		}
+	}
+
+	if d.Checkpoint, err = checkpoint.Read(p.ClonePath); err != nil {
+		warn("checkpoint", err)
+	}
+	hookID := p.Issue
+	if hookID == "" && d.Checkpoint != nil {
+		hookID = d.Checkpoint.HookedBead
+	}
+	if hookID != "" {
+		b := beads.New(p.ClonePath)
+		if issue, err := b.Show(hookID); err != nil {
+			warn("hooked bead", err)
+			d.HookBead = &BeadRef{ID: hookID}
+		} else {
+			d.HookBead = &BeadRef{ID: issue.ID, Title: issue.Title, Status: issue.Status}
+			if d.Molecule, err = moleculeProgress(b, issue); err != nil {
+				warn("molecule", err)
+			}
+		}
+		d.Convoy = f.trackingConvoy(hookID)
+	}
+
+	if d.Branch != "" {
+		if d.Diffstat, err = diffstat(p.ClonePath, d.Target); err != nil {
+			warn("diffstat", err)
+		}
+		d.MR, d.MRStatus = f.mergeStatus(r.Path, rigName, d.Branch)
+	}
+
+	hookBead := ""
+	if d.HookBead != nil {
+		hookBead = d.HookBead.ID
+	}
+	if d.Events, err = polecatEvents(filepath.Join(f.townRoot, events.EventsFile), rigName, name, d.SessionID, hookBead,
+		time.Now().Add(-polecatEventsWindow), polecatEventsLimit); err != nil {
+		warn("events", err)
+	}
+
+	return d, nil
+}
+
+// moleculeProgress returns the steps of the molecule attached to the hooked
+// bead, or nil if none is attached.
+func moleculeProgress(b *beads.Beads, hooked *beads.Issue) (*MoleculeProgress, error) {
+	attachment := beads.ParseAttachmentFields(hooked)
+	if attachment == nil || attachment.AttachedMolecule == "" {
+		return nil, nil
+	}
+	rootID := attachment.AttachedMolecule
+	root, err := b.Show(rootID)
+	if err != nil {
+		return nil, err
+	}
+	children, err := b.List(beads.ListOptions{Parent: rootID, Status: "all", Priority: -1})
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MoleculeProgress{RootID: rootID, Title: root.Title}
+	for _, c := range children {
+		m.Steps = append(m.Steps, BeadRef{ID: c.ID, Title: c.Title, Status: c.Status})
+		if c.Status == "closed" {
+			m.Done++
+		}
+	}
+	return m, nil
+}
+
+// trackingConvoy returns the convoy tracking a bead, or nil.
+func (f *LivePolecatFetcher) trackingConvoy(beadID string) *BeadRef {
+	dbPath := filepath.Join(f.townRoot, ".beads", "beads.db")
+	safeID := strings.ReplaceAll(beadID, "'", "''")
+	// #nosec G204 -- sqlite3 path is from trusted config, beadID is escaped
+	queryCmd := exec.Command("sqlite3", "-json", dbPath, fmt.Sprintf(`
+		SELECT i.id, i.title, i.status
+		FROM dependencies d
+		JOIN issues i ON d.issue_id = i.id
+		WHERE d.type = 'tracks'
+		AND i.issue_type = 'convoy'
+		AND (d.depends_on_id = '%s' OR d.depends_on_id LIKE '%%:%s')
+		LIMIT 1`, safeID, safeID))
+	var stdout bytes.Buffer
+	queryCmd.Stdout = &stdout
+	if err := queryCmd.Run(); err != nil {
+		return nil
+	}
+	var rows []BeadRef
+	if err := json.Unmarshal(stdout.Bytes(), &rows); err != nil || len(rows) == 0 {
+		return nil
+	}
+	return &rows[0]
+}
+
+// mergeStatus describes where the branch is in the rig's merge queue: its
+// pending MR if queued, otherwise the latest merge result.
+func (f *LivePolecatFetcher) mergeStatus(rigPath, rigName, branch string) (*LocalMRRow, string) {
+	now := time.Now()
+	if mrs, err := mrqueue.New(rigPath).List(); err == nil {
+		for _, mr := range mrs {
+			if mr.Branch == branch {
+				row := localMRRow(rigName, mr, now)
+				return &row, pendingMRStatus(row)
+			}
+		}
+	}
+	evs, _ := mrqueue.NewEventLoggerFromRig(rigPath).ReadEvents()
+	return nil, mergeResultStatus(evs, branch)
+}
+
+// pendingMRStatus describes a queued MR.
+func pendingMRStatus(mr LocalMRRow) string {
+	switch {
+	case mr.BlockedBy != "":
+		return "blocked by " + mr.BlockedBy
+	case mr.ClaimedBy != "":
+		return "merging (claimed by " + mr.ClaimedBy + ")"
+	case mr.RetryCount > 0:
+		return fmt.Sprintf("queued (retry %d)", mr.RetryCount)
+	}
+	return "queued"
+}
+
+// mergeResultStatus describes the latest merge queue event for a branch.
+func mergeResultStatus(evs []mrqueue.Event, branch string) string {
+	for i := len(evs) - 1; i >= 0; i-- {
+		e := evs[i]
+		if e.Branch != branch {
+			continue
+		}
+		switch e.Type {
+		case mrqueue.EventMerged:
+			if e.MergeCommit != "" {
+				return "merged (" + shortCommit(e.MergeCommit) + ")"
+			}
+			return "merged"
+		case mrqueue.EventMergeFailed:
+			return "merge failed: " + e.Reason
+		case mrqueue.EventMergeSkipped:
+			return "skipped: " + e.Reason
+		case mrqueue.EventMergeStarted:
+			return "merging"
+		}
+	}
+	return "not submitted"
+}
+
+func shortCommit(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+// diffstat returns git diff --stat of HEAD against the merge base with the
+// target branch, preferring the remote-tracking ref.
+func diffstat(clonePath, target string) (string, error) {
+	var lastErr error
+	for _, base := range []string{"origin/" + target, target} {
+		cmd := exec.Command("git", "diff", "--stat", base+"...HEAD")
+		cmd.Dir = clonePath
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			lastErr = fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
+			continue
+		}
+		return strings.TrimRight(stdout.String(), "\n"), nil
+	}
+	return "", lastErr
+}
+
+// polecatEvents returns up to limit events since the given time that involve
+// the polecat, newest first: events it emitted, or whose payload names it,
+// its session or its hooked bead.
+func polecatEvents(path, rigName, name, sessionID, hookBead string, since time.Time, limit int) ([]events.Event, error) {
+	ids := map[string]bool{
+		rigName + "/polecats/" + name: true,
+		rigName + "/" + name:          true,
+		sessionID:                     true,
+	}
+	if hookBead != "" {
+		ids[hookBead] = true
+	}
+	involves := func(e events.Event) bool {
+		if ids[e.Actor] {
+			return true
+		}
+		if e.Payload["rig"] == rigName && e.Payload["polecat"] == name {
+			return true
+		}
+		for _, v := range e.Payload {
+			if s, ok := v.(string); ok && ids[s] {
+				return true
+			}
+		}
+		return false
+	}
+
+	rd, err := logrotate.Open(path, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rd.Close()
+
+	var matched []events.Event
+	scanner := logrotate.NewScanner(rd)
+	for scanner.Scan() {
+		e, err := events.Decode(scanner.Bytes())
+		if err != nil {
+			continue
+		}
+		if ts, err := time.Parse(time.RFC3339, e.Timestamp); err == nil && ts.Before(since) {
+			continue
+		}
+		if involves(e) {
+			matched = append(matched, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	return matched, nil
+}
@@ -0,0 +1,200 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/checkpoint"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// mockPolecatFetcher returns a fixed detail for gastown/nux.
+type mockPolecatFetcher struct {
+	detail *PolecatDetail
+	err    error
+	lines  int
+}
+
+func (m *mockPolecatFetcher) FetchPolecat(rig, name string, paneLines int) (*PolecatDetail, error) {
+	m.lines = paneLines
+	if m.err != nil {
+		return nil, m.err
+	}
+	if rig != m.detail.Rig || name != m.detail.Name {
+		return nil, ErrPolecatNotFound
+	}
+	return m.detail, nil
+}
+
+func servePolecat(t *testing.T, fetcher PolecatFetcher, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	h, err := NewPolecatHandler(fetcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /polecats/{rig}/{name}", h)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestPolecatHandler_RendersDetail(t *testing.T) {
+	fetcher := &mockPolecatFetcher{detail: &PolecatDetail{
+		Rig:       "gastown",
+		Name:      "nux",
+		SessionID: "gt-gastown-nux",
+		Running:   true,
+		State:     "working",
+		Branch:    "polecat/nux",
+		Target:    "main",
+		HookBead:  &BeadRef{ID: "gt-abc", Title: "Fix the flux capacitor", Status: "hooked"},
+		Molecule: &MoleculeProgress{RootID: "gt-mol", Title: "mol-polecat-work", Done: 1, Steps: []BeadRef{
+			{ID: "gt-mol.1", Title: "Load context", Status: "closed"},
+			{ID: "gt-mol.2", Title: "Implement", Status: "in_progress"},
+		}},
+		Checkpoint: &checkpoint.Checkpoint{MoleculeID: "gt-mol", CurrentStep: "gt-mol.2", Timestamp: time.Now()},
+		Convoy:     &BeadRef{ID: "hq-cv-1", Title: "Work: flux"},
+		Diffstat:   " main.go | 4 ++--\n 1 file changed",
+		MR:         &LocalMRRow{ID: "mr-1", Score: 1010},
+		MRStatus:   "queued",
+		Events: []events.Event{
+			{Timestamp: "2026-03-01T12:00:00Z", Type: events.TypeSling, Actor: "mayor", Payload: map[string]interface{}{"bead": "gt-abc", "target": "gastown/polecats/nux"}},
+		},
+		Pane:     "> running tests <ok>",
+		Warnings: []string{"diffstat: unknown revision"},
+	}}
+
+	w := servePolecat(t, fetcher, "/polecats/gastown/nux")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if fetcher.lines != defaultPaneLines {
+		t.Errorf("pane lines = %d, want %d", fetcher.lines, defaultPaneLines)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"gastown/nux",
+		"gt-abc",
+		"Fix the flux capacitor",
+		`href="/#convoy-hq-cv-1"`,
+		"1/2 steps",
+		"Implement",
+		"molecule gt-mol, step gt-mol.2", // Checkpoint summary
+		"main.go | 4",
+		"mr-1",
+		"queued",
+		"bead=gt-abc target=gastown/polecats/nux",
+		"&gt; running tests &lt;ok&gt;", // Pane output is escaped
+		"diffstat: unknown revision",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+}
+
+func TestPolecatHandler_Errors(t *testing.T) {
+	fetcher := &mockPolecatFetcher{detail: &PolecatDetail{Rig: "gastown", Name: "nux"}}
+
+	if w := servePolecat(t, fetcher, "/polecats/gastown/furiosa"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown polecat: status = %d, want 404", w.Code)
+	}
+	if w := servePolecat(t, fetcher, "/polecats/gastown/nux?lines=abc"); w.Code != http.StatusBadRequest {
+		t.Errorf("bad lines: status = %d, want 400", w.Code)
+	}
+	if w := servePolecat(t, fetcher, "/polecats/gastown/nux?lines=100000"); w.Code != http.StatusOK || fetcher.lines != maxPaneLines {
+		t.Errorf("lines cap: status = %d, lines = %d", w.Code, fetcher.lines)
+	}
+
+	fetcher.err = errors.New("bd exploded")
+	if w := servePolecat(t, fetcher, "/polecats/gastown/nux"); w.Code != http.StatusInternalServerError {
+		t.Errorf("fetch error: status = %d, want 500", w.Code)
+	}
+}
+
+func TestLivePolecatFetcher_RejectsPathNames(t *testing.T) {
+	f := NewLivePolecatFetcher(t.TempDir())
+	for _, name := range []string{"", "..", "../../mayor", `nux\..`, ".hidden", "nux.bak"} {
+		if _, err := f.FetchPolecat("gastown", name, defaultPaneLines); !errors.Is(err, ErrPolecatNotFound) {
+			t.Errorf("FetchPolecat(%q) error = %v, want ErrPolecatNotFound", name, err)
+		}
+	}
+}
+
+func TestPolecatEvents(t *testing.T) {
+	now := time.Now().UTC()
+	ts := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+	lines := []string{
+		`{"ts":"` + ts(10*24*time.Hour) + `","type":"sling","actor":"mayor","payload":{"bead":"gt-abc","target":"gastown/polecats/nux"}}`,
+		`{"ts":"` + ts(5*time.Hour) + `","type":"spawn","actor":"gt","payload":{"rig":"gastown","polecat":"nux"}}`,
+		`{"ts":"` + ts(4*time.Hour) + `","type":"spawn","actor":"gt","payload":{"rig":"gastown","polecat":"toast"}}`,
+		`{"ts":"` + ts(3*time.Hour) + `","type":"sling","actor":"mayor","payload":{"bead":"gt-abc","target":"gastown/polecats/nux"}}`,
+		`{"ts":"` + ts(2*time.Hour) + `","type":"session_death","actor":"daemon","payload":{"session":"gt-gastown-nux","agent":"x","reason":"zombie","caller":"daemon"}}`,
+		`{"ts":"` + ts(time.Hour) + `","type":"done","actor":"gastown/polecats/nux","payload":{"bead":"gt-abc","branch":"polecat/nux"}}`,
+		`{"ts":"` + ts(time.Minute) + `","type":"done","actor":"gastown/polecats/toast","payload":{"bead":"gt-xyz","branch":"polecat/toast"}}`,
+	}
+	path := filepath.Join(t.TempDir(), events.EventsFile)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := polecatEvents(path, "gastown", "nux", "gt-gastown-nux", "gt-abc", now.Add(-7*24*time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, e := range got {
+		types = append(types, e.Type)
+	}
+	if want := "done session_death sling spawn"; strings.Join(types, " ") != want {
+		t.Errorf("events = %q, want %q", strings.Join(types, " "), want)
+	}
+
+	got, _ = polecatEvents(path, "gastown", "nux", "gt-gastown-nux", "gt-abc", now.Add(-7*24*time.Hour), 2)
+	if len(got) != 2 || got[0].Type != events.TypeDone || got[1].Type != events.TypeSessionDeath {
+		t.Errorf("limited events = %+v, want the 2 newest", got)
+	}
+}
+
+func TestMergeStatus(t *testing.T) {
+	pending := []struct {
+		mr   LocalMRRow
+		want string
+	}{
+		{LocalMRRow{}, "queued"},
+		{LocalMRRow{RetryCount: 2}, "queued (retry 2)"},
+		{LocalMRRow{ClaimedBy: "refinery-1"}, "merging (claimed by refinery-1)"},
+		{LocalMRRow{ClaimedBy: "refinery-1", BlockedBy: "gt-fix"}, "blocked by gt-fix"},
+	}
+	for _, tt := range pending {
+		if got := pendingMRStatus(tt.mr); got != tt.want {
+			t.Errorf("pendingMRStatus(%+v) = %q, want %q", tt.mr, got, tt.want)
+		}
+	}
+
+	evs := []mrqueue.Event{
+		{Type: mrqueue.EventMergeFailed, Branch: "polecat/nux", Reason: "tests failed"},
+		{Type: mrqueue.EventMerged, Branch: "polecat/toast", MergeCommit: "0123456789abcdef"},
+		{Type: mrqueue.EventMerged, Branch: "polecat/nux", MergeCommit: "fedcba9876543210"},
+	}
+	for branch, want := range map[string]string{
+		"polecat/nux":   "merged (fedcba98)",
+		"polecat/toast": "merged (01234567)",
+		"polecat/joe":   "not submitted",
+	} {
+		if got := mergeResultStatus(evs, branch); got != want {
+			t.Errorf("mergeResultStatus(%s) = %q, want %q", branch, got, want)
+		}
+	}
+	if got := mergeResultStatus(evs[:1], "polecat/nux"); got != "merge failed: tests failed" {
+		t.Errorf("failed status = %q", got)
+	}
+}
@@ -3,10 +3,15 @@ package web
 
 import (
 	"embed"
+	"fmt"
 	"html/template"
 	"io/fs"
+	"sort"
+	"strings"
 
 	"github.com/steveyegge/gastown/internal/activity"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/formula"
 )
 
 //go:embed templates/*.html
@@ -14,66 +19,73 @@ var templateFS embed.FS
 
 // ConvoyData represents data passed to the convoy template.
 type ConvoyData struct {
-	Convoys    []ConvoyRow
-	MergeQueue []MergeQueueRow
-	Polecats   []PolecatRow
-	TaskQueue  []TaskRow
+	Convoys         []ConvoyRow
+	LocalMergeQueue []RigMergeQueue
+	MergeQueue      []MergeQueueRow // GitHub PRs; nil when the GitHub view is off
+	Polecats        []PolecatRow
+	TaskQueue       []TaskRow
+}
+
+// ShowGitHub reports whether the GitHub PR view is turned on.
+func (d ConvoyData) ShowGitHub() bool {
+	return d.MergeQueue != nil
 }
 
 // TaskRow represents a standalone task in the task queue.
 type TaskRow struct {
-	ID         string
-	Title      string
-	Status     string        // "open", "in_progress", "closed"
-	Priority   int           // 0-4
-	Assignee   string
-	ColorClass string        // Row highlight color
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Status     string `json:"status"`   // "open", "in_progress", "closed"
+	Priority   int    `json:"priority"` // 0-4
+	Assignee   string `json:"assignee,omitempty"`
+	ColorClass string `json:"-"` // Row highlight color
 }
 
 // PolecatRow represents a polecat worker in the dashboard.
 type PolecatRow struct {
-	Name         string        // e.g., "dag", "nux"
-	Rig          string        // e.g., "roxas", "gastown"
-	SessionID    string        // e.g., "gt-roxas-dag"
-	LastActivity activity.Info // Colored activity display
-	StatusHint   string        // Last line from pane (optional)
+	Name         string        `json:"name"`                  // e.g., "dag", "nux"
+	Rig          string        `json:"rig"`                   // e.g., "roxas", "gastown"
+	SessionID    string        `json:"session_id"`            // e.g., "gt-roxas-dag"
+	LastActivity activity.Info `json:"last_activity"`         // Colored activity display
+	StatusHint   string        `json:"status_hint,omitempty"` // Last line from pane (optional)
 }
 
 // MergeQueueRow represents a PR in the merge queue.
 type MergeQueueRow struct {
-	Number     int
-	Repo       string // Short repo name (e.g., "roxas", "gastown")
-	Title      string
-	URL        string
-	CIStatus   string // "pass", "fail", "pending"
-	Mergeable  string // "ready", "conflict", "pending"
-	ColorClass string // "mq-green", "mq-yellow", "mq-red"
+	Number     int    `json:"number"`
+	Repo       string `json:"repo"` // Short repo name (e.g., "roxas", "gastown")
+	Title      string `json:"title"`
+	URL        string `json:"url"`
+	CIStatus   string `json:"ci_status"` // "pass", "fail", "pending"
+	Mergeable  string `json:"mergeable"` // "ready", "conflict", "pending"
+	ColorClass string `json:"-"`         // "mq-green", "mq-yellow", "mq-red"
 }
 
 // ConvoyRow represents a single convoy in the dashboard.
 type ConvoyRow struct {
-	ID            string
-	Title         string
-	Status        string // "open" or "closed" (raw beads status)
-	WorkStatus    string // Computed: "complete", "active", "stale", "stuck", "waiting"
-	Progress      string // e.g., "2/5"
-	Completed     int
-	Total         int
-	LastActivity  activity.Info
-	TrackedIssues []TrackedIssue
+	ID            string         `json:"id"`
+	Title         string         `json:"title"`
+	Status        string         `json:"status"`      // "open" or "closed" (raw beads status)
+	WorkStatus    string         `json:"work_status"` // Computed: "complete", "active", "stale", "stuck", "waiting"
+	Progress      string         `json:"progress"`    // e.g., "2/5"
+	Completed     int            `json:"completed"`
+	Total         int            `json:"total"`
+	LastActivity  activity.Info  `json:"last_activity"`
+	TrackedIssues []TrackedIssue `json:"tracked_issues,omitempty"`
+	Graph         *formula.Graph `json:"-"` // Dependencies among tracked issues; nil if they have none
 }
 
 // TrackedIssue represents an issue tracked by a convoy.
 type TrackedIssue struct {
-	ID          string
-	Title       string
-	Status      string
-	Assignee    string
-	Description string
-	Priority    int
-	Type        string
-	CreatedAt   string
-	UpdatedAt   string
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Status      string `json:"status"`
+	Assignee    string `json:"assignee,omitempty"`
+	Description string `json:"description,omitempty"`
+	Priority    int    `json:"priority"`
+	Type        string `json:"type,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
 }
 
 // issuesByStatus filters tracked issues by status for kanban columns.
@@ -98,6 +110,8 @@ func LoadTemplates() (*template.Template, error) {
 		"priorityLabel":   priorityLabel,
 		"progressPercent": progressPercent,
 		"issuesByStatus":  issuesByStatus,
+		"eventDetail":     eventDetail,
+		"graphLayers":     graphLayers,
 	}
 
 	// Get the templates subdirectory
@@ -115,6 +129,14 @@ func LoadTemplates() (*template.Template, error) {
 	return tmpl, nil
 }
 
+// graphLayers returns a graph's nodes by stage, for the convoy DAG view.
+func graphLayers(g *formula.Graph) [][]formula.GraphNode {
+	if g == nil {
+		return nil
+	}
+	return g.Layers()
+}
+
 // activityClass returns the CSS class for an activity color.
 func activityClass(info activity.Info) string {
 	switch info.ColorClass {
@@ -191,3 +213,17 @@ func progressPercent(completed, total int) int {
 	}
 	return (completed * 100) / total
 }
+
+// eventDetail renders an event's payload as sorted key=value pairs.
+func eventDetail(e events.Event) string {
+	keys := make([]string, 0, len(e.Payload))
+	for k := range e.Payload {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, e.Payload[k]))
+	}
+	return strings.Join(parts, " ")
+}
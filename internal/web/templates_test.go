@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/activity"
+	"github.com/steveyegge/gastown/internal/formula"
 )
 
 func TestConvoyTemplate_RendersConvoyList(t *testing.T) {
@@ -137,8 +138,8 @@ func TestConvoyTemplate_HtmxAutoRefresh(t *testing.T) {
 	if !strings.Contains(output, "hx-trigger") {
 		t.Error("Template should contain hx-trigger for auto-refresh")
 	}
-	if !strings.Contains(output, "every 10s") {
-		t.Error("Template should refresh every 10 seconds")
+	if !strings.Contains(output, "gt:update, every 60s") {
+		t.Error("Template should refresh on live updates, falling back to every 60 seconds")
 	}
 }
 
@@ -213,6 +214,43 @@ func TestConvoyTemplate_StatusIndicators(t *testing.T) {
 	}
 }
 
+func TestConvoyTemplate_DependencyGraph(t *testing.T) {
+	tmpl, err := LoadTemplates()
+	if err != nil {
+		t.Fatalf("LoadTemplates() error = %v", err)
+	}
+
+	graph := formula.LiveGraph("Fan-out", []formula.LiveNode{
+		{ID: "gt-a", Title: "Shard A", Status: "closed"},
+		{ID: "gt-b", Title: "Shard B", Status: "in_progress"},
+		{ID: "gt-merge", Title: "Merge shards", Status: "open", DependsOn: []string{"gt-a", "gt-b"}},
+	})
+	data := ConvoyData{
+		Convoys: []ConvoyRow{
+			{
+				ID:     "hq-cv-fan",
+				Title:  "Fan-out",
+				Status: "open",
+				Graph:  graph,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.ExecuteTemplate(&buf, "convoy.html", data)
+	if err != nil {
+		t.Fatalf("ExecuteTemplate() error = %v", err)
+	}
+
+	output := buf.String()
+
+	for _, want := range []string{"dag-done", "dag-in_progress", "dag-blocked", "Merge shards", "after gt-a, gt-b"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Template should contain %q", want)
+		}
+	}
+}
+
 func TestConvoyTemplate_EmptyState(t *testing.T) {
 	tmpl, err := LoadTemplates()
 	if err != nil {